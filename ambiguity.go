@@ -0,0 +1,63 @@
+package parser
+
+import "fmt"
+
+// Ambiguity describes alternatives of an OrAmbiguous that all matched the
+// same span of input. Alternatives are indexes into its parsers, in order.
+type Ambiguity struct {
+	Start, End   Position
+	Alternatives []int
+}
+
+func (a Ambiguity) String() string {
+	return fmt.Sprintf("%s-%s: alternatives %v all match", a.Start, a.End, a.Alternatives)
+}
+
+// OrAmbiguous behaves like Or, returning the first alternative that
+// matches, but goes on to try every remaining alternative from the same
+// start. Whenever two or more of them end at the same place, report is
+// called, catching grammars where the order of alternatives silently
+// decides the meaning. Positions are only filled in when sr tracks them.
+//
+// It is meant for testing grammars, since it always runs every alternative.
+// Reader states must be comparable.
+func OrAmbiguous[T any](report func(Ambiguity), ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		s := sr.State()
+		start := Pos(sr)
+		var (
+			first    T
+			firstEnd any
+			matched  bool
+			ends     []any
+			groups   = map[any][]int{}
+			posAt    = map[any]Position{}
+		)
+		for i, p := range ps {
+			v, err := p(sr)
+			if err == nil {
+				end := sr.State()
+				if _, ok := groups[end]; !ok {
+					ends = append(ends, end)
+					posAt[end] = Pos(sr)
+				}
+				groups[end] = append(groups[end], i)
+				if !matched {
+					first, firstEnd, matched = v, end, true
+				}
+			}
+			sr.Restore(s)
+		}
+		for _, end := range ends {
+			if len(groups[end]) > 1 {
+				report(Ambiguity{Start: start, End: posAt[end], Alternatives: groups[end]})
+			}
+		}
+		if !matched {
+			var t T
+			return t, msg(MsgNoMatch)
+		}
+		sr.Restore(firstEnd)
+		return first, nil
+	}
+}
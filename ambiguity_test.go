@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrAmbiguous(t *testing.T) {
+	var got []Ambiguity
+	p := OrAmbiguous(func(a Ambiguity) { got = append(got, a) },
+		Lit("for"),
+		join(Mult(1, 0, Set("a-z"))),
+		Lit("fo"),
+		Lit("forth"),
+	)
+	sr := NewPosReader(NewSimpleReader(strings.NewReader("for ")))
+	v, err := p(sr)
+	if err != nil || v != "for" {
+		t.Fatalf("got %q, %v", v, err)
+	}
+	if sr.Pos().Column != 4 {
+		t.Errorf("reader left at %s", sr.Pos())
+	}
+	want := []Ambiguity{{Start: Position{Offset: 0, Line: 1, Column: 1}, End: Position{Offset: 3, Line: 1, Column: 4}, Alternatives: []int{0, 1}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = nil
+	sr = NewPosReader(NewSimpleReader(strings.NewReader("fox")))
+	if v, err := p(sr); err != nil || v != "fox" || got != nil {
+		t.Errorf("got %q, %v, %v", v, err, got)
+	}
+	if _, err := p(NewSimpleReader(strings.NewReader("1"))); err == nil {
+		t.Error("expected no match")
+	}
+}
@@ -0,0 +1,245 @@
+// Package ast walks and rewrites the syntax trees grammars build with
+// Convert, whatever their types, so that each grammar needn't write its
+// own traversal.
+//
+// A node is a value of a defined struct type or a pointer to one, or a
+// value of any defined type held in an interface, such as a named number
+// standing for a literal in an interface-typed field. Other fields are
+// data: strings, numbers and enum kinds, and parser.Position and
+// time.Time wherever they appear. A node's children are the nodes
+// found in its exported fields or elements, looking through pointers,
+// interfaces, slices, arrays and unnamed structs along the way, in field
+// and element order. A node can list its children itself by implementing
+// Parent.
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/andyleap/parser"
+)
+
+// Parent is implemented by nodes that list their own children, in place
+// of the reflection Walk and Fold use otherwise. Rewrite always uses
+// reflection.
+type Parent interface {
+	Children() []any
+}
+
+// Visitor is called by Walk for each node. If Visit returns a non-nil w,
+// Walk visits each child of node with w and then calls w.Visit(nil), as
+// go/ast.Walk does.
+type Visitor interface {
+	Visit(node any) (w Visitor)
+}
+
+// Walk traverses the tree at node depth first with v.
+func Walk(v Visitor, node any) {
+	if node == nil {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	for _, c := range Children(node) {
+		Walk(v, c)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(any) bool
+
+func (f inspector) Visit(node any) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree at node depth first, calling f for each node
+// and then with nil once its children are done. Children are skipped when
+// f returns false.
+func Inspect(node any, f func(node any) bool) {
+	Walk(inspector(f), node)
+}
+
+// Fold computes a value for the tree at node bottom up, calling f for each
+// node with the values computed for its children, as an evaluator or a
+// pretty printer does:
+//
+//	v := ast.Fold(tree, func(n any, args []float64) float64 {
+//		switch n := n.(type) {
+//		case Num:
+//			return float64(n)
+//		case BinOp:
+//			return apply(n.Op, args[0], args[1])
+//		}
+//		...
+//	})
+func Fold[T any](node any, f func(node any, children []T) T) T {
+	cs := Children(node)
+	vs := make([]T, len(cs))
+	for i, c := range cs {
+		vs[i] = Fold(c, f)
+	}
+	return f(node, vs)
+}
+
+// Children returns the children of node.
+func Children(node any) []any {
+	if p, ok := node.(Parent); ok {
+		return p.Children()
+	}
+	cs := []any{}
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	contents(v, func(c reflect.Value) {
+		cs = append(cs, c.Interface())
+	})
+	return cs
+}
+
+// data lists struct types that are never nodes.
+var data = map[reflect.Type]bool{
+	reflect.TypeOf(parser.Position{}): true,
+	reflect.TypeOf(time.Time{}):       true,
+}
+
+// isNode reports whether values of type t are nodes, boxed saying whether
+// the value was held in an interface.
+func isNode(t reflect.Type, boxed bool) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Name() == "" || t.PkgPath() == "" || data[t] {
+		return false
+	}
+	return boxed || t.Kind() == reflect.Struct
+}
+
+// contents calls f with each node directly inside v, a node's value with
+// any pointer removed.
+func contents(v reflect.Value, f func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				find(v.Field(i), false, f)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			find(v.Index(i), false, f)
+		}
+	case reflect.Interface:
+		find(v, false, f)
+	}
+}
+
+// find calls f with v if it is a node, and otherwise with the nodes inside
+// it.
+func find(v reflect.Value, boxed bool, f func(reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			find(v.Elem(), true, f)
+			return
+		}
+	}
+	if isNode(v.Type(), boxed) {
+		f(v)
+		return
+	}
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	contents(v, f)
+}
+
+// Rewrite rebuilds the tree at node bottom up, replacing each node with
+// what f returns for it once its children have been rewritten, as for
+// constant folding or desugaring, and returns the new root. Returning the
+// node unchanged keeps it. A replacement must fit where the node was: a
+// node held in an interface field can become any implementation of that
+// interface, and a node held in a field of its own type can only change
+// its value. Rewrite panics otherwise.
+//
+// Structs, slices and arrays are copied, but nodes reached through
+// pointers are rewritten in place, so the original tree changes too.
+func Rewrite(node any, f func(node any) any) any {
+	if node == nil {
+		return nil
+	}
+	return f(children(reflect.ValueOf(node), f).Interface())
+}
+
+// children returns a copy of v with the nodes inside it rewritten by f.
+func children(v reflect.Value, f func(any) any) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			v.Elem().Set(children(v.Elem(), f))
+		}
+		return v
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			if c.Type().Field(i).IsExported() {
+				c.Field(i).Set(slot(c.Field(i), f))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(slot(v.Index(i), f))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(slot(v.Index(i), f))
+		}
+		return c
+	}
+	return v
+}
+
+// slot returns the rewritten contents of a field or element v.
+func slot(v reflect.Value, f func(any) any) reflect.Value {
+	inner := v
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		inner = v.Elem()
+	}
+	if !isNode(inner.Type(), v.Kind() == reflect.Interface) {
+		if v.Kind() != reflect.Interface {
+			return children(v, f)
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(children(inner, f))
+		return c
+	}
+	r := f(children(inner, f).Interface())
+	if r == nil {
+		return reflect.Zero(v.Type())
+	}
+	rv := reflect.ValueOf(r)
+	if !rv.Type().AssignableTo(v.Type()) {
+		panic(fmt.Sprintf("ast: Rewrite: can't replace a %s with a %s", v.Type(), rv.Type()))
+	}
+	return rv
+}
@@ -0,0 +1,167 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/examples/calc"
+)
+
+func parse(t *testing.T, s string) calc.Node {
+	t.Helper()
+	n, err := calc.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	tree := parse(t, "-x + f(2, y)")
+	names := []string{}
+	Inspect(tree, func(n any) bool {
+		if n != nil {
+			names = append(names, fmt.Sprintf("%T", n))
+		}
+		return true
+	})
+	want := []string{"calc.BinOp", "calc.Neg", "calc.Var", "calc.Call", "calc.Num", "calc.Var"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+	// Returning false skips the children.
+	n := 0
+	Inspect(tree, func(node any) bool {
+		if node != nil {
+			n++
+		}
+		_, call := node.(calc.Call)
+		return !call
+	})
+	if n != 4 {
+		t.Errorf("visited %d", n)
+	}
+}
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+	tree := parse(t, "1 + 2 * -3")
+	v := Fold(tree, func(n any, args []float64) float64 {
+		switch n := n.(type) {
+		case calc.Num:
+			return float64(n)
+		case calc.Neg:
+			return -args[0]
+		case calc.BinOp:
+			if n.Op == "+" {
+				return args[0] + args[1]
+			}
+			return args[0] * args[1]
+		}
+		return 0
+	})
+	if v != -5 {
+		t.Errorf("got %v", v)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+	tree := parse(t, "x * (2 + 3) + max(1 + 1, y)")
+	folded := Rewrite(tree, func(n any) any {
+		if b, ok := n.(calc.BinOp); ok && b.Op == "+" {
+			l, lok := b.Op1.(calc.Num)
+			r, rok := b.Op2.(calc.Num)
+			if lok && rok {
+				return l + r
+			}
+		}
+		return n
+	})
+	if got := folded.(calc.Node).String(); !strings.Contains(got, "5") || strings.Contains(got, "2 + 3") || strings.Contains(got, "1 + 1") {
+		t.Errorf("got %s", got)
+	}
+	if got := tree.String(); !strings.Contains(got, "2 + 3") {
+		t.Errorf("original changed to %s", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("misfit replacement accepted")
+		}
+	}()
+	Rewrite(tree, func(n any) any {
+		if _, ok := n.(calc.Num); ok {
+			return "not a node"
+		}
+		return n
+	})
+}
+
+type list struct {
+	items []any
+}
+
+func (l list) Children() []any {
+	return l.items
+}
+
+type leaf int
+
+func TestParent(t *testing.T) {
+	t.Parallel()
+	sum := Fold(list{[]any{leaf(1), list{[]any{leaf(2), leaf(3)}}}}, func(n any, cs []int) int {
+		if l, ok := n.(leaf); ok {
+			return int(l)
+		}
+		s := 0
+		for _, c := range cs {
+			s += c
+		}
+		return s
+	})
+	if sum != 6 {
+		t.Errorf("got %d", sum)
+	}
+}
+
+type kind int
+
+type binOp struct {
+	Pos  parser.Position
+	Kind kind
+	L, R any
+	At   time.Time
+}
+
+type ident struct {
+	Pos  parser.Position
+	Name string
+}
+
+func TestData(t *testing.T) {
+	t.Parallel()
+	l, r := ident{Name: "a"}, leaf(2)
+	tree := binOp{Pos: parser.Position{Line: 1, Column: 3}, L: l, R: r}
+	if cs := Children(tree); !reflect.DeepEqual(cs, []any{l, r}) {
+		t.Errorf("got %#v", cs)
+	}
+	if cs := Children(l); len(cs) != 0 {
+		t.Errorf("got %#v", cs)
+	}
+	out := Rewrite(tree, func(n any) any {
+		if p, ok := n.(parser.Position); ok {
+			t.Errorf("rewrote %v", p)
+		}
+		return n
+	})
+	if !reflect.DeepEqual(out, tree) {
+		t.Errorf("got %#v", out)
+	}
+}
@@ -0,0 +1,59 @@
+package parser
+
+import "strings"
+
+// Balanced matches open, then everything up to the close that balances it,
+// counting nested pairs, and returns the raw text between the outer pair.
+// Use it to skip a malformed block whole during error recovery, as in
+// Or(block, Convert(Balanced("{", "}"), badBlock)), or to capture a region whose
+// interior is parsed later, like a macro body. Delimiters inside strings or
+// comments are counted like any others. open and close must differ.
+func Balanced(open, close string) func(sr StatefulReader) (string, error) {
+	if open == "" || close == "" || open == close {
+		panic("parser: Balanced: open and close must be different and not empty")
+	}
+	start := Lit(open)
+	return func(sr StatefulReader) (string, error) {
+		s := save(sr)
+		if _, err := start(sr); err != nil {
+			return "", err
+		}
+		b := strings.Builder{}
+		depth := 1
+		for {
+			switch {
+			case matchText(sr, close):
+				depth--
+				if depth == 0 {
+					return b.String(), nil
+				}
+				b.WriteString(close)
+			case matchText(sr, open):
+				depth++
+				b.WriteString(open)
+			default:
+				c, ok := peekByte(sr)
+				if !ok {
+					expect(sr, Pos(sr), Expectation{Kind: ExpectLiteral, Text: close})
+					s.restore(sr)
+					return "", msg(MsgUnexpectedEOF)
+				}
+				skipByte(sr)
+				b.WriteByte(c)
+			}
+		}
+	}
+}
+
+// skipByte consumes the next byte, which peekByte has seen.
+func skipByte(sr StatefulReader) {
+	if pk, ok := sr.(peeker); ok {
+		if _, ok := pk.PeekBytes(1); ok {
+			pk.Advance(1)
+			return
+		}
+	}
+	buf := getScratch(1)
+	defer putScratch(buf)
+	sr.Read(*buf)
+}
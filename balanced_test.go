@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBalanced(t *testing.T) {
+	t.Parallel()
+	p := Balanced("{", "}")
+	for _, c := range []struct {
+		in, want, rest string
+		ok             bool
+	}{
+		{"{}", "", "", true},
+		{"{a}b", "a", "b", true},
+		{"{a{b}{c{d}}e}}", "a{b}{c{d}}e", "}", true},
+		{"{a{b}", "", "{a{b}", false},
+		{"x{}", "", "x{}", false},
+	} {
+		for name, sr := range map[string]StatefulReader{
+			"bytes":  NewBytesReader([]byte(c.in)),
+			"simple": NewSimpleReader(strings.NewReader(c.in)),
+		} {
+			v, err := p(sr)
+			rest := make([]byte, 100)
+			n, _ := sr.Read(rest)
+			if (err == nil) != c.ok || v != c.want || string(rest[:n]) != c.rest {
+				t.Errorf("%s %q: got %q, %v, leaving %q", name, c.in, v, err, rest[:n])
+			}
+		}
+	}
+
+	comments := Balanced("/*", "*/")
+	v, _, err := ParseBytes(comments, []byte("/* a /* b */ c */"))
+	assert(t, v, " a /* b */ c ")
+	assert(t, err, nil)
+
+	_, _, err = ParseBytes(p, []byte("{a{b}"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Pos.Offset != 5 || len(pe.Expected) != 1 || pe.Expected[0].Text != "}" {
+		t.Errorf("unclosed: got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package bench holds representative workloads for measuring the parser
+// package: JSON documents, access logs, arithmetic and binary
+// type-length-value records. Its benchmarks report throughput and its tests
+// put ceilings on allocations per input byte, so that changes made for
+// performance can be measured and regressions caught.
+//
+// Run them with
+//
+//	go test -bench . -benchmem ./bench
+package bench
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+	pbinary "github.com/andyleap/parser/binary"
+)
+
+// JSON returns an indented JSON array of n objects.
+func JSON(n int) []byte {
+	b := strings.Builder{}
+	b.WriteString("[\n")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, "  {\n    \"id\": %d,\n    \"name\": \"item \\u00e9 %d\",\n    \"price\": %d.%02d,\n    \"tags\": [\"a\", \"b\", null, true],\n    \"nested\": {\"x\": -1e3, \"y\": []}\n  }", i, i, i*3, i%100)
+	}
+	b.WriteString("\n]\n")
+	return []byte(b.String())
+}
+
+// AccessLog returns n lines in the Combined Log Format.
+func AccessLog(n int) []byte {
+	b := strings.Builder{}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "10.0.%d.%d - user%d [10/Oct/2023:13:55:%02d -0700] \"GET /page/%d?q=%d HTTP/1.1\" %d %d \"https://example.com/\" \"Mozilla/5.0 (X11; Linux x86_64)\"\n",
+			i/256%256, i%256, i%7, i%60, i, i*7, 200+i%3*100, 1000+i)
+	}
+	return []byte(b.String())
+}
+
+// Arithmetic returns n lines of arithmetic expressions.
+func Arithmetic(n int) []byte {
+	b := strings.Builder{}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "%d + %d * (x - %d.5) / max(%d, 2) ^ 2 - -%d\n", i, i%9+1, i%13, i%5, i%3)
+	}
+	return []byte(b.String())
+}
+
+// TLV returns n type-length-value records: a one byte tag, a big endian
+// 16-bit length and that many bytes of value.
+func TLV(n int) []byte {
+	out := []byte{}
+	for i := 0; i < n; i++ {
+		v := []byte(strings.Repeat("v", i%32))
+		out = append(out, byte(i%250))
+		out = append(out, 0, 0)
+		binary.BigEndian.PutUint16(out[len(out)-2:], uint16(len(v)))
+		out = append(out, v...)
+	}
+	return out
+}
+
+// Record is a type-length-value record.
+type Record struct {
+	Tag   uint8
+	Value []byte
+}
+
+// TLVRecords parses the output of TLV.
+var TLVRecords = parser.Mult(0, 0, parser.Map2(pbinary.U8(), parser.Bind(pbinary.U16BE(), func(n uint16) func(sr parser.StatefulReader) ([]byte, error) {
+	return pbinary.Bytes(int(n))
+}), func(tag uint8, v []byte) Record {
+	return Record{Tag: tag, Value: v}
+}))
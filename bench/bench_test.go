@@ -0,0 +1,118 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/examples/calc"
+	"github.com/andyleap/parser/formats/accesslog"
+	"github.com/andyleap/parser/formats/json"
+)
+
+type workload struct {
+	name  string
+	input []byte
+	parse func(sr parser.StatefulReader) error
+	// maxAllocs is the ceiling on allocations per input byte, a little
+	// above what the parse takes today
+	maxAllocs float64
+}
+
+func workloads(t testing.TB) []workload {
+	combined, err := accesslog.Compile(accesslog.Combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []workload{
+		{"JSON", JSON(50), func(sr parser.StatefulReader) error {
+			_, err := json.Parse(sr)
+			return err
+		}, 7.5},
+		{"AccessLog", AccessLog(50), func(sr parser.StatefulReader) error {
+			s := accesslog.NewScanner(sr, combined)
+			for s.Next() {
+			}
+			return s.Err()
+		}, 5.5},
+		{"Arithmetic", Arithmetic(50), func(sr parser.StatefulReader) error {
+			for {
+				if _, err := parser.EOF()(sr); err == nil {
+					return nil
+				}
+				if _, err := calc.ParseExpr(sr); err != nil {
+					return err
+				}
+				if _, err := parser.Lit("\n")(sr); err != nil {
+					return err
+				}
+			}
+		}, 33},
+		{"TLV", TLV(200), func(sr parser.StatefulReader) error {
+			rs, err := TLVRecords(sr)
+			if err == nil && len(rs) != 200 {
+				t.Errorf("parsed %d records", len(rs))
+			}
+			return err
+		}, 0.45},
+	}
+}
+
+func readers(input []byte) map[string]func() parser.StatefulReader {
+	return map[string]func() parser.StatefulReader{
+		"Simple": func() parser.StatefulReader { return parser.NewSimpleReader(bytes.NewReader(input)) },
+		"Bytes":  func() parser.StatefulReader { return parser.NewBytesReader(input) },
+	}
+}
+
+func TestWorkloads(t *testing.T) {
+	for _, w := range workloads(t) {
+		for name, reader := range readers(w.input) {
+			sr := reader()
+			if err := w.parse(sr); err != nil {
+				t.Errorf("%s/%s: %v", w.name, name, err)
+				continue
+			}
+			if _, err := parser.EOF()(sr); err != nil {
+				t.Errorf("%s/%s: input left over: %v", w.name, name, err)
+			}
+		}
+	}
+}
+
+func TestAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocation ceilings are slow to measure")
+	}
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	for _, w := range workloads(t) {
+		for name, reader := range readers(w.input) {
+			allocs := testing.AllocsPerRun(5, func() {
+				w.parse(reader())
+			})
+			perByte := allocs / float64(len(w.input))
+			t.Logf("%s/%s: %.3f allocations per byte", w.name, name, perByte)
+			if perByte > w.maxAllocs {
+				t.Errorf("%s/%s: %.3f allocations per byte, want at most %.3f", w.name, name, perByte, w.maxAllocs)
+			}
+		}
+	}
+}
+
+func BenchmarkWorkloads(b *testing.B) {
+	for _, w := range workloads(b) {
+		for name, reader := range readers(w.input) {
+			b.Run(w.name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(w.input)))
+				for i := 0; i < b.N; i++ {
+					if err := w.parse(reader()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
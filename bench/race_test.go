@@ -0,0 +1,7 @@
+//go:build race
+
+package bench
+
+// raceEnabled reports whether tests were built with the race detector,
+// which adds allocations of its own, so allocation ceilings are skipped.
+const raceEnabled = true
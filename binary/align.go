@@ -0,0 +1,73 @@
+package binary
+
+import (
+	"fmt"
+
+	"github.com/andyleap/parser"
+)
+
+// Origin runs p with alignment counted from where it starts, for formats
+// such as ELF and PE whose sections align relative to the start of an
+// embedded header rather than of the input. Outside Origin, alignment counts
+// from the start of the input, and inside LengthPrefixed it carries on
+// counting from the enclosing origin.
+func Origin[T any](p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		off, ok := offset(sr)
+		if !ok {
+			var t T
+			return t, fmt.Errorf("Origin needs a reader that reports offsets, got %T", sr)
+		}
+		return within(func(r *reader) { r.origin = off }, p)(sr)
+	}
+}
+
+// Align skips any bytes up to the next multiple of n from the origin, and
+// returns how many it skipped. It fails if the input ends first.
+func Align(n int) func(sr parser.StatefulReader) (int, error) {
+	return align("Align", n, func(b []byte) (int, error) { return 0, nil })
+}
+
+// Pad is Align for padding that must consist of the byte b, as in ar
+// archives padded with '\n'.
+func Pad(n int, b byte) func(sr parser.StatefulReader) (int, error) {
+	return align("Pad", n, func(pad []byte) (int, error) {
+		for i, c := range pad {
+			if c != b {
+				return i, fmt.Errorf("Expected padding 0x%02x, got 0x%02x", b, c)
+			}
+		}
+		return 0, nil
+	})
+}
+
+// align skips to the next multiple of n, checking the padding with check,
+// which returns the index of the first bad byte along with its error.
+func align(name string, n int, check func([]byte) (int, error)) func(sr parser.StatefulReader) (int, error) {
+	if n <= 0 {
+		panic(fmt.Sprintf("binary: %s: alignment %d is not positive", name, n))
+	}
+	return func(sr parser.StatefulReader) (int, error) {
+		off, ok := offset(sr)
+		if !ok {
+			return 0, fmt.Errorf("%s needs a reader that reports offsets, got %T", name, sr)
+		}
+		if r, ok := sr.(*reader); ok {
+			off -= r.origin
+		}
+		skip := int((int64(n) - off%int64(n)) % int64(n))
+		if skip == 0 {
+			return 0, nil
+		}
+		s := sr.State()
+		pad, err := Bytes(skip)(sr)
+		if err != nil {
+			return 0, err
+		}
+		if i, err := check(pad); err != nil {
+			sr.Restore(s)
+			return 0, errorAt(sr, int64(i), err)
+		}
+		return skip, nil
+	}
+}
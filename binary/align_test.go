@@ -0,0 +1,47 @@
+package binary
+
+import (
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestAlign(t *testing.T) {
+	t.Parallel()
+	// A one byte tag, aligned to 4, then a U32.
+	p := parser.Right(U8(), parser.Right(Align(4), U32()))
+	v, err := parse([]byte{1, 9, 9, 9, 0, 0, 0, 7}, p)
+	assert(t, err, nil)
+	assert(t, v, uint32(7))
+
+	n, err := parse([]byte{}, Align(4))
+	assert(t, err, nil)
+	assert(t, n, 0)
+	_, err = parse([]byte{1, 0}, parser.Right(U8(), Align(4)))
+	assert(t, err.Error(), "Unexpected EOF: wanted 3 bytes, got 1")
+}
+
+func TestPad(t *testing.T) {
+	t.Parallel()
+	p := parser.Right(Bytes(3), Pad(2, '\n'))
+	n, err := parse([]byte("abc\n"), p)
+	assert(t, err, nil)
+	assert(t, n, 1)
+	_, err = parse([]byte("abcd"), p)
+	assert(t, err.Error(), "Expected padding 0x0a, got 0x64")
+}
+
+func TestOrigin(t *testing.T) {
+	t.Parallel()
+	// After a 3 byte prefix, Origin counts alignment from the header.
+	p := parser.Right(Bytes(3), Origin(parser.Right(U8(), parser.Right(Align(4), U8()))))
+	v, err := parse([]byte{0, 0, 0, 1, 0, 0, 0, 5}, p)
+	assert(t, err, nil)
+	assert(t, v, uint8(5))
+
+	// A region keeps counting from the enclosing origin, not its own start.
+	lp := parser.Right(U8(), LengthPrefixed(U8(), parser.Right(Align(4), U8())))
+	v, err = parse([]byte{0, 3, 0, 0, 5}, lp)
+	assert(t, err, nil)
+	assert(t, v, uint8(5))
+}
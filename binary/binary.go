@@ -0,0 +1,219 @@
+// Package binary provides combinators for binary formats: fixed width
+// integers in a fixed or switchable byte order, varints, raw byte runs,
+// length-prefixed regions and bit fields.
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/andyleap/parser"
+)
+
+// Bytes matches exactly n bytes. Large runs are read in pieces, so a
+// corrupt length field fails at the end of the input instead of allocating
+// all of it first.
+func Bytes(n int) func(sr parser.StatefulReader) ([]byte, error) {
+	return func(sr parser.StatefulReader) ([]byte, error) {
+		if n < 0 {
+			return nil, errorAt(sr, 0, fmt.Errorf("Negative length %d", n))
+		}
+		s := sr.State()
+		var b []byte
+		var c int
+		if n <= 1<<16 {
+			b = make([]byte, n)
+			c, _ = io.ReadFull(sr, b)
+		} else {
+			b, _ = io.ReadAll(io.LimitReader(sr, int64(n)))
+			c = len(b)
+		}
+		if c < n {
+			err := errorAt(sr, 0, fmt.Errorf("Unexpected EOF: wanted %d bytes, got %d", n, c))
+			sr.Restore(s)
+			return nil, err
+		}
+		return b, nil
+	}
+}
+
+// Byte matches a single byte equal to b.
+func Byte(b byte) func(sr parser.StatefulReader) (byte, error) {
+	one := Bytes(1)
+	return func(sr parser.StatefulReader) (byte, error) {
+		s := sr.State()
+		v, err := one(sr)
+		if err != nil {
+			return 0, err
+		}
+		if v[0] != b {
+			sr.Restore(s)
+			return 0, errorAt(sr, 0, fmt.Errorf("Expected 0x%02x, got 0x%02x", b, v[0]))
+		}
+		return b, nil
+	}
+}
+
+func fixed[T any](n int, decode func([]byte) T) func(sr parser.StatefulReader) (T, error) {
+	p := Bytes(n)
+	return func(sr parser.StatefulReader) (T, error) {
+		b, err := p(sr)
+		if err != nil {
+			var t T
+			return t, err
+		}
+		return decode(b), nil
+	}
+}
+
+func U8() func(sr parser.StatefulReader) (uint8, error) {
+	return fixed(1, func(b []byte) uint8 { return b[0] })
+}
+
+func U16BE() func(sr parser.StatefulReader) (uint16, error) {
+	return fixed(2, binary.BigEndian.Uint16)
+}
+
+func U16LE() func(sr parser.StatefulReader) (uint16, error) {
+	return fixed(2, binary.LittleEndian.Uint16)
+}
+
+func U32BE() func(sr parser.StatefulReader) (uint32, error) {
+	return fixed(4, binary.BigEndian.Uint32)
+}
+
+func U32LE() func(sr parser.StatefulReader) (uint32, error) {
+	return fixed(4, binary.LittleEndian.Uint32)
+}
+
+func U64BE() func(sr parser.StatefulReader) (uint64, error) {
+	return fixed(8, binary.BigEndian.Uint64)
+}
+
+func U64LE() func(sr parser.StatefulReader) (uint64, error) {
+	return fixed(8, binary.LittleEndian.Uint64)
+}
+
+// Length is the set of integer types usable as length prefixes.
+type Length interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// LengthPrefixed reads a length with length, then runs p over exactly that
+// many following bytes. It fails if p does not consume the whole region.
+func LengthPrefixed[L Length, T any](length func(sr parser.StatefulReader) (L, error), p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		var t T
+		s := sr.State()
+		n, err := length(sr)
+		if err != nil {
+			return t, err
+		}
+		start, _ := offset(sr)
+		b, err := Bytes(int(n))(sr)
+		if err != nil {
+			sr.Restore(s)
+			return t, err
+		}
+		t, err = Region(b, carry(sr, start, p))
+		if err != nil {
+			// Offsets in the region count from its start. The error was
+			// made for this region alone, so it can be moved in place.
+			var e *Error
+			if errors.As(err, &e) {
+				e.Offset += start
+			}
+			sr.Restore(s)
+		}
+		return t, err
+	}
+}
+
+// Region runs p over b, which it must consume entirely.
+func Region[T any](b []byte, p func(sr parser.StatefulReader) (T, error)) (T, error) {
+	r := bytes.NewReader(b)
+	t, err := p(parser.NewSimpleReader(r))
+	if err != nil {
+		return t, err
+	}
+	if r.Len() > 0 {
+		return t, &Error{Offset: int64(len(b) - r.Len()), Err: fmt.Errorf("%d trailing bytes in %d byte region", r.Len(), len(b))}
+	}
+	return t, nil
+}
+
+// carry carries the byte order and alignment origin of sr over to p, for
+// running p on a separate reader over the bytes from start.
+func carry[T any](sr parser.StatefulReader, start int64, p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	order, origin := orderOf(sr), int64(0)
+	if r, ok := sr.(*reader); ok {
+		origin = r.origin
+	}
+	return within(func(r *reader) { r.order, r.origin = order, origin-start }, p)
+}
+
+// Checked runs p and then check with the exact bytes p consumed and p's
+// result, failing with check's error if it returns one, for validating a
+// checksum or CRC over the region just parsed. When the checksum is part of
+// the region, check can compute it over the bytes before it:
+//
+//	frame := Checked(body, func(raw []byte, f Frame) error {
+//		if crc32.ChecksumIEEE(raw[:len(raw)-4]) != f.CRC {
+//			return errors.New("Bad frame CRC")
+//		}
+//		return nil
+//	})
+//
+// p's reader must report offsets, as SimpleReader, BytesReader and readers
+// tracking positions do.
+func Checked[T any](p func(sr parser.StatefulReader) (T, error), check func(raw []byte, v T) error) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		var t T
+		s := sr.State()
+		start, ok := offset(sr)
+		if !ok {
+			return t, fmt.Errorf("Checked needs a reader that reports offsets, got %T", sr)
+		}
+		v, err := p(sr)
+		if err != nil {
+			return t, err
+		}
+		end, _ := offset(sr)
+		sr.Restore(s)
+		raw := make([]byte, end-start)
+		io.ReadFull(sr, raw)
+		if err := check(raw, v); err != nil {
+			sr.Restore(s)
+			return t, errorAt(sr, 0, err)
+		}
+		return v, nil
+	}
+}
+
+// offset returns the read offset of sr, from its position if it tracks one
+// or from its state.
+func offset(sr parser.StatefulReader) (int64, bool) {
+	if r, ok := sr.(*reader); ok {
+		sr = r.StatefulReader
+	}
+	if pr, ok := sr.(interface{ Pos() parser.Position }); ok {
+		return pr.Pos().Offset, true
+	}
+	off, ok := sr.State().(int64)
+	return off, ok
+}
+
+// Bits splits v into fields of the given widths, most significant first.
+// The widths must sum to at most 64; any remaining low bits are ignored.
+func Bits(v uint64, total int, widths ...int) []uint64 {
+	fields := make([]uint64, len(widths))
+	shift := total
+	for i, w := range widths {
+		shift -= w
+		fields[i] = (v >> shift) & (1<<w - 1)
+	}
+	return fields
+}
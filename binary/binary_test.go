@@ -0,0 +1,112 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func parse[T any](b []byte, p func(parser.StatefulReader) (T, error)) (T, error) {
+	return p(parser.NewSimpleReader(bytes.NewReader(b)))
+}
+
+func assert[T any](t *testing.T, got, expected T) {
+	t.Helper()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestIntegers(t *testing.T) {
+	t.Parallel()
+	b := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	u16, _ := parse(b, U16BE())
+	assert(t, u16, uint16(0x0102))
+	u16, _ = parse(b, U16LE())
+	assert(t, u16, uint16(0x0201))
+	u32, _ := parse(b, U32BE())
+	assert(t, u32, uint32(0x01020304))
+	u32, _ = parse(b, U32LE())
+	assert(t, u32, uint32(0x04030201))
+	u64, _ := parse(b, U64BE())
+	assert(t, u64, uint64(0x0102030405060708))
+	u64, _ = parse(b, U64LE())
+	assert(t, u64, uint64(0x0807060504030201))
+	if _, err := parse(b[:3], U32BE()); err == nil {
+		t.Error("Expected error for short input")
+	}
+}
+
+func TestByte(t *testing.T) {
+	t.Parallel()
+	out, err := parse([]byte{0x7f, 'E', 'L', 'F'}, parser.And(Byte(0x7f), Byte('E'), Byte('L'), Byte('F')))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []byte{0x7f, 'E', 'L', 'F'})
+	if _, err := parse([]byte{0x00}, Byte(0x7f)); err == nil {
+		t.Error("Expected error for wrong byte")
+	}
+}
+
+func TestLengthPrefixed(t *testing.T) {
+	t.Parallel()
+	p := LengthPrefixed(U8(), parser.Mult(0, 0, U16BE()))
+	out, err := parse([]byte{4, 0, 1, 0, 2, 0xff}, p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []uint16{1, 2})
+	sr := parser.NewSimpleReader(bytes.NewReader([]byte{3, 0, 1, 0}))
+	if _, err := p(sr); err == nil {
+		t.Error("Expected error for trailing byte in region")
+	}
+	assert(t, sr.State(), any(int64(0)))
+	if _, err := parse([]byte{5, 0, 1}, p); err == nil {
+		t.Error("Expected error for truncated region")
+	}
+}
+
+func TestChecked(t *testing.T) {
+	t.Parallel()
+	type frame struct {
+		Payload []byte
+		Sum     uint8
+	}
+	body := parser.Convert(parser.Seq2(LengthPrefixed(U8(), Bytes(2)), U8()), func(p parser.Pair[[]byte, uint8]) (frame, error) {
+		return frame{p.First, p.Second}, nil
+	})
+	p := Checked(body, func(raw []byte, f frame) error {
+		sum := uint8(0)
+		for _, b := range raw[:len(raw)-1] {
+			sum += b
+		}
+		if sum != f.Sum {
+			return fmt.Errorf("Bad checksum %d, want %d", f.Sum, sum)
+		}
+		return nil
+	})
+	out, err := parse([]byte{2, 10, 20, 32, 0xff}, p)
+	assert(t, err, nil)
+	assert(t, out, frame{[]byte{10, 20}, 32})
+
+	sr := parser.NewSimpleReader(bytes.NewReader([]byte{2, 10, 20, 33}))
+	if _, err := p(sr); err == nil || err.Error() != "Bad checksum 33, want 32" {
+		t.Errorf("got %v", err)
+	}
+	assert(t, sr.State(), any(int64(0)))
+
+	v, c, err := parser.ParseBytes(parser.Mult(1, 0, p), []byte{2, 1, 2, 5, 2, 3, 4, 9})
+	if err != nil || len(v) != 2 || c.Pos().Offset != 8 {
+		t.Errorf("on a Context got %v, %v", v, err)
+	}
+}
+
+func TestBits(t *testing.T) {
+	t.Parallel()
+	assert(t, Bits(0b1_0110_0_1_0, 8, 1, 4, 1, 1, 1), []uint64{1, 6, 0, 1, 0})
+	assert(t, Bits(0x8180, 16, 1, 4, 1, 1, 1, 1, 3, 4), []uint64{1, 0, 0, 0, 1, 1, 0, 0})
+}
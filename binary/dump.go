@@ -0,0 +1,90 @@
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Error is the error binary parsers fail with, carrying the offset of the
+// byte that was wrong, or where the input ran out, so Dump can point at it.
+// Its message is Err's, without the offset.
+type Error struct {
+	Offset int64
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns err as an *Error at delta bytes past sr's offset, or err
+// alone if sr doesn't report offsets.
+func errorAt(sr parser.StatefulReader, delta int64, err error) error {
+	off, ok := offset(sr)
+	if !ok {
+		return err
+	}
+	return &Error{Offset: off + delta, Err: err}
+}
+
+// Dump renders err with a hex dump of the bytes of data around where it
+// happened, offsets on the left and ASCII on the right, and carets under the
+// offending byte in both columns: the binary counterpart of render.Text.
+// Errors without an offset are rendered alone.
+//
+//	Expected 0x3f, got 0x3e at offset 0x12
+//	00000000  7f 45 4c 46 02 01 01 00  00 00 00 00 00 00 00 00  |.ELF............|
+//	00000010  03 00 3e 00                                       |..>.|
+//	                ^^                                             ^
+func Dump(data []byte, err error) string {
+	var e *Error
+	if err == nil {
+		return ""
+	}
+	if !errors.As(err, &e) {
+		return err.Error() + "\n"
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s at offset 0x%x\n", err, e.Offset)
+	at := int(e.Offset)
+	first := (at/16 - 1) * 16
+	if first < 0 {
+		first = 0
+	}
+	for row := first; row <= at/16*16+16 && (row < len(data) || row <= at); row += 16 {
+		fmt.Fprintf(b, "%08x  ", row)
+		ascii := make([]byte, 0, 16)
+		for i := row; i < row+16; i++ {
+			if i == row+8 {
+				b.WriteByte(' ')
+			}
+			if i >= len(data) {
+				b.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(b, "%02x ", data[i])
+			if c := data[i]; c >= 0x20 && c < 0x7f {
+				ascii = append(ascii, c)
+			} else {
+				ascii = append(ascii, '.')
+			}
+		}
+		fmt.Fprintf(b, " |%s|\n", ascii)
+		if at >= row && at < row+16 {
+			i := at - row
+			hex := 10 + 3*i
+			if i >= 8 {
+				hex++
+			}
+			fmt.Fprintf(b, "%s^^%s^\n", strings.Repeat(" ", hex), strings.Repeat(" ", 61+i-hex-2))
+		}
+	}
+	return b.String()
+}
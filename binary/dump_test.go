@@ -0,0 +1,54 @@
+package binary
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+	data := []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x03\x00\x3e\x00")
+	p := parser.Right(Bytes(18), Byte(0x3f))
+	_, err := parse(data, p)
+	var e *Error
+	if !errors.As(err, &e) || e.Offset != 18 {
+		t.Fatalf("got %#v", err)
+	}
+	want := strings.Join([]string{
+		"Expected 0x3f, got 0x3e at offset 0x12",
+		"00000000  7f 45 4c 46 02 01 01 00  00 00 00 00 00 00 00 00  |.ELF............|",
+		"00000010  03 00 3e 00                                       |..>.|",
+		"                ^^                                             ^",
+		"",
+	}, "\n")
+	assert(t, Dump(data, err), want)
+
+	// Running out of input points just past the end.
+	_, err = parse(data, Bytes(32))
+	assert(t, strings.Split(Dump(data, err), "\n")[3], strings.Repeat(" ", 22)+"^^"+strings.Repeat(" ", 41)+"^")
+
+	assert(t, Dump(data, errors.New("plain")), "plain\n")
+}
+
+func TestErrorOffsets(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		name string
+		in   []byte
+		p    func(sr parser.StatefulReader) (int, error)
+		at   int64
+	}{
+		{"Pad", []byte{1, 0, 0, 9}, parser.Right(U8(), Pad(4, 0)), 3},
+		{"region", []byte{0, 0, 2, 1, 2}, parser.Right(U16(), LengthPrefixed(U8(), parser.Right(U8(), Align(1)))), 4},
+		{"At", []byte{4, 0}, parser.Right(U8(), parser.Convert(At(9, U8()), func(v uint8) (int, error) { return int(v), nil })), 2},
+	} {
+		_, err := parse(c.in, c.p)
+		var e *Error
+		if !errors.As(err, &e) || e.Offset != c.at {
+			t.Errorf("%s: got %v, want an error at %d", c.name, err, c.at)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package binary
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/andyleap/parser"
+)
+
+// reader carries the byte order U16, U32 and U64 decode with and the origin
+// Align counts from. The order is part of the reader's state, so
+// backtracking over SetOrder undoes it.
+type reader struct {
+	parser.StatefulReader
+	order  binary.ByteOrder
+	origin int64
+}
+
+type orderState struct {
+	inner any
+	order binary.ByteOrder
+}
+
+func (r *reader) State() any {
+	return orderState{r.StatefulReader.State(), r.order}
+}
+
+func (r *reader) Restore(s any) {
+	os := s.(orderState)
+	r.StatefulReader.Restore(os.inner)
+	r.order = os.order
+}
+
+// orderOf returns the byte order in force on sr, big endian if it isn't
+// running under WithOrder.
+func orderOf(sr parser.StatefulReader) binary.ByteOrder {
+	if r, ok := sr.(*reader); ok && r.order != nil {
+		return r.order
+	}
+	return binary.BigEndian
+}
+
+// WithOrder runs p with order as the byte order for U16, U32 and U64, which
+// p can change part way through with SetOrder or ByteOrderMark. p sees a
+// wrapped reader, so parsers in it needing the parse's Context won't find
+// it; keep WithOrder inside grammar rules rather than around them.
+func WithOrder[T any](order binary.ByteOrder, p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return within(func(r *reader) { r.order = order }, p)
+}
+
+// within runs p on sr wrapped in a reader, changed by set for the duration.
+func within[T any](set func(r *reader), p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		r, ok := sr.(*reader)
+		if !ok {
+			r = &reader{StatefulReader: sr}
+		}
+		prev := *r
+		set(r)
+		v, err := p(r)
+		r.order, r.origin = prev.order, prev.origin
+		return v, err
+	}
+}
+
+// SetOrder switches the byte order of the enclosing WithOrder for the rest
+// of its parse. It consumes nothing, and fails outside WithOrder.
+func SetOrder(order binary.ByteOrder) func(sr parser.StatefulReader) (binary.ByteOrder, error) {
+	return func(sr parser.StatefulReader) (binary.ByteOrder, error) {
+		r, ok := sr.(*reader)
+		if !ok || r.order == nil {
+			return nil, fmt.Errorf("SetOrder outside WithOrder")
+		}
+		r.order = order
+		return order, nil
+	}
+}
+
+// ByteOrderMark matches a TIFF style byte order mark, "II" for little endian
+// or "MM" for big endian, and switches to that order as SetOrder does.
+func ByteOrderMark() func(sr parser.StatefulReader) (binary.ByteOrder, error) {
+	mark := Bytes(2)
+	return func(sr parser.StatefulReader) (binary.ByteOrder, error) {
+		s := sr.State()
+		b, err := mark(sr)
+		if err != nil {
+			return nil, err
+		}
+		var order binary.ByteOrder
+		switch string(b) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			sr.Restore(s)
+			return nil, errorAt(sr, 0, fmt.Errorf("Expected byte order mark, got %q", b))
+		}
+		o, err := SetOrder(order)(sr)
+		if err != nil {
+			sr.Restore(s)
+		}
+		return o, err
+	}
+}
+
+func ordered[T any](n int, decode func(binary.ByteOrder, []byte) T) func(sr parser.StatefulReader) (T, error) {
+	p := Bytes(n)
+	return func(sr parser.StatefulReader) (T, error) {
+		b, err := p(sr)
+		if err != nil {
+			var t T
+			return t, err
+		}
+		return decode(orderOf(sr), b), nil
+	}
+}
+
+// U16 matches an unsigned 16 bit integer in the byte order set by WithOrder,
+// or big endian outside it.
+func U16() func(sr parser.StatefulReader) (uint16, error) {
+	return ordered(2, binary.ByteOrder.Uint16)
+}
+
+// U32 is U16 for 32 bit integers.
+func U32() func(sr parser.StatefulReader) (uint32, error) {
+	return ordered(4, binary.ByteOrder.Uint32)
+}
+
+// U64 is U16 for 64 bit integers.
+func U64() func(sr parser.StatefulReader) (uint64, error) {
+	return ordered(8, binary.ByteOrder.Uint64)
+}
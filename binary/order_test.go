@@ -0,0 +1,55 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestOrder(t *testing.T) {
+	t.Parallel()
+	v, err := parse([]byte{1, 2}, U16())
+	assert(t, err, nil)
+	assert(t, v, uint16(0x0102))
+
+	v, err = parse([]byte{1, 2}, WithOrder(binary.LittleEndian, U16()))
+	assert(t, err, nil)
+	assert(t, v, uint16(0x0201))
+
+	// A TIFF header: byte order mark, magic 42, first IFD offset.
+	header := WithOrder(binary.BigEndian, parser.Right(ByteOrderMark(), parser.Right(U16(), U32())))
+	off, err := parse([]byte{'I', 'I', 42, 0, 8, 0, 0, 0}, header)
+	assert(t, err, nil)
+	assert(t, off, uint32(8))
+	off, err = parse([]byte{'M', 'M', 0, 42, 0, 0, 0, 8}, header)
+	assert(t, err, nil)
+	assert(t, off, uint32(8))
+	_, err = parse([]byte{'X', 'X', 0, 42, 0, 0, 0, 8}, header)
+	assert(t, err.Error(), `Expected byte order mark, got "XX"`)
+
+	_, err = parse([]byte{}, SetOrder(binary.LittleEndian))
+	assert(t, err.Error(), "SetOrder outside WithOrder")
+}
+
+func TestOrderBacktracks(t *testing.T) {
+	t.Parallel()
+	// The first branch switches to little endian and then fails, which must
+	// not leave the second branch reading little endian.
+	p := WithOrder(binary.BigEndian, parser.Or(
+		parser.Right(SetOrder(binary.LittleEndian), parser.Left(U16(), Byte(0xff))),
+		U16(),
+	))
+	v, err := parse([]byte{1, 2}, p)
+	assert(t, err, nil)
+	assert(t, v, uint16(0x0102))
+
+	sr := parser.NewSimpleReader(bytes.NewReader([]byte{1, 2, 3, 4}))
+	_, err = WithOrder(binary.LittleEndian, p)(sr)
+	assert(t, err, nil)
+	assert(t, sr.State(), any(int64(2)))
+	v, err = parse([]byte{'I', 'I', 2, 1, 2}, WithOrder(binary.BigEndian, parser.Right(ByteOrderMark(), LengthPrefixed(U8(), U16()))))
+	assert(t, err, nil)
+	assert(t, v, uint16(0x0201))
+}
@@ -0,0 +1,75 @@
+package binary
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andyleap/parser"
+)
+
+// SkipTo moves to offset, counted from the origin as for Align, and returns
+// how many bytes it moved, negative if it went back. Moving forward reads
+// over the gap and works on any reader; moving back needs a reader that can
+// rewind, such as a SimpleReader, BytesReader or Context. Positions after
+// moving back keep their offset but lose their line and column.
+func SkipTo(offset int64) func(sr parser.StatefulReader) (int64, error) {
+	return func(sr parser.StatefulReader) (int64, error) {
+		return seek(sr, offset)
+	}
+}
+
+// At runs p at offset, counted from the origin as for Align, and then
+// returns to where it started, for following the offsets in a table such
+// as a ZIP central directory or ELF section headers. It consumes nothing.
+func At[T any](offset int64, p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		var t T
+		s := sr.State()
+		if _, err := seek(sr, offset); err != nil {
+			return t, err
+		}
+		v, err := p(sr)
+		sr.Restore(s)
+		if err != nil {
+			return t, err
+		}
+		return v, nil
+	}
+}
+
+// seek moves sr to offset from its origin.
+func seek(sr parser.StatefulReader, to int64) (int64, error) {
+	from, ok := offset(sr)
+	if !ok {
+		return 0, fmt.Errorf("Seeking needs a reader that reports offsets, got %T", sr)
+	}
+	inner := sr
+	if r, ok := sr.(*reader); ok {
+		to += r.origin
+		inner = r.StatefulReader
+	}
+	if to < 0 {
+		return 0, fmt.Errorf("Offset %d is before the start of the input", to)
+	}
+	if to >= from {
+		s := sr.State()
+		n, _ := io.CopyN(io.Discard, sr, to-from)
+		if n < to-from {
+			sr.Restore(s)
+			return 0, errorAt(sr, n, fmt.Errorf("Unexpected EOF: offset %d is past the end of the input at %d", to, from+n))
+		}
+		return n, nil
+	}
+	cr, ok := inner.(parser.CheckpointReader)
+	if !ok {
+		return 0, fmt.Errorf("Seeking back needs a reader that can rewind, got %T", inner)
+	}
+	cp, ok := cr.Checkpoint()
+	if !ok {
+		return 0, fmt.Errorf("Seeking back needs a reader that can rewind, got %T", inner)
+	}
+	cp.Offset = to
+	cp.Pos = parser.Position{Offset: to, File: cp.Pos.File}
+	cr.Rewind(cp)
+	return to - from, nil
+}
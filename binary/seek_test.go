@@ -0,0 +1,59 @@
+package binary
+
+import (
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestAt(t *testing.T) {
+	t.Parallel()
+	// A count, then that many offsets of one byte strings stored further on.
+	data := []byte{2, 6, 5, 0, 0, 'b', 'a'}
+	table := parser.Bind(U8(), func(n uint8) func(sr parser.StatefulReader) ([]byte, error) {
+		return func(sr parser.StatefulReader) ([]byte, error) {
+			var out []byte
+			for i := uint8(0); i < n; i++ {
+				off, err := U8()(sr)
+				if err != nil {
+					return nil, err
+				}
+				b, err := At(int64(off), Bytes(1))(sr)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, b...)
+			}
+			return out, nil
+		}
+	})
+	v, err := parse(data, table)
+	assert(t, err, nil)
+	assert(t, string(v), "ab")
+
+	v, c, err := parser.ParseBytes(table, data)
+	assert(t, err, nil)
+	assert(t, string(v), "ab")
+	assert(t, c.Pos().Offset, 3)
+
+	_, err = parse([]byte{1, 9}, table)
+	assert(t, err.Error(), "Unexpected EOF: offset 9 is past the end of the input at 2")
+}
+
+func TestSkipTo(t *testing.T) {
+	t.Parallel()
+	p := parser.Right(SkipTo(4), parser.Right(U8(), parser.Right(SkipTo(1), U8())))
+	v, err := parse([]byte{0, 7, 0, 0, 9}, p)
+	assert(t, err, nil)
+	assert(t, v, uint8(7))
+
+	n, err := parse([]byte{0, 0, 0}, SkipTo(2))
+	assert(t, err, nil)
+	assert(t, n, int64(2))
+
+	// Offsets count from the origin.
+	o := parser.Right(U8(), Origin(parser.Right(SkipTo(2), U8())))
+	v, err = parse([]byte{0, 0, 0, 5}, o)
+	assert(t, err, nil)
+	assert(t, v, uint8(5))
+}
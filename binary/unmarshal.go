@@ -0,0 +1,298 @@
+package binary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// decoder reads one value into dst. parent is the struct dst is a field of,
+// for lengths and conditions that refer to earlier fields.
+type decoder func(sr parser.StatefulReader, dst, parent reflect.Value) error
+
+// Struct returns a parser for a value laid out as the fields of T, which
+// must be a struct, read in order with no padding unless a tag asks for it.
+// Integers, floats and booleans take their width from their type and are
+// read in the byte order in force (see WithOrder) unless tagged "le" or
+// "be"; nested structs and arrays are read field by field and element by
+// element. Options go in a `binary` tag, separated by commas:
+//
+//	type Record struct {
+//		Magic   [4]byte
+//		Version uint16 `binary:"le"`
+//		Flags   uint8
+//		NameLen uint8
+//		Name    string   `binary:"len=NameLen"`
+//		Extra   []uint32 `binary:"len=2,if=Flags"`
+//		Data    []byte   `binary:"align=4,len=NameLen,if=Version==2"`
+//	}
+//
+// "len=N" gives the number of bytes in a string or elements in a slice,
+// either as a number or as the name of an earlier integer field. "if=F"
+// reads the field only when the earlier field F is non-zero, or with
+// "if=F==N" only when it equals N, leaving it zero otherwise. "align=N"
+// skips padding up to a multiple of N first, and a tag of "-" skips the
+// struct field. Struct panics if T has a field it can't read.
+func Struct[T any]() func(sr parser.StatefulReader) (T, error) {
+	var zero T
+	rt := reflect.TypeOf(zero)
+	if rt == nil || rt.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("binary: Struct: %T is not a struct", zero))
+	}
+	dec := structDecoder(rt)
+	return func(sr parser.StatefulReader) (T, error) {
+		s := sr.State()
+		var v T
+		if err := dec(sr, reflect.ValueOf(&v).Elem(), reflect.Value{}); err != nil {
+			sr.Restore(s)
+			return zero, err
+		}
+		return v, nil
+	}
+}
+
+// Unmarshal reads data, which must hold exactly one value, into the struct v
+// points to, as laid out for Struct, in big endian byte order unless tagged
+// otherwise.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Unmarshal needs a pointer to a struct, got %T", v)
+	}
+	dec := structDecoder(rv.Elem().Type())
+	_, err := Region(data, func(sr parser.StatefulReader) (struct{}, error) {
+		return struct{}{}, dec(sr, rv.Elem(), reflect.Value{})
+	})
+	return err
+}
+
+func structDecoder(rt reflect.Type) decoder {
+	type field struct {
+		index int
+		name  string
+		dec   decoder
+	}
+	fields := []field{}
+	seen := map[string]reflect.Type{}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("binary")
+		if tag == "-" {
+			continue
+		}
+		if !f.IsExported() {
+			panic(fmt.Sprintf("binary: Struct: field %s of %s is not exported", f.Name, rt))
+		}
+		dec := fieldDecoder(rt, f, tag, seen)
+		fields = append(fields, field{i, f.Name, dec})
+		seen[f.Name] = f.Type
+	}
+	return func(sr parser.StatefulReader, dst, _ reflect.Value) error {
+		for _, f := range fields {
+			if err := f.dec(sr, dst.Field(f.index), dst); err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// fieldDecoder reads the field f of rt, applying the options in tag. seen
+// holds the fields before it, which len and if may refer to.
+func fieldDecoder(rt reflect.Type, f reflect.StructField, tag string, seen map[string]reflect.Type) decoder {
+	bad := func(format string, args ...any) {
+		panic(fmt.Sprintf("binary: Struct: field %s of %s: %s", f.Name, rt, fmt.Sprintf(format, args...)))
+	}
+	var order binary.ByteOrder
+	var length func(parent reflect.Value) int
+	var cond func(parent reflect.Value) bool
+	align := 0
+	if tag != "" {
+		for _, opt := range strings.Split(tag, ",") {
+			key, val, _ := strings.Cut(opt, "=")
+			switch key {
+			case "le":
+				order = binary.LittleEndian
+			case "be":
+				order = binary.BigEndian
+			case "len":
+				length = reference(val, seen, bad)
+			case "if":
+				name, want, eq := strings.Cut(val, "==")
+				get := reference(name, seen, bad)
+				n := 0
+				if eq {
+					var err error
+					if n, err = strconv.Atoi(want); err != nil {
+						bad("bad condition %q", val)
+					}
+				}
+				cond = func(parent reflect.Value) bool {
+					if eq {
+						return get(parent) == n
+					}
+					return get(parent) != 0
+				}
+			case "align":
+				n, err := strconv.Atoi(val)
+				if err != nil || n <= 0 {
+					bad("bad alignment %q", val)
+				}
+				align = n
+			default:
+				bad("unknown option %q", opt)
+			}
+		}
+	}
+	dec := typeDecoder(f.Type, order, length, bad)
+	if align > 0 {
+		pad, inner := Align(align), dec
+		dec = func(sr parser.StatefulReader, dst, parent reflect.Value) error {
+			if _, err := pad(sr); err != nil {
+				return err
+			}
+			return inner(sr, dst, parent)
+		}
+	}
+	if cond != nil {
+		inner := dec
+		dec = func(sr parser.StatefulReader, dst, parent reflect.Value) error {
+			if !cond(parent) {
+				return nil
+			}
+			return inner(sr, dst, parent)
+		}
+	}
+	return dec
+}
+
+// reference returns a getter for a len or if value: a number, or the name
+// of an earlier integer field.
+func reference(val string, seen map[string]reflect.Type, bad func(string, ...any)) func(parent reflect.Value) int {
+	if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+		return func(reflect.Value) int { return n }
+	}
+	t, ok := seen[val]
+	if !ok {
+		bad("%q is not an earlier field", val)
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(parent reflect.Value) int { return int(parent.FieldByName(val).Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(parent reflect.Value) int { return int(parent.FieldByName(val).Uint()) }
+	case reflect.Bool:
+		return func(parent reflect.Value) int {
+			if parent.FieldByName(val).Bool() {
+				return 1
+			}
+			return 0
+		}
+	}
+	bad("field %s is not an integer", val)
+	return nil
+}
+
+func typeDecoder(t reflect.Type, order binary.ByteOrder, length func(reflect.Value) int, bad func(string, ...any)) decoder {
+	if length != nil && t.Kind() != reflect.Slice && t.Kind() != reflect.String {
+		bad("len on a %s", t)
+	}
+	orderIn := func(sr parser.StatefulReader) binary.ByteOrder {
+		if order != nil {
+			return order
+		}
+		return orderOf(sr)
+	}
+	read := func(n int, set func(b []byte, o binary.ByteOrder, dst reflect.Value)) decoder {
+		p := Bytes(n)
+		return func(sr parser.StatefulReader, dst, _ reflect.Value) error {
+			b, err := p(sr)
+			if err != nil {
+				return err
+			}
+			set(b, orderIn(sr), dst)
+			return nil
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return read(1, func(b []byte, _ binary.ByteOrder, dst reflect.Value) { dst.SetBool(b[0] != 0) })
+	case reflect.Uint8:
+		return read(1, func(b []byte, _ binary.ByteOrder, dst reflect.Value) { dst.SetUint(uint64(b[0])) })
+	case reflect.Int8:
+		return read(1, func(b []byte, _ binary.ByteOrder, dst reflect.Value) { dst.SetInt(int64(int8(b[0]))) })
+	case reflect.Uint16:
+		return read(2, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetUint(uint64(o.Uint16(b))) })
+	case reflect.Int16:
+		return read(2, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetInt(int64(int16(o.Uint16(b)))) })
+	case reflect.Uint32:
+		return read(4, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetUint(uint64(o.Uint32(b))) })
+	case reflect.Int32:
+		return read(4, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetInt(int64(int32(o.Uint32(b)))) })
+	case reflect.Uint64:
+		return read(8, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetUint(o.Uint64(b)) })
+	case reflect.Int64:
+		return read(8, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetInt(int64(o.Uint64(b))) })
+	case reflect.Float32:
+		return read(4, func(b []byte, o binary.ByteOrder, dst reflect.Value) {
+			dst.SetFloat(float64(math.Float32frombits(o.Uint32(b))))
+		})
+	case reflect.Float64:
+		return read(8, func(b []byte, o binary.ByteOrder, dst reflect.Value) { dst.SetFloat(math.Float64frombits(o.Uint64(b))) })
+	case reflect.Struct:
+		return structDecoder(t)
+	case reflect.Array:
+		elem := typeDecoder(t.Elem(), order, nil, bad)
+		return func(sr parser.StatefulReader, dst, parent reflect.Value) error {
+			for i := 0; i < dst.Len(); i++ {
+				if err := elem(sr, dst.Index(i), parent); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+			return nil
+		}
+	case reflect.String, reflect.Slice:
+		if length == nil {
+			bad("%s needs a len", t)
+		}
+		if t.Kind() == reflect.String || t.Elem().Kind() == reflect.Uint8 {
+			return func(sr parser.StatefulReader, dst, parent reflect.Value) error {
+				b, err := Bytes(length(parent))(sr)
+				if err != nil {
+					return err
+				}
+				if t.Kind() == reflect.String {
+					dst.SetString(string(b))
+				} else {
+					dst.SetBytes(b)
+				}
+				return nil
+			}
+		}
+		elem := typeDecoder(t.Elem(), order, nil, bad)
+		return func(sr parser.StatefulReader, dst, parent reflect.Value) error {
+			n := length(parent)
+			if n < 0 {
+				return errorAt(sr, 0, fmt.Errorf("Negative length %d", n))
+			}
+			// Grow as elements are read, so a corrupt length fails at the
+			// end of the input rather than allocating all of it up front.
+			s := reflect.MakeSlice(t, 0, 0)
+			for i := 0; i < n; i++ {
+				s = reflect.Append(s, reflect.Zero(t.Elem()))
+				if err := elem(sr, s.Index(i), parent); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+			dst.Set(s)
+			return nil
+		}
+	}
+	bad("can't read a %s", t)
+	return nil
+}
@@ -0,0 +1,95 @@
+package binary
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type point struct {
+	X, Y int16
+}
+
+type record struct {
+	Magic   [2]byte
+	Version uint16 `binary:"le"`
+	Flags   uint8
+	NameLen uint8
+	Name    string   `binary:"len=NameLen"`
+	Extra   []uint32 `binary:"len=2,if=Flags"`
+	Points  []point  `binary:"len=NameLen,if=Version==2"`
+	Scale   float32  `binary:"align=4"`
+	Ignored int      `binary:"-"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+	data := []byte{
+		'R', 'C', 2, 0, 1, 2, 'h', 'i',
+		0, 0, 0, 1, 0, 0, 0, 2,
+		0, 1, 0xff, 0xfe, 0, 3, 0, 4,
+		0x3f, 0x80, 0, 0,
+	}
+	var r record
+	if err := Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	want := record{
+		Magic: [2]byte{'R', 'C'}, Version: 2, Flags: 1, NameLen: 2, Name: "hi",
+		Extra:  []uint32{1, 2},
+		Points: []point{{1, -2}, {3, 4}},
+		Scale:  1,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+
+	// Without the flag and at version 1 the optional fields are absent.
+	data = []byte{'R', 'C', 1, 0, 0, 1, 'x', 0, 0x3f, 0x80, 0, 0}
+	r = record{}
+	if err := Unmarshal(data, &r); err != nil || r.Name != "x" || r.Extra != nil || r.Points != nil || r.Scale != 1 {
+		t.Errorf("got %+v, %v", r, err)
+	}
+
+	err := Unmarshal(data[:7], &r)
+	var e *Error
+	if !errors.As(err, &e) || e.Offset != 7 || err.Error() != "Scale: Unexpected EOF: wanted 1 bytes, got 0" {
+		t.Errorf("got %v", err)
+	}
+	if err := Unmarshal(data, r); err == nil {
+		t.Errorf("non-pointer accepted")
+	}
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+	p := WithOrder(binary.LittleEndian, Struct[point]())
+	v, err := parse([]byte{1, 0, 0xfe, 0xff}, p)
+	assert(t, err, nil)
+	assert(t, v, point{1, -2})
+
+	for _, f := range []func(){
+		func() { Struct[int]() },
+		func() {
+			Struct[struct {
+				S string
+			}]()
+		},
+		func() {
+			Struct[struct {
+				B []byte `binary:"len=N"`
+				N int
+			}]()
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("bad type accepted")
+				}
+			}()
+			f()
+		}()
+	}
+}
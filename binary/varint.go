@@ -0,0 +1,44 @@
+package binary
+
+import (
+	"fmt"
+
+	"github.com/andyleap/parser"
+)
+
+// Uvarint matches an unsigned LEB128 varint of up to 64 bits, as used by
+// protobuf and encoding/binary: seven bits per byte, least significant
+// first, with the high bit set on every byte but the last.
+func Uvarint() func(sr parser.StatefulReader) (uint64, error) {
+	one := Bytes(1)
+	return func(sr parser.StatefulReader) (uint64, error) {
+		s := sr.State()
+		var v uint64
+		for i := 0; ; i++ {
+			b, err := one(sr)
+			if err != nil {
+				sr.Restore(s)
+				return 0, err
+			}
+			if i == 9 && b[0] > 1 {
+				err := errorAt(sr, -1, fmt.Errorf("Varint overflows 64 bits"))
+				sr.Restore(s)
+				return 0, err
+			}
+			v |= uint64(b[0]&0x7f) << (7 * i)
+			if b[0] < 0x80 {
+				return v, nil
+			}
+		}
+	}
+}
+
+// Varint matches a signed varint in zig-zag encoding, as protobuf's sint
+// types and encoding/binary's Varint use.
+func Varint() func(sr parser.StatefulReader) (int64, error) {
+	u := Uvarint()
+	return func(sr parser.StatefulReader) (int64, error) {
+		v, err := u(sr)
+		return int64(v>>1) ^ -int64(v&1), err
+	}
+}
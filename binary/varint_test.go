@@ -0,0 +1,34 @@
+package binary
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestVarint(t *testing.T) {
+	t.Parallel()
+	for _, v := range []uint64{0, 1, 127, 128, 300, math.MaxUint32, math.MaxUint64} {
+		b := make([]byte, binary.MaxVarintLen64)
+		got, err := parse(b[:binary.PutUvarint(b, v)], Uvarint())
+		assert(t, err, nil)
+		assert(t, got, v)
+	}
+	for _, v := range []int64{0, -1, 1, -64, 64, math.MinInt64, math.MaxInt64} {
+		b := make([]byte, binary.MaxVarintLen64)
+		got, err := parse(b[:binary.PutVarint(b, v)], Varint())
+		assert(t, err, nil)
+		assert(t, got, v)
+	}
+
+	_, err := parse([]byte{0x80, 0x80}, Uvarint())
+	var e *Error
+	if !errors.As(err, &e) || e.Offset != 2 {
+		t.Errorf("truncated: got %v", err)
+	}
+	_, err = parse([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, Uvarint())
+	if !errors.As(err, &e) || e.Offset != 9 || err.Error() != "Varint overflows 64 bits" {
+		t.Errorf("overflow: got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// peeker is implemented by readers over data held in memory, letting Lit,
+// Set and NotSet look at the next bytes directly instead of reading them
+// into a buffer and restoring the reader on a mismatch. PeekBytes returns
+// up to n bytes without consuming them, fewer at the end of the input, and
+// ok false if the reader can't peek after all, as when a wrapper's inner
+// reader is not in memory.
+type peeker interface {
+	PeekBytes(n int) (b []byte, ok bool)
+	Advance(n int)
+}
+
+// BytesReader is a StatefulReader over a byte slice. It supports the same
+// parsers as a SimpleReader, but lets the terminal parsers compare against
+// the slice directly, which is much faster. Its state is the offset as an
+// int64, as for SimpleReader.
+type BytesReader struct {
+	data []byte
+	off  int
+}
+
+// NewBytesReader returns a reader over data, which must not be modified
+// while it is in use.
+func NewBytesReader(data []byte) *BytesReader {
+	return &BytesReader{data: data}
+}
+
+func (br *BytesReader) Read(p []byte) (int, error) {
+	if br.off >= len(br.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, br.data[br.off:])
+	br.off += n
+	return n, nil
+}
+
+func (br *BytesReader) State() any {
+	return int64(br.off)
+}
+
+func (br *BytesReader) Restore(s any) {
+	br.off = int(s.(int64))
+}
+
+// Clone returns an independent reader over the same data.
+func (br *BytesReader) Clone() (StatefulReader, bool) {
+	return &BytesReader{data: br.data, off: br.off}, true
+}
+
+// PeekBytes returns up to the next n bytes without consuming them. The
+// result aliases the reader's data and must not be modified.
+func (br *BytesReader) PeekBytes(n int) ([]byte, bool) {
+	end := br.off + n
+	if end > len(br.data) {
+		end = len(br.data)
+	}
+	return br.data[br.off:end], true
+}
+
+// Advance consumes n bytes, which must have been returned by PeekBytes.
+func (br *BytesReader) Advance(n int) {
+	br.off += n
+}
+
+// PeekBytes forwards to the wrapped reader, if it can peek.
+func (pr *PosReader) PeekBytes(n int) ([]byte, bool) {
+	if pk, ok := pr.sr.(peeker); ok {
+		return pk.PeekBytes(n)
+	}
+	return nil, false
+}
+
+// Advance consumes n peeked bytes, updating the position.
+func (pr *PosReader) Advance(n int) {
+	pk := pr.sr.(peeker)
+	b, _ := pk.PeekBytes(n)
+	pr.track(b)
+	pk.Advance(n)
+}
+
+// PeekBytes forwards to the wrapped reader, if it can peek and the parse
+// hasn't been abandoned.
+func (mr *MemoReader) PeekBytes(n int) ([]byte, bool) {
+	if pk, ok := mr.sr.(peeker); ok && mr.abort == nil {
+		return pk.PeekBytes(n)
+	}
+	return nil, false
+}
+
+// Advance forwards to the wrapped reader.
+func (mr *MemoReader) Advance(n int) {
+	mr.sr.(peeker).Advance(n)
+}
+
+// peekRune decodes the next rune without consuming it. ok is false if sr
+// can't peek, and size is 0 at the end of the input.
+func peekRune(sr StatefulReader) (r rune, size int, ok bool) {
+	pk, ok := sr.(peeker)
+	if !ok {
+		return 0, 0, false
+	}
+	b, ok := pk.PeekBytes(utf8.UTFMax)
+	if !ok || len(b) == 0 {
+		return 0, 0, ok
+	}
+	r, size = utf8.DecodeRune(b)
+	return r, size, true
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBytesReader(t *testing.T) {
+	t.Parallel()
+	word := join(Mult(1, 0, Set("a-zé")))
+	p := And(word, Lit(" = "), join(Mult(1, 0, NotSet("\n"))), Lit("\n"), EOF())
+	input := "clé = valeur ñ\n"
+	for name, sr := range map[string]StatefulReader{
+		"simple": NewPosReader(NewSimpleReader(strings.NewReader(input))),
+		"bytes":  NewPosReader(NewBytesReader([]byte(input))),
+	} {
+		out, err := p(sr)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		assert(t, out, []string{"clé", " = ", "valeur ñ", "\n", ""})
+		assert(t, Pos(sr), Position{Offset: 17, Line: 2, Column: 1})
+	}
+}
+
+// TestBytesReaderErrors checks that the fast path fails the same way, in
+// the same place, and expecting the same things as reading does.
+func TestBytesReaderErrors(t *testing.T) {
+	t.Parallel()
+	p := And(Lit("ab"), Or(Lit("cd"), Set("x-z"), NotSet("q")), EOF())
+	for _, input := range []string{"", "a", "abc", "abce", "abq", "abxy"} {
+		_, simple, serr := ParseReader(p, strings.NewReader(input))
+		_, fast, ferr := ParseBytes(p, []byte(input))
+		if !reflect.DeepEqual(serr, ferr) {
+			t.Errorf("%q: got %v, want %v", input, ferr, serr)
+		}
+		if simple.Pos() != fast.Pos() {
+			t.Errorf("%q: left at %v, want %v", input, fast.Pos(), simple.Pos())
+		}
+	}
+	if _, err := Set("a")(NewBytesReader(nil)); err != io.EOF {
+		t.Errorf("Set at EOF: got %v", err)
+	}
+}
+
+func TestBytesReaderTabWidth(t *testing.T) {
+	t.Parallel()
+	pr := NewPosReader(NewBytesReader([]byte("\t世x")))
+	pr.TabWidth = 4
+	if _, err := Lit("\t世")(pr); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, pr.Pos().Column, 7)
+}
+
+func BenchmarkParseReader(b *testing.B) {
+	benchmarkParse(b, func(p func(sr StatefulReader) ([]string, error), s string) {
+		ParseReader(p, strings.NewReader(s))
+	})
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	benchmarkParse(b, func(p func(sr StatefulReader) ([]string, error), s string) {
+		ParseBytes(p, []byte(s))
+	})
+}
+
+func benchmarkParse(b *testing.B, parse func(p func(sr StatefulReader) ([]string, error), s string)) {
+	ident := join(Mult(1, 0, Set("a-z")))
+	p := Mult(0, 0, Or(Lit("let "), ident, Lit(" = "), Lit(";\n")))
+	input := strings.Repeat("let abc = def;\n", 100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		parse(p, input)
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import "fmt"
+
+// ContractError describes a parser that broke the reader contract: failing
+// parsers must leave the reader where they started, and succeeding parsers
+// must not move it backwards.
+type ContractError struct {
+	Parser     string
+	Start, End Position
+	Failed     bool
+}
+
+func (e *ContractError) Error() string {
+	if e.Failed {
+		return fmt.Sprintf("parser %s failed at %s but left the reader at %s", e.Parser, where(e.Start), where(e.End))
+	}
+	return fmt.Sprintf("parser %s matched at %s but moved the reader back to %s", e.Parser, where(e.Start), where(e.End))
+}
+
+// where describes p as a line and column, or as an offset if the reader
+// didn't track lines.
+func where(p Position) string {
+	if p.Line == 0 {
+		return fmt.Sprintf("offset %d", p.Offset)
+	}
+	return p.String()
+}
+
+// offset returns the read offset of sr and whether it could be determined.
+func offset(sr StatefulReader) (int64, bool) {
+	if pr, ok := sr.(interface{ Pos() Position }); ok {
+		return pr.Pos().Offset, true
+	}
+	switch s := sr.State().(type) {
+	case int64:
+		return s, true
+	case int:
+		return int64(s), true
+	}
+	return 0, false
+}
+
+// Checked is a debugging aid that runs p and panics with a *ContractError
+// if p breaks the reader contract, naming p so the bug can be found. It is
+// most useful around hand written parsers, and is cheap enough to leave in
+// tests. Combine it with Recover or RecoverPanics to get an error instead.
+//
+// Offsets come from the reader's position if it tracks one, or from
+// SimpleReader and SliceReader states. The CheckContracts option applies
+// the same check to every Named parser and Grammar rule.
+func Checked[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Checked", p)
+	return func(sr StatefulReader) (T, error) {
+		return checkContract(name, p, sr)
+	}
+}
+
+// contracted runs p through checkContract when sr is a Context with
+// CheckContracts set.
+func contracted[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		if c := ContextOf(sr); c != nil && c.CheckContracts {
+			return checkContract(name, p, sr)
+		}
+		return p(sr)
+	}
+}
+
+func checkContract[T any](name string, p func(sr StatefulReader) (T, error), sr StatefulReader) (T, error) {
+	start, ok := offset(sr)
+	startPos := Pos(sr)
+	v, err := p(sr)
+	if !ok {
+		return v, err
+	}
+	// an abandoned parse unwinds without restoring
+	if _, fatal := err.(fatalError); fatal {
+		return v, err
+	}
+	end, _ := offset(sr)
+	if (err != nil && end != start) || (err == nil && end < start) {
+		endPos := Pos(sr)
+		if endPos == (Position{}) {
+			startPos, endPos = Position{Offset: start}, Position{Offset: end}
+		}
+		panic(&ContractError{Parser: name, Start: startPos, End: endPos, Failed: err != nil})
+	}
+	return v, err
+}
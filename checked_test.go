@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChecked(t *testing.T) {
+	sloppy := func(sr StatefulReader) (string, error) {
+		b := make([]byte, 2)
+		sr.Read(b)
+		if string(b) != "ab" {
+			return "", errors.New("Expected \"ab\"")
+		}
+		return "ab", nil
+	}
+	rewinds := func(sr StatefulReader) (string, error) {
+		s := sr.State()
+		Lit("x")(sr)
+		Lit("y")(sr)
+		sr.Restore(s)
+		Lit("x")(sr)
+		return "x", nil
+	}
+	p := Or(Checked("ab", sloppy), Lit("a"))
+
+	if v, err := p(NewSimpleReader(strings.NewReader("ab"))); err != nil || v != "ab" {
+		t.Errorf("got %q, %v", v, err)
+	}
+
+	_, _, err := ParseReader(p, strings.NewReader("ax"), RecoverPanics())
+	var ce *ContractError
+	if !errors.As(err, &ce) {
+		t.Fatalf("got %v", err)
+	}
+	if ce.Parser != "ab" || !ce.Failed || ce.End.Offset != 2 {
+		t.Errorf("got %+v", ce)
+	}
+	if ce.Error() != "parser ab failed at 1:1 but left the reader at 1:3" {
+		t.Errorf("Error() = %q", ce.Error())
+	}
+
+	sr := NewSimpleReader(strings.NewReader("xy"))
+	Lit("x")(sr)
+	_, err = Recover(Checked("rewinds", func(sr StatefulReader) (string, error) {
+		sr.Restore(int64(0))
+		return "", nil
+	}))(sr)
+	if !errors.As(err, &ce) || ce.Failed || ce.Start.Offset != 1 || ce.End.Offset != 0 {
+		t.Errorf("got %v", err)
+	}
+	if ce.Error() != "parser rewinds matched at offset 1 but moved the reader back to offset 0" {
+		t.Errorf("Error() = %q", ce.Error())
+	}
+
+	if v, err := Checked("rewinds", rewinds)(NewSimpleReader(strings.NewReader("xy"))); err != nil || v != "x" {
+		t.Errorf("got %q, %v", v, err)
+	}
+}
+
+func TestCheckContracts(t *testing.T) {
+	t.Parallel()
+	g := NewGrammar()
+	sloppy := Rule(g, "sloppy", func() func(sr StatefulReader) (string, error) {
+		return func(sr StatefulReader) (string, error) {
+			readRune(sr)
+			return "", errors.New("Expected nothing")
+		}
+	})
+	p := Or(Named("item", Lit("ab")), sloppy, Lit("c"))
+
+	// Memo restores the reader once the rule fails, hiding the bug
+	if v, _, err := ParseReader(p, strings.NewReader("c")); err != nil || v != "c" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	if v, _, err := ParseReader(p, strings.NewReader("ab"), CheckContracts()); err != nil || v != "ab" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	_, _, err := ParseReader(p, strings.NewReader("c"), CheckContracts(), RecoverPanics())
+	var ce *ContractError
+	if !errors.As(err, &ce) {
+		t.Fatalf("got %v", err)
+	}
+	if ce.Parser != "sloppy" || !ce.Failed || ce.End.Offset != 1 {
+		t.Errorf("got %+v", ce)
+	}
+}
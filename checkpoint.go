@@ -0,0 +1,109 @@
+package parser
+
+// Checkpoint is a reader state held in a plain struct, so that saving it
+// doesn't allocate the way boxing a state in State's interface value does.
+// Readers fill in the fields they need: the byte or element offset, the
+// position a PosReader tracks, and the number of highlighting spans and
+// recovered errors a MemoReader has recorded.
+type Checkpoint struct {
+	Offset int64
+	Pos    Position
+	Spans  int
+	Errors int
+}
+
+// CheckpointReader is a StatefulReader that can also save its state as a
+// Checkpoint. The combinators prefer Checkpoint and Rewind to State and
+// Restore when a reader offers them; ok is false when it can't after all,
+// as when a wrapper's inner reader only has State. Readers that implement
+// just StatefulReader keep working through State and Restore.
+type CheckpointReader interface {
+	StatefulReader
+	Checkpoint() (cp Checkpoint, ok bool)
+	Rewind(Checkpoint)
+}
+
+// mark is a saved reader state, as a Checkpoint when the reader offers one
+// and from State otherwise.
+type mark struct {
+	cp    Checkpoint
+	typed bool
+	state any
+}
+
+func save(sr StatefulReader) mark {
+	if c, ok := sr.(CheckpointReader); ok {
+		if cp, ok := c.Checkpoint(); ok {
+			return mark{cp: cp, typed: true}
+		}
+	}
+	return mark{state: sr.State()}
+}
+
+func (m mark) restore(sr StatefulReader) {
+	if m.typed {
+		sr.(CheckpointReader).Rewind(m.cp)
+		return
+	}
+	sr.Restore(m.state)
+}
+
+func (sr SimpleReader) Checkpoint() (Checkpoint, bool) {
+	off, err := sr.r.Seek(0, 1)
+	return Checkpoint{Offset: off}, err == nil
+}
+
+func (sr SimpleReader) Rewind(cp Checkpoint) {
+	sr.r.Seek(cp.Offset, 0)
+}
+
+func (br *BytesReader) Checkpoint() (Checkpoint, bool) {
+	return Checkpoint{Offset: int64(br.off)}, true
+}
+
+func (br *BytesReader) Rewind(cp Checkpoint) {
+	br.off = int(cp.Offset)
+}
+
+func (r *SliceReader[E]) Checkpoint() (Checkpoint, bool) {
+	return Checkpoint{Offset: int64(r.pos)}, true
+}
+
+func (r *SliceReader[E]) Rewind(cp Checkpoint) {
+	r.pos = int(cp.Offset)
+}
+
+func (pr *PosReader) Checkpoint() (Checkpoint, bool) {
+	c, ok := pr.sr.(CheckpointReader)
+	if !ok {
+		return Checkpoint{}, false
+	}
+	cp, ok := c.Checkpoint()
+	cp.Pos = pr.pos
+	return cp, ok
+}
+
+func (pr *PosReader) Rewind(cp Checkpoint) {
+	pr.sr.(CheckpointReader).Rewind(cp)
+	pr.pos = cp.Pos
+	pr.partial = pr.partial[:0]
+}
+
+func (mr *MemoReader) Checkpoint() (Checkpoint, bool) {
+	c, ok := mr.sr.(CheckpointReader)
+	if !ok {
+		return Checkpoint{}, false
+	}
+	cp, ok := c.Checkpoint()
+	l := mr.logs()
+	cp.Spans, cp.Errors = l.spans, l.errors
+	return cp, ok
+}
+
+func (mr *MemoReader) Rewind(cp Checkpoint) {
+	if mr.lookahead > 0 || mr.countRewinds {
+		defer mr.backtracked(mr.Pos().Offset)
+	}
+	mr.sr.(CheckpointReader).Rewind(cp)
+	mr.truncate(logs{spans: cp.Spans, errors: cp.Errors})
+}
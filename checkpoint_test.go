@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// stateOnly hides every method of a reader except those of StatefulReader.
+type stateOnly struct {
+	StatefulReader
+}
+
+func TestCheckpoint(t *testing.T) {
+	t.Parallel()
+	p := And(Lit("ab"), Or(Lit("cx"), Lit("cd")), Optional(Lit("!")), EOF())
+	for name, sr := range map[string]StatefulReader{
+		"bytes":     NewMemoReader(NewPosReader(NewBytesReader([]byte("abcd")))),
+		"simple":    NewMemoReader(NewPosReader(NewSimpleReader(strings.NewReader("abcd")))),
+		"stateOnly": NewMemoReader(NewPosReader(stateOnly{NewSimpleReader(strings.NewReader("abcd"))})),
+	} {
+		out, err := p(sr)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		assert(t, out, []string{"ab", "cd", "", ""})
+		assert(t, Pos(sr), Position{Offset: 4, Line: 1, Column: 5})
+	}
+	if _, ok := NewPosReader(stateOnly{NewBytesReader(nil)}).Checkpoint(); ok {
+		t.Error("PosReader over a reader without checkpoints made one")
+	}
+}
+
+func TestCheckpointSpans(t *testing.T) {
+	t.Parallel()
+	kw := Classify(ClassKeyword, Lit("if"))
+	p := Or(And(kw, Lit("x")), And(kw, Lit(" y")))
+	_, c, err := ParseReader(p, strings.NewReader("if y"), Highlight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spans := c.Spans(); len(spans) != 1 {
+		t.Errorf("got spans %v", spans)
+	}
+}
+
+func TestCheckpointAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	for name, sr := range map[string]StatefulReader{
+		"bytes":  newContext(NewBytesReader([]byte("abc"))),
+		"simple": newContext(NewSimpleReader(strings.NewReader("abc"))),
+	} {
+		allocs := testing.AllocsPerRun(100, func() {
+			m := save(sr)
+			readRune(sr)
+			readRune(sr)
+			m.restore(sr)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: %v allocations per save and restore", name, allocs)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package parser
+
+// Strategy is how a Choice picks among its alternatives.
+type Strategy int
+
+const (
+	// FirstMatch takes the first alternative that matches, as Or does.
+	// This is ordered choice as in a PEG, and the cheapest.
+	FirstMatch Strategy = iota
+	// LongestMatch tries every alternative and takes the one that
+	// consumes the most input, as OrLongest does.
+	LongestMatch
+	// AllMatches takes the first alternative that matches, as Or does,
+	// but tries the rest too and reports a warning diagnostic with code
+	// "ambiguous" wherever two of them match the same input, as
+	// OrAmbiguous does. Set the Context's Severities to make it an error.
+	AllMatches
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case FirstMatch:
+		return "first-match"
+	case LongestMatch:
+		return "longest-match"
+	case AllMatches:
+		return "all-matches"
+	}
+	return "unknown"
+}
+
+// OrLongest tries every alternative from the same start and returns the
+// one that consumed the most input, preferring the earliest on a tie.
+// Lengths come from the reader's position, or the states of the readers
+// Checked understands; when neither is available it behaves like Or.
+func OrLongest[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("OrLongest", ps...)
+	return func(sr StatefulReader) (T, error) {
+		s := save(sr)
+		var (
+			best    T
+			bestEnd mark
+			bestOff int64
+			matched bool
+		)
+		for _, p := range ps {
+			v, err := p(sr)
+			if err == nil {
+				end, ok := offset(sr)
+				if !ok {
+					return v, nil
+				}
+				if !matched || end > bestOff {
+					best, bestEnd, bestOff, matched = v, save(sr), end, true
+				}
+			}
+			s.restore(sr)
+		}
+		if !matched {
+			var t T
+			return t, msg(MsgNoMatch)
+		}
+		bestEnd.restore(sr)
+		return best, nil
+	}
+}
+
+// Choice is a choice between alternatives whose Strategy is set by the
+// grammar: the UseStrategy option of the enclosing rule if it has one, and
+// otherwise g.Strategy when the parse runs. The same grammar can so run as
+// a strict PEG in production and check itself for ambiguity in tests.
+// Outside any rule of g, or on a reader other than a Context or
+// MemoReader, g.Strategy applies.
+func Choice[T any](g *Grammar, ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Choice", ps...)
+	first := Or(ps...)
+	longest := OrLongest(ps...)
+	return func(sr StatefulReader) (T, error) {
+		switch g.strategy(sr) {
+		case LongestMatch:
+			return longest(sr)
+		case AllMatches:
+			return OrAmbiguous(func(a Ambiguity) {
+				ReportCode(sr, SeverityWarning, "ambiguous", a.String())
+			}, ps...)(sr)
+		}
+		return first(sr)
+	}
+}
+
+// strategy returns the Strategy in force for a Choice of g.
+func (g *Grammar) strategy(sr StatefulReader) Strategy {
+	if m, ok := sr.(interface{ memo() *MemoReader }); ok {
+		if mr := m.memo(); mr.grammar == g {
+			return mr.strategy
+		}
+	}
+	return g.Strategy
+}
+
+// withStrategy makes the rule's Strategy, or g's, the one in force for
+// Choices of g while p runs.
+func withStrategy[T any](g *Grammar, o ruleOptions, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		m, ok := sr.(interface{ memo() *MemoReader })
+		if !ok {
+			return p(sr)
+		}
+		mr := m.memo()
+		s := g.Strategy
+		if o.hasStrategy {
+			s = o.strategy
+		}
+		prevG, prevS := mr.grammar, mr.strategy
+		mr.grammar, mr.strategy = g, s
+		defer func() { mr.grammar, mr.strategy = prevG, prevS }()
+		return p(sr)
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrLongest(t *testing.T) {
+	t.Parallel()
+	p := OrLongest(Lit("<"), Lit("<="), Lit("<<="), Lit("<<"))
+	for in, want := range map[string]string{"<": "<", "<=": "<=", "<<=": "<<=", "<<x": "<<"} {
+		sr := NewPosReader(NewSimpleReader(strings.NewReader(in)))
+		v, err := p(sr)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		assert(t, v, want)
+		assert(t, sr.Pos().Offset, int64(len(want)))
+	}
+	if _, err := p(NewSimpleReader(strings.NewReader("x"))); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// keywordGrammar has a Choice between a keyword and an identifier, which
+// are ambiguous on the keyword itself.
+func keywordGrammar(opts ...RuleOption) (*Grammar, func(StatefulReader) (string, error)) {
+	g := NewGrammar()
+	ident := join(Mult(1, 0, Set("a-z")))
+	word := Rule(g, "word", func() func(StatefulReader) (string, error) {
+		return Choice(g, Lit("if"), ident)
+	}, opts...)
+	words := Rule(g, "words", func() func(StatefulReader) (string, error) {
+		return join(MultSep(1, 0, word, Lit(" ")))
+	})
+	return g, words
+}
+
+func TestChoiceStrategy(t *testing.T) {
+	t.Parallel()
+	g, words := keywordGrammar()
+	tests := []struct {
+		strategy    Strategy
+		out         string
+		diagnostics int
+	}{
+		{FirstMatch, "ifif", 0},
+		{LongestMatch, "ifify", 0},
+		{AllMatches, "ifif", 1},
+	}
+	for _, test := range tests {
+		g.Strategy = test.strategy
+		out, c, err := ParseReader(words, strings.NewReader("if ify"))
+		if err != nil {
+			t.Errorf("%v: %v", test.strategy, err)
+			continue
+		}
+		if out != test.out || len(c.Diagnostics) != test.diagnostics {
+			t.Errorf("%v: got %q with %v, want %q with %d diagnostics", test.strategy, out, c.Diagnostics, test.out, test.diagnostics)
+		}
+	}
+}
+
+func TestChoiceAmbiguous(t *testing.T) {
+	t.Parallel()
+	g, words := keywordGrammar()
+	g.Strategy = AllMatches
+	_, c, err := ParseReader(words, strings.NewReader("x if"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Diagnostics) != 1 || c.Diagnostics[0].Code != "ambiguous" || c.Diagnostics[0].Pos.Offset != 2 {
+		t.Errorf("got %v", c.Diagnostics)
+	}
+	_, _, err = ParseReader(words, strings.NewReader("x if"), Severities(map[string]Severity{"ambiguous": SeverityError}))
+	if err == nil {
+		t.Error("expected the ambiguity to be an error")
+	}
+}
+
+func TestChoiceRuleOverride(t *testing.T) {
+	t.Parallel()
+	g, words := keywordGrammar(UseStrategy(LongestMatch))
+	out, _, err := ParseReader(words, strings.NewReader("ify"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, g.Strategy, FirstMatch)
+	assert(t, out, "ify")
+	// without a Context there is no rule state, so the grammar's applies
+	out, err = words(NewSimpleReader(strings.NewReader("ify")))
+	assert(t, out, "if")
+	assert(t, err, nil)
+}
@@ -0,0 +1,58 @@
+package parser
+
+// Column matches nothing and returns the current column, for building
+// layout rules with Bind. It needs a reader that tracks positions, as a
+// Context does, and returns 0 on others.
+func Column() func(sr StatefulReader) (int, error) {
+	return func(sr StatefulReader) (int, error) {
+		return Pos(sr).Column, nil
+	}
+}
+
+// AtColumn runs p only if the input is at column n, as in the fields of
+// fixed-width records or the lines of an indented block:
+//
+//	block := Bind(Column(), func(col int) func(sr StatefulReader) ([]Stmt, error) {
+//		return Mult(1, 0, AtColumn(col, stmt))
+//	})
+//
+// The check is made where p starts, so line breaks and indentation before
+// it must already be consumed, typically by the end of the line before.
+// Like Column it needs a reader that tracks positions.
+func AtColumn[T any](n int, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("AtColumn", p)
+	return func(sr StatefulReader) (T, error) {
+		if pos := Pos(sr); pos.Column != n {
+			var t T
+			return t, &ParseError{Pos: pos, Err: msg(MsgColumn, n, pos.Column)}
+		}
+		return p(sr)
+	}
+}
+
+// SameColumnAs runs ref and then p, requiring p to start in the column ref
+// started in, as for a value aligned under its header or a continuation
+// aligned with the line it continues. As with AtColumn, ref must consume
+// whatever comes between the two.
+func SameColumnAs[R, T any](ref func(sr StatefulReader) (R, error), p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (Pair[R, T], error) {
+	mustParsers("SameColumnAs", ref)
+	mustParsers("SameColumnAs", p)
+	return func(sr StatefulReader) (Pair[R, T], error) {
+		s := save(sr)
+		col := Pos(sr).Column
+		r, err := ref(sr)
+		if err != nil {
+			return Pair[R, T]{}, err
+		}
+		if pos := Pos(sr); pos.Column != col {
+			s.restore(sr)
+			return Pair[R, T]{}, &ParseError{Pos: pos, Err: msg(MsgColumn, col, pos.Column)}
+		}
+		v, err := p(sr)
+		if err != nil {
+			s.restore(sr)
+			return Pair[R, T]{}, err
+		}
+		return Pair[R, T]{r, v}, nil
+	}
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestAtColumn(t *testing.T) {
+	t.Parallel()
+	word := join(Mult(1, 0, Set("a-z")))
+	line := Left(word, join(And(Lit("\n"), join(Mult(0, 0, Lit(" "))))))
+	block := Bind(Column(), func(col int) func(sr StatefulReader) ([]string, error) {
+		return Mult(1, 0, AtColumn(col, line))
+	})
+	item := Right(Lit("- "), block)
+	for _, c := range []struct {
+		in   string
+		want int
+	}{
+		{"- a\n  b\n  c\n", 3},
+		{"- a\n  b\n   c\n", 2},
+		{"- a\n b\n", 1},
+	} {
+		v, _, err := ParseBytes(item, []byte(c.in))
+		if err != nil || len(v) != c.want {
+			t.Errorf("%q: got %q, %v", c.in, v, err)
+		}
+	}
+
+	_, _, err := ParseBytes(AtColumn(3, word), []byte("ab"))
+	assert(t, err.Error(), "1:1: Expected column 3, at column 1")
+}
+
+func TestSameColumnAs(t *testing.T) {
+	t.Parallel()
+	// a value on the line below its header, aligned under it
+	header := Left(join(Mult(1, 0, Set("A-Z"))), Lit("\n"))
+	value := join(Mult(1, 0, Set("0-9")))
+	record := Right(join(Mult(0, 0, Lit(" "))), SameColumnAs(header, value))
+	v, _, err := ParseBytes(Right(Lit("\n"), record), []byte("\nID\n42"))
+	if err != nil || v.First != "ID" || v.Second != "42" {
+		t.Errorf("got %v, %v", v, err)
+	}
+	_, _, err = ParseBytes(record, []byte("  ID\n42"))
+	assert(t, err.Error(), "2:1: Expected column 3, at column 1")
+}
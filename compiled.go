@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"io"
+	"sync"
+)
+
+// Compiled is a grammar prepared for repeated use, such as by a server
+// parsing many documents. Combinators do their setup (expanding sets,
+// sorting operators) when they are constructed, so the grammar is built
+// once, before Compile; Compiled adds a pool of Contexts so that each Run
+// reuses the reader and memo table allocations of earlier runs.
+//
+// A Compiled is safe for concurrent use.
+type Compiled[T any] struct {
+	p    func(sr StatefulReader) (T, error)
+	o    options
+	pool sync.Pool
+}
+
+// Compile wraps p for repeated use.
+func Compile[T any](p func(sr StatefulReader) (T, error), opts ...Option) *Compiled[T] {
+	o := buildOptions(opts)
+	return &Compiled[T]{p: wrap(p, o), o: o}
+}
+
+func (c *Compiled[T]) context(sr StatefulReader) *Context {
+	ctx, ok := c.pool.Get().(*Context)
+	if ok {
+		ctx.reset(sr)
+	} else {
+		ctx = newContext(sr)
+	}
+	c.o.configure(ctx)
+	return ctx
+}
+
+// Run parses r. Use ParseReader instead when the memo table or diagnostics
+// are needed afterwards.
+func (c *Compiled[T]) Run(r io.ReadSeeker) (T, error) {
+	return c.run(NewSimpleReader(r))
+}
+
+// RunBytes parses data through a BytesReader.
+func (c *Compiled[T]) RunBytes(data []byte) (T, error) {
+	return c.run(NewBytesReader(data))
+}
+
+// RunString parses s through a BytesReader.
+func (c *Compiled[T]) RunString(s string) (T, error) {
+	return c.run(NewBytesReader([]byte(s)))
+}
+
+func (c *Compiled[T]) run(sr StatefulReader) (T, error) {
+	ctx := c.context(sr)
+	defer c.pool.Put(ctx)
+	done := c.o.observe(ctx, sr)
+	if err := c.o.checkSize(ctx, sr); err != nil {
+		done(err)
+		var t T
+		return t, err
+	}
+	v, err := c.p(ctx)
+	v, err = finish(ctx, v, err)
+	done(err)
+	return v, err
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompiled(t *testing.T) {
+	built := 0
+	num := Memo("num", join(Mult(1, 0, Set("0-9"))))
+	sum := Lazy(func() func(sr StatefulReader) (int, error) {
+		built++
+		return Convert(And(num, Lit("+"), num), func(vs []string) (int, error) {
+			a, _ := strconv.Atoi(vs[0])
+			b, _ := strconv.Atoi(vs[2])
+			return a + b, nil
+		})
+	})
+	var runs int64
+	c := Compile(Bind(sum, func(v int) func(sr StatefulReader) (int, error) {
+		return Convert(EOF(), func(string) (int, error) { return v, nil })
+	}), Observe(func(ParseStats) { atomic.AddInt64(&runs, 1) }))
+	if built != 0 || runs != 0 {
+		t.Errorf("Compile ran the grammar")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				v, err := c.RunString(strconv.Itoa(i) + "+" + strconv.Itoa(j))
+				if err != nil || v != i+j {
+					t.Errorf("%d+%d: got %d, %v", i, j, v, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if built != 1 {
+		t.Errorf("Lazy built %d times", built)
+	}
+
+	if _, err := c.Run(strings.NewReader("1+")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	num := Memo("num", join(Mult(1, 0, Set("0-9"))))
+	c := Compile(And(num, Lit(","), num))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.RunString("123,456")
+	}
+}
+
+func TestCompiledErrors(t *testing.T) {
+	c := Compile(stmts())
+	_, err := c.RunString("a=x;")
+	first, ok := err.(ErrorList)
+	if !ok || len(first) != 1 {
+		t.Fatalf("got %v", err)
+	}
+	// a later run mustn't reuse the list returned earlier
+	c.RunString("b=1;c=y;")
+	if first[0].Pos.Offset != 0 {
+		t.Errorf("first error list changed to %v", first)
+	}
+}
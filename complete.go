@@ -0,0 +1,34 @@
+package parser
+
+import "strings"
+
+// Completion lists what could be typed at a point in the input.
+type Completion struct {
+	// Pos is where the completed token starts, which is before the offset
+	// asked about when a literal has been partly typed.
+	Pos Position
+	// Prefix is the partly typed text between Pos and the offset.
+	Prefix string
+	// Expected holds the terminals and rule names that could go at Pos.
+	// Literals are only included if they start with Prefix, and other kinds
+	// only if Prefix is empty.
+	Expected []Expectation
+}
+
+// Complete runs p over input up to offset and reports what could legally
+// follow, for tab completion in shells and editors. It works from the
+// furthest point the parse reached, so p's result and errors don't matter.
+func Complete[T any](p func(sr StatefulReader) (T, error), input string, offset int) Completion {
+	c := newContext(NewBytesReader([]byte(input[:offset])))
+	p(c)
+	if !c.failed {
+		return Completion{Pos: c.Pos()}
+	}
+	comp := Completion{Pos: c.failPos, Prefix: input[c.failPos.Offset:offset]}
+	for _, e := range c.expected {
+		if comp.Prefix == "" || (e.Kind == ExpectLiteral && strings.HasPrefix(e.Text, comp.Prefix)) {
+			comp.Expected = append(comp.Expected, e)
+		}
+	}
+	return comp
+}
@@ -0,0 +1,33 @@
+package parser
+
+import "testing"
+
+func TestComplete(t *testing.T) {
+	ws := join(Mult(0, 0, Set(" ")))
+	cmd := join(And(
+		Or(Lit("get"), Lit("set"), Lit("delete")),
+		ws,
+		Named("key", join(Mult(1, 0, Set("a-z")))),
+		ws,
+		Or(Lit("--force"), Lit("--verbose"), EOF()),
+	))
+	for _, tc := range []struct {
+		in     string
+		prefix string
+		want   []string
+	}{
+		{"", "", []string{`"get"`, `"set"`, `"delete"`}},
+		{"de", "de", []string{`"delete"`}},
+		{"get ", "", []string{"[ ]", "key"}},
+		{"get k --", "--", []string{`"--force"`, `"--verbose"`}},
+		{"get k --v", "--v", []string{`"--verbose"`}},
+	} {
+		c := Complete(cmd, tc.in+"trailing text", len(tc.in))
+		got := []string{}
+		for _, e := range c.Expected {
+			got = append(got, e.String())
+		}
+		assertSrc(t, tc.in, c.Prefix, tc.prefix)
+		assertSrc(t, tc.in, got, tc.want)
+	}
+}
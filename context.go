@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Context is the reader for a single parse. It tracks positions, holds the
+// memo table used by Memo, and carries user state and diagnostics, so that
+// grammars themselves can stay immutable and shared. A Context must not be
+// used by more than one goroutine.
+type Context struct {
+	*MemoReader
+	// User is free for the grammar's own per-parse state, such as a symbol
+	// table.
+	User        any
+	Diagnostics []Diagnostic
+	// Errors holds the errors Resync recovered from.
+	Errors ErrorList
+	// MaxErrors is how many errors Resync may recover from before the parse
+	// is abandoned with ErrTooManyErrors, so grossly corrupt input doesn't
+	// produce thousands of cascading errors. Zero means DefaultMaxErrors and
+	// a negative value means no limit.
+	MaxErrors int
+	// CascadeWindow, if positive, suppresses errors that start within this
+	// many bytes after the end of the last recovery, unless a Resync parser
+	// has matched cleanly in between. Suppressed errors are still skipped
+	// over but not recorded, so one missing brace doesn't produce a wall of
+	// follow-on errors.
+	CascadeWindow int64
+	// Severities overrides the severity of diagnostics by code, to promote
+	// warnings to errors or silence them with SeverityIgnore.
+	Severities map[string]Severity
+	// Features are the feature flags enabled for When and Unless, and
+	// Version the language version for Since.
+	Features map[string]bool
+	Version  int
+	// Trace, if set, is called as Named parsers and Grammar rules start and
+	// finish.
+	Trace func(TraceEvent)
+	// CheckContracts, if set, checks every Named parser and Grammar rule as
+	// Checked does, panicking with a *ContractError for the first to break
+	// the reader contract.
+	CheckContracts bool
+
+	traceDepth int
+	watches    []watch
+	session    *Session
+	// failPos and expected describe the furthest failure so far
+	failed   bool
+	failPos  Position
+	expected []Expectation
+	// quietUntil is the offset errors are suppressed before
+	quietUntil int64
+	// decisions is the Record or Replay in progress
+	decisions *decisions
+	// profile holds the pprof labels of the running rule, for ProfileRules
+	profile context.Context
+}
+
+// NewContext returns a Context reading from r.
+func NewContext(r io.ReadSeeker) *Context {
+	return newContext(NewSimpleReader(r))
+}
+
+// newContext returns a Context tracking positions over sr.
+func newContext(sr StatefulReader) *Context {
+	c := &Context{
+		MemoReader: NewMemoReader(NewPosReader(sr)),
+	}
+	c.errs = &c.Errors
+	return c
+}
+
+// reset readies c to parse sr as a new Context would, keeping the memo
+// table's allocations.
+func (c *Context) reset(sr StatefulReader) {
+	c.MemoReader.reset(NewPosReader(sr))
+	*c = Context{MemoReader: c.MemoReader}
+}
+
+// ContextOf returns the Context sr belongs to, or nil if it has none.
+func ContextOf(sr StatefulReader) *Context {
+	c, _ := sr.(*Context)
+	return c
+}
+
+// finish settles the outcome of a parse on c: an abandoned parse returns
+// the reason, a failed parse reports the furthest point reached and what
+// was expected there, and recovered errors and error diagnostics are
+// returned even if the parse otherwise succeeded.
+func finish[T any](c *Context, v T, err error) (T, error) {
+	if c.abort != nil {
+		var t T
+		return t, c.abort
+	}
+	if err != nil {
+		switch err.(type) {
+		case *ParseError, ErrorList:
+		default:
+			if c.failed {
+				err = &ParseError{Pos: c.failPos, Err: err, Expected: c.expected}
+			}
+		}
+		return v, err
+	}
+	if err == nil {
+		errs := c.Errors
+		for _, d := range c.Diagnostics {
+			if d.Severity == SeverityError {
+				errs = append(errs[:len(errs):len(errs)], &ParseError{Pos: d.Pos, Err: errors.New(d.text())})
+			}
+		}
+		if len(errs) > 0 {
+			return v, errs
+		}
+	}
+	return v, err
+}
+
+// ParseReader runs p over r in a fresh Context and returns the result along
+// with the Context, so its memo table and diagnostics can be inspected.
+func ParseReader[T any](p func(sr StatefulReader) (T, error), r io.ReadSeeker, opts ...Option) (T, *Context, error) {
+	return parseWith(p, NewSimpleReader(r), opts)
+}
+
+// ParseBytes is like ParseReader, but parses data held in memory through a
+// BytesReader, which is much faster than going through io.Reader.
+func ParseBytes[T any](p func(sr StatefulReader) (T, error), data []byte, opts ...Option) (T, *Context, error) {
+	return parseWith(p, NewBytesReader(data), opts)
+}
+
+func parseWith[T any](p func(sr StatefulReader) (T, error), sr StatefulReader, opts []Option) (T, *Context, error) {
+	return parseIn(p, newContext(sr), sr, opts)
+}
+
+// parseIn runs p in c, a fresh Context over sr.
+func parseIn[T any](p func(sr StatefulReader) (T, error), c *Context, sr StatefulReader, opts []Option) (T, *Context, error) {
+	o := buildOptions(opts)
+	o.configure(c)
+	done := o.observe(c, sr)
+	if err := o.checkSize(c, sr); err != nil {
+		done(err)
+		var t T
+		return t, c, err
+	}
+	v, err := wrap(p, o)(c)
+	v, err = finish(c, v, err)
+	done(err)
+	return v, c, err
+}
+
+// ParsePrefix runs p at the start of input without requiring it to consume
+// everything, and returns the unconsumed remainder, for embedding a grammar
+// in a hand written scanner. The offset p stopped at is
+// len(input) - len(rest).
+func ParsePrefix[T any](input string, p func(sr StatefulReader) (T, error), opts ...Option) (v T, rest string, err error) {
+	v, c, err := ParseBytes(p, []byte(input), opts...)
+	if err != nil {
+		return v, input, err
+	}
+	return v, input[c.Pos().Offset:], nil
+}
+
+// ParseAt runs p over input starting at byte offset, with positions that
+// count from the start of input, so that text within a larger document,
+// such as an attribute value or a code fence, can be parsed with errors
+// pointing into the document. Like ParsePrefix, p need not consume the rest
+// of input; the Context's Pos is where it stopped.
+func ParseAt[T any](input string, offset int, p func(sr StatefulReader) (T, error), opts ...Option) (T, *Context, error) {
+	pr := NewPosReader(NewBytesReader([]byte(input[:offset])))
+	pr.TabWidth = buildOptions(opts).tabWidth
+	pr.Advance(offset)
+	return parseFrom(p, []byte(input[offset:]), pr.Pos(), opts)
+}
+
+// parseFrom is ParseBytes with positions starting at pos.
+func parseFrom[T any](p func(sr StatefulReader) (T, error), data []byte, pos Position, opts []Option) (T, *Context, error) {
+	sr := NewBytesReader(data)
+	c := newContext(sr)
+	c.MemoReader.sr.(*PosReader).pos = pos
+	return parseIn(p, c, sr, opts)
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseReader(t *testing.T) {
+	word := Memo("word", join(Mult(1, 0, Set("a-z"))))
+	p := Convert(And(word, Lit(";")), func(vs []string) (string, error) {
+		return vs[0], nil
+	})
+	warned := Bind(word, func(w string) func(sr StatefulReader) (string, error) {
+		return func(sr StatefulReader) (string, error) {
+			if w == "old" {
+				Report(sr, "deprecated word")
+			}
+			ContextOf(sr).User = w
+			return w, nil
+		}
+	})
+	v, c, err := ParseReader(Or(p, warned), strings.NewReader("old"))
+	if err != nil || v != "old" {
+		t.Fatalf("got %q, %v", v, err)
+	}
+	if c.User != "old" {
+		t.Errorf("User = %v", c.User)
+	}
+	if len(c.Diagnostics) != 1 || c.Diagnostics[0].String() != "1:4: warning: deprecated word" {
+		t.Errorf("Diagnostics = %v", c.Diagnostics)
+	}
+	if es := c.At(0); len(es) != 1 || es[0].Rule != "word" {
+		t.Errorf("memo At(0) = %v", es)
+	}
+	if ContextOf(NewSimpleReader(strings.NewReader(""))) != nil {
+		t.Error("ContextOf a plain reader should be nil")
+	}
+}
+
+// TestSharedGrammar runs one grammar from many goroutines; run with -race.
+func TestSharedGrammar(t *testing.T) {
+	num := Memo("num", Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi))
+	pr := NewPratt(num)
+	pr.Infix("+", 10, func(op string, l, r int) (int, error) { return l + r, nil })
+	pr.Infix("*", 20, func(op string, l, r int) (int, error) { return l * r, nil })
+	expr := Lazy(func() func(sr StatefulReader) (int, error) {
+		return func(sr StatefulReader) (int, error) {
+			Report(sr, "expr")
+			return pr.Parse(sr)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			in := fmt.Sprintf("%d+2*3", i)
+			v, c, err := ParseReader(expr, strings.NewReader(in))
+			if err != nil || v != i+6 {
+				t.Errorf("%s: got %d, %v", in, v, err)
+			}
+			if len(c.Diagnostics) != 1 {
+				t.Errorf("%s: diagnostics leaked between parses: %v", in, c.Diagnostics)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestParsePrefix(t *testing.T) {
+	num := Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	v, rest, err := ParsePrefix("42}} tail", num)
+	if err != nil || v != 42 || rest != "}} tail" {
+		t.Errorf("got %d, %q, %v", v, rest, err)
+	}
+	v, rest, err = ParsePrefix("ñ7", Right(Lit("ñ"), num))
+	if err != nil || v != 7 || rest != "" {
+		t.Errorf("got %d, %q, %v", v, rest, err)
+	}
+	if _, rest, err := ParsePrefix("x", num); err == nil || rest != "x" {
+		t.Errorf("got %q, %v", rest, err)
+	}
+}
+
+func TestParseAt(t *testing.T) {
+	doc := "<a>\n\t<b x=\"12+y\"/>"
+	num := Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	sum := Left(MultSep(1, 0, num, Lit("+")), Lit(`"`))
+	at := strings.Index(doc, "12")
+
+	v, c, err := ParseAt(doc, at, Left(num, Lit("+")))
+	if err != nil || v != 12 || c.Pos() != (Position{Offset: 14, Line: 2, Column: 11}) {
+		t.Errorf("got %d, %v at %v", v, err, c.Pos())
+	}
+	_, _, err = ParseAt(doc, at, sum)
+	if err == nil || !strings.HasPrefix(err.Error(), "2:11:") {
+		t.Errorf("got %v", err)
+	}
+	_, _, err = ParseAt(doc, at, sum, TabWidth(4))
+	if err == nil || !strings.HasPrefix(err.Error(), "2:14:") {
+		t.Errorf("with TabWidth got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Stop is a point a Debugger paused at: a Grammar rule or Named parser
+// starting or finishing.
+type Stop struct {
+	TraceEvent
+	// Stack holds the names of the rules and Named parsers running,
+	// outermost first, including the one stopped at.
+	Stack []string
+	// Ahead is the input following the stop's position, up to the
+	// Debugger's Window.
+	Ahead string
+}
+
+func (s Stop) String() string {
+	pos, what := s.Start, "enter"
+	if s.Exit {
+		pos, what = s.End, "match"
+		if s.Err != nil {
+			pos, what = s.Start, "fail"
+		}
+	}
+	str := fmt.Sprintf("%s: %s %s [%s] %q", pos, what, s.Name, strings.Join(s.Stack, " > "), s.Ahead)
+	for _, w := range s.Watches {
+		str += fmt.Sprintf(" %s=%v", w.Name, w.Value)
+	}
+	return str
+}
+
+// Debugger steps through a parse of in-memory input, pausing at each rule
+// and Named parser as it starts and finishes, so a grammar that takes the
+// wrong branch can be followed as it goes wrong. The parse runs on its own
+// goroutine and only moves while Step, Continue or Result is waiting for
+// it. A Debugger must be used from one goroutine, and finished with Result.
+type Debugger[T any] struct {
+	// Window is how many bytes of upcoming input a Stop shows. Zero means
+	// 32.
+	Window int
+
+	p      func(sr StatefulReader) (T, error)
+	data   []byte
+	opts   []Option
+	breaks map[string]bool
+	// stepping pauses at every event, and quiet at none
+	stepping, quiet bool
+	stack           []string
+	started, done   bool
+	stops           chan Stop
+	resume          chan struct{}
+	finished        chan struct{}
+	ctx             *Context
+	v               T
+	err             error
+}
+
+// Debug returns a Debugger for parsing data with p. Nothing runs until the
+// first Step or Continue.
+func Debug[T any](p func(sr StatefulReader) (T, error), data []byte, opts ...Option) *Debugger[T] {
+	return &Debugger[T]{
+		p:        p,
+		data:     data,
+		opts:     opts,
+		breaks:   map[string]bool{},
+		stops:    make(chan Stop),
+		resume:   make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// Break makes Continue stop when the rule or Named parser name starts.
+func (d *Debugger[T]) Break(name string) {
+	d.breaks[name] = true
+}
+
+// Clear removes the breakpoint on name.
+func (d *Debugger[T]) Clear(name string) {
+	delete(d.breaks, name)
+}
+
+// Step runs the parse to the next rule or Named parser starting or
+// finishing. ok is false once the parse has finished.
+func (d *Debugger[T]) Step() (s Stop, ok bool) {
+	return d.run(true, false)
+}
+
+// Continue runs the parse to the next breakpoint. ok is false once the
+// parse has finished.
+func (d *Debugger[T]) Continue() (s Stop, ok bool) {
+	return d.run(false, false)
+}
+
+// Result runs the parse to the end, ignoring breakpoints, and returns its
+// outcome.
+func (d *Debugger[T]) Result() (T, *Context, error) {
+	d.run(false, true)
+	return d.v, d.ctx, d.err
+}
+
+// Context returns the parse's Context, for inspecting its memo table and
+// diagnostics while paused. It is nil until the parse starts.
+func (d *Debugger[T]) Context() *Context {
+	return d.ctx
+}
+
+func (d *Debugger[T]) run(stepping, quiet bool) (Stop, bool) {
+	if d.done {
+		return Stop{}, false
+	}
+	d.stepping, d.quiet = stepping, quiet
+	if !d.started {
+		d.started = true
+		d.ctx = newContext(NewBytesReader(d.data))
+		go d.parse()
+	} else {
+		d.resume <- struct{}{}
+	}
+	select {
+	case s := <-d.stops:
+		return s, true
+	case <-d.finished:
+		d.done = true
+		return Stop{}, false
+	}
+}
+
+func (d *Debugger[T]) parse() {
+	defer close(d.finished)
+	o := buildOptions(d.opts)
+	o.configure(d.ctx)
+	d.ctx.Trace = d.trace
+	v, err := wrap(d.p, o)(d.ctx)
+	d.v, d.err = finish(d.ctx, v, err)
+}
+
+func (d *Debugger[T]) trace(e TraceEvent) {
+	if !e.Exit {
+		d.stack = append(d.stack, e.Name)
+	}
+	pause := !d.quiet && (d.stepping || !e.Exit && d.breaks[e.Name])
+	if pause {
+		d.stops <- d.stop(e)
+		<-d.resume
+	}
+	if e.Exit {
+		d.stack = d.stack[:len(d.stack)-1]
+	}
+}
+
+func (d *Debugger[T]) stop(e TraceEvent) Stop {
+	off := e.Start.Offset
+	if e.Exit && e.Err == nil {
+		off = e.End.Offset
+	}
+	w := d.Window
+	if w <= 0 {
+		w = 32
+	}
+	end := off + int64(w)
+	if end > int64(len(d.data)) {
+		end = int64(len(d.data))
+	}
+	return Stop{TraceEvent: e, Stack: append([]string{}, d.stack...), Ahead: string(d.data[off:end])}
+}
+
+// Console drives d from commands read from in, one per line, writing each
+// stop to out, for debugging a grammar from a terminal:
+//
+//	s, or an empty line   step to the next event
+//	c                     continue to the next breakpoint
+//	b NAME, d NAME        set or delete a breakpoint
+//	memo                  list the memo table so far
+//	q                     run to the end
+//
+// It returns the parse's outcome when the parse finishes or in runs out.
+func (d *Debugger[T]) Console(in io.Reader, out io.Writer) (T, error) {
+	lines := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "(debug) ")
+		if !lines.Scan() {
+			break
+		}
+		cmd := strings.Fields(lines.Text())
+		var s Stop
+		ok := true
+		switch {
+		case len(cmd) == 0 || cmd[0] == "s":
+			s, ok = d.Step()
+		case cmd[0] == "c":
+			s, ok = d.Continue()
+		case cmd[0] == "b" && len(cmd) == 2:
+			d.Break(cmd[1])
+			continue
+		case cmd[0] == "d" && len(cmd) == 2:
+			d.Clear(cmd[1])
+			continue
+		case cmd[0] == "memo":
+			if d.ctx != nil {
+				for _, e := range d.ctx.Entries() {
+					fmt.Fprintf(out, "%s-%s %s %v\n", e.Start, e.End, e.Rule, e.Matched)
+				}
+			}
+			continue
+		case cmd[0] == "q":
+			ok = false
+		default:
+			fmt.Fprintf(out, "unknown command %q\n", lines.Text())
+			continue
+		}
+		if !ok {
+			break
+		}
+		fmt.Fprintln(out, s)
+	}
+	v, _, err := d.Result()
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+	} else {
+		fmt.Fprintf(out, "result: %v\n", v)
+	}
+	return v, err
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func debugGrammar() func(StatefulReader) (string, error) {
+	g := NewGrammar()
+	num := Rule(g, "num", func() func(StatefulReader) (string, error) {
+		return join(Mult(1, 0, Set("0-9")))
+	})
+	return Rule(g, "sum", func() func(StatefulReader) (string, error) {
+		return join(And(num, Lit("+"), num))
+	})
+}
+
+func TestDebuggerStep(t *testing.T) {
+	t.Parallel()
+	d := Debug(debugGrammar(), []byte("1+23"))
+	stops := []string{}
+	for {
+		s, ok := d.Step()
+		if !ok {
+			break
+		}
+		stops = append(stops, s.String())
+	}
+	assert(t, stops, []string{
+		`1:1: enter sum [sum] "1+23"`,
+		`1:1: enter num [sum > num] "1+23"`,
+		`1:2: match num [sum > num] "+23"`,
+		`1:3: enter num [sum > num] "23"`,
+		`1:5: match num [sum > num] ""`,
+		`1:5: match sum [sum] ""`,
+	})
+	v, _, err := d.Result()
+	if err != nil || v != "1+23" {
+		t.Errorf("got %q, %v", v, err)
+	}
+}
+
+func TestDebuggerBreak(t *testing.T) {
+	t.Parallel()
+	d := Debug(debugGrammar(), []byte("1+x"))
+	d.Window = 2
+	d.Break("num")
+	s, ok := d.Continue()
+	if !ok || s.Name != "num" || s.Ahead != "1+" {
+		t.Fatalf("first break: %v, %v", s, ok)
+	}
+	if d.Context() == nil {
+		t.Error("no Context while paused")
+	}
+	s, ok = d.Continue()
+	if !ok || s.Start.Offset != 2 || s.Ahead != "x" {
+		t.Fatalf("second break: %v, %v", s, ok)
+	}
+	s, ok = d.Step()
+	if !ok || !s.Exit || s.Err == nil || s.String() != `1:3: fail num [sum > num] "x"` {
+		t.Errorf("step: %v", s)
+	}
+	if _, ok := d.Continue(); ok {
+		t.Error("stopped after the last breakpoint")
+	}
+	if _, _, err := d.Result(); err == nil {
+		t.Error("parsed 1+x")
+	}
+}
+
+func TestDebuggerConsole(t *testing.T) {
+	t.Parallel()
+	out := &strings.Builder{}
+	d := Debug(debugGrammar(), []byte("1+2"))
+	v, err := d.Console(strings.NewReader("b num\nc\n\nbogus\nq\n"), out)
+	if err != nil || v != "1+2" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	want := `(debug) (debug) 1:1: enter num [sum > num] "1+2"
+(debug) 1:2: match num [sum > num] "+2"
+(debug) unknown command "bogus"
+(debug) result: 1+2
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestDebuggerWatch(t *testing.T) {
+	t.Parallel()
+	d := Debug(debugGrammar(), []byte("1+2"), Watch("at", func(c *Context) any {
+		return c.Pos().Offset
+	}))
+	d.Step()
+	s, _ := d.Step()
+	if want := `1:1: enter num [sum > num] "1+2" at=0`; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	d.Result()
+}
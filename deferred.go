@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"errors"
+	"io"
+)
+
+var errNoOffset = errors.New("Deferred needs a reader that reports offsets")
+
+// Region is a stretch of input captured by Deferred, to be parsed later.
+type Region[T any] struct {
+	// Text is the input the region parser consumed, and Start and End are
+	// where it lay in the input.
+	Text       string
+	Start, End Position
+	inner      func(sr StatefulReader) (T, error)
+}
+
+// Parse parses the region with Deferred's inner parser, in a fresh Context
+// as ParseBytes does, requiring it to consume all of Text. Positions in
+// the result and errors are those in the original input.
+func (r *Region[T]) Parse(opts ...Option) (T, *Context, error) {
+	return parseFrom(r.inner, []byte(r.Text), r.Start, opts)
+}
+
+// Deferred runs region and returns the input it consumed as a Region, to
+// be parsed with inner later, or never. It splits parsing into two passes:
+// the first finds the extent of a template body, macro or large section
+// cheaply, as with Balanced, and the second parses only the regions that
+// are needed. region's own result is discarded.
+func Deferred[R, T any](region func(sr StatefulReader) (R, error), inner func(sr StatefulReader) (T, error)) func(sr StatefulReader) (*Region[T], error) {
+	mustParsers("Deferred", region)
+	mustParsers("Deferred", inner)
+	whole := Left(inner, EOF())
+	return func(sr StatefulReader) (*Region[T], error) {
+		start := save(sr)
+		from, ok := offset(sr)
+		if !ok {
+			return nil, errNoOffset
+		}
+		r := &Region[T]{Start: Pos(sr), inner: whole}
+		if _, err := region(sr); err != nil {
+			return nil, err
+		}
+		to, _ := offset(sr)
+		end := save(sr)
+		r.End = Pos(sr)
+		start.restore(sr)
+		buf := make([]byte, to-from)
+		n, _ := io.ReadFull(sr, buf)
+		end.restore(sr)
+		r.Text = string(buf[:n])
+		return r, nil
+	}
+}
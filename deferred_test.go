@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeferred(t *testing.T) {
+	t.Parallel()
+	digits := join(Mult(1, 0, Set("0-9")))
+	body := join(And(Lit("{"), join(MultSep(1, 0, digits, join(And(Lit("+"), Optional(Lit("\n")))))), Lit("}")))
+	block := Deferred(Balanced("{", "}"), body)
+	p := Right(Lit("x = "), Left(block, Lit(";")))
+
+	for name, parse := range map[string]func(string) (*Region[string], error){
+		"bytes": func(in string) (*Region[string], error) {
+			r, _, err := ParseBytes(p, []byte(in))
+			return r, err
+		},
+		"reader": func(in string) (*Region[string], error) {
+			r, _, err := ParseReader(p, strings.NewReader(in))
+			return r, err
+		},
+	} {
+		r, err := parse("x = {1+\n2};")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		assert(t, r.Text, "{1+\n2}")
+		assert(t, r.Start, Position{Offset: 4, Line: 1, Column: 5})
+		assert(t, r.End, Position{Offset: 10, Line: 2, Column: 3})
+		v, _, err := r.Parse()
+		assert(t, v, "{12}")
+		assert(t, err, nil)
+
+		r, err = parse("x = {1+\nx};")
+		if err != nil {
+			t.Fatalf("%s: first pass failed: %v", name, err)
+		}
+		_, _, err = r.Parse()
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Pos != (Position{Offset: 8, Line: 2, Column: 1}) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+}
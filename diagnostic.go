@@ -0,0 +1,75 @@
+package parser
+
+import "fmt"
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+const (
+	SeverityNote Severity = iota
+	SeverityWarning
+	// SeverityError diagnostics make ParseReader and Compiled.Run fail once
+	// the parse is complete.
+	SeverityError
+	// SeverityIgnore is only used in overrides, to silence a code.
+	SeverityIgnore
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNote:
+		return "note"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityIgnore:
+		return "ignore"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic is a message recorded during a parse that doesn't by itself
+// stop the parse, such as a lint-like check in a grammar. Code identifies
+// the kind of message so its severity can be overridden.
+type Diagnostic struct {
+	Pos      Position
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+func (d Diagnostic) text() string {
+	if d.Code == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s [%s]", d.Message, d.Code)
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.text())
+}
+
+// Report records a warning at the current position if sr is a Context,
+// and does nothing otherwise. Diagnostics are kept even if the parser that
+// reported them is later backtracked over.
+func Report(sr StatefulReader, msg string) {
+	ReportCode(sr, SeverityWarning, "", msg)
+}
+
+// ReportCode records a diagnostic with a severity and code at the current
+// position if sr is a Context. The Context's Severities override the given
+// severity for the code.
+func ReportCode(sr StatefulReader, sev Severity, code, msg string) {
+	c := ContextOf(sr)
+	if c == nil {
+		return
+	}
+	if s, ok := c.Severities[code]; ok && code != "" {
+		sev = s
+	}
+	if sev == SeverityIgnore {
+		return
+	}
+	c.Diagnostics = append(c.Diagnostics, Diagnostic{Pos: c.Pos(), Severity: sev, Code: code, Message: msg})
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func lintedWords() func(StatefulReader) ([]string, error) {
+	word := Bind(join(Mult(1, 0, Set("a-zA-Z"))), func(w string) func(StatefulReader) (string, error) {
+		return func(sr StatefulReader) (string, error) {
+			if strings.ToUpper(w) == w {
+				ReportCode(sr, SeverityWarning, "shouting", "word in capitals")
+			}
+			if w == "todo" {
+				ReportCode(sr, SeverityNote, "todo", "todo left in text")
+			}
+			return w, nil
+		}
+	})
+	return MultSep(0, 0, word, Lit(" "))
+}
+
+func TestSeverities(t *testing.T) {
+	in := "HELLO todo world"
+	_, c, err := ParseReader(lintedWords(), strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, d := range c.Diagnostics {
+		got = append(got, d.String())
+	}
+	assert(t, got, []string{
+		"1:6: warning: word in capitals [shouting]",
+		"1:11: note: todo left in text [todo]",
+	})
+
+	_, c, err = ParseReader(lintedWords(), strings.NewReader(in), Severities(map[string]Severity{
+		"shouting": SeverityError,
+		"todo":     SeverityIgnore,
+	}))
+	var l ErrorList
+	if !errors.As(err, &l) || len(l) != 1 || l[0].Error() != "1:6: word in capitals [shouting]" {
+		t.Errorf("got %v", err)
+	}
+	assert(t, len(c.Diagnostics), 1)
+	assert(t, c.Diagnostics[0].Severity, SeverityError)
+}
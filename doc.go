@@ -0,0 +1,11 @@
+// Package parser is a parser combinator library. Parsers are plain functions
+// from a StatefulReader to a value and an error, built up from small
+// combinators such as Lit, Set, Or, And and Mult.
+//
+// A parser, once constructed, holds no per-parse state and may be shared by
+// any number of goroutines, each parsing its own reader. Everything that
+// changes during a parse (the read position, memo tables, user state and
+// diagnostics) lives in the reader, usually a Context created by
+// ParseReader. Types with registration methods, such as Pratt, must be fully
+// set up before they are shared.
+package parser
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Documents parses a sequence of documents from one input, such as
+// concatenated JSON values or log records, one at a time. Positions run on
+// across documents, and each document is parsed on a fresh Context so memo
+// tables don't grow with the input.
+type Documents[T any] struct {
+	// Skip, if set, runs before each document to consume separators such as
+	// whitespace or "---" lines.
+	Skip func(sr StatefulReader) (string, error)
+
+	p          func(sr StatefulReader) (T, error)
+	pr         *PosReader
+	v          T
+	start, end Position
+	err        error
+}
+
+// NewDocuments returns a Documents reading r from its start.
+func NewDocuments[T any](r io.ReadSeeker, p func(sr StatefulReader) (T, error)) *Documents[T] {
+	return NewDocumentsAt(r, p, Position{Line: 1, Column: 1})
+}
+
+// NewDocumentsAt returns a Documents resuming at pos, typically the End of
+// the last document a previous Documents returned, so that reported
+// positions carry on from where it stopped.
+func NewDocumentsAt[T any](r io.ReadSeeker, p func(sr StatefulReader) (T, error), pos Position) *Documents[T] {
+	pr := NewPosReader(NewSimpleReader(r))
+	pr.Restore(posState{inner: pos.Offset, pos: pos})
+	return &Documents[T]{p: p, pr: pr, start: pos, end: pos}
+}
+
+// Next parses the next document, returning false at the end of the input or
+// on an error. After an error, Err returns it and the reader is left at the
+// start of the failed document.
+func (d *Documents[T]) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	if d.Skip != nil {
+		d.Skip(d.pr)
+	}
+	if _, err := EOF()(d.pr); err == nil {
+		return false
+	}
+	c := &Context{MemoReader: NewMemoReader(d.pr)}
+	c.errs = &c.Errors
+	d.start = d.pr.Pos()
+	v, err := d.p(c)
+	if err == nil && d.pr.Pos().Offset == d.start.Offset {
+		err = fmt.Errorf("Document matched no input")
+	}
+	if err != nil {
+		d.pr.Restore(posState{inner: d.start.Offset, pos: d.start})
+		d.err = fmt.Errorf("%s: %w", d.start, err)
+		return false
+	}
+	d.v, d.end = v, d.pr.Pos()
+	return true
+}
+
+// Value returns the document parsed by the last call to Next.
+func (d *Documents[T]) Value() T {
+	return d.v
+}
+
+// Start returns the position the last document started at.
+func (d *Documents[T]) Start() Position {
+	return d.start
+}
+
+// End returns the position just after the last document, where parsing
+// resumes.
+func (d *Documents[T]) End() Position {
+	return d.end
+}
+
+// Err returns the error that stopped Next, if any.
+func (d *Documents[T]) Err() error {
+	return d.err
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocuments(t *testing.T) {
+	doc := join(And(Lit("{"), join(Mult(0, 0, NotSet("}"))), Lit("}")))
+	ws := join(Mult(0, 0, Set(" \n")))
+	in := "{a}{b}\n  {c\nd} {e"
+	d := NewDocuments(strings.NewReader(in), doc)
+	d.Skip = ws
+	got := []string{}
+	ends := []string{}
+	for d.Next() {
+		got = append(got, d.Value())
+		ends = append(ends, d.Start().String()+"-"+d.End().String())
+	}
+	assert(t, got, []string{"{a}", "{b}", "{c\nd}"})
+	assert(t, ends, []string{"1:1-1:4", "1:4-1:7", "2:3-3:3"})
+	if d.Err() == nil || !strings.HasPrefix(d.Err().Error(), "3:4: ") {
+		t.Errorf("Err() = %v", d.Err())
+	}
+
+	// resume a fresh driver where the second document ended
+	r := strings.NewReader(in)
+	d = NewDocumentsAt(r, doc, Position{Offset: 6, Line: 1, Column: 7})
+	d.Skip = ws
+	if !d.Next() || d.Value() != "{c\nd}" || d.Start().String() != "2:3" {
+		t.Errorf("got %q at %s", d.Value(), d.Start())
+	}
+
+	d = NewDocuments(strings.NewReader("  "), doc)
+	d.Skip = ws
+	if d.Next() || d.Err() != nil {
+		t.Errorf("empty input: %v", d.Err())
+	}
+}
@@ -0,0 +1,205 @@
+package earley
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EndOfInput stands for the end of the input in FOLLOW sets.
+const EndOfInput = "$"
+
+// Sets are the FIRST and FOLLOW sets of a grammar's nonterminals, with
+// terminals identified by name. Terminal predicates can't be compared, so
+// terminals with different names are assumed never to match the same
+// element.
+type Sets struct {
+	// Nullable holds the nonterminals that can match the empty input.
+	Nullable map[string]bool
+	// First maps each nonterminal to the sorted terminals that can start
+	// it.
+	First map[string][]string
+	// Follow maps each nonterminal to the sorted terminals that can come
+	// straight after it, including EndOfInput after the start symbol.
+	Follow map[string][]string
+}
+
+type nameSet map[string]bool
+
+// addAll adds the members of from to s, reporting whether s grew.
+func (s nameSet) addAll(from nameSet) bool {
+	grew := false
+	for n := range from {
+		if !s[n] {
+			s[n] = true
+			grew = true
+		}
+	}
+	return grew
+}
+
+func (s nameSet) sorted() []string {
+	out := make([]string, 0, len(s))
+	for n := range s {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type analysis struct {
+	nullable map[string]bool
+	first    map[string]nameSet
+	follow   map[string]nameSet
+}
+
+// firstOf returns the FIRST set of the symbol sequence syms and whether it
+// is nullable.
+func firstOf[E any](a *analysis, syms []Symbol[E]) (nameSet, bool) {
+	out := nameSet{}
+	for _, s := range syms {
+		if s.term != nil {
+			out[s.name] = true
+			return out, false
+		}
+		out.addAll(a.first[s.name])
+		if !a.nullable[s.name] {
+			return out, false
+		}
+	}
+	return out, true
+}
+
+func (g *Grammar[E]) analyse() *analysis {
+	a := &analysis{nullable: map[string]bool{}, first: map[string]nameSet{}, follow: map[string]nameSet{}}
+	for _, r := range g.Rules {
+		a.first[r.Name] = nameSet{}
+		a.follow[r.Name] = nameSet{}
+	}
+	if _, ok := a.follow[g.Start]; ok {
+		a.follow[g.Start][EndOfInput] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			first, nullable := firstOf(a, r.Symbols)
+			if a.first[r.Name].addAll(first) {
+				changed = true
+			}
+			if nullable && !a.nullable[r.Name] {
+				a.nullable[r.Name] = true
+				changed = true
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			syms := r.Symbols
+			for j, s := range syms {
+				if s.term != nil {
+					continue
+				}
+				if _, ok := a.follow[s.name]; !ok {
+					// undefined nonterminals have no sets
+					continue
+				}
+				rest, nullable := firstOf(a, syms[j+1:])
+				if a.follow[s.name].addAll(rest) {
+					changed = true
+				}
+				if nullable && a.follow[s.name].addAll(a.follow[r.Name]) {
+					changed = true
+				}
+			}
+		}
+	}
+	return a
+}
+
+// Sets computes the FIRST and FOLLOW sets of g's nonterminals.
+func (g *Grammar[E]) Sets() Sets {
+	a := g.analyse()
+	s := Sets{Nullable: a.nullable, First: map[string][]string{}, Follow: map[string][]string{}}
+	for n := range a.first {
+		s.First[n] = a.first[n].sorted()
+		s.Follow[n] = a.follow[n].sorted()
+	}
+	return s
+}
+
+// Conflict is an LL(1) conflict: more than one rule for the nonterminal
+// Name can start with each of Terminals, so a parser choosing between them
+// by the next element alone would have to backtrack. Rules are indexes
+// into the grammar's Rules.
+type Conflict struct {
+	Name      string
+	Rules     []int
+	Terminals []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: rules %v can all start with %s", c.Name, c.Rules, strings.Join(c.Terminals, " "))
+}
+
+// Conflicts reports the choice points of g that one element of lookahead
+// can't decide, ordered by nonterminal and then by rule. A grammar without
+// any is LL(1): a parser built from it with ordered choice and no
+// memoization runs in linear time, and the order of alternatives doesn't
+// change what it accepts. Left recursive rules always conflict.
+func (g *Grammar[E]) Conflicts() []Conflict {
+	a := g.analyse()
+	// predict[name][terminal] lists the rules chosen by terminal
+	predict := map[string]map[string][]int{}
+	names := []string{}
+	for i, r := range g.Rules {
+		if predict[r.Name] == nil {
+			predict[r.Name] = map[string][]int{}
+			names = append(names, r.Name)
+		}
+		first, nullable := firstOf(a, r.Symbols)
+		if nullable {
+			first.addAll(a.follow[r.Name])
+		}
+		for t := range first {
+			predict[r.Name][t] = append(predict[r.Name][t], i)
+		}
+	}
+	sort.Strings(names)
+	out := []Conflict{}
+	for _, n := range names {
+		byRules := map[string]*Conflict{}
+		keys := []string{}
+		for t, rs := range predict[n] {
+			if len(rs) < 2 {
+				continue
+			}
+			key := fmt.Sprint(rs)
+			if byRules[key] == nil {
+				byRules[key] = &Conflict{Name: n, Rules: rs}
+				keys = append(keys, key)
+			}
+			byRules[key].Terminals = append(byRules[key].Terminals, t)
+		}
+		cs := []Conflict{}
+		for _, k := range keys {
+			c := byRules[k]
+			sort.Strings(c.Terminals)
+			cs = append(cs, *c)
+		}
+		sort.Slice(cs, func(i, j int) bool {
+			return lessInts(cs[i].Rules, cs[j].Rules)
+		})
+		out = append(out, cs...)
+	}
+	return out
+}
+
+func lessInts(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
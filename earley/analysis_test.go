@@ -0,0 +1,66 @@
+package earley
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ll1Grammar is the textbook expression grammar with left recursion
+// removed.
+func ll1Grammar() *Grammar[rune] {
+	g := &Grammar[rune]{Start: "E"}
+	g.Add("E", N[rune]("T"), N[rune]("E'"))
+	g.Add("E'", Is('+'), N[rune]("T"), N[rune]("E'"))
+	g.Add("E'")
+	g.Add("T", N[rune]("F"), N[rune]("T'"))
+	g.Add("T'", Is('*'), N[rune]("F"), N[rune]("T'"))
+	g.Add("T'")
+	g.Add("F", Is('('), N[rune]("E"), Is(')'))
+	g.Add("F", T("digit", digit))
+	return g
+}
+
+func TestSets(t *testing.T) {
+	s := ll1Grammar().Sets()
+	if !reflect.DeepEqual(s.Nullable, map[string]bool{"E'": true, "T'": true}) {
+		t.Errorf("Nullable = %v", s.Nullable)
+	}
+	first := map[string][]string{
+		"E": {"(", "digit"}, "E'": {"+"}, "T": {"(", "digit"}, "T'": {"*"}, "F": {"(", "digit"},
+	}
+	if !reflect.DeepEqual(s.First, first) {
+		t.Errorf("First = %v", s.First)
+	}
+	follow := map[string][]string{
+		"E": {"$", ")"}, "E'": {"$", ")"}, "T": {"$", ")", "+"}, "T'": {"$", ")", "+"}, "F": {"$", ")", "*", "+"},
+	}
+	if !reflect.DeepEqual(s.Follow, follow) {
+		t.Errorf("Follow = %v", s.Follow)
+	}
+	if cs := ll1Grammar().Conflicts(); len(cs) != 0 {
+		t.Errorf("unexpected conflicts %v", cs)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	cs := exprGrammar().Conflicts()
+	want := []Conflict{{Name: "E", Rules: []int{0, 1, 2}, Terminals: []string{"digit"}}}
+	if !reflect.DeepEqual(cs, want) {
+		t.Errorf("got %v, want %v", cs, want)
+	}
+
+	// a nullable alternative conflicts with what can follow it
+	g := &Grammar[rune]{Start: "S"}
+	g.Add("S", N[rune]("A"), Is('a'))
+	g.Add("A", Is('a'))
+	g.Add("A", Is('b'))
+	g.Add("A")
+	cs = g.Conflicts()
+	want = []Conflict{{Name: "A", Rules: []int{1, 3}, Terminals: []string{"a"}}}
+	if !reflect.DeepEqual(cs, want) {
+		t.Errorf("got %v, want %v", cs, want)
+	}
+	if cs[0].String() != "A: rules [1 3] can all start with a" {
+		t.Errorf("String() = %q", cs[0].String())
+	}
+}
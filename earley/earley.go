@@ -0,0 +1,314 @@
+// Package earley is an Earley parser backend for ambiguous context-free
+// grammars. Unlike the PEG combinators, whose ordered choice commits to the
+// first alternative that matches, it returns every parse of the input.
+//
+// Grammars are declared as data rather than as combinator closures, because
+// the algorithm needs to see each rule's symbols. They work over any element
+// type: runes, bytes or pre-lexed tokens.
+package earley
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Symbol is a terminal or nonterminal in a rule.
+type Symbol[E any] struct {
+	name string
+	term func(E) bool
+}
+
+// N refers to the nonterminal called name.
+func N[E any](name string) Symbol[E] {
+	return Symbol[E]{name: name}
+}
+
+// T is a terminal matching one element accepted by pred. The name is used
+// in errors.
+func T[E any](name string, pred func(E) bool) Symbol[E] {
+	return Symbol[E]{name: name, term: pred}
+}
+
+// Is is a terminal matching one element equal to e.
+func Is[E comparable](e E) Symbol[E] {
+	return T(show(e), func(x E) bool { return x == e })
+}
+
+// show formats an element, printing runes as characters rather than code
+// points.
+func show(e any) string {
+	if r, ok := e.(rune); ok {
+		return string(r)
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+// Rule is a single production Name -> Symbols.
+type Rule[E any] struct {
+	Name    string
+	Symbols []Symbol[E]
+}
+
+// Grammar is a set of rules with a start symbol.
+type Grammar[E any] struct {
+	Start string
+	Rules []Rule[E]
+	// MaxTrees limits the number of parses returned for highly ambiguous
+	// input. Zero means 1000.
+	MaxTrees int
+}
+
+// Add appends the rule name -> syms. An empty syms adds an empty
+// production.
+func (g *Grammar[E]) Add(name string, syms ...Symbol[E]) {
+	g.Rules = append(g.Rules, Rule[E]{Name: name, Symbols: syms})
+}
+
+// Tree is one parse. Leaves have a nil Rule and hold the matched element.
+type Tree[E any] struct {
+	Rule       *Rule[E]
+	Start, End int
+	Children   []*Tree[E]
+	Leaf       E
+}
+
+func (t *Tree[E]) String() string {
+	if t.Rule == nil {
+		return show(t.Leaf)
+	}
+	parts := []string{t.Rule.Name}
+	for _, c := range t.Children {
+		parts = append(parts, c.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+type item struct {
+	rule   int
+	dot    int
+	origin int
+}
+
+type chart[E any] struct {
+	g      *Grammar[E]
+	sets   [][]item
+	seen   []map[item]bool
+	byName map[string][]int
+}
+
+func (c *chart[E]) add(i int, it item) {
+	if c.seen[i][it] {
+		return
+	}
+	c.seen[i][it] = true
+	c.sets[i] = append(c.sets[i], it)
+}
+
+func (c *chart[E]) next(it item) *Symbol[E] {
+	syms := c.g.Rules[it.rule].Symbols
+	if it.dot >= len(syms) {
+		return nil
+	}
+	return &syms[it.dot]
+}
+
+// recognise fills the chart for input.
+func (g *Grammar[E]) recognise(input []E) *chart[E] {
+	c := &chart[E]{
+		g:      g,
+		sets:   make([][]item, len(input)+1),
+		seen:   make([]map[item]bool, len(input)+1),
+		byName: map[string][]int{},
+	}
+	for i := range c.seen {
+		c.seen[i] = map[item]bool{}
+	}
+	for i, r := range g.Rules {
+		c.byName[r.Name] = append(c.byName[r.Name], i)
+	}
+	for _, r := range c.byName[g.Start] {
+		c.add(0, item{rule: r})
+	}
+	for i := 0; i <= len(input); i++ {
+		for j := 0; j < len(c.sets[i]); j++ {
+			it := c.sets[i][j]
+			sym := c.next(it)
+			switch {
+			case sym == nil:
+				// complete: advance every item in the origin set waiting on
+				// this rule's name
+				name := g.Rules[it.rule].Name
+				for k := 0; k < len(c.sets[it.origin]); k++ {
+					w := c.sets[it.origin][k]
+					if s := c.next(w); s != nil && s.term == nil && s.name == name {
+						c.add(i, item{rule: w.rule, dot: w.dot + 1, origin: w.origin})
+					}
+				}
+			case sym.term == nil:
+				for _, r := range c.byName[sym.name] {
+					c.add(i, item{rule: r, origin: i})
+				}
+				// nullable rules already completed here will not complete
+				// again, so advance over them now
+				for _, done := range c.sets[i] {
+					if done.origin == i && c.next(done) == nil && g.Rules[done.rule].Name == sym.name {
+						c.add(i, item{rule: it.rule, dot: it.dot + 1, origin: it.origin})
+					}
+				}
+			default:
+				if i < len(input) && sym.term(input[i]) {
+					c.add(i+1, item{rule: it.rule, dot: it.dot + 1, origin: it.origin})
+				}
+			}
+		}
+	}
+	return c
+}
+
+// Error reports where the input stopped matching the grammar.
+type Error struct {
+	Offset   int
+	Expected []string
+}
+
+func (e *Error) Error() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("offset %d: unexpected input", e.Offset)
+	}
+	return fmt.Sprintf("offset %d: expected %s", e.Offset, strings.Join(e.Expected, " or "))
+}
+
+// Parse returns every parse of the whole input, up to MaxTrees.
+func (g *Grammar[E]) Parse(input []E) ([]*Tree[E], error) {
+	c := g.recognise(input)
+	n := len(input)
+	for i := n; i >= 0; i-- {
+		if len(c.sets[i]) > 0 {
+			if i < n {
+				return nil, c.expected(i)
+			}
+			break
+		}
+	}
+	max := g.MaxTrees
+	if max == 0 {
+		max = 1000
+	}
+	b := &builder[E]{c: c, input: input, max: max, busy: map[span]bool{}}
+	trees := []*Tree[E]{}
+	for _, it := range c.sets[n] {
+		if it.origin == 0 && c.next(it) == nil && g.Rules[it.rule].Name == g.Start {
+			trees = append(trees, b.trees(it.rule, 0, n)...)
+		}
+	}
+	if len(trees) == 0 {
+		return nil, c.expected(n)
+	}
+	if len(trees) > max {
+		trees = trees[:max]
+	}
+	return trees, nil
+}
+
+func (c *chart[E]) expected(i int) *Error {
+	e := &Error{Offset: i}
+	seen := map[string]bool{}
+	for _, it := range c.sets[i] {
+		if s := c.next(it); s != nil && s.term != nil && !seen[s.name] {
+			seen[s.name] = true
+			e.Expected = append(e.Expected, s.name)
+		}
+	}
+	return e
+}
+
+type span struct {
+	rule, sym, start, end int
+}
+
+type builder[E any] struct {
+	c     *chart[E]
+	input []E
+	max   int
+	busy  map[span]bool
+}
+
+// completed reports whether the chart holds rule r spanning start to end.
+func (b *builder[E]) completed(r, start, end int) bool {
+	return b.c.seen[end][item{rule: r, dot: len(b.c.g.Rules[r].Symbols), origin: start}]
+}
+
+func (b *builder[E]) trees(r, start, end int) []*Tree[E] {
+	out := []*Tree[E]{}
+	for _, kids := range b.children(r, len(b.c.g.Rules[r].Symbols), start, end) {
+		out = append(out, &Tree[E]{Rule: &b.c.g.Rules[r], Start: start, End: end, Children: kids})
+	}
+	return out
+}
+
+// children returns every way the first n symbols of rule r can span start to
+// end. Cyclic derivations are cut off rather than expanded forever.
+func (b *builder[E]) children(r, n, start, end int) [][]*Tree[E] {
+	if n == 0 {
+		if start == end {
+			return [][]*Tree[E]{{}}
+		}
+		return nil
+	}
+	key := span{r, n, start, end}
+	if b.busy[key] {
+		return nil
+	}
+	b.busy[key] = true
+	defer delete(b.busy, key)
+
+	sym := b.c.g.Rules[r].Symbols[n-1]
+	out := [][]*Tree[E]{}
+	if sym.term != nil {
+		if end > start && sym.term(b.input[end-1]) {
+			leaf := &Tree[E]{Start: end - 1, End: end, Leaf: b.input[end-1]}
+			for _, prefix := range b.children(r, n-1, start, end-1) {
+				out = append(out, append(prefix, leaf))
+			}
+		}
+		return out
+	}
+	for mid := end; mid >= start && len(out) < b.max; mid-- {
+		for _, sub := range b.c.byName[sym.name] {
+			if !b.completed(sub, mid, end) {
+				continue
+			}
+			prefixes := b.children(r, n-1, start, mid)
+			if len(prefixes) == 0 {
+				continue
+			}
+			for _, t := range b.trees(sub, mid, end) {
+				for _, prefix := range prefixes {
+					out = append(out, append(append([]*Tree[E]{}, prefix...), t))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Parser adapts the grammar to the combinator interface. It consumes the
+// rest of a *parser.SliceReader[E] and returns all parses.
+func (g *Grammar[E]) Parser() func(sr parser.StatefulReader) ([]*Tree[E], error) {
+	return func(sr parser.StatefulReader) ([]*Tree[E], error) {
+		r, ok := sr.(*parser.SliceReader[E])
+		if !ok {
+			var zero E
+			return nil, fmt.Errorf("Expected a *parser.SliceReader[%T], got %T", zero, sr)
+		}
+		rest := r.Remaining()
+		trees, err := g.Parse(rest)
+		if err != nil {
+			return nil, err
+		}
+		r.Restore(r.Offset() + len(rest))
+		return trees, nil
+	}
+}
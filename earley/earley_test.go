@@ -0,0 +1,110 @@
+package earley
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func digit(r rune) bool { return r >= '0' && r <= '9' }
+
+func exprGrammar() *Grammar[rune] {
+	g := &Grammar[rune]{Start: "E"}
+	g.Add("E", N[rune]("E"), Is('+'), N[rune]("E"))
+	g.Add("E", N[rune]("E"), Is('*'), N[rune]("E"))
+	g.Add("E", T("digit", digit))
+	return g
+}
+
+func TestAmbiguous(t *testing.T) {
+	trees, err := exprGrammar().Parse([]rune("1+2*3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, tr := range trees {
+		got = append(got, tr.String())
+	}
+	sort.Strings(got)
+	want := []string{
+		"(E (E (E 1) + (E 2)) * (E 3))",
+		"(E (E 1) + (E (E 2) * (E 3)))",
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCatalan(t *testing.T) {
+	// 1+1+1+1+1 has Catalan(4) = 14 bracketings
+	trees, err := exprGrammar().Parse([]rune("1+1+1+1+1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) != 14 {
+		t.Errorf("got %d parses, want 14", len(trees))
+	}
+	g := exprGrammar()
+	g.MaxTrees = 3
+	trees, _ = g.Parse([]rune("1+1+1+1+1"))
+	if len(trees) != 3 {
+		t.Errorf("got %d parses with MaxTrees 3", len(trees))
+	}
+}
+
+func TestNullable(t *testing.T) {
+	g := &Grammar[rune]{Start: "S"}
+	g.Add("S", N[rune]("A"), N[rune]("A"), Is('x'))
+	g.Add("A")
+	g.Add("A", Is('a'))
+	for in, n := range map[string]int{"x": 1, "ax": 2, "aax": 1} {
+		trees, err := g.Parse([]rune(in))
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if len(trees) != n {
+			t.Errorf("%q: got %d parses, want %d", in, len(trees), n)
+		}
+	}
+}
+
+func TestCycle(t *testing.T) {
+	g := &Grammar[rune]{Start: "S"}
+	g.Add("S", N[rune]("S"))
+	g.Add("S", Is('a'))
+	trees, err := g.Parse([]rune("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trees) == 0 {
+		t.Error("expected a parse")
+	}
+}
+
+func TestError(t *testing.T) {
+	_, err := exprGrammar().Parse([]rune("1+*2"))
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v", err)
+	}
+	if e.Offset != 2 || len(e.Expected) != 1 || e.Expected[0] != "digit" {
+		t.Errorf("got %+v", e)
+	}
+	if _, err := exprGrammar().Parse([]rune("1+")); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
+
+func TestParser(t *testing.T) {
+	sr := parser.NewSliceReader([]rune("1*2"))
+	trees, err := exprGrammar().Parser()(sr)
+	if err != nil || len(trees) != 1 {
+		t.Fatalf("got %v, %v", trees, err)
+	}
+	if len(sr.Remaining()) != 0 {
+		t.Errorf("left %q", string(sr.Remaining()))
+	}
+}
@@ -0,0 +1,141 @@
+package earley
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prefix is a set of rules for the nonterminal Name that all start with the
+// same symbols, so a parser trying them in turn reparses those symbols for
+// each. Rules are indexes into the grammar's Rules and Symbols names the
+// shared prefix.
+type Prefix struct {
+	Name    string
+	Rules   []int
+	Symbols []string
+}
+
+func (p Prefix) String() string {
+	return fmt.Sprintf("%s: rules %v share the prefix %s", p.Name, p.Rules, strings.Join(p.Symbols, " "))
+}
+
+// key identifies a symbol. Terminals are compared by name, like in Sets.
+func (s Symbol[E]) key() string {
+	if s.term != nil {
+		return "t:" + s.name
+	}
+	return "n:" + s.name
+}
+
+// commonPrefix returns the number of leading symbols the rules share.
+func commonPrefix[E any](rules []Rule[E]) int {
+	n := len(rules[0].Symbols)
+	for _, r := range rules[1:] {
+		i := 0
+		for i < n && i < len(r.Symbols) && r.Symbols[i].key() == rules[0].Symbols[i].key() {
+			i++
+		}
+		n = i
+	}
+	return n
+}
+
+// prefixGroups returns, for each nonterminal in order of first definition,
+// the groups of its rules that start with the same symbol.
+func (g *Grammar[E]) prefixGroups() [][]int {
+	groups := [][]int{}
+	index := map[string]int{}
+	for i, r := range g.Rules {
+		if len(r.Symbols) == 0 {
+			continue
+		}
+		k := r.Name + "\x00" + r.Symbols[0].key()
+		j, ok := index[k]
+		if !ok {
+			j = len(groups)
+			index[k] = j
+			groups = append(groups, nil)
+		}
+		groups[j] = append(groups[j], i)
+	}
+	out := [][]int{}
+	for _, grp := range groups {
+		if len(grp) > 1 {
+			out = append(out, grp)
+		}
+	}
+	return out
+}
+
+// CommonPrefixes reports the rules of each nonterminal that share a prefix,
+// as candidates for left factoring.
+func (g *Grammar[E]) CommonPrefixes() []Prefix {
+	out := []Prefix{}
+	for _, grp := range g.prefixGroups() {
+		rules := make([]Rule[E], len(grp))
+		for i, r := range grp {
+			rules[i] = g.Rules[r]
+		}
+		p := Prefix{Name: rules[0].Name, Rules: grp}
+		for _, s := range rules[0].Symbols[:commonPrefix(rules)] {
+			p.Symbols = append(p.Symbols, s.name)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// LeftFactor returns a copy of g with shared prefixes factored out: rules
+// A -> x y and A -> x z become A -> x A~1 with A~1 -> y and A~1 -> z,
+// repeated until no rules of a nonterminal share a first symbol. The
+// language is unchanged, but parse trees gain nodes for the new
+// nonterminals.
+func (g *Grammar[E]) LeftFactor() *Grammar[E] {
+	out := &Grammar[E]{Start: g.Start, MaxTrees: g.MaxTrees, Rules: append([]Rule[E]{}, g.Rules...)}
+	used := map[string]bool{}
+	for _, r := range out.Rules {
+		used[r.Name] = true
+	}
+	fresh := func(name string) string {
+		for i := 1; ; i++ {
+			n := fmt.Sprintf("%s~%d", name, i)
+			if !used[n] {
+				used[n] = true
+				return n
+			}
+		}
+	}
+	for {
+		groups := out.prefixGroups()
+		if len(groups) == 0 {
+			return out
+		}
+		grp := groups[0]
+		rules := make([]Rule[E], len(grp))
+		for i, r := range grp {
+			rules[i] = out.Rules[r]
+		}
+		n := commonPrefix(rules)
+		name := rules[0].Name
+		tail := fresh(name)
+		factored := Rule[E]{Name: name, Symbols: append(append([]Symbol[E]{}, rules[0].Symbols[:n]...), N[E](tail))}
+		next := []Rule[E]{}
+		member := map[int]bool{}
+		for _, r := range grp {
+			member[r] = true
+		}
+		for i, r := range out.Rules {
+			switch {
+			case i == grp[0]:
+				next = append(next, factored)
+			case member[i]:
+			default:
+				next = append(next, r)
+			}
+		}
+		for _, r := range rules {
+			next = append(next, Rule[E]{Name: tail, Symbols: append([]Symbol[E]{}, r.Symbols[n:]...)})
+		}
+		out.Rules = next
+	}
+}
@@ -0,0 +1,88 @@
+package earley
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ifGrammar has the classic shared prefix of if and if-else statements.
+func ifGrammar() *Grammar[rune] {
+	g := &Grammar[rune]{Start: "S"}
+	g.Add("S", Is('i'), N[rune]("C"), Is('t'), N[rune]("S"))
+	g.Add("S", Is('i'), N[rune]("C"), Is('t'), N[rune]("S"), Is('e'), N[rune]("S"))
+	g.Add("S", Is('x'))
+	g.Add("C", Is('c'))
+	return g
+}
+
+func TestCommonPrefixes(t *testing.T) {
+	ps := ifGrammar().CommonPrefixes()
+	want := []Prefix{{Name: "S", Rules: []int{0, 1}, Symbols: []string{"i", "C", "t", "S"}}}
+	if !reflect.DeepEqual(ps, want) {
+		t.Errorf("got %v, want %v", ps, want)
+	}
+	if len(ll1Grammar().CommonPrefixes()) != 0 {
+		t.Error("unexpected prefixes in an LL(1) grammar")
+	}
+}
+
+func rulesString(g *Grammar[rune]) []string {
+	out := []string{}
+	for _, r := range g.Rules {
+		s := r.Name + " ->"
+		for _, sym := range r.Symbols {
+			s += " " + sym.name
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestLeftFactor(t *testing.T) {
+	g := ifGrammar()
+	f := g.LeftFactor()
+	want := []string{
+		"S -> i C t S S~1",
+		"S -> x",
+		"C -> c",
+		"S~1 ->",
+		"S~1 -> e S",
+	}
+	if got := rulesString(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(g.Rules) != 4 {
+		t.Error("LeftFactor modified the original grammar")
+	}
+	if len(f.CommonPrefixes()) != 0 {
+		t.Errorf("prefixes left: %v", f.CommonPrefixes())
+	}
+	// the language is the same, including the dangling else ambiguity
+	for _, in := range []string{"x", "ictx", "ictxex", "ictictxex"} {
+		a, errA := g.Parse([]rune(in))
+		b, errB := f.Parse([]rune(in))
+		if (errA == nil) != (errB == nil) || len(a) != len(b) {
+			t.Errorf("%q: %d parses, %v before and %d, %v after", in, len(a), errA, len(b), errB)
+		}
+	}
+	if _, err := f.Parse([]rune("ict")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLeftFactorNested(t *testing.T) {
+	g := &Grammar[rune]{Start: "A"}
+	g.Add("A", Is('a'), Is('b'), Is('c'))
+	g.Add("A", Is('a'), Is('b'), Is('d'))
+	g.Add("A", Is('a'), Is('e'))
+	want := []string{
+		"A -> a A~1",
+		"A~1 -> b A~1~1",
+		"A~1 -> e",
+		"A~1~1 -> c",
+		"A~1~1 -> d",
+	}
+	if got := rulesString(g.LeftFactor()); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
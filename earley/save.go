@@ -0,0 +1,83 @@
+package earley
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// saveVersion is the version of the format written by Save.
+const saveVersion = 1
+
+type savedGrammar struct {
+	Version  int         `json:"version"`
+	Start    string      `json:"start"`
+	MaxTrees int         `json:"maxTrees,omitempty"`
+	Rules    []savedRule `json:"rules"`
+}
+
+type savedRule struct {
+	Name    string        `json:"name"`
+	Symbols []savedSymbol `json:"symbols"`
+}
+
+// savedSymbol has N set for a nonterminal and T for a terminal.
+type savedSymbol struct {
+	N string `json:"n,omitempty"`
+	T string `json:"t,omitempty"`
+}
+
+// Save writes g to w as JSON, so a tool that builds a large grammar at
+// startup can build it once and Load it afterwards. Terminals are written
+// by name only, since their predicates are functions.
+func (g *Grammar[E]) Save(w io.Writer) error {
+	sg := savedGrammar{Version: saveVersion, Start: g.Start, MaxTrees: g.MaxTrees, Rules: []savedRule{}}
+	for _, r := range g.Rules {
+		sr := savedRule{Name: r.Name, Symbols: []savedSymbol{}}
+		for _, s := range r.Symbols {
+			if s.term != nil {
+				sr.Symbols = append(sr.Symbols, savedSymbol{T: s.name})
+			} else {
+				sr.Symbols = append(sr.Symbols, savedSymbol{N: s.name})
+			}
+		}
+		sg.Rules = append(sg.Rules, sr)
+	}
+	return json.NewEncoder(w).Encode(sg)
+}
+
+// Load reads a grammar written by Save. terminal is called once for each
+// distinct terminal name to get its predicate back; for terminals made with
+// Is, the name is the element as printed with %v, or the character for a
+// rune.
+func Load[E any](r io.Reader, terminal func(name string) (func(E) bool, error)) (*Grammar[E], error) {
+	sg := savedGrammar{}
+	if err := json.NewDecoder(r).Decode(&sg); err != nil {
+		return nil, err
+	}
+	if sg.Version != saveVersion {
+		return nil, fmt.Errorf("Unsupported grammar version %d", sg.Version)
+	}
+	g := &Grammar[E]{Start: sg.Start, MaxTrees: sg.MaxTrees}
+	preds := map[string]func(E) bool{}
+	for _, r := range sg.Rules {
+		syms := []Symbol[E]{}
+		for _, s := range r.Symbols {
+			if s.T == "" {
+				syms = append(syms, N[E](s.N))
+				continue
+			}
+			pred, ok := preds[s.T]
+			if !ok {
+				var err error
+				if pred, err = terminal(s.T); err != nil {
+					return nil, fmt.Errorf("Terminal %q: %w", s.T, err)
+				}
+				preds[s.T] = pred
+			}
+			syms = append(syms, T(s.T, pred))
+		}
+		g.Add(r.Name, syms...)
+	}
+	return g, nil
+}
@@ -0,0 +1,57 @@
+package earley
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func runeTerminal(name string) (func(rune) bool, error) {
+	if name == "digit" {
+		return digit, nil
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	if size != len(name) {
+		return nil, errors.New("unknown terminal")
+	}
+	return func(x rune) bool { return x == r }, nil
+}
+
+func TestSaveLoad(t *testing.T) {
+	g := ll1Grammar()
+	g.MaxTrees = 5
+	buf := &bytes.Buffer{}
+	if err := g.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(buf, runeTerminal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rulesString(loaded), rulesString(g)) || loaded.Start != "E" || loaded.MaxTrees != 5 {
+		t.Errorf("got %q", rulesString(loaded))
+	}
+	trees, err := loaded.Parse([]rune("(1+2)*3"))
+	if err != nil || len(trees) != 1 {
+		t.Errorf("got %v, %v", trees, err)
+	}
+	if _, err := loaded.Parse([]rune("1+")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	g := &Grammar[rune]{Start: "S"}
+	g.Add("S", T("word", func(rune) bool { return true }))
+	buf := &bytes.Buffer{}
+	g.Save(buf)
+	if _, err := Load(buf, runeTerminal); err == nil || !strings.Contains(err.Error(), `"word"`) {
+		t.Errorf("got %v", err)
+	}
+	if _, err := Load(strings.NewReader(`{"version": 2}`), runeTerminal); err == nil {
+		t.Error("expected a version error")
+	}
+}
@@ -0,0 +1,356 @@
+// Package calc is a floating point calculator grammar with variables,
+// function calls and the usual operator precedence. It is kept as a
+// maintained reference for building expression grammars with the parser
+// package.
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Node is a node of a parsed expression.
+type Node interface {
+	Eval(env *Env) (float64, error)
+	String() string
+}
+
+// Num is a numeric literal.
+type Num float64
+
+// Var is a reference to a variable.
+type Var string
+
+// BinOp applies one of + - * / % ^ to two operands.
+type BinOp struct {
+	Op1 Node
+	Op  string
+	Op2 Node
+}
+
+// Neg is unary minus.
+type Neg struct {
+	X Node
+}
+
+// Call is a function call.
+type Call struct {
+	Name string
+	Args []Node
+}
+
+// Env supplies variables and functions to Eval.
+type Env struct {
+	Vars  map[string]float64
+	Funcs map[string]func(args ...float64) (float64, error)
+}
+
+func fixed(n int, f func(args ...float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) != n {
+			return 0, fmt.Errorf("Expected %d arguments, got %d", n, len(args))
+		}
+		return f(args...), nil
+	}
+}
+
+func variadic(f func(a, b float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("Expected at least 1 argument")
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			v = f(v, a)
+		}
+		return v, nil
+	}
+}
+
+// NewEnv returns an Env with pi, e and common math functions defined.
+func NewEnv() *Env {
+	return &Env{
+		Vars: map[string]float64{
+			"pi": math.Pi,
+			"e":  math.E,
+		},
+		Funcs: map[string]func(args ...float64) (float64, error){
+			"abs":   fixed(1, func(a ...float64) float64 { return math.Abs(a[0]) }),
+			"sqrt":  fixed(1, func(a ...float64) float64 { return math.Sqrt(a[0]) }),
+			"sin":   fixed(1, func(a ...float64) float64 { return math.Sin(a[0]) }),
+			"cos":   fixed(1, func(a ...float64) float64 { return math.Cos(a[0]) }),
+			"tan":   fixed(1, func(a ...float64) float64 { return math.Tan(a[0]) }),
+			"ln":    fixed(1, func(a ...float64) float64 { return math.Log(a[0]) }),
+			"log":   fixed(1, func(a ...float64) float64 { return math.Log10(a[0]) }),
+			"exp":   fixed(1, func(a ...float64) float64 { return math.Exp(a[0]) }),
+			"floor": fixed(1, func(a ...float64) float64 { return math.Floor(a[0]) }),
+			"ceil":  fixed(1, func(a ...float64) float64 { return math.Ceil(a[0]) }),
+			"pow":   fixed(2, func(a ...float64) float64 { return math.Pow(a[0], a[1]) }),
+			"min":   variadic(math.Min),
+			"max":   variadic(math.Max),
+		},
+	}
+}
+
+func (n Num) Eval(*Env) (float64, error) {
+	return float64(n), nil
+}
+
+func (v Var) Eval(env *Env) (float64, error) {
+	x, ok := env.Vars[string(v)]
+	if !ok {
+		return 0, fmt.Errorf("Undefined variable %q", string(v))
+	}
+	return x, nil
+}
+
+func (bo BinOp) Eval(env *Env) (float64, error) {
+	a, err := bo.Op1.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	b, err := bo.Op2.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch bo.Op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return 0, fmt.Errorf("Division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return 0, fmt.Errorf("Division by zero")
+		}
+		return math.Mod(a, b), nil
+	case "^":
+		return math.Pow(a, b), nil
+	}
+	return 0, fmt.Errorf("Unknown operator %q", bo.Op)
+}
+
+func (n Neg) Eval(env *Env) (float64, error) {
+	x, err := n.X.Eval(env)
+	return -x, err
+}
+
+func (c Call) Eval(env *Env) (float64, error) {
+	f, ok := env.Funcs[c.Name]
+	if !ok {
+		return 0, fmt.Errorf("Undefined function %q", c.Name)
+	}
+	args := make([]float64, len(c.Args))
+	for i, a := range c.Args {
+		v, err := a.Eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	v, err := f(args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", c.Name, err)
+	}
+	return v, nil
+}
+
+func (n Num) String() string { return strconv.FormatFloat(float64(n), 'g', -1, 64) }
+func (v Var) String() string { return string(v) }
+func (bo BinOp) String() string {
+	return "(" + bo.Op1.String() + " " + bo.Op + " " + bo.Op2.String() + ")"
+}
+func (n Neg) String() string { return "-" + n.X.String() }
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = a.String()
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	ws     = parser.Mult(0, 0, parser.Set(" \t\r\n"))
+	digits = join(parser.Mult(1, 0, parser.Set("0-9")))
+	number = parser.Convert(join(parser.And(
+		parser.Or(
+			join(parser.And(digits, parser.Optional(join(parser.And(parser.Lit("."), parser.Optional(digits)))))),
+			join(parser.And(parser.Lit("."), digits)),
+		),
+		parser.Optional(join(parser.And(parser.Set("eE"), parser.Optional(parser.Set("+-")), digits))),
+	)), func(s string) (Node, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		return Num(f), err
+	})
+	ident = join(parser.And(parser.Set("a-zA-Z_"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_")))))
+)
+
+func token(text string) func(sr parser.StatefulReader) (string, error) {
+	lit := parser.Lit(text)
+	return func(sr parser.StatefulReader) (string, error) {
+		s := sr.State()
+		ws(sr)
+		t, err := lit(sr)
+		if err != nil {
+			sr.Restore(s)
+		}
+		return t, err
+	}
+}
+
+// ParseExpr parses an expression, stopping at the first token that cannot
+// continue it.
+func ParseExpr(sr parser.StatefulReader) (Node, error) {
+	return sum(sr)
+}
+
+// binOp parses a left-associative chain of operand joined by ops.
+func binOp(operand func(parser.StatefulReader) (Node, error), ops ...string) func(parser.StatefulReader) (Node, error) {
+	parseOps := []func(parser.StatefulReader) (string, error){}
+	for _, op := range ops {
+		parseOps = append(parseOps, token(op))
+	}
+	opParser := parser.Or(parseOps...)
+	return func(sr parser.StatefulReader) (Node, error) {
+		n, err := operand(sr)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			op, err := opParser(sr)
+			if err != nil {
+				return n, nil
+			}
+			n2, err := operand(sr)
+			if err != nil {
+				return nil, err
+			}
+			n = BinOp{Op1: n, Op: op, Op2: n2}
+		}
+	}
+}
+
+var (
+	sum     func(parser.StatefulReader) (Node, error)
+	product func(parser.StatefulReader) (Node, error)
+)
+
+func init() {
+	product = binOp(unary, "*", "/", "%")
+	sum = binOp(product, "+", "-")
+}
+
+// unary handles prefix minus, which binds looser than ^ so -2^2 is -4.
+func unary(sr parser.StatefulReader) (Node, error) {
+	if _, err := token("-")(sr); err == nil {
+		x, err := unary(sr)
+		if err != nil {
+			return nil, err
+		}
+		return Neg{X: x}, nil
+	}
+	if _, err := token("+")(sr); err == nil {
+		return unary(sr)
+	}
+	return power(sr)
+}
+
+// power is right-associative: 2^3^2 is 2^(3^2).
+func power(sr parser.StatefulReader) (Node, error) {
+	base, err := primary(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := token("^")(sr); err != nil {
+		return base, nil
+	}
+	exp, err := unary(sr)
+	if err != nil {
+		return nil, err
+	}
+	return BinOp{Op1: base, Op: "^", Op2: exp}, nil
+}
+
+func primary(sr parser.StatefulReader) (Node, error) {
+	ws(sr)
+	pos := parser.Pos(sr)
+	if _, err := parser.Lit("(")(sr); err == nil {
+		n, err := ParseExpr(sr)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := token(")")(sr); err != nil {
+			return nil, &parser.ParseError{Pos: parser.Pos(sr), Err: errors.New("Expected ')'")}
+		}
+		return n, nil
+	}
+	if n, err := number(sr); err == nil {
+		return n, nil
+	}
+	name, err := ident(sr)
+	if err != nil {
+		return nil, &parser.ParseError{Pos: pos, Err: errors.New("Expected number, variable or '('")}
+	}
+	if _, err := token("(")(sr); err != nil {
+		return Var(name), nil
+	}
+	c := Call{Name: name, Args: []Node{}}
+	if _, err := token(")")(sr); err == nil {
+		return c, nil
+	}
+	for {
+		a, err := ParseExpr(sr)
+		if err != nil {
+			return nil, err
+		}
+		c.Args = append(c.Args, a)
+		if _, err := token(",")(sr); err == nil {
+			continue
+		}
+		if _, err := token(")")(sr); err != nil {
+			return nil, &parser.ParseError{Pos: parser.Pos(sr), Err: errors.New("Expected ',' or ')'")}
+		}
+		return c, nil
+	}
+}
+
+// Parse parses a complete expression.
+func Parse(s string) (Node, error) {
+	sr := parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s)))
+	n, err := ParseExpr(sr)
+	if err != nil {
+		return nil, err
+	}
+	ws(sr)
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, &parser.ParseError{Pos: parser.Pos(sr), Err: err}
+	}
+	return n, nil
+}
+
+// Eval parses and evaluates s in env.
+func Eval(s string, env *Env) (float64, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return n.Eval(env)
+}
@@ -0,0 +1,72 @@
+package calc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+	env := NewEnv()
+	env.Vars["x"] = 4
+	tests := []struct {
+		in  string
+		out float64
+	}{
+		{"1+2", 3},
+		{"1+2*3-4", 3},
+		{"(1+2)*(3-4)", -3},
+		{"7/2", 3.5},
+		{"7 % 4", 3},
+		{"2^3^2", 512},
+		{"-2^2", -4},
+		{"(-2)^2", 4},
+		{"--3", 3},
+		{"2*-x", -8},
+		{"1.5e2 + .5", 150.5},
+		{"sqrt(x) + max(1, 7, 3)", 9},
+		{"pow(2, 10)", 1024},
+		{"floor(pi)", 3},
+	}
+	for _, test := range tests {
+		out, err := Eval(test.in, env)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if math.Abs(out-test.out) > 1e-9 {
+			t.Errorf("Expected (%q) %v, got %v", test.in, test.out, out)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+	n, err := Parse("-a + f(b, 2) * 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.String() != "(-a + (f(b, 2) * 3))" {
+		t.Errorf("Unexpected tree %s", n)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"1 +":        "1:4: Expected number, variable or '('",
+		"(1 + 2":     "1:7: Expected ')'",
+		"max(1 2)":   "1:6: Expected ',' or ')'",
+		"1 2":        "1:3: Expected EOF, got \"2\"",
+		"y + 1":      `Undefined variable "y"`,
+		"nope(1)":    `Undefined function "nope"`,
+		"sqrt(1, 2)": "sqrt: Expected 1 arguments, got 2",
+		"1/0":        "Division by zero",
+	}
+	for in, msg := range tests {
+		_, err := Eval(in, NewEnv())
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Command repl is an interactive calculator built on the calc example
+// grammar, showing the parser package's interactive-facing APIs. It reads
+// standard input a line at a time, carries on a statement over several
+// lines while it is incomplete, points at syntax errors with a caret, and
+// lists what could be typed next with ":complete".
+//
+//	> x = 2 * (3 +
+//	... 4)
+//	14
+//	> sqrt(x)
+//	3.7416573867739413
+//	> :complete sq
+//	sqrt(
+//	> 1 2
+//	1 2
+//	  ^ error: Expected EOF, got "2"
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/examples/calc"
+	"github.com/andyleap/parser/render"
+)
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	ws     = parser.Mult(0, 0, parser.Set(" \t\r\n"))
+	name   = join(parser.And(parser.Set("a-zA-Z_"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_")))))
+	assign = parser.Left(parser.Right(ws, name), parser.Right(ws, parser.Lit("=")))
+)
+
+// statement is an expression, optionally assigned to a variable.
+type statement struct {
+	Name string
+	Expr calc.Node
+}
+
+func parseStatement(sr parser.StatefulReader) (statement, error) {
+	st := statement{}
+	if n, err := assign(sr); err == nil {
+		st.Name = n
+	}
+	e, err := calc.ParseExpr(sr)
+	if err != nil {
+		return st, err
+	}
+	ws(sr)
+	if _, err := parser.EOF()(sr); err != nil {
+		return st, err
+	}
+	st.Expr = e
+	return st, nil
+}
+
+type repl struct {
+	env   *calc.Env
+	out   io.Writer
+	color bool
+}
+
+// incomplete reports whether err is a syntax error at the very end of src,
+// meaning more input might fix it.
+func incomplete(err error, src string) bool {
+	var pe *parser.ParseError
+	return errors.As(err, &pe) && pe.Pos.Offset == int64(len(src))
+}
+
+func (r *repl) run(in io.Reader) error {
+	sc := bufio.NewScanner(in)
+	src := ""
+	fmt.Fprint(r.out, "> ")
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case src == "" && strings.HasPrefix(line, ":"):
+			if !r.command(line) {
+				return nil
+			}
+			fmt.Fprint(r.out, "> ")
+			continue
+		case src == "" && strings.TrimSpace(line) == "":
+			fmt.Fprint(r.out, "> ")
+			continue
+		case src != "":
+			src += "\n"
+		}
+		src += line
+		st, _, err := parser.ParseReader(parseStatement, strings.NewReader(src))
+		// a blank line gives up on an incomplete statement
+		if err != nil && incomplete(err, src) && strings.TrimSpace(line) != "" {
+			fmt.Fprint(r.out, "... ")
+			continue
+		}
+		if err != nil {
+			r.report(src, err)
+		} else {
+			r.eval(st)
+		}
+		src = ""
+		fmt.Fprint(r.out, "> ")
+	}
+	return sc.Err()
+}
+
+func (r *repl) report(src string, err error) {
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) {
+		fmt.Fprintf(r.out, "error: %v\n", err)
+		return
+	}
+	if r.color {
+		fmt.Fprint(r.out, render.ANSI(src, nil, render.Errors(err), nil))
+	} else {
+		fmt.Fprint(r.out, render.Text(src, render.Errors(err)))
+	}
+}
+
+func (r *repl) eval(st statement) {
+	v, err := st.Expr.Eval(r.env)
+	if err != nil {
+		r.report("", err)
+		return
+	}
+	if st.Name != "" {
+		r.env.Vars[st.Name] = v
+	}
+	fmt.Fprintln(r.out, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// command runs a ":" command, returning false to quit.
+func (r *repl) command(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	switch cmd {
+	case ":quit":
+		return false
+	case ":complete":
+		fmt.Fprintln(r.out, strings.Join(r.complete(arg), " "))
+	default:
+		fmt.Fprintln(r.out, "commands: :complete TEXT, :quit")
+	}
+	return true
+}
+
+const identChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+// complete lists what could follow text: the names of variables and
+// functions when an identifier can go there or is partly typed, and
+// otherwise the operators and punctuation the grammar expected.
+func (r *repl) complete(text string) []string {
+	c := parser.Complete(parseStatement, text, len(text))
+	word := text[len(strings.TrimRight(text, identChars)):]
+	if word != "" && word[0] >= '0' && word[0] <= '9' {
+		// a number being typed, not a name
+		word = ""
+	}
+	names := word != ""
+	seen := map[string]bool{}
+	for _, e := range c.Expected {
+		switch {
+		case e.Kind == parser.ExpectClass && strings.Contains(e.Text, "a-z"):
+			names = true
+		case e.Kind == parser.ExpectLiteral && word == "":
+			seen[e.Text] = true
+		}
+	}
+	if names {
+		for v := range r.env.Vars {
+			if strings.HasPrefix(v, word) {
+				seen[v] = true
+			}
+		}
+		for f := range r.env.Funcs {
+			if strings.HasPrefix(f, word) {
+				seen[f+"("] = true
+			}
+		}
+	}
+	out := []string{}
+	for s := range seen {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func main() {
+	color := flag.Bool("color", false, "colour errors with ANSI escapes")
+	flag.Parse()
+	r := &repl{env: calc.NewEnv(), out: os.Stdout, color: *color}
+	if err := r.run(os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
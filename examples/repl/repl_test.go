@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser/examples/calc"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	in := strings.Join([]string{
+		"x = 2 * (3 +",
+		"4)",
+		"x / 7",
+		"1 2",
+		"sqrt(x",
+		"",
+		"y",
+		":quit",
+		"ignored",
+	}, "\n")
+	out := &strings.Builder{}
+	r := &repl{env: calc.NewEnv(), out: out}
+	if err := r.run(strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	want := "> ... 14\n" +
+		"> 2\n" +
+		"> 1 2\n  ^ error: Expected EOF, got \"2\"\n" +
+		"> ... sqrt(x\n      ^ error: Expected ',' or ')'\n" +
+		"> error: Undefined variable \"y\"\n" +
+		"> "
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+	r := &repl{env: calc.NewEnv()}
+	r.env.Vars["speed"] = 1
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"s", []string{"sin(", "speed", "sqrt("}},
+		{"1 + c", []string{"ceil(", "cos("}},
+		{"max(1", []string{"%", ")", "*", "+", ",", "-", ".", "/", "^"}},
+		{"zz", []string{}},
+	}
+	for _, test := range tests {
+		if got := r.complete(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.text, got, test.want)
+		}
+	}
+}
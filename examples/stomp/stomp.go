@@ -0,0 +1,157 @@
+// Package stomp reads STOMP 1.2 frames from a live connection. It is kept
+// as a maintained reference for parsing a streaming protocol with the
+// parser package: a StreamReader pulls bytes from the connection as the
+// grammar needs them, and Commit releases each frame's bytes once it has
+// been parsed, so a long-lived connection runs in constant memory however
+// far the grammar could otherwise backtrack.
+package stomp
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Frame is a STOMP frame.
+type Frame struct {
+	Command string
+	Header  []Header
+	Body    []byte
+}
+
+// Header is one header line of a frame, with escapes decoded.
+type Header struct {
+	Name, Value string
+}
+
+// Get returns the value of the first header named name, which is the one
+// that counts when a header is repeated.
+func (f Frame) Get(name string) (string, bool) {
+	for _, h := range f.Header {
+		if h.Name == name {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// Commands are the client and server frame commands of STOMP 1.2.
+var Commands = []string{
+	"CONNECT", "STOMP", "SEND", "SUBSCRIBE", "UNSUBSCRIBE", "BEGIN", "COMMIT",
+	"ABORT", "ACK", "NACK", "DISCONNECT", "CONNECTED", "MESSAGE", "RECEIPT",
+	"ERROR",
+}
+
+// MaxBody is the longest frame body Reader accepts.
+const MaxBody = 1 << 20
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var unescape = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r", `\c`, ":")
+
+var (
+	eol    = parser.Or(parser.Lit("\n"), parser.Lit("\r\n"))
+	escape = parser.Right(parser.Lit(`\`), parser.Set(`\nrc`))
+	octets = join(parser.Mult(0, 0, parser.Or(parser.Convert(escape, func(c string) (string, error) { return `\` + c, nil }), parser.NotSet("\\:\r\n"))))
+	header = parser.Convert(parser.Seq2(parser.Left(octets, parser.Lit(":")), parser.Left(octets, eol)), func(p parser.Pair[string, string]) (Header, error) {
+		return Header{unescape.Replace(p.First), unescape.Replace(p.Second)}, nil
+	})
+	command = parser.Left(parser.Lits(Commands...), eol)
+	headers = parser.Left(parser.Mult(0, 0, header), eol)
+)
+
+// ParseFrame parses one frame: its command, headers, a blank line and a
+// body ended by a NUL byte. The body is read as exactly content-length
+// bytes when that header is present, and otherwise up to the first NUL.
+func ParseFrame(sr parser.StatefulReader) (Frame, error) {
+	s := sr.State()
+	f := Frame{}
+	var err error
+	if f.Command, err = command(sr); err != nil {
+		return f, err
+	}
+	if f.Header, err = headers(sr); err != nil {
+		sr.Restore(s)
+		return f, fmt.Errorf("%s headers: %w", f.Command, err)
+	}
+	if f.Body, err = body(sr, f); err != nil {
+		sr.Restore(s)
+		return f, err
+	}
+	return f, nil
+}
+
+func body(sr parser.StatefulReader, f Frame) ([]byte, error) {
+	b := []byte{}
+	one := make([]byte, 1)
+	if cl, ok := f.Get("content-length"); ok {
+		n, err := strconv.Atoi(cl)
+		if err != nil || n < 0 || n > MaxBody {
+			return nil, fmt.Errorf("Bad content-length %q", cl)
+		}
+		b = make([]byte, n)
+		if _, err := io.ReadFull(sr, b); err != nil {
+			return nil, fmt.Errorf("%s body: %w", f.Command, err)
+		}
+		if c, _ := sr.Read(one); c == 0 || one[0] != 0 {
+			return nil, fmt.Errorf("%s body: Expected NUL after %d bytes", f.Command, n)
+		}
+		return b, nil
+	}
+	for {
+		if c, _ := sr.Read(one); c == 0 {
+			return nil, fmt.Errorf("%s body: %w", f.Command, io.ErrUnexpectedEOF)
+		}
+		if one[0] == 0 {
+			return b, nil
+		}
+		if len(b) == MaxBody {
+			return nil, fmt.Errorf("%s body: Longer than %d bytes", f.Command, MaxBody)
+		}
+		b = append(b, one[0])
+	}
+}
+
+// Reader reads frames from a connection as they arrive.
+type Reader struct {
+	s *parser.StreamReader
+}
+
+// NewReader returns a Reader over r. Frames are parsed straight from r,
+// with only the frame in progress buffered.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: parser.NewStreamReader(r, 4096)}
+}
+
+var heartbeats = parser.Mult(0, 0, eol)
+
+// Next returns the next frame, skipping the blank lines peers send as
+// heart-beats. It returns io.EOF when the connection closes between frames.
+// Any other error leaves the stream at the bad frame, and the connection
+// should be closed.
+func (r *Reader) Next() (Frame, error) {
+	heartbeats(r.s)
+	r.s.Commit()
+	if _, err := parser.EOF()(r.s); err == nil {
+		if err := r.s.Err(); err != nil {
+			return Frame{}, err
+		}
+		return Frame{}, io.EOF
+	}
+	f, err := ParseFrame(r.s)
+	if serr := r.s.Err(); serr != nil {
+		return Frame{}, serr
+	}
+	if err != nil {
+		return Frame{}, err
+	}
+	r.s.Commit()
+	return f, nil
+}
@@ -0,0 +1,122 @@
+package stomp
+
+import (
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyleap/parser"
+)
+
+func TestParseFrame(t *testing.T) {
+	t.Parallel()
+	in := "SEND\ndestination:/queue/a\nkey:a\\cb\\nc\n\nhello\x00"
+	f, _, err := parser.ParseBytes(ParseFrame, []byte(in))
+	want := Frame{
+		Command: "SEND",
+		Header:  []Header{{"destination", "/queue/a"}, {"key", "a:b\nc"}},
+		Body:    []byte("hello"),
+	}
+	if err != nil || !reflect.DeepEqual(f, want) {
+		t.Errorf("got %+v, %v", f, err)
+	}
+
+	// With content-length the body may hold NULs.
+	in = "MESSAGE\r\ncontent-length:3\r\n\r\na\x00b\x00"
+	f, _, err = parser.ParseBytes(ParseFrame, []byte(in))
+	if err != nil || string(f.Body) != "a\x00b" {
+		t.Errorf("content-length: got %q, %v", f.Body, err)
+	}
+
+	for _, in := range []string{
+		"SEND\n\nno end",
+		"SEND\ncontent-length:9\n\nshort\x00",
+		"SEND\nbroken header\n\n\x00",
+		"SHOUT\n\n\x00",
+	} {
+		if _, _, err := parser.ParseBytes(ParseFrame, []byte(in)); err == nil {
+			t.Errorf("%q accepted", in)
+		}
+	}
+}
+
+// TestReader feeds frames and heart-beats through a pipe a few bytes at a
+// time, as they might arrive over a network.
+func TestReader(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	stream := "CONNECT\naccept-version:1.2\n\n\x00\n\n" +
+		"SEND\ndestination:/queue/a\ncontent-length:5\n\nhello\x00\n" +
+		"SEND\ndestination:/queue/b\n\n" + strings.Repeat("x", 10000) + "\x00" +
+		"DISCONNECT\n\n\x00"
+	go func() {
+		for i := 0; i < len(stream); i += 7 {
+			end := i + 7
+			if end > len(stream) {
+				end = len(stream)
+			}
+			client.Write([]byte(stream[i:end]))
+		}
+		client.Close()
+	}()
+
+	r := NewReader(server)
+	cmds := []string{}
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmds = append(cmds, f.Command)
+		if f.Command == "SEND" {
+			if d, _ := f.Get("destination"); d == "/queue/b" && len(f.Body) != 10000 {
+				t.Errorf("Body of %d bytes", len(f.Body))
+			}
+		}
+	}
+	if !reflect.DeepEqual(cmds, []string{"CONNECT", "SEND", "SEND", "DISCONNECT"}) {
+		t.Errorf("Unexpected frames %v", cmds)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader("SEND\n\nok\x00BOGUS\n\n\x00"))
+	if f, err := r.Next(); err != nil || string(f.Body) != "ok" {
+		t.Fatalf("got %+v, %v", f, err)
+	}
+	if _, err := r.Next(); err == nil || err == io.EOF {
+		t.Errorf("bad frame: got %v", err)
+	}
+}
+
+// TestReaderLive checks that each frame is returned as soon as it has
+// arrived, while the connection stays open.
+func TestReaderLive(t *testing.T) {
+	t.Parallel()
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := NewReader(pr)
+	for _, in := range []string{"DISCONNECT\n\n\x00", "SEND\na:b\n\n\x00", "ACK\n\n\x00"} {
+		go pw.Write([]byte(in))
+		done := make(chan error, 1)
+		go func() {
+			_, err := r.Next()
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("%q: %v", in, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: Next blocked on the open connection", in)
+		}
+	}
+}
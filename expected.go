@@ -0,0 +1,115 @@
+package parser
+
+import "fmt"
+
+// ExpectKind is the kind of thing an Expectation names.
+type ExpectKind int
+
+const (
+	ExpectLiteral ExpectKind = iota
+	ExpectClass
+	ExpectNotClass
+	ExpectEOF
+	// ExpectRule is a Named parser or Expecting label that failed without
+	// getting past its first terminal, standing in for the terminals it
+	// would have accepted.
+	ExpectRule
+)
+
+// Expectation is something that would have let the parse continue at the
+// point it failed.
+type Expectation struct {
+	Kind ExpectKind
+	// Text is the literal, the set (in Set syntax) or the rule name.
+	Text string
+}
+
+func (e Expectation) String() string {
+	switch e.Kind {
+	case ExpectLiteral:
+		return fmt.Sprintf("%q", e.Text)
+	case ExpectClass:
+		return "[" + e.Text + "]"
+	case ExpectNotClass:
+		return "[^" + e.Text + "]"
+	case ExpectEOF:
+		return "EOF"
+	}
+	return e.Text
+}
+
+// expect records that e was tried and failed at pos if sr is a Context.
+// Only failures at the furthest position reached are kept, since that is
+// where the input went wrong.
+func expect(sr StatefulReader, pos Position, e Expectation) {
+	c := ContextOf(sr)
+	if c == nil {
+		return
+	}
+	switch {
+	case !c.failed || pos.Offset > c.failPos.Offset:
+		c.failed, c.failPos, c.expected = true, pos, []Expectation{e}
+	case pos.Offset == c.failPos.Offset:
+		for _, x := range c.expected {
+			if x == e {
+				return
+			}
+		}
+		c.expected = append(c.expected, e)
+	}
+}
+
+// label is the expected list as a parser started at start, for relabel.
+type label struct {
+	start   Position
+	mark    int
+	prev    Position
+	hadFail bool
+}
+
+func (c *Context) label(start Position) label {
+	if c == nil {
+		return label{start: start}
+	}
+	return label{start, len(c.expected), c.failPos, c.failed}
+}
+
+// relabel replaces the expectations a parser recorded with e if it failed
+// before getting past its first terminal, so that it is reported by name
+// rather than by the terminals it tried. It reports whether it did.
+func (l label) relabel(c *Context, sr StatefulReader, e Expectation) bool {
+	if c == nil || !c.failed || c.failPos.Offset != l.start.Offset {
+		return false
+	}
+	mark := l.mark
+	if !l.hadFail || l.prev.Offset != l.start.Offset {
+		mark = 0
+	}
+	c.expected = c.expected[:mark]
+	expect(sr, l.start, e)
+	return true
+}
+
+// Expecting names what p matches, such as "digit" for Set("0-9"), so that
+// errors and the expected list say "digit" rather than showing the set. If
+// p fails without getting past its first terminal, the expectations it
+// recorded are replaced by name, which completion then offers as a rule,
+// and an "Expected X, got Y" error from it is reworded to use name.
+func Expecting[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Expecting", p)
+	return func(sr StatefulReader) (T, error) {
+		start := Pos(sr)
+		c := ContextOf(sr)
+		l := c.label(start)
+		v, err := p(sr)
+		if err == nil {
+			return v, nil
+		}
+		if l.relabel(c, sr, Expectation{Kind: ExpectRule, Text: name}) || c == nil {
+			if me, ok := err.(*MessageError); ok && me.Key == MsgExpected {
+				err = msg(MsgExpectedName, name, me.Args[1])
+			}
+		}
+		return v, err
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpected(t *testing.T) {
+	num := Named("number", join(Mult(1, 0, Set("0-9"))))
+	value := Or(num, Lit("true"), Lit("false"))
+	assign := join(And(Set("a-z"), Lit("="), value, EOF()))
+
+	for _, tc := range []struct {
+		in     string
+		offset int64
+		want   []string
+	}{
+		{"a=", 2, []string{"number", `"true"`, `"false"`}},
+		{"a=x", 2, []string{"number", `"true"`, `"false"`}},
+		{"a=12x", 4, []string{"[0-9]", "EOF"}},
+		{"=", 0, []string{"[a-z]"}},
+	} {
+		_, _, err := ParseReader(assign, strings.NewReader(tc.in))
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%q: got %v", tc.in, err)
+			continue
+		}
+		got := []string{}
+		for _, e := range pe.Expected {
+			got = append(got, e.String())
+		}
+		if pe.Pos.Offset != tc.offset {
+			t.Errorf("%q: failed at %d, want %d", tc.in, pe.Pos.Offset, tc.offset)
+		}
+		assertSrc(t, tc.in, got, tc.want)
+	}
+
+	// a rule tried twice at the same place is only listed once
+	twice := Or(join(And(num, Lit("a"))), join(And(num, Lit("b"))), Lit("c"))
+	_, _, err := ParseReader(twice, strings.NewReader("x"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || len(pe.Expected) != 2 {
+		t.Errorf("got %v", pe.Expected)
+	}
+}
+
+func TestExpecting(t *testing.T) {
+	digit := Expecting("digit", Set("0-9"))
+	num := join(Mult(1, 0, digit))
+	assign := join(And(Expecting("identifier", Set("a-z")), Lit("="), Or(Lit("-"), num), EOF()))
+
+	_, _, err := ParseBytes(assign, []byte("a=x"))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v", err)
+	}
+	got := []string{}
+	for _, e := range pe.Expected {
+		got = append(got, e.String())
+	}
+	assert(t, got, []string{`"-"`, "digit"})
+	assert(t, pe.Error(), "1:3: No match")
+
+	_, _, err = ParseBytes(assign, []byte("1=2"))
+	assert(t, err.Error(), `1:1: Expected identifier, got "1"`)
+	if _, err := digit(NewBytesReader([]byte("x"))); err == nil || err.Error() != `Expected digit, got "x"` {
+		t.Errorf("without a Context got %v", err)
+	}
+
+	comp := Complete(assign, "a=", 2)
+	assert(t, len(comp.Expected), 2)
+	assert(t, comp.Expected[1], Expectation{Kind: ExpectRule, Text: "digit"})
+}
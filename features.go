@@ -0,0 +1,72 @@
+package parser
+
+// Features enables named feature flags for When and Unless, such as
+// experimental syntax or a dialect's extensions. Later options add to
+// earlier ones.
+func Features(names ...string) Option {
+	return func(o *options) {
+		if o.features == nil {
+			o.features = map[string]bool{}
+		}
+		for _, n := range names {
+			o.features[n] = true
+		}
+	}
+}
+
+// LanguageVersion sets the version of the language being parsed, for Since.
+func LanguageVersion(v int) Option {
+	return func(o *options) {
+		o.version = v
+	}
+}
+
+// When runs p only if feature is enabled in the Context, and otherwise
+// fails without trying it, so that one grammar can describe several
+// dialects:
+//
+//	expr := Or(When("lambda", lambda), call, ident)
+//
+// A disabled p records no expectations, so it isn't offered in errors or
+// completions. Without a Context no features are enabled.
+func When[T any](feature string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("When", p)
+	return func(sr StatefulReader) (T, error) {
+		if c := ContextOf(sr); c == nil || !c.Features[feature] {
+			var t T
+			return t, msg(MsgFeatureOff, feature)
+		}
+		return p(sr)
+	}
+}
+
+// Unless runs p only if feature is not enabled, for syntax a feature
+// replaces.
+func Unless[T any](feature string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Unless", p)
+	return func(sr StatefulReader) (T, error) {
+		if c := ContextOf(sr); c != nil && c.Features[feature] {
+			var t T
+			return t, msg(MsgFeatureOn, feature)
+		}
+		return p(sr)
+	}
+}
+
+// Since runs p only if the Context's Version is at least version, for
+// syntax added in a later version of a language. Without a Context the
+// version is 0.
+func Since[T any](version int, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Since", p)
+	return func(sr StatefulReader) (T, error) {
+		v := 0
+		if c := ContextOf(sr); c != nil {
+			v = c.Version
+		}
+		if v < version {
+			var t T
+			return t, msg(MsgTooOld, version, v)
+		}
+		return p(sr)
+	}
+}
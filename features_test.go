@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	t.Parallel()
+	arrow := When("lambda", Lit("=>"))
+	assign := Or(arrow, Unless("strict", Lit("=")), Since(2, Lit(":=")))
+	p := Left(assign, EOF())
+	cases := []struct {
+		in   string
+		opts []Option
+		ok   bool
+	}{
+		{"=>", nil, false},
+		{"=>", []Option{Features("lambda")}, true},
+		{"=", nil, true},
+		{"=", []Option{Features("lambda", "strict")}, false},
+		{":=", nil, false},
+		{":=", []Option{LanguageVersion(1)}, false},
+		{":=", []Option{LanguageVersion(2)}, true},
+		{":=", []Option{LanguageVersion(3)}, true},
+	}
+	for _, c := range cases {
+		v, _, err := ParseBytes(p, []byte(c.in), c.opts...)
+		if (err == nil) != c.ok || (c.ok && v != c.in) {
+			t.Errorf("%q with %d options: got %q, %v", c.in, len(c.opts), v, err)
+		}
+	}
+}
+
+func TestFeaturesExpected(t *testing.T) {
+	t.Parallel()
+	p := Or(When("lambda", Lit("=>")), Lit("="))
+	_, _, err := ParseBytes(p, []byte("x"))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v", err)
+	}
+	if len(pe.Expected) != 1 || pe.Expected[0].Text != "=" {
+		t.Errorf("disabled branch was expected: %v", pe.Expected)
+	}
+	if _, err := When("lambda", Lit("=>"))(NewBytesReader([]byte("=>"))); err == nil || err.Error() != `Requires feature "lambda"` {
+		t.Errorf("without a Context got %v", err)
+	}
+}
+
+func TestFeaturesCompiled(t *testing.T) {
+	t.Parallel()
+	p := Since(2, Lit(":="))
+	if _, err := Compile(p).RunString(":="); err == nil {
+		t.Error("version 0 parsed version 2 syntax")
+	}
+	c := Compile(p, LanguageVersion(2))
+	for i := 0; i < 2; i++ {
+		if _, err := c.RunString(":="); err != nil {
+			t.Errorf("run %d: %v", i, err)
+		}
+	}
+}
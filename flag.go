@@ -0,0 +1,70 @@
+package parser
+
+import "fmt"
+
+// FlagValue adapts a parser to flag.Value, and to pflag.Value through its
+// Type method, so a command line flag can take a mini-language such as a
+// filter expression or a list of key=value pairs and report the parser's
+// errors.
+type FlagValue[T any] struct {
+	// TypeName is returned by Type, for pflag's help output. Empty means
+	// "value".
+	TypeName string
+
+	p      func(sr StatefulReader) (T, error)
+	target *T
+	opts   []Option
+	text   string
+	set    bool
+}
+
+// AsFlagValue returns a FlagValue that parses flag arguments with p, which
+// must consume all of the argument, and stores the result in target:
+//
+//	var f Filter
+//	flag.Var(parser.AsFlagValue(filter, &f), "filter", "rows to keep")
+func AsFlagValue[T any](p func(sr StatefulReader) (T, error), target *T, opts ...Option) *FlagValue[T] {
+	if p == nil {
+		panic("parser: AsFlagValue: parser is nil")
+	}
+	if target == nil {
+		panic("parser: AsFlagValue: target is nil")
+	}
+	return &FlagValue[T]{p: p, target: target, opts: opts}
+}
+
+// Set parses s and stores the result, leaving the target unchanged if s
+// doesn't parse.
+func (f *FlagValue[T]) Set(s string) error {
+	err := AsTextUnmarshaler(f.p, f.target, f.opts...).UnmarshalText([]byte(s))
+	if err != nil {
+		return err
+	}
+	f.text, f.set = s, true
+	return nil
+}
+
+// String returns the text last passed to Set, or the target formatted with
+// fmt if Set hasn't been called.
+func (f *FlagValue[T]) String() string {
+	switch {
+	case f == nil || f.target == nil:
+		return ""
+	case f.set:
+		return f.text
+	}
+	return fmt.Sprint(*f.target)
+}
+
+// Get returns the current value, for flag.Getter.
+func (f *FlagValue[T]) Get() any {
+	return *f.target
+}
+
+// Type returns TypeName, for pflag.Value.
+func (f *FlagValue[T]) Type() string {
+	if f.TypeName == "" {
+		return "value"
+	}
+	return f.TypeName
+}
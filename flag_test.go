@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFlagValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	v := Version{Major: 1}
+	fv := AsFlagValue(Semver(), &v)
+	fs.Var(fv, "min", "minimum version")
+	if fv.String() != "1.0.0" {
+		t.Errorf("default String() = %q", fv.String())
+	}
+	if err := fs.Parse([]string{"-min", "2.3.4+build"}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Major != 2 || v.Minor != 3 || v.Patch != 4 {
+		t.Errorf("got %v", v)
+	}
+	if fv.String() != "2.3.4+build" {
+		t.Errorf("String() = %q", fv.String())
+	}
+	if got := fv.Get().(Version); got.Major != 2 {
+		t.Errorf("Get() = %v", got)
+	}
+
+	err := fs.Parse([]string{"-min", "2.x"})
+	if err == nil || !strings.Contains(err.Error(), "1:3") {
+		t.Errorf("got %v, want an error at 1:3", err)
+	}
+	if v.Major != 2 {
+		t.Errorf("target changed on failure: %v", v)
+	}
+}
+
+func TestFlagValueType(t *testing.T) {
+	var v Version
+	fv := AsFlagValue(Semver(), &v)
+	if fv.Type() != "value" {
+		t.Errorf("Type() = %q", fv.Type())
+	}
+	fv.TypeName = "semver"
+	if fv.Type() != "semver" {
+		t.Errorf("Type() = %q", fv.Type())
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import "strings"
+
+// LitFold matches text case-insensitively, like a Lit under Unicode case
+// folding, and returns the input as written rather than text, for protocols
+// like HTTP where header names are case-insensitive but the original
+// spelling should be kept. Since input is compared a byte length at a time,
+// it only matches spellings as long as text in UTF-8, which is always so
+// for ASCII.
+func LitFold(text string) func(sr StatefulReader) (string, error) {
+	if text == "" {
+		panic("parser: LitFold: empty text")
+	}
+	return func(sr StatefulReader) (string, error) {
+		if pk, ok := sr.(peeker); ok {
+			if b, ok := pk.PeekBytes(len(text)); ok {
+				if len(b) == len(text) && strings.EqualFold(string(b), text) {
+					got := string(b)
+					pk.Advance(len(text))
+					return got, nil
+				}
+				return litFailed(sr, text, b)
+			}
+		}
+		s := save(sr)
+		buf := getScratch(len(text))
+		defer putScratch(buf)
+		b := *buf
+		c, ok := readPrefix(sr, b, text, true)
+		if ok {
+			return string(b), nil
+		}
+		s.restore(sr)
+		return litFailed(sr, text, b[:c])
+	}
+}
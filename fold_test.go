@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLitFold(t *testing.T) {
+	t.Parallel()
+	p := LitFold("Content-Type")
+	for _, c := range []struct {
+		in, want string
+		ok       bool
+	}{
+		{"Content-Type", "Content-Type", true},
+		{"content-type: x", "content-type", true},
+		{"CONTENT-TYPE", "CONTENT-TYPE", true},
+		{"Content-Typo", "", false},
+		{"Content", "", false},
+	} {
+		for name, sr := range map[string]StatefulReader{
+			"bytes":  NewBytesReader([]byte(c.in)),
+			"simple": NewSimpleReader(strings.NewReader(c.in)),
+		} {
+			v, err := p(sr)
+			if (err == nil) != c.ok || v != c.want {
+				t.Errorf("%s %q: got %q, %v", name, c.in, v, err)
+			}
+			if rest, _ := io.ReadAll(sr); err != nil && string(rest) != c.in {
+				t.Errorf("%s %q: failed leaving %q", name, c.in, rest)
+			}
+		}
+	}
+	_, _, err := ParseBytes(LitFold("select"), []byte("delete"))
+	if err == nil || err.Error() != `1:1: Expected "select", got "delete"` {
+		t.Errorf("got %v", err)
+	}
+}
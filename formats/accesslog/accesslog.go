@@ -0,0 +1,246 @@
+// Package accesslog parses web server access logs in the Apache common and
+// combined formats, or in any custom LogFormat string.
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+)
+
+const (
+	// Common is the Apache Common Log Format.
+	Common = `%h %l %u %t "%r" %>s %b`
+	// Combined is the Apache Combined Log Format.
+	Combined = Common + ` "%{Referer}i" "%{User-agent}i"`
+)
+
+// TimeLayout is the layout of the %t directive, without its brackets.
+const TimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// Record is a single parsed log line. Fields holds the raw text of every
+// directive, keyed by the directive as written in the format (e.g. "%>s" or
+// "%{Referer}i"); well-known directives are also decoded into the typed
+// fields. A "-" in the log is kept in Fields but decoded as the zero value.
+type Record struct {
+	RemoteHost string
+	Ident      string
+	User       string
+	Time       time.Time
+	Request    string
+	Status     int
+	Bytes      int64
+	Referer    string
+	UserAgent  string
+	Fields     map[string]string
+}
+
+type segment struct {
+	literal   string
+	directive string
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var formatSegment = parser.Or(
+	parser.Convert(parser.Lit("%%"), func(string) (segment, error) { return segment{literal: "%"}, nil }),
+	parser.Convert(join(parser.And(
+		parser.Lit("%"),
+		join(parser.Mult(0, 0, parser.Set("<>!,0-9"))),
+		parser.Optional(join(parser.And(parser.Lit("{"), join(parser.Mult(0, 0, parser.NotSet("}"))), parser.Lit("}")))),
+		parser.Set("a-zA-Z"),
+	)), func(s string) (segment, error) { return segment{directive: s}, nil }),
+	parser.Convert(join(parser.Mult(1, 0, parser.NotSet("%"))), func(s string) (segment, error) { return segment{literal: s}, nil }),
+)
+
+// Compile builds a parser for log lines written with the given Apache
+// LogFormat string. The returned parser does not consume the line
+// terminator.
+func Compile(format string) (func(sr parser.StatefulReader) (Record, error), error) {
+	sr := parser.NewSimpleReader(strings.NewReader(format))
+	segs, err := parser.Mult(0, 0, formatSegment)(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, fmt.Errorf("Invalid log format %q: %w", format, err)
+	}
+	fields := []func(sr parser.StatefulReader) (string, error){}
+	for i, seg := range segs {
+		if seg.directive == "" {
+			fields = append(fields, parser.Lit(seg.literal))
+			continue
+		}
+		if i+1 < len(segs) && segs[i+1].directive != "" {
+			return nil, fmt.Errorf("Directives %s and %s must be separated by literal text", seg.directive, segs[i+1].directive)
+		}
+		if seg.directive == "%t" {
+			fields = append(fields, bracketed)
+			continue
+		}
+		stop := "\r\n"
+		if i+1 < len(segs) {
+			r, _ := utf8.DecodeRuneInString(segs[i+1].literal)
+			stop += string(r)
+		}
+		fields = append(fields, value(stop))
+	}
+	return func(sr parser.StatefulReader) (Record, error) {
+		s := sr.State()
+		rec := Record{Fields: map[string]string{}}
+		for i, f := range fields {
+			v, err := f(sr)
+			if err != nil {
+				sr.Restore(s)
+				if segs[i].directive != "" {
+					return Record{}, fmt.Errorf("%s: %w", segs[i].directive, err)
+				}
+				return Record{}, err
+			}
+			if segs[i].directive != "" {
+				if err := rec.set(segs[i].directive, v); err != nil {
+					sr.Restore(s)
+					return Record{}, err
+				}
+			}
+		}
+		return rec, nil
+	}, nil
+}
+
+var bracketed = join(parser.And(parser.Lit("["), join(parser.Mult(0, 0, parser.NotSet("]\r\n"))), parser.Lit("]")))
+
+// value reads a directive's text up to the first unescaped rune in stop.
+func value(stop string) func(sr parser.StatefulReader) (string, error) {
+	escaped := parser.Convert(parser.And(parser.Lit(`\`), parser.NotSet("\r\n")), func(s []string) (string, error) {
+		switch s[1] {
+		case "n":
+			return "\n", nil
+		case "t":
+			return "\t", nil
+		}
+		return s[1], nil
+	})
+	return join(parser.Mult(0, 0, parser.Or(escaped, except(stop+`\`))))
+}
+
+var anyRune = parser.NotSet("")
+
+// except matches any rune but those in stop. Unlike NotSet, stop is taken
+// literally, so a literal such as "-" after a directive isn't read as a
+// range.
+func except(stop string) func(sr parser.StatefulReader) (string, error) {
+	return func(sr parser.StatefulReader) (string, error) {
+		s := sr.State()
+		c, err := anyRune(sr)
+		if err != nil {
+			return "", err
+		}
+		if r, _ := utf8.DecodeRuneInString(c); strings.ContainsRune(stop, r) {
+			sr.Restore(s)
+			return "", fmt.Errorf("Unexpected %q", c)
+		}
+		return c, nil
+	}
+}
+
+func (r *Record) set(directive, v string) error {
+	r.Fields[directive] = v
+	if v == "-" {
+		return nil
+	}
+	var err error
+	switch directive {
+	case "%h", "%a":
+		r.RemoteHost = v
+	case "%l":
+		r.Ident = v
+	case "%u":
+		r.User = v
+	case "%t":
+		if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+			return fmt.Errorf("%%t: Expected bracketed time, got %q", v)
+		}
+		r.Time, err = time.Parse(TimeLayout, v[1:len(v)-1])
+	case "%r":
+		r.Request = v
+	case "%s", "%>s", "%<s":
+		r.Status, err = strconv.Atoi(v)
+	case "%b", "%B":
+		r.Bytes, err = strconv.ParseInt(v, 10, 64)
+	case "%{Referer}i":
+		r.Referer = v
+	case "%{User-agent}i", "%{User-Agent}i":
+		r.UserAgent = v
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", directive, err)
+	}
+	return nil
+}
+
+// Scanner reads log records one line at a time.
+type Scanner struct {
+	sr     parser.StatefulReader
+	record func(sr parser.StatefulReader) (Record, error)
+	eol    func(sr parser.StatefulReader) (string, error)
+	line   int
+	rec    Record
+	err    error
+}
+
+// NewScanner returns a Scanner reading lines parsed by record, as returned by
+// Compile.
+func NewScanner(sr parser.StatefulReader, record func(sr parser.StatefulReader) (Record, error)) *Scanner {
+	return &Scanner{
+		sr:     sr,
+		record: record,
+		eol:    parser.Or(parser.Lit("\r\n"), parser.Lit("\n"), parser.EOF()),
+	}
+}
+
+// Next advances to the next record, returning false at the end of the input
+// or on the first error.
+func (s *Scanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if _, err := parser.EOF()(s.sr); err == nil {
+		s.err = io.EOF
+		return false
+	}
+	s.line++
+	rec, err := s.record(s.sr)
+	if err == nil {
+		_, err = s.eol(s.sr)
+	}
+	if err != nil {
+		s.err = fmt.Errorf("line %d: %w", s.line, err)
+		return false
+	}
+	s.rec = rec
+	return true
+}
+
+// Record returns the record read by the last successful call to Next.
+func (s *Scanner) Record() Record {
+	return s.rec
+}
+
+// Err returns the error that stopped the Scanner, or nil at the end of the
+// input.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
@@ -0,0 +1,94 @@
+package accesslog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyleap/parser"
+)
+
+func TestCombined(t *testing.T) {
+	t.Parallel()
+	p, err := Compile(Combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"
+10.0.0.2 - - [11/Oct/2000:01:02:03 +0000] "POST /q?x=\"y\" HTTP/1.1" 404 - "-" "curl/8.0"
+`
+	s := NewScanner(parser.NewSimpleReader(strings.NewReader(in)), p)
+	recs := []Record{}
+	for s.Next() {
+		recs = append(recs, s.Record())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(recs))
+	}
+	r := recs[0]
+	if r.RemoteHost != "127.0.0.1" || r.User != "frank" || r.Status != 200 || r.Bytes != 2326 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if !r.Time.Equal(time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)) {
+		t.Errorf("Unexpected time %v", r.Time)
+	}
+	if r.Referer != "http://www.example.com/start.html" || r.UserAgent != "Mozilla/4.08 [en] (Win98; I ;Nav)" {
+		t.Errorf("Unexpected referer/agent %q %q", r.Referer, r.UserAgent)
+	}
+	r = recs[1]
+	if r.Request != `POST /q?x="y" HTTP/1.1` || r.Bytes != 0 || r.Fields["%b"] != "-" || r.Referer != "" {
+		t.Errorf("Unexpected record %+v", r)
+	}
+}
+
+func TestCustomFormat(t *testing.T) {
+	t.Parallel()
+	p, err := Compile(`%h|%{X-Trace}i|%D%%`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScanner(parser.NewSimpleReader(strings.NewReader("host|abc123|42%\n")), p)
+	if !s.Next() {
+		t.Fatal(s.Err())
+	}
+	if f := s.Record().Fields; f["%{X-Trace}i"] != "abc123" || f["%D"] != "42" {
+		t.Errorf("Unexpected fields %v", f)
+	}
+}
+
+func TestStopRunes(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		format, line, user string
+	}{
+		{"%h-%u", "1.2.3.4-bob", "bob"},
+		{"%h→%u", "1.2.3.4→bob", "bob"},
+	} {
+		p, err := Compile(c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := p(parser.NewSimpleReader(strings.NewReader(c.line)))
+		if err != nil || r.RemoteHost != "1.2.3.4" || r.User != c.user {
+			t.Errorf("%s: got %+v, %v", c.format, r, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := Compile("%h%u"); err == nil {
+		t.Error("Expected error for adjacent directives")
+	}
+	p, _ := Compile(Common)
+	s := NewScanner(parser.NewSimpleReader(strings.NewReader(`1.2.3.4 - - [bad] "GET / HTTP/1.0" 200 1`+"\n")), p)
+	if s.Next() {
+		t.Fatal("Expected failure")
+	}
+	if err := s.Err(); err == nil || !strings.HasPrefix(err.Error(), "line 1: %t:") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+// Package cbor parses CBOR (RFC 8949) into plain Go values, following its
+// nested definite and indefinite length framing with the binary
+// combinators. It checks well-formedness, not the validity rules of
+// particular tags.
+//
+// Values decode as follows: unsigned integers to uint64, negative integers
+// to int64, or *big.Int below math.MinInt64, byte strings to []byte, text
+// strings to string, arrays to []any, maps to Map, tagged items to Tag,
+// false, true and null to false, true and nil, undefined to Undefined,
+// other simple values to Simple, and floats of all three sizes to float64.
+package cbor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/binary"
+)
+
+// Map is a CBOR map, with its entries in the order they were encoded. Keys
+// may be any value, so it isn't a Go map.
+type Map []Entry
+
+// Entry is one key and value of a Map.
+type Entry struct {
+	Key, Value any
+}
+
+// Get returns the value of the first entry whose key equals key, compared
+// with ==, so it only finds keys of comparable types such as strings and
+// integers.
+func (m Map) Get(key any) (any, bool) {
+	for _, e := range m {
+		if hashable(e.Key) && hashable(key) && e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+func hashable(v any) bool {
+	switch v.(type) {
+	case []byte, []any, Map, *big.Int, Tag:
+		return false
+	}
+	return true
+}
+
+// Tag is a tagged item, such as a date (tag 0 or 1) or a bignum (tag 2 or
+// 3), left for the caller to interpret.
+type Tag struct {
+	Number uint64
+	Value  any
+}
+
+// Simple is a simple value other than false, true, null and undefined.
+type Simple uint8
+
+// Undefined is the undefined simple value.
+type Undefined struct{}
+
+// MaxDepth is how deeply arrays, maps and tags may nest.
+const MaxDepth = 256
+
+const (
+	majorUint = iota
+	majorNeg
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+var (
+	u8  = binary.U8()
+	u16 = binary.U16BE()
+	u32 = binary.U32BE()
+	u64 = binary.U64BE()
+)
+
+// errBreak is what item returns on a break code, which only ends an
+// indefinite length item.
+var errBreak = fmt.Errorf("Unexpected break")
+
+func offset(sr parser.StatefulReader) int64 {
+	off, _ := sr.State().(int64)
+	return off
+}
+
+func fail(off int64, format string, args ...any) error {
+	return &binary.Error{Offset: off, Err: fmt.Errorf(format, args...)}
+}
+
+// Value returns a parser for one CBOR item, on a reader whose state is its
+// offset, such as a SimpleReader or BytesReader.
+func Value() func(sr parser.StatefulReader) (any, error) {
+	return func(sr parser.StatefulReader) (any, error) {
+		s := sr.State()
+		v, err := item(sr, 0)
+		if err == errBreak {
+			err = fail(offset(sr)-1, "Unexpected break")
+		}
+		if err != nil {
+			sr.Restore(s)
+		}
+		return v, err
+	}
+}
+
+// Parse parses data holding exactly one CBOR item.
+func Parse(data []byte) (any, error) {
+	return binary.Region(data, Value())
+}
+
+// Sequence parses a CBOR sequence (RFC 8742), zero or more items one after
+// another.
+func Sequence(data []byte) ([]any, error) {
+	return binary.Region(data, parser.Mult(0, 0, Value()))
+}
+
+// head reads an item's initial byte, split into its major type and
+// additional information, and its argument. indef reports additional
+// information 31, which has no argument: an indefinite length item or, for
+// major type 7, a break.
+func head(sr parser.StatefulReader) (major, ai byte, arg uint64, indef bool, err error) {
+	start := offset(sr)
+	ib, err := u8(sr)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, ai = ib>>5, ib&0x1f
+	switch {
+	case ai < 24:
+		arg = uint64(ai)
+	case ai == 24:
+		var v uint8
+		v, err = u8(sr)
+		arg = uint64(v)
+	case ai == 25:
+		var v uint16
+		v, err = u16(sr)
+		arg = uint64(v)
+	case ai == 26:
+		var v uint32
+		v, err = u32(sr)
+		arg = uint64(v)
+	case ai == 27:
+		arg, err = u64(sr)
+	case ai == 31 && major != majorUint && major != majorNeg && major != majorTag:
+		indef = true
+	default:
+		return 0, 0, 0, false, fail(start, "Reserved additional information %d for major type %d", ai, major)
+	}
+	return major, ai, arg, indef, err
+}
+
+func item(sr parser.StatefulReader, depth int) (any, error) {
+	start := offset(sr)
+	major, ai, arg, indef, err := head(sr)
+	if err != nil {
+		return nil, err
+	}
+	if depth > MaxDepth {
+		return nil, fail(start, "Nested more than %d deep", MaxDepth)
+	}
+	switch major {
+	case majorUint:
+		return arg, nil
+	case majorNeg:
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg), nil
+		}
+		n := new(big.Int).SetUint64(arg)
+		return n.Neg(n).Sub(n, big.NewInt(1)), nil
+	case majorBytes, majorText:
+		b, err := str(sr, major, arg, indef, start)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorBytes {
+			return b, nil
+		}
+		if !utf8.Valid(b) {
+			return nil, fail(start, "Invalid UTF-8 in text string")
+		}
+		return string(b), nil
+	case majorArray:
+		vs := []any{}
+		for i := uint64(0); indef || i < arg; i++ {
+			v, err := item(sr, depth+1)
+			if err == errBreak && indef {
+				return vs, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	case majorMap:
+		m := Map{}
+		for i := uint64(0); indef || i < arg; i++ {
+			k, err := item(sr, depth+1)
+			if err == errBreak && indef {
+				return m, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			v, err := item(sr, depth+1)
+			if err != nil {
+				if err == errBreak {
+					err = fail(offset(sr)-1, "Break after a map key")
+				}
+				return nil, err
+			}
+			m = append(m, Entry{k, v})
+		}
+		return m, nil
+	case majorTag:
+		v, err := item(sr, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return Tag{arg, v}, nil
+	}
+	return simple(ai, arg, start)
+}
+
+// str reads the contents of a byte or text string, joining the chunks of
+// an indefinite length one.
+func str(sr parser.StatefulReader, major byte, arg uint64, indef bool, start int64) ([]byte, error) {
+	if !indef {
+		if arg > math.MaxInt32 {
+			return nil, fail(start, "String of %d bytes is too long", arg)
+		}
+		return binary.Bytes(int(arg))(sr)
+	}
+	b := []byte{}
+	for {
+		at := offset(sr)
+		m, _, n, chunked, err := head(sr)
+		if err != nil {
+			return nil, err
+		}
+		if m == majorSimple && chunked {
+			return b, nil
+		}
+		if m != major || chunked {
+			return nil, fail(at, "Chunk of major type %d in an indefinite length string of major type %d", m, major)
+		}
+		chunk, err := str(sr, major, n, false, at)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorText && !utf8.Valid(chunk) {
+			return nil, fail(at, "Invalid UTF-8 in text string")
+		}
+		b = append(b, chunk...)
+	}
+}
+
+// simple decodes major type 7 from its head.
+func simple(ai byte, arg uint64, start int64) (any, error) {
+	switch {
+	case ai == 31:
+		return nil, errBreak
+	case ai == 25:
+		return half(uint16(arg)), nil
+	case ai == 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case ai == 27:
+		return math.Float64frombits(arg), nil
+	case ai == 24 && arg < 32:
+		return nil, fail(start, "Simple value %d in two bytes", arg)
+	}
+	switch arg {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22:
+		return nil, nil
+	case 23:
+		return Undefined{}, nil
+	}
+	return Simple(arg), nil
+}
+
+// half decodes an IEEE 754 half precision float.
+func half(h uint16) float64 {
+	exp, frac := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(frac, -24)
+	case 31:
+		if frac == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(frac+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,134 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser/binary"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestAppendixA checks examples from RFC 8949 appendix A.
+func TestAppendixA(t *testing.T) {
+	t.Parallel()
+	min65, _ := new(big.Int).SetString("-18446744073709551616", 10)
+	for _, c := range []struct {
+		hex  string
+		want any
+	}{
+		{"00", uint64(0)},
+		{"17", uint64(23)},
+		{"1818", uint64(24)},
+		{"1903e8", uint64(1000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"20", int64(-1)},
+		{"3863", int64(-100)},
+		{"3bffffffffffffffff", min65},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f9c400", -4.0},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f97c00", math.Inf(1)},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", Undefined{}},
+		{"f0", Simple(16)},
+		{"f8ff", Simple(255)},
+		{"c074323031332d30332d32315432303a30343a30305a", Tag{0, "2013-03-21T20:04:00Z"}},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"62c3bc", "ü"},
+		{"80", []any{}},
+		{"8301820203820405", []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+		{"a201020304", Map{{uint64(1), uint64(2)}, {uint64(3), uint64(4)}}},
+		{"a26161016162820203", Map{{"a", uint64(1)}, {"b", []any{uint64(2), uint64(3)}}}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{uint64(1), []any{uint64(2), uint64(3)}, []any{uint64(4), uint64(5)}}},
+		{"bf61610161629f0203ffff", Map{{"a", uint64(1)}, {"b", []any{uint64(2), uint64(3)}}}},
+	} {
+		got, err := Parse(mustHex(c.hex))
+		if err != nil {
+			t.Errorf("%s: %v", c.hex, err)
+			continue
+		}
+		if b, ok := c.want.(*big.Int); ok {
+			if g, ok := got.(*big.Int); !ok || g.Cmp(b) != 0 {
+				t.Errorf("%s: got %v, want %v", c.hex, got, c.want)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %#v, want %#v", c.hex, got, c.want)
+		}
+	}
+	for _, h := range []string{"f97e00", "fbffffffffffffffff"} {
+		if v, err := Parse(mustHex(h)); err != nil || !math.IsNaN(v.(float64)) {
+			t.Errorf("%s: got %v, %v", h, v, err)
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		hex string
+		at  int64
+		msg string
+	}{
+		{"1c", 0, "Reserved additional information 28 for major type 0"},
+		{"1f", 0, "Reserved additional information 31 for major type 0"},
+		{"ff", 0, "Unexpected break"},
+		{"8201ff", 2, "Unexpected break"},
+		{"9bffffffffffffffff01ff", 10, "Unexpected break"},
+		{"bf01ff", 2, "Break after a map key"},
+		{"5f6161ff", 1, "Chunk of major type 3 in an indefinite length string of major type 2"},
+		{"62c328", 0, "Invalid UTF-8 in text string"},
+		{"f818", 0, "Simple value 24 in two bytes"},
+		{"83010203ff", 4, "1 trailing bytes in 5 byte region"},
+		{"5a00000010aa", 6, "Unexpected EOF: wanted 16 bytes, got 1"},
+	} {
+		_, err := Parse(mustHex(c.hex))
+		var e *binary.Error
+		if !errors.As(err, &e) || e.Offset != c.at || err.Error() != c.msg {
+			t.Errorf("%s: got %v at %v", c.hex, err, e)
+		}
+	}
+
+	deep := make([]byte, MaxDepth+2)
+	for i := range deep {
+		deep[i] = 0x81
+	}
+	if _, err := Parse(append(deep, 0)); err == nil {
+		t.Errorf("Deep nesting accepted")
+	}
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+	vs, err := Sequence(mustHex("0161618100"))
+	if err != nil || !reflect.DeepEqual(vs, []any{uint64(1), "a", []any{uint64(0)}}) {
+		t.Errorf("got %#v, %v", vs, err)
+	}
+	m, _ := Parse(mustHex("a26161016162820203"))
+	if v, ok := m.(Map).Get("a"); !ok || v != uint64(1) {
+		t.Errorf("Get: got %v", v)
+	}
+	if _, ok := m.(Map).Get([]byte("a")); ok {
+		t.Errorf("Get found a byte string key")
+	}
+}
@@ -0,0 +1,222 @@
+// Package cron parses 5 and 6 field cron expressions into a schedule of
+// allowed values per field.
+package cron
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Field describes one column of a cron expression.
+type Field struct {
+	Name     string
+	Min, Max int
+	Names    []string
+}
+
+var (
+	Second     = Field{Name: "second", Min: 0, Max: 59}
+	Minute     = Field{Name: "minute", Min: 0, Max: 59}
+	Hour       = Field{Name: "hour", Min: 0, Max: 23}
+	DayOfMonth = Field{Name: "day of month", Min: 1, Max: 31}
+	Month      = Field{Name: "month", Min: 1, Max: 12, Names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	DayOfWeek  = Field{Name: "day of week", Min: 0, Max: 7, Names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+)
+
+// Schedule holds the sorted allowed values of each field. Seconds is nil
+// for 5 field expressions. Day of week 7 is normalised to 0 (Sunday).
+type Schedule struct {
+	Seconds     []int
+	Minutes     []int
+	Hours       []int
+	DaysOfMonth []int
+	Months      []int
+	DaysOfWeek  []int
+}
+
+var aliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Error is a problem with a specific field, with the column it starts at.
+type Error struct {
+	Column int
+	Field  string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s: %s", e.Column, e.Field, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	blanks    = join(parser.Mult(1, 0, parser.Set(" \t")))
+	fieldText = join(parser.Mult(1, 0, parser.NotSet(" \t\r\n")))
+	number    = parser.Convert(join(parser.Mult(1, 0, parser.Set("0-9"))), strconv.Atoi)
+	name      = join(parser.Mult(3, 3, parser.Set("a-zA-Z")))
+)
+
+func (f Field) value(sr parser.StatefulReader) (int, error) {
+	if n, err := number(sr); err == nil {
+		if n < f.Min || n > f.Max {
+			return 0, fmt.Errorf("Value %d out of range %d-%d", n, f.Min, f.Max)
+		}
+		return n, nil
+	}
+	if len(f.Names) > 0 {
+		if s, err := name(sr); err == nil {
+			for i, n := range f.Names {
+				if strings.EqualFold(n, s) {
+					return i + f.Min, nil
+				}
+			}
+			return 0, fmt.Errorf("Unknown name %q", s)
+		}
+	}
+	return 0, fmt.Errorf("Expected value")
+}
+
+// parse reads a comma separated list of *, values, ranges and steps.
+// Errors are reported at the start of the offending list element.
+func (f Field) parse(sr parser.StatefulReader) ([]int, error) {
+	set := map[int]bool{}
+	for {
+		start := parser.Pos(sr).Column
+		fail := func(err error) ([]int, error) {
+			return nil, &Error{Column: start, Field: f.Name, Err: err}
+		}
+		lo, hi := f.Min, f.Max
+		single := false
+		if _, err := parser.Lit("*")(sr); err != nil {
+			if lo, err = f.value(sr); err != nil {
+				return fail(err)
+			}
+			hi = lo
+			single = true
+			if _, err := parser.Lit("-")(sr); err == nil {
+				single = false
+				if hi, err = f.value(sr); err != nil {
+					return fail(err)
+				}
+				if hi < lo {
+					return fail(fmt.Errorf("Range %d-%d is backwards", lo, hi))
+				}
+			}
+		}
+		step := 1
+		if _, err := parser.Lit("/")(sr); err == nil {
+			var err error
+			if step, err = number(sr); err != nil {
+				return fail(fmt.Errorf("Expected step"))
+			}
+			if step == 0 {
+				return fail(fmt.Errorf("Step must be positive"))
+			}
+			if max := f.Max - f.Min + 1; step > max {
+				return fail(fmt.Errorf("Step %d out of range 1-%d", step, max))
+			}
+			// a single value with a step means "from here to the maximum"
+			if single {
+				hi = f.Max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+		if _, err := parser.Lit(",")(sr); err != nil {
+			break
+		}
+	}
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, &Error{Column: parser.Pos(sr).Column, Field: f.Name, Err: err}
+	}
+	vs := make([]int, 0, len(set))
+	for v := range set {
+		vs = append(vs, v)
+	}
+	sort.Ints(vs)
+	return vs, nil
+}
+
+// Parse parses a cron expression with 5 fields (minute to day of week), 6
+// fields (with leading seconds) or one of the @ aliases such as @daily.
+func Parse(expr string) (Schedule, error) {
+	if alias, ok := aliases[strings.TrimSpace(expr)]; ok {
+		expr = alias
+	}
+	pr := parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(expr)))
+	type word struct {
+		text   string
+		column int
+	}
+	words := []word{}
+	for {
+		blanks(pr)
+		col := parser.Pos(pr).Column
+		w, err := fieldText(pr)
+		if err != nil {
+			break
+		}
+		words = append(words, word{w, col})
+	}
+	if _, err := parser.EOF()(pr); err != nil {
+		return Schedule{}, &Error{Column: parser.Pos(pr).Column, Field: "expression", Err: err}
+	}
+	fields := []Field{Minute, Hour, DayOfMonth, Month, DayOfWeek}
+	switch len(words) {
+	case 5:
+	case 6:
+		fields = append([]Field{Second}, fields...)
+	default:
+		return Schedule{}, fmt.Errorf("Expected 5 or 6 fields, got %d", len(words))
+	}
+	values := make([][]int, len(fields))
+	for i, f := range fields {
+		fr := parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(words[i].text)))
+		vs, err := f.parse(fr)
+		if err != nil {
+			e := err.(*Error)
+			e.Column += words[i].column - 1
+			return Schedule{}, e
+		}
+		values[i] = vs
+	}
+	s := Schedule{}
+	if len(values) == 6 {
+		s.Seconds, values = values[0], values[1:]
+	}
+	s.Minutes, s.Hours, s.DaysOfMonth, s.Months = values[0], values[1], values[2], values[3]
+	s.DaysOfWeek = normaliseWeekdays(values[4])
+	return s, nil
+}
+
+func normaliseWeekdays(vs []int) []int {
+	if len(vs) == 0 || vs[len(vs)-1] != 7 {
+		return vs
+	}
+	vs = vs[:len(vs)-1]
+	if len(vs) == 0 || vs[0] != 0 {
+		vs = append([]int{0}, vs...)
+	}
+	return vs
+}
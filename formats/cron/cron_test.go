@@ -0,0 +1,75 @@
+package cron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	s, err := Parse("*/15 9-17 1,15 JAN-mar mon-fri")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Schedule{
+		Minutes:     []int{0, 15, 30, 45},
+		Hours:       []int{9, 10, 11, 12, 13, 14, 15, 16, 17},
+		DaysOfMonth: []int{1, 15},
+		Months:      []int{1, 2, 3},
+		DaysOfWeek:  []int{1, 2, 3, 4, 5},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, s)
+	}
+}
+
+func TestSeconds(t *testing.T) {
+	t.Parallel()
+	s, err := Parse("30 5/20 0 * * 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInts(t, s.Seconds, []int{30})
+	assertInts(t, s.Minutes, []int{5, 25, 45})
+	assertInts(t, s.DaysOfWeek, []int{0})
+	if len(s.Months) != 12 {
+		t.Errorf("Expected every month, got %v", s.Months)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+	s, err := Parse("@weekly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInts(t, s.Minutes, []int{0})
+	assertInts(t, s.DaysOfWeek, []int{0})
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"* * * *":                       "Expected 5 or 6 fields, got 4",
+		"* 24 * * *":                    "column 3: hour: Value 24 out of range 0-23",
+		"* * * FOO *":                   "column 7: month: Unknown name \"FOO\"",
+		"* * 5-1 * *":                   "column 5: day of month: Range 5-1 is backwards",
+		"*/0 * * * *":                   "column 1: minute: Step must be positive",
+		"1/9223372036854775807 * * * *": "column 1: minute: Step 9223372036854775807 out of range 1-60",
+		"* * * * 1;2":                   "column 10: day of week: Expected EOF, got \";\"",
+		"0 0 * * * * *":                 "Expected 5 or 6 fields, got 7",
+	}
+	for in, msg := range tests {
+		_, err := Parse(in)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
+
+func assertInts(t *testing.T, got, expected []int) {
+	t.Helper()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
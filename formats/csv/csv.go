@@ -0,0 +1,149 @@
+// Package csv reads RFC 4180 comma-separated values using the parser
+// combinators.
+package csv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+// Field returns a parser for a single quoted or unquoted field.
+func Field(comma rune) func(sr parser.StatefulReader) (string, error) {
+	quoted := parser.Convert(parser.And(
+		parser.Lit(`"`),
+		join(parser.Mult(0, 0, parser.Or(
+			parser.NotSet(`"`),
+			parser.Convert(parser.Lit(`""`), func(string) (string, error) { return `"`, nil }),
+		))),
+		parser.Lit(`"`),
+	), func(s []string) (string, error) {
+		return s[1], nil
+	})
+	bare := join(parser.Mult(0, 0, parser.NotSet(string(comma)+"\"\r\n")))
+	return parser.Or(quoted, bare)
+}
+
+// Record returns a parser for the fields of one record, not including the
+// line terminator.
+func Record(comma rune) func(sr parser.StatefulReader) ([]string, error) {
+	field := Field(comma)
+	sep := parser.Lit(string(comma))
+	return func(sr parser.StatefulReader) ([]string, error) {
+		f, err := field(sr)
+		if err != nil {
+			return nil, err
+		}
+		fields := []string{f}
+		for {
+			s := sr.State()
+			if _, err := sep(sr); err != nil {
+				sr.Restore(s)
+				return fields, nil
+			}
+			f, err := field(sr)
+			if err != nil {
+				sr.Restore(s)
+				return nil, err
+			}
+			fields = append(fields, f)
+		}
+	}
+}
+
+// Reader reads records one at a time from a StatefulReader.
+type Reader struct {
+	// Comma is the field delimiter. It defaults to ','.
+	Comma rune
+	// Comment, if not 0, starts a line that is skipped entirely.
+	Comment rune
+
+	sr      parser.StatefulReader
+	line    int
+	record  func(sr parser.StatefulReader) ([]string, error)
+	eol     func(sr parser.StatefulReader) (string, error)
+	comment func(sr parser.StatefulReader) ([]string, error)
+}
+
+// NewReader returns a Reader with the default settings. Comma and Comment
+// may be changed before the first call to Read.
+func NewReader(sr parser.StatefulReader) *Reader {
+	return &Reader{
+		Comma: ',',
+		sr:    sr,
+		line:  1,
+	}
+}
+
+func (r *Reader) init() {
+	if r.record != nil {
+		return
+	}
+	r.record = Record(r.Comma)
+	r.eol = parser.Or(parser.Lit("\r\n"), parser.Lit("\n"), parser.EOF())
+	if r.Comment != 0 {
+		r.comment = parser.And(
+			parser.Lit(string(r.Comment)),
+			join(parser.Mult(0, 0, parser.NotSet("\n"))),
+			r.eol,
+		)
+	}
+}
+
+// Read returns the next record. It returns io.EOF once the input is
+// exhausted.
+func (r *Reader) Read() ([]string, error) {
+	r.init()
+	for {
+		if _, err := parser.EOF()(r.sr); err == nil {
+			return nil, io.EOF
+		}
+		if r.comment != nil {
+			if _, err := r.comment(r.sr); err == nil {
+				r.line++
+				continue
+			}
+		}
+		if _, err := parser.Or(parser.Lit("\r\n"), parser.Lit("\n"))(r.sr); err == nil {
+			r.line++
+			continue
+		}
+		break
+	}
+	line := r.line
+	fields, err := r.record(r.sr)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	if _, err := r.eol(r.sr); err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	for _, f := range fields {
+		r.line += strings.Count(f, "\n")
+	}
+	r.line++
+	return fields, nil
+}
+
+// ReadAll reads all remaining records.
+func (r *Reader) ReadAll() ([][]string, error) {
+	records := [][]string{}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,86 @@
+package csv
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func newReader(s string) *Reader {
+	return NewReader(parser.NewSimpleReader(strings.NewReader(s)))
+}
+
+func TestReadAll(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out [][]string
+	}{
+		{"a,b,c\n", [][]string{{"a", "b", "c"}}},
+		{"a,b\r\nc,d", [][]string{{"a", "b"}, {"c", "d"}}},
+		{`"a ""quoted"" field",b` + "\n", [][]string{{`a "quoted" field`, "b"}}},
+		{"\"multi\nline\",x\n\ny,z\n", [][]string{{"multi\nline", "x"}, {"y", "z"}}},
+		{"a,,\n", [][]string{{"a", "", ""}}},
+		{"", [][]string{}},
+	}
+	for _, test := range tests {
+		out, err := newReader(test.in).ReadAll()
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("Expected (%q) %q, got %q", test.in, test.out, out)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+	r := newReader("# header\na;b\n#skip\nc;d\n")
+	r.Comma = ';'
+	r.Comment = '#'
+	out, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, [][]string{{"a", "b"}, {"c", "d"}}) {
+		t.Errorf("Unexpected records %q", out)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	t.Parallel()
+	r := newReader("a\nb\n")
+	for _, want := range []string{"a", "b"} {
+		rec, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec[0] != want {
+			t.Errorf("Expected %q, got %q", want, rec[0])
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	r := newReader("a,b\nc,\"d\n")
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.Read()
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("Expected line 2 error, got %v", err)
+	}
+	_, err = newReader("a\"b\n").Read()
+	if err == nil {
+		t.Error("Expected error for bare quote")
+	}
+}
@@ -0,0 +1,202 @@
+// Package dotenv parses .env files into ordered key/value pairs, keeping
+// positions and recording ${VAR} interpolation markers without expanding
+// them.
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Part is a piece of a value: either literal Text or a reference to the
+// variable Var.
+type Part struct {
+	Text string
+	Var  string
+}
+
+// Entry is a single assignment.
+type Entry struct {
+	Key      string
+	Pos      parser.Position
+	Exported bool
+	// Quote is the quote character the value was written with, or 0.
+	Quote rune
+	// Value is the value after quote and escape processing, with variable
+	// references normalised to ${NAME} rather than expanded.
+	Value string
+	Parts []Part
+}
+
+// Expand returns the value with variable references replaced using lookup.
+// Unknown variables expand to the empty string.
+func (e Entry) Expand(lookup func(string) (string, bool)) string {
+	sb := strings.Builder{}
+	for _, p := range e.Parts {
+		if p.Var == "" {
+			sb.WriteString(p.Text)
+			continue
+		}
+		v, _ := lookup(p.Var)
+		sb.WriteString(v)
+	}
+	return sb.String()
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+func text(s string) (Part, error) {
+	return Part{Text: s}, nil
+}
+
+var (
+	blank   = join(parser.Mult(0, 0, parser.Set(" \t")))
+	newline = parser.Or(parser.Lit("\r\n"), parser.Lit("\n"))
+	eol     = parser.Or(newline, parser.EOF())
+	comment = join(parser.And(parser.Lit("#"), join(parser.Mult(0, 0, parser.NotSet("\r\n")))))
+	key     = join(parser.And(parser.Set("a-zA-Z_"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_.")))))
+	export  = join(parser.And(parser.Lit("export"), join(parser.Mult(1, 0, parser.Set(" \t")))))
+
+	varName = join(parser.And(parser.Set("a-zA-Z_"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_")))))
+	varRef  = parser.Or(
+		parser.Convert(parser.And(parser.Lit("${"), varName, parser.Lit("}")), func(s []string) (Part, error) {
+			return Part{Var: s[1]}, nil
+		}),
+		parser.Convert(parser.And(parser.Lit("$"), varName), func(s []string) (Part, error) {
+			return Part{Var: s[1]}, nil
+		}),
+	)
+
+	singleQuoted = parser.Convert(parser.And(
+		parser.Lit("'"),
+		join(parser.Mult(0, 0, parser.NotSet("'"))),
+		parser.Lit("'"),
+	), func(s []string) ([]Part, error) {
+		return []Part{{Text: s[1]}}, nil
+	})
+
+	dqEscapes = map[string]string{"n": "\n", "r": "\r", "t": "\t", `"`: `"`, `\`: `\`, "$": "$"}
+	dqEscape  = parser.Convert(parser.And(parser.Lit(`\`), parser.Set(`nrt"\$`)), func(s []string) (Part, error) {
+		return Part{Text: dqEscapes[s[1]]}, nil
+	})
+	dqPart = parser.Or(dqEscape, varRef, parser.Convert(parser.NotSet(`"\$`), text), parser.Convert(parser.Lit("$"), text))
+
+	// unquoted values end at a newline or at whitespace followed by '#'
+	unquoted = parser.Mult(0, 0, parser.Or(
+		varRef,
+		parser.Convert(parser.NotSet(" \t\r\n$"), text),
+		parser.Convert(parser.Lit("$"), text),
+		parser.Convert(join(parser.And(join(parser.Mult(1, 0, parser.Set(" \t"))), parser.NotSet("# \t\r\n"))), text),
+	))
+)
+
+func doubleQuoted(sr parser.StatefulReader) ([]Part, error) {
+	s := sr.State()
+	if _, err := parser.Lit(`"`)(sr); err != nil {
+		return nil, err
+	}
+	parts, err := parser.Mult(0, 0, dqPart)(sr)
+	if err != nil {
+		sr.Restore(s)
+		return nil, err
+	}
+	if _, err := parser.Lit(`"`)(sr); err != nil {
+		sr.Restore(s)
+		return nil, fmt.Errorf("Unterminated double quoted value")
+	}
+	return parts, nil
+}
+
+// merge joins adjacent text parts.
+func merge(parts []Part) []Part {
+	out := []Part{}
+	for _, p := range parts {
+		if p.Var == "" && len(out) > 0 && out[len(out)-1].Var == "" {
+			out[len(out)-1].Text += p.Text
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func raw(parts []Part) string {
+	sb := strings.Builder{}
+	for _, p := range parts {
+		if p.Var != "" {
+			sb.WriteString("${" + p.Var + "}")
+		} else {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
+func entry(sr parser.StatefulReader) (Entry, error) {
+	e := Entry{}
+	if _, err := export(sr); err == nil {
+		e.Exported = true
+	}
+	var err error
+	if e.Key, err = key(sr); err != nil {
+		return e, fmt.Errorf("Expected variable name")
+	}
+	blank(sr)
+	if _, err := parser.Lit("=")(sr); err != nil {
+		return e, fmt.Errorf("Expected '=' after %s", e.Key)
+	}
+	blank(sr)
+	var parts []Part
+	if parts, err = singleQuoted(sr); err == nil {
+		e.Quote = '\''
+	} else if _, err := parser.Lit("'")(sr); err == nil {
+		return e, fmt.Errorf("Unterminated single quoted value")
+	} else if parts, err = doubleQuoted(sr); err == nil {
+		e.Quote = '"'
+	} else if _, err := parser.Lit(`"`)(sr); err == nil {
+		return e, fmt.Errorf("Unterminated double quoted value")
+	} else if parts, err = unquoted(sr); err != nil {
+		return e, err
+	}
+	e.Parts = merge(parts)
+	e.Value = raw(e.Parts)
+	blank(sr)
+	comment(sr)
+	if _, err := eol(sr); err != nil {
+		return e, fmt.Errorf("Unexpected text after value of %s", e.Key)
+	}
+	return e, nil
+}
+
+// Parse reads a complete .env file.
+func Parse(sr parser.StatefulReader) ([]Entry, error) {
+	entries := []Entry{}
+	for {
+		blank(sr)
+		if _, err := parser.EOF()(sr); err == nil {
+			return entries, nil
+		}
+		if _, err := parser.Or(comment, newline)(sr); err == nil {
+			newline(sr)
+			continue
+		}
+		pos := parser.Pos(sr)
+		e, err := entry(sr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pos, err)
+		}
+		e.Pos = pos
+		entries = append(entries, e)
+	}
+}
+
+// ParseString parses a .env file held in a string, with positions tracked.
+func ParseString(s string) ([]Entry, error) {
+	return Parse(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
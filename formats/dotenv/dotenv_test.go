@@ -0,0 +1,97 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+const sample = `# database
+DB_HOST=localhost
+export DB_PORT = 5432
+DB_URL="postgres://${DB_HOST}:$DB_PORT/app\n"
+RAW='no $expansion here'
+GREETING=hello world # trailing comment
+HASH=a#b
+EMPTY=
+PRICE="\$5"
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	entries, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	keys := []string{}
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+		got[e.Key] = e.Value
+	}
+	if !reflect.DeepEqual(keys, []string{"DB_HOST", "DB_PORT", "DB_URL", "RAW", "GREETING", "HASH", "EMPTY", "PRICE"}) {
+		t.Errorf("Unexpected keys %q", keys)
+	}
+	expected := map[string]string{
+		"DB_HOST":  "localhost",
+		"DB_PORT":  "5432",
+		"DB_URL":   "postgres://${DB_HOST}:${DB_PORT}/app\n",
+		"RAW":      "no $expansion here",
+		"GREETING": "hello world",
+		"HASH":     "a#b",
+		"EMPTY":    "",
+		"PRICE":    "$5",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if !entries[1].Exported || entries[0].Exported {
+		t.Error("Unexpected export flags")
+	}
+	if entries[2].Quote != '"' || entries[3].Quote != '\'' || entries[0].Quote != 0 {
+		t.Error("Unexpected quote characters")
+	}
+	if entries[2].Pos != (parser.Position{Offset: 51, Line: 4, Column: 1}) {
+		t.Errorf("Unexpected position %v", entries[2].Pos)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	entries, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := map[string]string{}
+	lookup := func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+	for _, e := range entries {
+		vars[e.Key] = e.Expand(lookup)
+	}
+	if vars["DB_URL"] != "postgres://localhost:5432/app\n" {
+		t.Errorf("Unexpected expansion %q", vars["DB_URL"])
+	}
+	if vars["RAW"] != "no $expansion here" || vars["PRICE"] != "$5" {
+		t.Errorf("Unexpected expansion %q %q", vars["RAW"], vars["PRICE"])
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"A=1\nB 2\n":  "2:1: Expected '=' after B",
+		"A='open\n":   "1:1: Unterminated single quoted value",
+		"A=\"open\n":  "1:1: Unterminated double quoted value",
+		"A=\"x\" y\n": "1:1: Unexpected text after value of A",
+		"1A=x\n":      "1:1: Expected variable name",
+	}
+	for in, msg := range tests {
+		_, err := ParseString(in)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
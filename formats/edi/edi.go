@@ -0,0 +1,156 @@
+// Package edi reads X12 and EDIFACT interchanges as segments of elements
+// and components. The delimiters are not fixed: each interchange declares
+// its own in the ISA header or UNA service string advice, so Interchange
+// reads them first and builds the segment grammar from them with Bind.
+package edi
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Delimiters are the separators of an interchange. Release, if not zero,
+// makes the following character literal, as "?+" does in EDIFACT.
+// Repetition is recorded for the caller but repeated elements are not
+// split, since X12 versions before 00501 use that position for other
+// purposes.
+type Delimiters struct {
+	Segment, Element, Component, Repetition, Release byte
+}
+
+// EDIFACT are the EDIFACT default delimiters, used when an interchange has
+// no UNA segment.
+var EDIFACT = Delimiters{Segment: '\'', Element: '+', Component: ':', Release: '?'}
+
+// Segment is one segment of an interchange. Each element is a list of its
+// components, so a simple element has one.
+type Segment struct {
+	ID       string
+	Elements [][]string
+	Pos      parser.Position
+}
+
+// Element returns the text of element i, counting from 1 as EDI
+// documentation does (ISA01 is Element(1)), with components joined by sep.
+// It returns "" for an element the segment doesn't have.
+func (s Segment) Element(i int, sep string) string {
+	if i < 1 || i > len(s.Elements) {
+		return ""
+	}
+	return strings.Join(s.Elements[i-1], sep)
+}
+
+// isaLength is the fixed length of an X12 ISA segment, terminator
+// included.
+const isaLength = 106
+
+// Header reads the delimiters an interchange declares. An X12 ISA segment
+// is looked at but not consumed, since it is an ordinary segment too; an
+// EDIFACT UNA segment is consumed. An interchange starting with UNB uses
+// the EDIFACT defaults.
+func Header() func(sr parser.StatefulReader) (Delimiters, error) {
+	return func(sr parser.StatefulReader) (Delimiters, error) {
+		st := sr.State()
+		pos := parser.Pos(sr)
+		b := make([]byte, isaLength)
+		n, _ := io.ReadFull(sr, b)
+		b = b[:n]
+		switch {
+		case n >= 9 && string(b[:3]) == "UNA":
+			sr.Restore(st)
+			io.ReadFull(sr, make([]byte, 9))
+			return Delimiters{Component: b[3], Element: b[4], Release: b[6], Segment: b[8]}, nil
+		case n == isaLength && string(b[:3]) == "ISA":
+			sr.Restore(st)
+			return Delimiters{Element: b[3], Repetition: b[82], Component: b[104], Segment: b[105]}, nil
+		case n >= 3 && string(b[:3]) == "UNB":
+			sr.Restore(st)
+			return EDIFACT, nil
+		}
+		sr.Restore(st)
+		return Delimiters{}, &parser.ParseError{Pos: pos, Err: fmt.Errorf("Expected an ISA, UNA or UNB header")}
+	}
+}
+
+// SegmentOf returns a parser for one segment delimited by d, skipping line
+// breaks after its terminator. ISA elements are not split into components,
+// since ISA16 is the component separator itself.
+func SegmentOf(d Delimiters) func(sr parser.StatefulReader) (Segment, error) {
+	return func(sr parser.StatefulReader) (Segment, error) {
+		one := make([]byte, 1)
+		st := sr.State()
+		seg := Segment{Pos: parser.Pos(sr)}
+		fail := func(err error) (Segment, error) {
+			sr.Restore(st)
+			return Segment{}, &parser.ParseError{Pos: seg.Pos, Err: err}
+		}
+		var text []byte
+		var element []string
+		done := false
+		for !done {
+			if n, _ := sr.Read(one); n == 0 {
+				if seg.ID == "" && len(text) == 0 && len(element) == 0 {
+					sr.Restore(st)
+					return Segment{}, fmt.Errorf("Unexpected EOF")
+				}
+				return fail(fmt.Errorf("Unterminated segment"))
+			}
+			c := one[0]
+			switch {
+			case d.Release != 0 && c == d.Release:
+				if n, _ := sr.Read(one); n == 0 {
+					return fail(fmt.Errorf("Unterminated segment"))
+				}
+				text = append(text, one[0])
+				continue
+			case seg.ID == "" && (c == d.Element || c == d.Segment):
+				seg.ID = string(text)
+				if seg.ID == "" {
+					return fail(fmt.Errorf("Segment has no ID"))
+				}
+			case seg.ID != "" && seg.ID != "ISA" && c == d.Component:
+				element = append(element, string(text))
+			case seg.ID != "" && (c == d.Element || c == d.Segment):
+				seg.Elements = append(seg.Elements, append(element, string(text)))
+				element = nil
+			default:
+				text = append(text, c)
+				continue
+			}
+			text = text[:0]
+			done = c == d.Segment
+		}
+		for {
+			s := sr.State()
+			if n, _ := sr.Read(one); n == 0 || one[0] != '\r' && one[0] != '\n' {
+				sr.Restore(s)
+				return seg, nil
+			}
+		}
+	}
+}
+
+// Interchange reads a whole interchange: its header, then segments
+// delimited as the header says, to the end of the input.
+func Interchange() func(sr parser.StatefulReader) ([]Segment, error) {
+	eof := parser.EOF()
+	return parser.Bind(Header(), func(d Delimiters) func(sr parser.StatefulReader) ([]Segment, error) {
+		segment := SegmentOf(d)
+		return func(sr parser.StatefulReader) ([]Segment, error) {
+			segs := []Segment{}
+			for {
+				if _, err := eof(sr); err == nil {
+					return segs, nil
+				}
+				s, err := segment(sr)
+				if err != nil {
+					return nil, err
+				}
+				segs = append(segs, s)
+			}
+		}
+	})
+}
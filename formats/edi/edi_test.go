@@ -0,0 +1,76 @@
+package edi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+const x12 = "ISA*00*          *00*          *ZZ*SENDER         *ZZ*RECEIVER       *230101*1253*^*00501*000000001*0*P*>~\n" +
+	"GS*PO*SENDER*RECEIVER*20230101*1253*1*X*005010~\n" +
+	"PO1*1*10*EA*9.95**BP*W>123~\n" +
+	"N1*ST**92~\n"
+
+func TestX12(t *testing.T) {
+	t.Parallel()
+	segs, _, err := parser.ParseBytes(Interchange(), []byte(x12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, s := range segs {
+		ids = append(ids, s.ID)
+	}
+	if strings.Join(ids, " ") != "ISA GS PO1 N1" {
+		t.Fatalf("got segments %v", ids)
+	}
+	if len(segs[0].Elements) != 16 || segs[0].Element(11, "") != "^" || segs[0].Element(16, "") != ">" {
+		t.Errorf("ISA elements %q", segs[0].Elements)
+	}
+	po1 := segs[2]
+	if po1.Element(4, "") != "9.95" || po1.Element(5, "") != "" || len(po1.Elements[6]) != 2 || po1.Element(7, "-") != "W-123" {
+		t.Errorf("PO1 elements %q", po1.Elements)
+	}
+	if po1.Pos.Line != 3 || po1.Element(99, "") != "" {
+		t.Errorf("PO1 at %v", po1.Pos)
+	}
+}
+
+func TestEDIFACT(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{
+		"UNA:+.? 'UNB+UNOA:3+SENDER+RECEIVER'FTX+AAI+++Price 5?+2?'s'UNZ+1'",
+		"UNB+UNOA:3+SENDER+RECEIVER'FTX+AAI+++Price 5?+2?'s'UNZ+1'",
+	} {
+		segs, _, err := parser.ParseBytes(Interchange(), []byte(in))
+		if err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		if len(segs) != 3 || segs[0].Element(1, ":") != "UNOA:3" || segs[1].Element(4, "") != "Price 5+2's" {
+			t.Errorf("%q: got %q", in, segs)
+		}
+	}
+
+	// UNA can declare other delimiters
+	segs, _, err := parser.ParseBytes(Interchange(), []byte("UNA|*.\\ ~UNB*A|B~"))
+	if err != nil || len(segs) != 1 || len(segs[0].Elements[0]) != 2 {
+		t.Errorf("got %q, %v", segs, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"", "XYZ*1~", "UNB+A'FTX+1"} {
+		_, _, err := parser.ParseBytes(Interchange(), []byte(in))
+		var pe *parser.ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%q: got %v", in, err)
+		}
+	}
+	_, _, err := parser.ParseBytes(Interchange(), []byte("UNB+A'FTX+1"))
+	if err == nil || err.Error() != "1:7: Unterminated segment" {
+		t.Errorf("got %v", err)
+	}
+}
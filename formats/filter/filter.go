@@ -0,0 +1,196 @@
+// Package filter parses boolean filter expressions such as
+// `status == "open" and (priority >= 2 or not assigned)` into an AST that
+// can be evaluated against a set of variables.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of a parsed filter.
+type Expr interface {
+	Eval(vars map[string]any) (any, error)
+	String() string
+}
+
+type (
+	// Ident refers to a variable. Dotted names are looked up as a whole.
+	Ident string
+	// String is a quoted string literal.
+	String string
+	// Number is a numeric literal.
+	Number float64
+	// Bool is true or false.
+	Bool bool
+
+	// Compare applies a comparison operator to two operands.
+	Compare struct {
+		Op   string
+		L, R Expr
+	}
+	// And is true when both operands are true.
+	And struct {
+		L, R Expr
+	}
+	// Or is true when either operand is true.
+	Or struct {
+		L, R Expr
+	}
+	// Not negates its operand.
+	Not struct {
+		X Expr
+	}
+)
+
+func (i Ident) Eval(vars map[string]any) (any, error) {
+	v, ok := vars[string(i)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown variable %q", string(i))
+	}
+	return normalise(v), nil
+}
+
+func (s String) Eval(map[string]any) (any, error) { return string(s), nil }
+func (n Number) Eval(map[string]any) (any, error) { return float64(n), nil }
+func (b Bool) Eval(map[string]any) (any, error)   { return bool(b), nil }
+
+func (c Compare) Eval(vars map[string]any) (any, error) {
+	l, err := c.L.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.R.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Op {
+	case "==", "!=":
+		if !comparable(l) || !comparable(r) {
+			return nil, fmt.Errorf("Cannot compare values of type %T and %T", l, r)
+		}
+		return (l == r) == (c.Op == "=="), nil
+	}
+	var cmp int
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, fmt.Errorf("Cannot compare number with %T", r)
+		}
+		cmp = compare(lv < rv, lv > rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("Cannot compare string with %T", r)
+		}
+		cmp = strings.Compare(lv, rv)
+	default:
+		return nil, fmt.Errorf("Cannot order values of type %T", l)
+	}
+	switch c.Op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return nil, fmt.Errorf("Unknown operator %q", c.Op)
+}
+
+// comparable reports whether v can be used with == without panicking.
+func comparable(v any) bool {
+	return v == nil || reflect.TypeOf(v).Comparable()
+}
+
+func compare(less, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+func (a And) Eval(vars map[string]any) (any, error) {
+	l, err := evalBool(a.L, vars)
+	if err != nil || !l {
+		return false, err
+	}
+	return evalBool(a.R, vars)
+}
+
+func (o Or) Eval(vars map[string]any) (any, error) {
+	l, err := evalBool(o.L, vars)
+	if err != nil || l {
+		return l, err
+	}
+	return evalBool(o.R, vars)
+}
+
+func (n Not) Eval(vars map[string]any) (any, error) {
+	v, err := evalBool(n.X, vars)
+	return !v, err
+}
+
+func (i Ident) String() string   { return string(i) }
+func (s String) String() string  { return strconv.Quote(string(s)) }
+func (n Number) String() string  { return strconv.FormatFloat(float64(n), 'g', -1, 64) }
+func (b Bool) String() string    { return strconv.FormatBool(bool(b)) }
+func (c Compare) String() string { return "(" + c.L.String() + " " + c.Op + " " + c.R.String() + ")" }
+func (a And) String() string     { return "(" + a.L.String() + " and " + a.R.String() + ")" }
+func (o Or) String() string      { return "(" + o.L.String() + " or " + o.R.String() + ")" }
+func (n Not) String() string     { return "not " + n.X.String() }
+
+func evalBool(e Expr, vars map[string]any) (bool, error) {
+	v, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s is not a boolean", e)
+	}
+	return b, nil
+}
+
+// normalise converts Go numeric types to float64 so they compare equal to
+// Number literals.
+func normalise(v any) any {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int8:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case uint16:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// Eval evaluates e against vars and requires a boolean result.
+func Eval(e Expr, vars map[string]any) (bool, error) {
+	return evalBool(e, vars)
+}
@@ -0,0 +1,105 @@
+package filter
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{`a == 1`, `(a == 1)`},
+		{`a = 'x' and b != "y"`, `((a == "x") and (b != "y"))`},
+		{`a or b and c`, `(a or (b and c))`},
+		{`(a or b) and c`, `((a or b) and c)`},
+		{`not a && !b`, `(not a and not b)`},
+		{`NOT x.y >= -2.5 OR z`, `(not (x.y >= -2.5) or z)`},
+		{`android and order`, `(android and order)`},
+		{`s == "quo\"te"`, `(s == "quo\"te")`},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if e.String() != test.out {
+			t.Errorf("Expected (%q) %s, got %s", test.in, test.out, e)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+	vars := map[string]any{
+		"status":   "open",
+		"priority": 3,
+		"assigned": false,
+		"owner":    "ana",
+	}
+	tests := []struct {
+		in  string
+		out bool
+	}{
+		{`status == "open"`, true},
+		{`priority >= 2 and not assigned`, true},
+		{`priority < 3`, false},
+		{`owner > "aaa" and owner <= "ana"`, true},
+		{`assigned or status != 'open'`, false},
+		{`priority == 3.0`, true},
+		{`true`, true},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		out, err := Eval(e, vars)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if out != test.out {
+			t.Errorf("Expected (%q) %v, got %v", test.in, test.out, out)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	parseErrors := map[string]string{
+		`a ==`:     "1:5: Expected value",
+		`(a or b`:  "1:8: Expected ')'",
+		`a b`:      "1:3: Expected EOF, got \"b\"",
+		`a and or`: "1:7: Expected value",
+	}
+	for in, msg := range parseErrors {
+		_, err := Parse(in)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+	evalErrors := map[string]string{
+		`missing`:      `Unknown variable "missing"`,
+		`n < "x"`:      "Cannot compare number with string",
+		`n and true`:   "n is not a boolean",
+		`true < false`: "Cannot order values of type bool",
+		`tags == tags`: "Cannot compare values of type []string and []string",
+		`meta != "x"`:  "Cannot compare values of type map[string]int and string",
+	}
+	for in, msg := range evalErrors {
+		e, err := Parse(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Eval(e, map[string]any{
+			"n":    1,
+			"tags": []string{"a"},
+			"meta": map[string]int{},
+		})
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
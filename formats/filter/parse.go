@@ -0,0 +1,190 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	ws    = parser.Mult(0, 0, parser.Set(" \t\r\n"))
+	word  = join(parser.And(parser.Set("a-zA-Z_"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_.")))))
+	digit = join(parser.Mult(1, 0, parser.Set("0-9")))
+	num   = join(parser.And(
+		parser.Optional(parser.Lit("-")),
+		digit,
+		parser.Optional(join(parser.And(parser.Lit("."), digit))),
+	))
+	quoted = func(q string) func(sr parser.StatefulReader) (string, error) {
+		escape := parser.Convert(parser.And(parser.Lit(`\`), parser.NotSet("")), func(s []string) (string, error) {
+			return s[1], nil
+		})
+		return parser.Convert(parser.And(
+			parser.Lit(q),
+			join(parser.Mult(0, 0, parser.Or(escape, parser.NotSet(q+`\`)))),
+			parser.Lit(q),
+		), func(s []string) (string, error) { return s[1], nil })
+	}
+	str        = parser.Or(quoted(`"`), quoted(`'`))
+	comparison = parser.Or(parser.Lit("=="), parser.Lit("!="), parser.Lit("<="), parser.Lit(">="), parser.Lit("<"), parser.Lit(">"), parser.Lit("="))
+)
+
+var keywords = map[string]bool{"and": true, "or": true, "not": true, "true": true, "false": true}
+
+func token[T any](p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		ws(sr)
+		return p(sr)
+	}
+}
+
+// keyword matches a case-insensitive keyword or one of its symbolic
+// spellings.
+func keyword(kw string, symbols ...string) func(sr parser.StatefulReader) (string, error) {
+	alts := []func(sr parser.StatefulReader) (string, error){}
+	for _, s := range symbols {
+		alts = append(alts, parser.Lit(s))
+	}
+	alts = append(alts, func(sr parser.StatefulReader) (string, error) {
+		s := sr.State()
+		w, err := word(sr)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(w, kw) {
+			sr.Restore(s)
+			return "", fmt.Errorf("Expected %q, got %q", kw, w)
+		}
+		return kw, nil
+	})
+	return token(parser.Or(alts...))
+}
+
+var (
+	andOp = keyword("and", "&&")
+	orOp  = keyword("or", "||")
+	notOp = keyword("not", "!")
+)
+
+func primary(sr parser.StatefulReader) (Expr, error) {
+	ws(sr)
+	if _, err := parser.Lit("(")(sr); err == nil {
+		e, err := orExpr(sr)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := token(parser.Lit(")"))(sr); err != nil {
+			return nil, fmt.Errorf("%s: Expected ')'", parser.Pos(sr))
+		}
+		return e, nil
+	}
+	if s, err := str(sr); err == nil {
+		return String(s), nil
+	}
+	if n, err := num(sr); err == nil {
+		f, err := strconv.ParseFloat(n, 64)
+		return Number(f), err
+	}
+	s := sr.State()
+	if w, err := word(sr); err == nil {
+		switch strings.ToLower(w) {
+		case "true":
+			return Bool(true), nil
+		case "false":
+			return Bool(false), nil
+		}
+		if !keywords[strings.ToLower(w)] {
+			return Ident(w), nil
+		}
+		sr.Restore(s)
+	}
+	return nil, fmt.Errorf("%s: Expected value", parser.Pos(sr))
+}
+
+func compareExpr(sr parser.StatefulReader) (Expr, error) {
+	l, err := primary(sr)
+	if err != nil {
+		return nil, err
+	}
+	op, err := token(comparison)(sr)
+	if err != nil {
+		return l, nil
+	}
+	if op == "=" {
+		op = "=="
+	}
+	r, err := primary(sr)
+	if err != nil {
+		return nil, err
+	}
+	return Compare{Op: op, L: l, R: r}, nil
+}
+
+func notExpr(sr parser.StatefulReader) (Expr, error) {
+	if _, err := notOp(sr); err == nil {
+		x, err := notExpr(sr)
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	}
+	return compareExpr(sr)
+}
+
+// binary parses left-associative chains of operand separated by op.
+func binary(operand func(sr parser.StatefulReader) (Expr, error), op func(sr parser.StatefulReader) (string, error), build func(l, r Expr) Expr) func(sr parser.StatefulReader) (Expr, error) {
+	return func(sr parser.StatefulReader) (Expr, error) {
+		e, err := operand(sr)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			if _, err := op(sr); err != nil {
+				return e, nil
+			}
+			r, err := operand(sr)
+			if err != nil {
+				return nil, err
+			}
+			e = build(e, r)
+		}
+	}
+}
+
+var (
+	andExpr func(sr parser.StatefulReader) (Expr, error)
+	orExpr  func(sr parser.StatefulReader) (Expr, error)
+)
+
+// the grammar is recursive through parentheses, so it is tied together here
+// rather than in the variable declarations
+func init() {
+	andExpr = binary(notExpr, andOp, func(l, r Expr) Expr { return And{L: l, R: r} })
+	orExpr = binary(andExpr, orOp, func(l, r Expr) Expr { return Or{L: l, R: r} })
+}
+
+// Filter parses a complete filter expression from sr.
+func Filter(sr parser.StatefulReader) (Expr, error) {
+	e, err := orExpr(sr)
+	if err != nil {
+		return nil, err
+	}
+	ws(sr)
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, fmt.Errorf("%s: %w", parser.Pos(sr), err)
+	}
+	return e, nil
+}
+
+// Parse parses a filter expression held in a string.
+func Parse(s string) (Expr, error) {
+	return Filter(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
@@ -0,0 +1,237 @@
+// Package fixedwidth reads flat files of fixed-width fields, as used by
+// banking, EDI and mainframe exports: Fixed matches a single field, and
+// Record maps a run of fields onto a struct.
+package fixedwidth
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+)
+
+// Option changes how Fixed and Record read fields.
+type Option func(*options)
+
+type options struct {
+	bytes       bool
+	left, right string
+	// trimmed is set once an option has chosen the trimming
+	trimmed bool
+}
+
+// Bytes makes widths count bytes rather than runes, for files in
+// single-byte encodings or with byte-based layouts.
+func Bytes() Option {
+	return func(o *options) {
+		o.bytes = true
+	}
+}
+
+// TrimLeft removes leading padding in cutset, as for right-aligned numbers.
+func TrimLeft(cutset string) Option {
+	return func(o *options) {
+		o.left, o.trimmed = cutset, true
+	}
+}
+
+// TrimRight removes trailing padding in cutset, as for left-aligned text.
+func TrimRight(cutset string) Option {
+	return func(o *options) {
+		o.right, o.trimmed = cutset, true
+	}
+}
+
+// Raw keeps a field's padding.
+func Raw() Option {
+	return func(o *options) {
+		o.left, o.right, o.trimmed = "", "", true
+	}
+}
+
+func build(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) trim(s string) string {
+	if o.left != "" {
+		s = strings.TrimLeft(s, o.left)
+	}
+	if o.right != "" {
+		s = strings.TrimRight(s, o.right)
+	}
+	return s
+}
+
+// Fixed matches a field exactly n runes wide (or n bytes with Bytes) and
+// returns it with the padding the options name trimmed; by default it is
+// returned as is. A field may not contain a line break, so a short line
+// fails rather than running into the next record.
+func Fixed(n int, opts ...Option) func(sr parser.StatefulReader) (string, error) {
+	o := build(opts)
+	return func(sr parser.StatefulReader) (string, error) {
+		s, err := field(sr, n, o.bytes)
+		if err != nil {
+			return "", err
+		}
+		return o.trim(s), nil
+	}
+}
+
+func field(sr parser.StatefulReader, n int, bytes bool) (string, error) {
+	st := sr.State()
+	pos := parser.Pos(sr)
+	b := []byte{}
+	one := make([]byte, 1)
+	for got := 0; got < n; got++ {
+		start := len(b)
+		c, _ := sr.Read(one)
+		for c == 1 && one[0] != '\n' && one[0] != '\r' {
+			b = append(b, one[0])
+			if bytes || utf8.FullRune(b[start:]) {
+				break
+			}
+			c, _ = sr.Read(one)
+		}
+		if len(b) == start || !bytes && !utf8.FullRune(b[start:]) {
+			sr.Restore(st)
+			unit := "characters"
+			if bytes {
+				unit = "bytes"
+			}
+			return "", &parser.ParseError{Pos: pos, Err: fmt.Errorf("Expected a field of %d %s, got %d", n, unit, got)}
+		}
+	}
+	return string(b), nil
+}
+
+// Record returns a parser for a record laid out as the fields of T, which
+// must be a struct. Each field's width comes from its `fixed` tag, as in
+//
+//	type Payment struct {
+//		Account string  `fixed:"10"`
+//		Amount  int64   `fixed:"12"`
+//		Filler  string  `fixed:"4,raw"`
+//		Rate    float64 `fixed:"6"`
+//	}
+//
+// Fields are read in order with no separators. Strings have trailing
+// spaces trimmed and numbers and booleans surrounding spaces; a tag option
+// of "raw" keeps the padding, "zeros" trims leading zeros as well, and
+// "-" as the tag skips the struct field. The opts apply to every field.
+// Record panics if T has a field it can't read.
+func Record[T any](opts ...Option) func(sr parser.StatefulReader) (T, error) {
+	o := build(opts)
+	var zero T
+	rt := reflect.TypeOf(zero)
+	if rt == nil || rt.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("fixedwidth: Record: %T is not a struct", zero))
+	}
+	type column struct {
+		index int
+		name  string
+		width int
+		o     options
+	}
+	cols := []column{}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("fixed")
+		if !ok || tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		width, err := strconv.Atoi(parts[0])
+		if err != nil || width <= 0 || !f.IsExported() {
+			panic(fmt.Sprintf("fixedwidth: Record: bad field %s with tag %q", f.Name, tag))
+		}
+		c := column{index: i, name: f.Name, width: width, o: o}
+		if !c.o.trimmed {
+			if f.Type.Kind() == reflect.String {
+				c.o.right = " "
+			} else {
+				c.o.left, c.o.right = " ", " "
+			}
+		}
+		for _, flag := range parts[1:] {
+			switch flag {
+			case "raw":
+				c.o.left, c.o.right = "", ""
+			case "zeros":
+				c.o.left += "0"
+			default:
+				panic(fmt.Sprintf("fixedwidth: Record: unknown option %q on field %s", flag, f.Name))
+			}
+		}
+		if err := convert(reflect.New(f.Type).Elem(), ""); err == errKind {
+			panic(fmt.Sprintf("fixedwidth: Record: can't read field %s of type %s", f.Name, f.Type))
+		}
+		cols = append(cols, c)
+	}
+	return func(sr parser.StatefulReader) (T, error) {
+		st := sr.State()
+		var v T
+		rv := reflect.ValueOf(&v).Elem()
+		for _, c := range cols {
+			pos := parser.Pos(sr)
+			s, err := field(sr, c.width, c.o.bytes)
+			if err != nil {
+				sr.Restore(st)
+				return zero, err
+			}
+			if err := convert(rv.Field(c.index), c.o.trim(s)); err != nil {
+				sr.Restore(st)
+				return zero, &parser.ParseError{Pos: pos, Err: fmt.Errorf("%s: %w", c.name, err)}
+			}
+		}
+		return v, nil
+	}
+}
+
+var errKind = errors.New("unsupported kind")
+
+// convert sets dst from s. An empty number or boolean is its zero value.
+func convert(dst reflect.Value, s string) error {
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		dst.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		dst.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		dst.SetFloat(f)
+		return err
+	case reflect.Bool:
+		if s == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		dst.SetBool(b)
+		return err
+	}
+	return errKind
+}
@@ -0,0 +1,82 @@
+package fixedwidth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestFixed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		p    func(sr parser.StatefulReader) (string, error)
+		want string
+		ok   bool
+	}{
+		{"ab  cd", Fixed(4), "ab  ", true},
+		{"ab  cd", Fixed(4, TrimRight(" ")), "ab", true},
+		{"00042x", Fixed(5, TrimLeft("0")), "42", true},
+		{"héllo", Fixed(3), "hél", true},
+		{"héllo", Fixed(3, Bytes()), "hé", true},
+		{"ab\ncd", Fixed(4), "", false},
+		{"ab", Fixed(3), "", false},
+	}
+	for _, test := range tests {
+		v, _, err := parser.ParseBytes(test.p, []byte(test.in))
+		if (err == nil) != test.ok || v != test.want {
+			t.Errorf("%q: got %q, %v", test.in, v, err)
+		}
+	}
+}
+
+type payment struct {
+	Account string  `fixed:"8"`
+	Amount  int64   `fixed:"10,zeros"`
+	Filler  string  `fixed:"2,raw"`
+	Rate    float64 `fixed:"5"`
+	Settled bool    `fixed:"5"`
+	Note    string
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+	records := parser.MultSep(1, 0, Record[payment](), parser.Lit("\n"))
+	v, _, err := parser.ParseBytes(records, []byte(
+		"ACC1    0000012345   1.25 true\n"+
+			"ACC2    00000000-7xx  0.5false"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []payment{
+		{Account: "ACC1", Amount: 12345, Filler: "  ", Rate: 1.25, Settled: true},
+		{Account: "ACC2", Amount: -7, Filler: "xx", Rate: 0.5, Settled: false},
+	}
+	if len(v) != 2 || v[0] != want[0] || v[1] != want[1] {
+		t.Errorf("got %+v", v)
+	}
+
+	_, _, err = parser.ParseBytes(Record[payment](), []byte("ACC1    00000x2345   1.25 true"))
+	var pe *parser.ParseError
+	if !errors.As(err, &pe) || pe.Pos.Column != 9 || pe.Error() != `1:9: Amount: strconv.ParseInt: parsing "x2345": invalid syntax` {
+		t.Errorf("got %v", err)
+	}
+	_, _, err = parser.ParseBytes(Record[payment](), []byte("ACC1    0000012345   1.25"))
+	if !errors.As(err, &pe) || pe.Pos.Column != 26 {
+		t.Errorf("short record: got %v", err)
+	}
+}
+
+func TestRecordBadType(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a slice field")
+		}
+	}()
+	type bad struct {
+		List []string `fixed:"3"`
+	}
+	Record[bad]()
+}
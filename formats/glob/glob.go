@@ -0,0 +1,388 @@
+// Package glob parses shell glob patterns with brace alternation into a
+// syntax tree that can be matched against names.
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+)
+
+// Node is an element of a parsed pattern.
+type Node interface {
+	String() string
+}
+
+// Literal matches its text exactly.
+type Literal string
+
+// Any matches a single rune other than '/'.
+type Any struct{}
+
+// Star matches any run of runes other than '/', or any run at all when Deep
+// is set ("**").
+type Star struct {
+	Deep bool
+}
+
+// Range is an inclusive range of runes within a Class.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Class matches a single rune in (or, when Negated, not in) its ranges.
+type Class struct {
+	Negated bool
+	Ranges  []Range
+}
+
+// Alt matches any one of its alternatives, written {a,b,c}.
+type Alt []Pattern
+
+// Pattern is a sequence of nodes.
+type Pattern []Node
+
+func (l Literal) String() string {
+	return escape(string(l))
+}
+
+func (Any) String() string {
+	return "?"
+}
+
+func (s Star) String() string {
+	if s.Deep {
+		return "**"
+	}
+	return "*"
+}
+
+func (c Class) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("[")
+	if c.Negated {
+		sb.WriteString("!")
+	}
+	for _, r := range c.Ranges {
+		sb.WriteRune(r.Lo)
+		if r.Hi != r.Lo {
+			sb.WriteString("-")
+			sb.WriteRune(r.Hi)
+		}
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func (a Alt) String() string {
+	parts := make([]string, len(a))
+	for i, p := range a {
+		parts[i] = p.String()
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func (p Pattern) String() string {
+	sb := strings.Builder{}
+	for _, n := range p {
+		sb.WriteString(n.String())
+	}
+	return sb.String()
+}
+
+func escape(s string) string {
+	sb := strings.Builder{}
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+const special = `*?[]{},\`
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+func node[T Node](p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (Node, error) {
+	return parser.Convert(p, func(t T) (Node, error) {
+		return t, nil
+	})
+}
+
+var (
+	escaped = parser.Convert(parser.And(parser.Lit(`\`), parser.NotSet("")), func(s []string) (string, error) {
+		return s[1], nil
+	})
+	star = parser.Or(
+		parser.Convert(parser.Lit("**"), func(string) (Star, error) { return Star{Deep: true}, nil }),
+		parser.Convert(parser.Lit("*"), func(string) (Star, error) { return Star{}, nil }),
+	)
+	anyRune = parser.Convert(parser.Lit("?"), func(string) (Any, error) { return Any{}, nil })
+
+	classRune = parser.Convert(parser.Or(escaped, parser.NotSet("]")), func(s string) (rune, error) {
+		r, _ := utf8.DecodeRuneInString(s)
+		return r, nil
+	})
+)
+
+func class(sr parser.StatefulReader) (Class, error) {
+	s := sr.State()
+	if _, err := parser.Lit("[")(sr); err != nil {
+		return Class{}, err
+	}
+	c := Class{}
+	if _, err := parser.Set("!^")(sr); err == nil {
+		c.Negated = true
+	}
+	// a ']' straight after the opening bracket is a literal
+	if _, err := parser.Lit("]")(sr); err == nil {
+		c.Ranges = append(c.Ranges, Range{Lo: ']', Hi: ']'})
+	}
+	for {
+		if _, err := parser.Lit("]")(sr); err == nil {
+			break
+		}
+		lo, err := classRune(sr)
+		if err != nil {
+			sr.Restore(s)
+			return Class{}, fmt.Errorf("Unterminated character class")
+		}
+		hi := lo
+		rs := sr.State()
+		if _, err := parser.Lit("-")(sr); err == nil {
+			if hi, err = classRune(sr); err != nil {
+				sr.Restore(rs)
+				hi = lo
+			}
+		}
+		if lo > hi {
+			sr.Restore(s)
+			return Class{}, fmt.Errorf("Invalid range %c-%c", lo, hi)
+		}
+		c.Ranges = append(c.Ranges, Range{Lo: lo, Hi: hi})
+	}
+	return c, nil
+}
+
+func newPattern() func(sr parser.StatefulReader) (Pattern, error) {
+	var alt func(sr parser.StatefulReader) (Alt, error)
+	lazyAlt := parser.Lazy(func() func(sr parser.StatefulReader) (Alt, error) { return alt })
+	seq := func(stop string) func(sr parser.StatefulReader) (Pattern, error) {
+		literal := parser.Convert(join(parser.Mult(1, 0, parser.Or(escaped, parser.NotSet(stop)))), func(s string) (Literal, error) {
+			return Literal(s), nil
+		})
+		elem := parser.Or(node(literal), node(star), node(anyRune), node(class), node(lazyAlt))
+		return parser.Convert(parser.Mult(0, 0, elem), func(ns []Node) (Pattern, error) {
+			return Pattern(ns), nil
+		})
+	}
+	inner := seq(`*?[{\,}`)
+	alt = func(sr parser.StatefulReader) (Alt, error) {
+		return alternatives(sr, inner)
+	}
+	return seq(`*?[{\`)
+}
+
+func alternatives(sr parser.StatefulReader, inner func(sr parser.StatefulReader) (Pattern, error)) (Alt, error) {
+	s := sr.State()
+	if _, err := parser.Lit("{")(sr); err != nil {
+		return nil, err
+	}
+	a := Alt{}
+	for {
+		p, err := inner(sr)
+		if err != nil {
+			sr.Restore(s)
+			return nil, err
+		}
+		a = append(a, p)
+		if _, err := parser.Lit(",")(sr); err == nil {
+			continue
+		}
+		if _, err := parser.Lit("}")(sr); err != nil {
+			sr.Restore(s)
+			return nil, fmt.Errorf("Unterminated brace alternation")
+		}
+		return a, nil
+	}
+}
+
+var top = newPattern()
+
+// ParsePattern parses a glob pattern from sr, stopping at the first rune that
+// cannot continue it.
+func ParsePattern(sr parser.StatefulReader) (Pattern, error) {
+	return top(sr)
+}
+
+// Parse parses a complete glob pattern.
+func Parse(pattern string) (Pattern, error) {
+	sr := parser.NewSimpleReader(strings.NewReader(pattern))
+	p, err := top(sr)
+	if err != nil {
+		return nil, err
+	}
+	// anything left over is a malformed class or an unbalanced brace
+	s := sr.State()
+	if _, err := parser.Lit("[")(sr); err == nil {
+		sr.Restore(s)
+		_, err := class(sr)
+		return nil, err
+	}
+	for _, check := range []struct {
+		lit, msg string
+	}{
+		{"{", "Unterminated brace alternation"},
+		{`\`, "Trailing backslash"},
+	} {
+		if _, err := parser.Lit(check.lit)(sr); err == nil {
+			return nil, errors.New(check.msg)
+		}
+	}
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Match reports whether name matches the whole pattern.
+func (p Pattern) Match(name string) bool {
+	m := matcher{name: name}
+	m.compile(p)
+	m.prog = append(m.prog, inst{op: opMatch})
+	m.seen = make([]bool, len(m.prog)*(len(name)+1))
+	return m.run(0, 0)
+}
+
+type opcode int
+
+const (
+	opNode opcode = iota
+	opSplit
+	opJump
+	opMatch
+)
+
+// inst is one step of a compiled pattern. Alternations become a split to
+// the start of each alternative, each of which jumps past the others.
+type inst struct {
+	op   opcode
+	node Node
+	next []int
+}
+
+// matcher visits each (instruction, offset) pair at most once, so stars
+// cost polynomial rather than exponential time however they are nested.
+type matcher struct {
+	name string
+	prog []inst
+	seen []bool
+}
+
+func (m *matcher) compile(p Pattern) {
+	for _, n := range p {
+		a, ok := n.(Alt)
+		if !ok {
+			m.prog = append(m.prog, inst{op: opNode, node: n})
+			continue
+		}
+		split := len(m.prog)
+		m.prog = append(m.prog, inst{op: opSplit})
+		var jumps []int
+		for _, alt := range a {
+			m.prog[split].next = append(m.prog[split].next, len(m.prog))
+			m.compile(alt)
+			jumps = append(jumps, len(m.prog))
+			m.prog = append(m.prog, inst{op: opJump})
+		}
+		for _, j := range jumps {
+			m.prog[j].next = []int{len(m.prog)}
+		}
+	}
+}
+
+func (m *matcher) run(pc, i int) bool {
+	key := pc*(len(m.name)+1) + i
+	if m.seen[key] {
+		return false
+	}
+	m.seen[key] = true
+	in := m.prog[pc]
+	switch in.op {
+	case opMatch:
+		return i == len(m.name)
+	case opJump:
+		return m.run(in.next[0], i)
+	case opSplit:
+		for _, next := range in.next {
+			if m.run(next, i) {
+				return true
+			}
+		}
+		return false
+	}
+	rest := m.name[i:]
+	switch n := in.node.(type) {
+	case Literal:
+		return strings.HasPrefix(rest, string(n)) && m.run(pc+1, i+len(n))
+	case Any:
+		r, size := utf8.DecodeRuneInString(rest)
+		return size > 0 && r != '/' && m.run(pc+1, i+size)
+	case Class:
+		r, size := utf8.DecodeRuneInString(rest)
+		return size > 0 && n.contains(r) && m.run(pc+1, i+size)
+	case Star:
+		if m.run(pc+1, i) {
+			return true
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		if size == 0 || r == '/' && !n.Deep {
+			return false
+		}
+		return m.run(pc, i+size)
+	}
+	return false
+}
+
+func (c Class) contains(r rune) bool {
+	for _, rg := range c.Ranges {
+		if r >= rg.Lo && r <= rg.Hi {
+			return !c.Negated
+		}
+	}
+	return c.Negated
+}
+
+// Expand performs brace expansion, returning one pattern per combination of
+// alternatives in source order.
+func (p Pattern) Expand() []Pattern {
+	out := []Pattern{{}}
+	for _, n := range p {
+		a, ok := n.(Alt)
+		if !ok {
+			for i := range out {
+				out[i] = append(out[i], n)
+			}
+			continue
+		}
+		next := []Pattern{}
+		for _, prefix := range out {
+			for _, alt := range a {
+				for _, e := range alt.Expand() {
+					next = append(next, append(append(Pattern{}, prefix...), e...))
+				}
+			}
+		}
+		out = next
+	}
+	return out
+}
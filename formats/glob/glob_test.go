@@ -0,0 +1,112 @@
+package glob
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	p, err := Parse(`src/**/*.{go,[ch]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Pattern{
+		Literal("src/"), Star{Deep: true}, Literal("/"), Star{}, Literal("."),
+		Alt{{Literal("go")}, {Class{Ranges: []Range{{'c', 'c'}, {'h', 'h'}}}}},
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, p)
+	}
+	if p.String() != `src/**/*.{go,[ch]}` {
+		t.Errorf("Unexpected round trip %q", p.String())
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**.go", "cmd/main.go", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"[a-c]x", "bx", true},
+		{"[!a-c]x", "bx", false},
+		{"[]]", "]", true},
+		{"{foo,bar}.{c,h}", "bar.h", true},
+		{"{foo,bar}.{c,h}", "baz.h", false},
+		{"a{b,{c,d}e}f", "adef", true},
+		{`\*`, "*", true},
+		{`\*`, "x", false},
+		{"ñ?", "ñé", true},
+		{"{a*,b}c", "axxc", true},
+		{"{a*,b}c", "bxc", false},
+		{"**/{x,y}*/z", "a/b/yq/z", true},
+	}
+	for _, test := range tests {
+		p, err := Parse(test.pattern)
+		if err != nil {
+			t.Errorf("%q: %v", test.pattern, err)
+			continue
+		}
+		if p.Match(test.name) != test.match {
+			t.Errorf("%q matching %q: expected %v", test.pattern, test.name, test.match)
+		}
+	}
+}
+
+func TestMatchPathological(t *testing.T) {
+	t.Parallel()
+	p, err := Parse(strings.Repeat("*a", 30) + "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := strings.Repeat("a", 100)
+	done := make(chan bool)
+	go func() { done <- p.Match(name) }()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Errorf("Expected no match")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Match did not finish")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	p, err := Parse("a{b,c{d,e}}f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := []string{}
+	for _, e := range p.Expand() {
+		out = append(out, e.String())
+	}
+	if !reflect.DeepEqual(out, []string{"abf", "acdf", "acef"}) {
+		t.Errorf("Unexpected expansion %q", out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"[abc":  "Unterminated character class",
+		"{a,b":  "Unterminated brace alternation",
+		`a\`:    "Trailing backslash",
+		"[z-a]": "Invalid range z-a",
+	}
+	for in, msg := range tests {
+		_, err := Parse(in)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package ini parses INI and Java-style properties files into an ordered
+// model that keeps comments and source positions.
+package ini
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// File is a parsed INI file. Keys that appear before the first section header
+// belong to a section with an empty name, which is always Sections[0].
+type File struct {
+	Sections []*Section
+	// Comments holds comment lines after the last entry.
+	Comments []string
+}
+
+// Section is a [name] header and the entries that follow it.
+type Section struct {
+	Name     string
+	Pos      parser.Position
+	Comments []string
+	Entries  []*Entry
+}
+
+// Entry is a single key/value pair. Comments holds the comment lines directly
+// preceding it, without their comment characters.
+type Entry struct {
+	Key      string
+	Value    string
+	Pos      parser.Position
+	ValuePos parser.Position
+	Comments []string
+}
+
+// Section returns the last section with the given name.
+func (f *File) Section(name string) *Section {
+	for i := len(f.Sections) - 1; i >= 0; i-- {
+		if f.Sections[i].Name == name {
+			return f.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Get returns the value of the last entry for key in the named section.
+func (f *File) Get(section, key string) (string, bool) {
+	s := f.Section(section)
+	if s == nil {
+		return "", false
+	}
+	for i := len(s.Entries) - 1; i >= 0; i-- {
+		if s.Entries[i].Key == key {
+			return s.Entries[i].Value, true
+		}
+	}
+	return "", false
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	blank    = join(parser.Mult(0, 0, parser.Set(" \t")))
+	eol      = parser.Or(parser.Lit("\r\n"), parser.Lit("\n"), parser.EOF())
+	restLine = join(parser.Mult(0, 0, parser.NotSet("\r\n")))
+	comment  = parser.Convert(parser.And(blank, parser.Set(";#"), restLine, eol), func(s []string) (string, error) {
+		return strings.TrimSpace(s[2]), nil
+	})
+	// a blank last line may end at EOF rather than in a newline
+	emptyLine = parser.And(blank, eol)
+	header    = parser.Convert(parser.And(blank, parser.Lit("["), join(parser.Mult(1, 0, parser.NotSet("]\r\n"))), parser.Lit("]"), blank, eol), func(s []string) (string, error) {
+		return strings.TrimSpace(s[2]), nil
+	})
+	key       = join(parser.Mult(1, 0, parser.NotSet("=:\r\n[;#")))
+	separator = parser.Set("=:")
+	// a trailing backslash continues the value on the next line
+	valueLine = join(parser.Mult(0, 0, parser.Or(
+		parser.Convert(parser.And(parser.Lit("\\"), parser.Or(parser.Lit("\r\n"), parser.Lit("\n")), blank), func([]string) (string, error) { return "", nil }),
+		parser.NotSet("\r\n"),
+	)))
+)
+
+// Parse reads a complete INI file. Positions are populated when sr tracks
+// them (see parser.PosReader).
+func Parse(sr parser.StatefulReader) (*File, error) {
+	f := &File{Sections: []*Section{{Pos: parser.Pos(sr)}}}
+	cur := f.Sections[0]
+	comments := []string{}
+	for {
+		if _, err := parser.EOF()(sr); err == nil {
+			f.Comments = comments
+			return f, nil
+		}
+		if c, err := comment(sr); err == nil {
+			comments = append(comments, c)
+			continue
+		}
+		if _, err := emptyLine(sr); err == nil {
+			continue
+		}
+		blank(sr)
+		pos := parser.Pos(sr)
+		if name, err := header(sr); err == nil {
+			cur = &Section{Name: name, Pos: pos, Comments: comments}
+			f.Sections = append(f.Sections, cur)
+			comments = []string{}
+			continue
+		}
+		e, err := entry(sr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pos, err)
+		}
+		e.Pos = pos
+		e.Comments = comments
+		comments = []string{}
+		cur.Entries = append(cur.Entries, e)
+	}
+}
+
+// ParseString parses an INI file held in a string, with positions tracked.
+func ParseString(s string) (*File, error) {
+	return Parse(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
+
+func entry(sr parser.StatefulReader) (*Entry, error) {
+	k, err := key(sr)
+	if err != nil {
+		return nil, fmt.Errorf("Expected key or section header")
+	}
+	if _, err := separator(sr); err != nil {
+		return nil, fmt.Errorf("Expected '=' or ':' after key %q", strings.TrimSpace(k))
+	}
+	blank(sr)
+	e := &Entry{Key: strings.TrimSpace(k), ValuePos: parser.Pos(sr)}
+	v, err := valueLine(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := eol(sr); err != nil {
+		return nil, err
+	}
+	e.Value = strings.TrimSpace(v)
+	return e, nil
+}
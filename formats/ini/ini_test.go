@@ -0,0 +1,101 @@
+package ini
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+const sample = `; global settings
+name = demo
+
+# database
+[database]
+host: localhost
+port=5432
+; credentials follow
+user = admin
+query = SELECT * \
+        FROM t
+
+[ empty ]
+; trailing
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	f, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, s := range f.Sections {
+		names = append(names, s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"", "database", "empty"}) {
+		t.Errorf("Unexpected sections %q", names)
+	}
+	tests := []struct {
+		section, key, value string
+	}{
+		{"", "name", "demo"},
+		{"database", "host", "localhost"},
+		{"database", "port", "5432"},
+		{"database", "user", "admin"},
+		{"database", "query", "SELECT * FROM t"},
+	}
+	for _, test := range tests {
+		v, ok := f.Get(test.section, test.key)
+		if !ok || v != test.value {
+			t.Errorf("Expected %s.%s = %q, got %q", test.section, test.key, test.value, v)
+		}
+	}
+	db := f.Section("database")
+	if !reflect.DeepEqual(db.Comments, []string{"database"}) {
+		t.Errorf("Unexpected section comments %q", db.Comments)
+	}
+	if !reflect.DeepEqual(db.Entries[2].Comments, []string{"credentials follow"}) {
+		t.Errorf("Unexpected entry comments %q", db.Entries[2].Comments)
+	}
+	if !reflect.DeepEqual(f.Comments, []string{"trailing"}) {
+		t.Errorf("Unexpected trailing comments %q", f.Comments)
+	}
+	if db.Pos != (parser.Position{Offset: 42, Line: 5, Column: 1}) {
+		t.Errorf("Unexpected section position %v", db.Pos)
+	}
+	if p := db.Entries[1].ValuePos; p.Line != 7 || p.Column != 6 {
+		t.Errorf("Unexpected value position %v", p)
+	}
+}
+
+func TestTrailingBlank(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"a=1\n   ", "a=1\n\t", "a=1\r\n \r\n  "} {
+		f, err := ParseString(in)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if v, _ := f.Get("", "a"); v != "1" {
+			t.Errorf("%q: got a = %q", in, v)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"a = 1\nbogus\n", "2:1: Expected '=' or ':' after key \"bogus\""},
+		{"[open\n", "1:1: Expected key or section header"},
+	}
+	for _, test := range tests {
+		_, err := ParseString(test.in)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+// Package json parses JSON documents with the parser combinators, producing
+// either plain Go values or a positioned syntax tree.
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/andyleap/parser"
+)
+
+// Kind identifies the type of a JSON value.
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Array
+	Object
+)
+
+// Node is a JSON value annotated with the position it started at. Numbers are
+// kept in their source form.
+type Node struct {
+	Kind    Kind
+	Pos     parser.Position
+	Bool    bool
+	Number  string
+	String  string
+	Elems   []*Node
+	Members []Member
+}
+
+// Member is a single key/value pair of an object, in source order.
+type Member struct {
+	Key    string
+	KeyPos parser.Position
+	Value  *Node
+}
+
+// Interface converts n to the values produced by encoding/json: nil, bool,
+// float64, string, []any and map[string]any.
+func (n *Node) Interface() (any, error) {
+	switch n.Kind {
+	case Null:
+		return nil, nil
+	case Bool:
+		return n.Bool, nil
+	case Number:
+		return strconv.ParseFloat(n.Number, 64)
+	case String:
+		return n.String, nil
+	case Array:
+		vs := make([]any, 0, len(n.Elems))
+		for _, e := range n.Elems {
+			v, err := e.Interface()
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	case Object:
+		m := make(map[string]any, len(n.Members))
+		for _, mem := range n.Members {
+			v, err := mem.Value.Interface()
+			if err != nil {
+				return nil, err
+			}
+			m[mem.Key] = v
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("Unknown kind %d", n.Kind)
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	ws     = parser.Mult(0, 0, parser.Set(" \t\r\n"))
+	digits = join(parser.Mult(1, 0, parser.Set("0-9")))
+)
+
+// NumberLit matches a JSON number and returns its source text.
+var NumberLit = join(parser.And(
+	parser.Optional(parser.Lit("-")),
+	parser.Or(parser.Lit("0"), join(parser.And(parser.Set("1-9"), join(parser.Mult(0, 0, parser.Set("0-9")))))),
+	parser.Optional(join(parser.And(parser.Lit("."), digits))),
+	parser.Optional(join(parser.And(parser.Set("eE"), parser.Optional(parser.Set("+-")), digits))),
+))
+
+var hex4 = parser.Convert(join(parser.Mult(4, 4, parser.Set("0-9a-fA-F"))), func(s string) (rune, error) {
+	v, err := strconv.ParseUint(s, 16, 16)
+	return rune(v), err
+})
+
+var escapes = map[string]string{
+	`"`: `"`, `\`: `\`, `/`: `/`, `b`: "\b", `f`: "\f", `n`: "\n", `r`: "\r", `t`: "\t",
+}
+
+func escape(sr parser.StatefulReader) (string, error) {
+	s := sr.State()
+	if _, err := parser.Lit(`\`)(sr); err != nil {
+		return "", err
+	}
+	c, err := parser.Set(`"\/bfnrtu`)(sr)
+	if err != nil {
+		sr.Restore(s)
+		return "", fmt.Errorf("Invalid escape: %w", err)
+	}
+	if c != "u" {
+		return escapes[c], nil
+	}
+	r, err := hex4(sr)
+	if err != nil {
+		sr.Restore(s)
+		return "", err
+	}
+	if utf16.IsSurrogate(r) {
+		ls := sr.State()
+		if _, err := parser.Lit(`\u`)(sr); err == nil {
+			if r2, err := hex4(sr); err == nil {
+				if d := utf16.DecodeRune(r, r2); d != '�' {
+					return string(d), nil
+				}
+			}
+		}
+		sr.Restore(ls)
+		return "�", nil
+	}
+	return string(r), nil
+}
+
+// StringLit matches a JSON string literal and returns its decoded contents.
+var StringLit = parser.Convert(parser.And(
+	parser.Lit(`"`),
+	join(parser.Mult(0, 0, parser.Or(parser.NotSet("\"\\\x00-\x1f"), escape))),
+	parser.Lit(`"`),
+), func(s []string) (string, error) {
+	return s[1], nil
+})
+
+func token(text string) func(sr parser.StatefulReader) (string, error) {
+	lit := parser.Lit(text)
+	return func(sr parser.StatefulReader) (string, error) {
+		s := sr.State()
+		ws(sr)
+		t, err := lit(sr)
+		if err != nil {
+			sr.Restore(s)
+		}
+		return t, err
+	}
+}
+
+var (
+	openBrace    = token("{")
+	closeBrace   = token("}")
+	openBracket  = token("[")
+	closeBracket = token("]")
+	comma        = token(",")
+	colon        = token(":")
+)
+
+// AST parses a single JSON value, including surrounding whitespace. Node
+// positions are only populated when sr tracks positions (see
+// parser.PosReader).
+func AST(sr parser.StatefulReader) (*Node, error) {
+	ws(sr)
+	n, err := value(sr)
+	if err != nil {
+		return nil, err
+	}
+	ws(sr)
+	return n, nil
+}
+
+// Value parses a single JSON value into the same representation as
+// encoding/json.
+var Value = parser.Convert(AST, (*Node).Interface)
+
+// Parse parses a complete JSON document, rejecting trailing data. On a
+// parser.Context it recovers from bad array elements and object members,
+// as ParseAll describes.
+func Parse(sr parser.StatefulReader) (*Node, error) {
+	n, err := AST(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, &parser.ParseError{Pos: parser.Pos(sr), Err: err}
+	}
+	return n, nil
+}
+
+// ParseString parses a complete JSON document held in a string, with
+// positions tracked.
+func ParseString(s string) (*Node, error) {
+	return Parse(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
+
+// ParseAll parses a complete JSON document held in a string, carrying on
+// past bad array elements and object members so that every such error is
+// found in one pass, as an editor or linter wants. The errors are returned
+// as a parser.ErrorList along with the tree, which leaves the bad elements
+// and members out. Arrays and objects may nest at most 1000 deep.
+func ParseAll(s string) (*Node, error) {
+	n, _, err := parser.ParseReader(Parse, strings.NewReader(s))
+	return n, err
+}
+
+func fail(pos parser.Position, msg string) error {
+	return &parser.ParseError{Pos: pos, Err: errors.New(msg)}
+}
+
+func value(sr parser.StatefulReader) (*Node, error) {
+	ws(sr)
+	n := &Node{Pos: parser.Pos(sr)}
+	var err error
+	if n.String, err = StringLit(sr); err == nil {
+		n.Kind = String
+		return n, nil
+	}
+	if n.Number, err = NumberLit(sr); err == nil {
+		n.Kind = Number
+		return n, nil
+	}
+	if _, err = parser.Lit("true")(sr); err == nil {
+		n.Kind, n.Bool = Bool, true
+		return n, nil
+	}
+	if _, err = parser.Lit("false")(sr); err == nil {
+		n.Kind = Bool
+		return n, nil
+	}
+	if _, err = parser.Lit("null")(sr); err == nil {
+		n.Kind = Null
+		return n, nil
+	}
+	if _, err = openBracket(sr); err == nil {
+		n.Kind = Array
+		n.Elems, err = elems(sr)
+		return n, err
+	}
+	if _, err = openBrace(sr); err == nil {
+		n.Kind = Object
+		n.Members, err = members(sr)
+		return n, err
+	}
+	return nil, fail(n.Pos, "Expected JSON value")
+}
+
+// grammar holds the rules for array and object contents, which are
+// memoized and limited to the grammar's MaxDepth of nesting on a Context.
+var grammar = parser.NewGrammar()
+
+// element and member skip to the next separator or closing bracket when
+// they fail on a Context.
+var (
+	element func(sr parser.StatefulReader) (*Node, error)
+	member  func(sr parser.StatefulReader) (Member, error)
+	elems   func(sr parser.StatefulReader) ([]*Node, error)
+	members func(sr parser.StatefulReader) ([]Member, error)
+)
+
+// values nest through arrays and objects, so the recovering parsers are
+// tied together here rather than in the variable declarations
+func init() {
+	element = parser.Resync(value, parser.Assert(parser.Or(comma, closeBracket), "Expected ',' or ']'"))
+	member = parser.Resync(parseMember, parser.Assert(parser.Or(comma, closeBrace), "Expected ',' or '}'"))
+	elems = parser.Rule(grammar, "elements", func() func(sr parser.StatefulReader) ([]*Node, error) {
+		return parseElems
+	})
+	members = parser.Rule(grammar, "members", func() func(sr parser.StatefulReader) ([]Member, error) {
+		return parseMembers
+	})
+}
+
+func parseElems(sr parser.StatefulReader) ([]*Node, error) {
+	es := []*Node{}
+	if _, err := closeBracket(sr); err == nil {
+		return es, nil
+	}
+	for {
+		e, err := element(sr)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			es = append(es, e)
+		}
+		if _, err := comma(sr); err == nil {
+			continue
+		}
+		if _, err := closeBracket(sr); err != nil {
+			return nil, fail(parser.Pos(sr), "Expected ',' or ']'")
+		}
+		return es, nil
+	}
+}
+
+func parseMembers(sr parser.StatefulReader) ([]Member, error) {
+	ms := []Member{}
+	if _, err := closeBrace(sr); err == nil {
+		return ms, nil
+	}
+	for {
+		m, err := member(sr)
+		if err != nil {
+			return nil, err
+		}
+		if m.Value != nil {
+			ms = append(ms, m)
+		}
+		if _, err := comma(sr); err == nil {
+			continue
+		}
+		if _, err := closeBrace(sr); err != nil {
+			return nil, fail(parser.Pos(sr), "Expected ',' or '}'")
+		}
+		return ms, nil
+	}
+}
+
+func parseMember(sr parser.StatefulReader) (Member, error) {
+	ws(sr)
+	m := Member{KeyPos: parser.Pos(sr)}
+	var err error
+	if m.Key, err = StringLit(sr); err != nil {
+		return Member{}, fail(m.KeyPos, "Expected object key")
+	}
+	if _, err := colon(sr); err != nil {
+		return Member{}, fail(parser.Pos(sr), "Expected ':'")
+	}
+	if m.Value, err = value(sr); err != nil {
+		return Member{}, err
+	}
+	return m, nil
+}
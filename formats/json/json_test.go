@@ -0,0 +1,143 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+const sample = `{
+	"name": "parser",
+	"tags": ["go", "peg", "é😀"],
+	"stars": 12.5e1,
+	"fork": false,
+	"parent": null,
+	"nested": {"a": [1, -2, 0.5, {}], "b": "tab\there"}
+}`
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+	n, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := n.Interface()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected any
+	if err := json.Unmarshal([]byte(sample), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	t.Parallel()
+	n, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := n.Members[1]
+	if tags.Key != "tags" || tags.KeyPos != (parser.Position{Offset: 22, Line: 3, Column: 2}) {
+		t.Errorf("Unexpected key position %v for %q", tags.KeyPos, tags.Key)
+	}
+	if p := tags.Value.Elems[1].Pos; p.Line != 3 || p.Column != 17 {
+		t.Errorf("Unexpected element position %v", p)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{`[1, 2`, "1:6: Expected ',' or ']'"},
+		{`{"a" 1}`, "1:5: Expected ':'"},
+		{"{\n\t1: 2}", "2:2: Expected object key"},
+		{`[01]`, "1:3: Expected ',' or ']'"},
+		{`{} x`, "1:4: Expected EOF, got \"x\""},
+		{`"abc`, "1:1: Expected JSON value"},
+	}
+	for _, test := range tests {
+		_, err := ParseString(test.in)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
+
+func TestValueParser(t *testing.T) {
+	t.Parallel()
+	v, err := Value(parser.NewSimpleReader(strings.NewReader(` [true, "x"] `)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, []any{true, "x"}) {
+		t.Errorf("Unexpected value %v", v)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.SetBytes(int64(len(sample)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Value(parser.NewSimpleReader(strings.NewReader(sample))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodingJSON(b *testing.B) {
+	b.SetBytes(int64(len(sample)))
+	for i := 0; i < b.N; i++ {
+		var v any
+		if err := json.Unmarshal([]byte(sample), &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	t.Parallel()
+	n, err := ParseAll(`[1, x, 3, {"a": 1, "b" 2, "c": [y]}]`)
+	var l parser.ErrorList
+	if !errors.As(err, &l) {
+		t.Fatalf("got %v", err)
+	}
+	got := []string{}
+	for _, e := range l {
+		got = append(got, e.Error())
+	}
+	want := []string{"1:5: Expected JSON value", "1:23: Expected ':'", "1:33: Expected JSON value"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected errors %q, got %q", want, got)
+	}
+	v, _ := n.Interface()
+	if want := []any{1.0, 3.0, map[string]any{"a": 1.0, "c": []any{}}}; !reflect.DeepEqual(v, want) {
+		t.Errorf("Expected %v, got %v", want, v)
+	}
+
+	if _, err := ParseAll(`{"a": [1, 2]}`); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseAllDepth(t *testing.T) {
+	t.Parallel()
+	nested := func(n int) string {
+		return strings.Repeat(`{"a":[`, n) + strings.Repeat("]}", n)
+	}
+	if _, err := ParseAll(nested(400)); err != nil {
+		t.Errorf("got %v", err)
+	}
+	if _, err := ParseAll(nested(600)); !errors.Is(err, parser.ErrTooDeep) {
+		t.Errorf("Expected ErrTooDeep, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package markdown provides combinators for the block structures static site
+// tools need before handing text to a Markdown renderer: front matter and
+// fenced code blocks.
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// FrontMatter is a metadata block at the very start of a document.
+type FrontMatter struct {
+	// Format is "yaml" for --- delimited blocks and "toml" for +++.
+	Format  string
+	Content string
+}
+
+// CodeBlock is a fenced code block.
+type CodeBlock struct {
+	Pos parser.Position
+	// Fence is the opening fence, e.g. "```" or "~~~~".
+	Fence string
+	Info  string
+	// Content has the opening fence's indentation removed from each line.
+	Content string
+	// Closed is false when the block ran to the end of the input.
+	Closed bool
+}
+
+// ErrUnclosed is returned when front matter is opened but never closed.
+var ErrUnclosed = errors.New("Unclosed front matter")
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	newline = parser.Or(parser.Lit("\r\n"), parser.Lit("\n"))
+	eol     = parser.Or(newline, parser.EOF())
+	line    = join(parser.Mult(0, 0, parser.NotSet("\r\n")))
+	spaces  = join(parser.Mult(0, 0, parser.Lit(" ")))
+	indent  = join(parser.Mult(0, 3, parser.Lit(" ")))
+)
+
+// closing matches a line consisting of exactly the given delimiter, with
+// optional trailing spaces.
+func closing(delims ...string) func(sr parser.StatefulReader) (string, error) {
+	lits := []func(sr parser.StatefulReader) (string, error){}
+	for _, d := range delims {
+		lits = append(lits, parser.Lit(d))
+	}
+	return join(parser.And(parser.Or(lits...), spaces, eol))
+}
+
+// frontMatter builds a parser for a block opened by open and closed by any of
+// closers.
+func frontMatter(format, open string, closers ...string) func(sr parser.StatefulReader) (FrontMatter, error) {
+	opener := join(parser.And(parser.Lit(open), spaces, newline))
+	closer := closing(closers...)
+	return func(sr parser.StatefulReader) (FrontMatter, error) {
+		s := sr.State()
+		if _, err := opener(sr); err != nil {
+			return FrontMatter{}, err
+		}
+		lines := []string{}
+		for {
+			if _, err := closer(sr); err == nil {
+				return FrontMatter{Format: format, Content: strings.Join(lines, "")}, nil
+			}
+			if _, err := parser.EOF()(sr); err == nil {
+				sr.Restore(s)
+				return FrontMatter{}, fmt.Errorf("%w (%s)", ErrUnclosed, format)
+			}
+			l, _ := line(sr)
+			nl, _ := eol(sr)
+			lines = append(lines, l+nl)
+		}
+	}
+}
+
+var (
+	yamlFrontMatter = frontMatter("yaml", "---", "---", "...")
+	tomlFrontMatter = frontMatter("toml", "+++", "+++")
+)
+
+// ParseFrontMatter matches a YAML (---) or TOML (+++) front matter block. It
+// must be run at the start of the document.
+func ParseFrontMatter(sr parser.StatefulReader) (FrontMatter, error) {
+	fm, err := yamlFrontMatter(sr)
+	if err == nil || errors.Is(err, ErrUnclosed) {
+		return fm, err
+	}
+	return tomlFrontMatter(sr)
+}
+
+var fenceRun = parser.Or(
+	join(parser.Mult(3, 0, parser.Lit("`"))),
+	join(parser.Mult(3, 0, parser.Lit("~"))),
+)
+
+// ParseCodeBlock matches a fenced code block as described by CommonMark: an
+// opening fence of at least three backticks or tildes indented by at most
+// three spaces, an optional info string, and content up to a closing fence
+// of the same character that is at least as long.
+func ParseCodeBlock(sr parser.StatefulReader) (CodeBlock, error) {
+	s := sr.State()
+	pos := parser.Pos(sr)
+	ind, _ := indent(sr)
+	fence, err := fenceRun(sr)
+	if err != nil {
+		sr.Restore(s)
+		return CodeBlock{}, fmt.Errorf("Expected code fence")
+	}
+	info, _ := line(sr)
+	if fence[0] == '`' && strings.Contains(info, "`") {
+		sr.Restore(s)
+		return CodeBlock{}, fmt.Errorf("Backtick fence info string may not contain backticks")
+	}
+	if _, err := eol(sr); err != nil {
+		sr.Restore(s)
+		return CodeBlock{}, err
+	}
+	closer := join(parser.And(indent, parser.Lit(fence), join(parser.Mult(0, 0, parser.Lit(fence[:1]))), spaces, eol))
+	cb := CodeBlock{Pos: pos, Fence: fence, Info: strings.TrimSpace(info)}
+	content := strings.Builder{}
+	for {
+		if _, err := closer(sr); err == nil {
+			cb.Closed = true
+			break
+		}
+		if _, err := parser.EOF()(sr); err == nil {
+			break
+		}
+		l, _ := line(sr)
+		nl, _ := eol(sr)
+		// strip up to the fence's own indentation
+		for i := 0; i < len(ind) && strings.HasPrefix(l, " "); i++ {
+			l = l[1:]
+		}
+		content.WriteString(l)
+		content.WriteString(nl)
+	}
+	cb.Content = content.String()
+	return cb, nil
+}
+
+// Document is a Markdown document split into front matter and body.
+type Document struct {
+	FrontMatter *FrontMatter
+	Body        string
+}
+
+// ParseDocument separates optional front matter from the rest of the
+// document.
+func ParseDocument(sr parser.StatefulReader) (Document, error) {
+	doc := Document{}
+	if fm, err := ParseFrontMatter(sr); err == nil {
+		doc.FrontMatter = &fm
+	} else if errors.Is(err, ErrUnclosed) {
+		return doc, err
+	}
+	body, err := join(parser.Mult(0, 0, parser.NotSet("")))(sr)
+	if err != nil {
+		return doc, err
+	}
+	doc.Body = body
+	return doc, nil
+}
+
+// CodeBlocks returns every fenced code block found at the start of a line in
+// the rest of the input.
+func CodeBlocks(sr parser.StatefulReader) ([]CodeBlock, error) {
+	blocks := []CodeBlock{}
+	for {
+		if _, err := parser.EOF()(sr); err == nil {
+			return blocks, nil
+		}
+		if cb, err := ParseCodeBlock(sr); err == nil {
+			blocks = append(blocks, cb)
+			continue
+		}
+		line(sr)
+		eol(sr)
+	}
+}
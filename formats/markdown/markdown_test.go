@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func reader(s string) parser.StatefulReader {
+	return parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s)))
+}
+
+func TestFrontMatter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		fm  *FrontMatter
+		out string
+	}{
+		{"---\ntitle: Hi\ntags: [a]\n---\n# Body\n", &FrontMatter{Format: "yaml", Content: "title: Hi\ntags: [a]\n"}, "# Body\n"},
+		{"---\nx: 1\n...\nbody", &FrontMatter{Format: "yaml", Content: "x: 1\n"}, "body"},
+		{"+++\ntitle = \"Hi\"\n+++\r\nbody", &FrontMatter{Format: "toml", Content: "title = \"Hi\"\n"}, "body"},
+		{"---\n---\n", &FrontMatter{Format: "yaml", Content: ""}, ""},
+		{"# No front matter\n---\n", nil, "# No front matter\n---\n"},
+	}
+	for _, test := range tests {
+		doc, err := ParseDocument(reader(test.in))
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(doc.FrontMatter, test.fm) || doc.Body != test.out {
+			t.Errorf("Unexpected (%q) %+v", test.in, doc)
+		}
+	}
+	_, err := ParseDocument(reader("---\ntitle: x\n"))
+	if !errors.Is(err, ErrUnclosed) {
+		t.Errorf("Expected ErrUnclosed, got %v", err)
+	}
+}
+
+func TestCodeBlock(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in string
+		cb CodeBlock
+	}{
+		{"```go\nfmt.Println()\n```\n", CodeBlock{Fence: "```", Info: "go", Content: "fmt.Println()\n", Closed: true}},
+		{"````\n```\nnested\n```\n````", CodeBlock{Fence: "````", Content: "```\nnested\n```\n", Closed: true}},
+		{"~~~ python  \ncode\n~~~~~\n", CodeBlock{Fence: "~~~", Info: "python", Content: "code\n", Closed: true}},
+		{"  ```\n   a\n  b\n```\n", CodeBlock{Fence: "```", Content: " a\nb\n", Closed: true}},
+		{"```\nunterminated\n", CodeBlock{Fence: "```", Content: "unterminated\n"}},
+		{"```\n~~~\n```", CodeBlock{Fence: "```", Content: "~~~\n", Closed: true}},
+	}
+	for _, test := range tests {
+		cb, err := ParseCodeBlock(reader(test.in))
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		cb.Pos = parser.Position{}
+		if !reflect.DeepEqual(cb, test.cb) {
+			t.Errorf("Expected (%q) %+v, got %+v", test.in, test.cb, cb)
+		}
+	}
+	for _, bad := range []string{"``\ncode\n``", "``` a`b\n```", "    ```\ncode\n```"} {
+		if _, err := ParseCodeBlock(reader(bad)); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestCodeBlocks(t *testing.T) {
+	t.Parallel()
+	blocks, err := CodeBlocks(reader("# Title\n\n```sh\nls\n```\ntext\n~~~\nx\n~~~\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[0].Info != "sh" || blocks[1].Content != "x\n" {
+		t.Errorf("Unexpected blocks %+v", blocks)
+	}
+	if blocks[0].Pos.Line != 3 || blocks[1].Pos.Line != 7 {
+		t.Errorf("Unexpected positions %v %v", blocks[0].Pos, blocks[1].Pos)
+	}
+}
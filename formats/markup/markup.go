@@ -0,0 +1,220 @@
+// Package markup provides combinators for XML and HTML tags, for extracting
+// structure from markup without a full DOM library. It does not decode
+// entities or validate documents.
+package markup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Attr is a single attribute. Value is empty for bare HTML attributes such
+// as "disabled".
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// StartTag is an opening tag such as <a href="x">, or a self-closing tag
+// such as <br/>.
+type StartTag struct {
+	Name        string
+	Attrs       []Attr
+	SelfClosing bool
+}
+
+// Attr returns the value of the named attribute, compared
+// case-insensitively.
+func (st StartTag) Attr(name string) (string, bool) {
+	for _, a := range st.Attrs {
+		if strings.EqualFold(a.Name, name) {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Element is a start tag, the content parsed between it and its end tag.
+type Element[T any] struct {
+	StartTag
+	Content T
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	ws     = join(parser.Mult(0, 0, parser.Set(" \t\r\n")))
+	ws1    = join(parser.Mult(1, 0, parser.Set(" \t\r\n")))
+	name   = join(parser.And(parser.Set("a-zA-Z_:"), join(parser.Mult(0, 0, parser.Set("a-zA-Z0-9_:.-")))))
+	quoted = func(q string) func(sr parser.StatefulReader) (string, error) {
+		return parser.Convert(parser.And(parser.Lit(q), join(parser.Mult(0, 0, parser.NotSet(q))), parser.Lit(q)), func(s []string) (string, error) {
+			return s[1], nil
+		})
+	}
+	unquoted  = join(parser.Mult(1, 0, parser.NotSet(" \t\r\n\"'=<>`")))
+	attrValue = parser.Or(quoted(`"`), quoted("'"), unquoted)
+)
+
+// ParseAttr parses name, name=value, name="value" or name='value'.
+func ParseAttr(sr parser.StatefulReader) (Attr, error) {
+	n, err := name(sr)
+	if err != nil {
+		return Attr{}, err
+	}
+	s := sr.State()
+	_, err = parser.And(ws, parser.Lit("="), ws)(sr)
+	if err != nil {
+		sr.Restore(s)
+		return Attr{Name: n}, nil
+	}
+	v, err := attrValue(sr)
+	if err != nil {
+		sr.Restore(s)
+		return Attr{}, fmt.Errorf("Expected value for attribute %q", n)
+	}
+	return Attr{Name: n, Value: v}, nil
+}
+
+// ParseStartTag parses an opening or self-closing tag.
+func ParseStartTag(sr parser.StatefulReader) (StartTag, error) {
+	s := sr.State()
+	st, err := parseStartTag(sr)
+	if err != nil {
+		sr.Restore(s)
+	}
+	return st, err
+}
+
+func parseStartTag(sr parser.StatefulReader) (StartTag, error) {
+	st := StartTag{Attrs: []Attr{}}
+	if _, err := parser.Lit("<")(sr); err != nil {
+		return st, err
+	}
+	var err error
+	if st.Name, err = name(sr); err != nil {
+		return st, fmt.Errorf("Expected tag name")
+	}
+	for {
+		s := sr.State()
+		if _, err := ws1(sr); err != nil {
+			break
+		}
+		a, err := ParseAttr(sr)
+		if err != nil {
+			sr.Restore(s)
+			break
+		}
+		st.Attrs = append(st.Attrs, a)
+	}
+	ws(sr)
+	if _, err := parser.Lit("/>")(sr); err == nil {
+		st.SelfClosing = true
+		return st, nil
+	}
+	if _, err := parser.Lit(">")(sr); err != nil {
+		return st, fmt.Errorf("Expected '>' to close <%s", st.Name)
+	}
+	return st, nil
+}
+
+// ParseEndTag parses any end tag and returns its name.
+func ParseEndTag(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(parser.And(parser.Lit("</"), name, ws, parser.Lit(">")), func(s []string) (string, error) {
+		return s[1], nil
+	})(sr)
+}
+
+// EndTag returns a parser for the end tag of the named element. Names are
+// compared case-insensitively, as in HTML.
+func EndTag(tag string) func(sr parser.StatefulReader) (string, error) {
+	return func(sr parser.StatefulReader) (string, error) {
+		s := sr.State()
+		n, err := ParseEndTag(sr)
+		if err != nil {
+			return "", fmt.Errorf("Expected </%s>", tag)
+		}
+		if !strings.EqualFold(n, tag) {
+			sr.Restore(s)
+			return "", fmt.Errorf("Expected </%s>, got </%s>", tag, n)
+		}
+		return n, nil
+	}
+}
+
+// ParseElement returns a parser for a whole element: a start tag, content,
+// and the end tag matching the start tag's name. Self-closing tags have the
+// zero value as content.
+func ParseElement[T any](content func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (Element[T], error) {
+	return parser.Bind(ParseStartTag, func(st StartTag) func(sr parser.StatefulReader) (Element[T], error) {
+		return func(sr parser.StatefulReader) (Element[T], error) {
+			e := Element[T]{StartTag: st}
+			if st.SelfClosing {
+				return e, nil
+			}
+			var err error
+			if e.Content, err = content(sr); err != nil {
+				return e, err
+			}
+			if _, err := EndTag(st.Name)(sr); err != nil {
+				return e, err
+			}
+			return e, nil
+		}
+	})
+}
+
+// Text parses character data up to the next '<'.
+var Text = join(parser.Mult(1, 0, parser.NotSet("<")))
+
+// Comment parses <!-- ... --> and returns its contents.
+var Comment = parser.Convert(parser.And(
+	parser.Lit("<!--"),
+	join(parser.Mult(0, 0, parser.Or(parser.NotSet("-"), join(parser.And(parser.Lit("-"), parser.NotSet("-")))))),
+	parser.Lit("-->"),
+), func(s []string) (string, error) {
+	return s[1], nil
+})
+
+// Node is a generic markup tree node produced by Tree: either Text, or an
+// element with children.
+type Node struct {
+	Text     string
+	Tag      *StartTag
+	Children []Node
+}
+
+// Tree parses nested elements and text into a Node slice until it reaches an
+// end tag or the end of the input. Comments are skipped.
+func Tree(sr parser.StatefulReader) ([]Node, error) {
+	nodes := []Node{}
+	elem := ParseElement(Tree)
+	for {
+		if _, err := Comment(sr); err == nil {
+			continue
+		}
+		if t, err := Text(sr); err == nil {
+			nodes = append(nodes, Node{Text: t})
+			continue
+		}
+		s := sr.State()
+		if _, err := parser.Lit("</")(sr); err == nil {
+			sr.Restore(s)
+			return nodes, nil
+		}
+		if _, err := parser.EOF()(sr); err == nil {
+			return nodes, nil
+		}
+		e, err := elem(sr)
+		if err != nil {
+			return nil, err
+		}
+		st := e.StartTag
+		nodes = append(nodes, Node{Tag: &st, Children: e.Content})
+	}
+}
@@ -0,0 +1,83 @@
+package markup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func reader(s string) parser.StatefulReader {
+	return parser.NewSimpleReader(strings.NewReader(s))
+}
+
+func TestStartTag(t *testing.T) {
+	t.Parallel()
+	st, err := ParseStartTag(reader(`<a href="/x" class='btn primary' data-id=42 disabled>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := StartTag{Name: "a", Attrs: []Attr{
+		{Name: "href", Value: "/x"},
+		{Name: "class", Value: "btn primary"},
+		{Name: "data-id", Value: "42"},
+		{Name: "disabled"},
+	}}
+	if !reflect.DeepEqual(st, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, st)
+	}
+	if v, ok := st.Attr("CLASS"); !ok || v != "btn primary" {
+		t.Errorf("Unexpected class %q", v)
+	}
+	st, err = ParseStartTag(reader(`<br />`))
+	if err != nil || !st.SelfClosing || st.Name != "br" {
+		t.Errorf("Unexpected tag %+v, %v", st, err)
+	}
+	for _, bad := range []string{`<a href=>`, `<a`, `< a>`, `<a x="1"`} {
+		if _, err := ParseStartTag(reader(bad)); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+func TestElement(t *testing.T) {
+	t.Parallel()
+	p := ParseElement(Text)
+	e, err := p(reader(`<Title lang="en">Hello</title>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "Title" || e.Content != "Hello" {
+		t.Errorf("Unexpected element %+v", e)
+	}
+	sr := reader(`<b>bold</i>`)
+	_, err = p(sr)
+	if err == nil || err.Error() != "Expected </b>, got </i>" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if sr.State() != any(int64(0)) {
+		t.Errorf("Expected reader to be restored, at %v", sr.State())
+	}
+}
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+	nodes, err := Tree(reader(`<ul><!-- items --><li>one</li><li><b>two</b></li><img src=x /></ul>tail`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[1].Text != "tail" {
+		t.Fatalf("Unexpected nodes %+v", nodes)
+	}
+	ul := nodes[0]
+	if ul.Tag.Name != "ul" || len(ul.Children) != 3 {
+		t.Fatalf("Unexpected ul %+v", ul)
+	}
+	if ul.Children[1].Children[0].Tag.Name != "b" || ul.Children[1].Children[0].Children[0].Text != "two" {
+		t.Errorf("Unexpected li %+v", ul.Children[1])
+	}
+	if src, _ := ul.Children[2].Tag.Attr("src"); src != "x" || !ul.Children[2].Tag.SelfClosing {
+		t.Errorf("Unexpected img %+v", ul.Children[2].Tag)
+	}
+}
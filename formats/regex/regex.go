@@ -0,0 +1,620 @@
+// Package regex parses regular expressions in the RE2 syntax used by Go's
+// regexp package into a syntax tree, as a front end for custom matchers and
+// for tools that inspect or rewrite patterns.
+//
+// It covers a subset of that syntax: literals and escapes, ".", anchors,
+// character classes with Perl (`\d`) and POSIX (`[[:alpha:]]`) shorthands,
+// capturing, non-capturing and named groups, alternation and repeats.
+// Flags such as (?i), Unicode classes such as `\pL` and quoted text in
+// `\Q...\E` are rejected as errors rather than misread.
+package regex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+)
+
+// Node is an element of a parsed expression.
+type Node interface {
+	String() string
+}
+
+// Literal matches a single rune.
+type Literal rune
+
+// Any matches any rune other than a newline, written ".".
+type Any struct{}
+
+// Anchor matches an empty string at a boundary: "^" or `\A` at the start,
+// "$" or `\z` at the end, `\b` at a word boundary and `\B` elsewhere.
+type Anchor string
+
+// Range is an inclusive range of runes within a Class.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Class matches a single rune in (or, when Negated, not in) its ranges.
+// Shorthands such as `\d` are expanded into ranges.
+type Class struct {
+	Negated bool
+	Ranges  []Range
+}
+
+// Group is a parenthesized expression. Capturing groups are numbered from 1
+// in order of their opening parenthesis, and may be named.
+type Group struct {
+	Capture bool
+	Index   int
+	Name    string
+	Expr    Node
+}
+
+// Repeat matches Expr between Min and Max times, with Max -1 for no upper
+// bound. Lazy repeats prefer fewer matches.
+type Repeat struct {
+	Min, Max int
+	Lazy     bool
+	Expr     Node
+}
+
+// Concat matches its nodes one after another. An empty Concat matches the
+// empty string.
+type Concat []Node
+
+// Alternate matches any one of its alternatives, written a|b|c.
+type Alternate []Node
+
+// MaxRepeat is the largest count allowed in a {n,m} repeat.
+const MaxRepeat = 1000
+
+const special = `\.+*?()|[]{}^$`
+
+func escapeRune(r rune, special string) string {
+	switch {
+	case strings.ContainsRune(special, r):
+		return `\` + string(r)
+	case r == '\n':
+		return `\n`
+	case r == '\t':
+		return `\t`
+	case r == '\r':
+		return `\r`
+	case r == '\f':
+		return `\f`
+	case r == '\v':
+		return `\v`
+	case !unicode.IsPrint(r):
+		return fmt.Sprintf(`\x{%x}`, r)
+	}
+	return string(r)
+}
+
+func (l Literal) String() string {
+	return escapeRune(rune(l), special)
+}
+
+func (Any) String() string {
+	return "."
+}
+
+func (a Anchor) String() string {
+	return string(a)
+}
+
+func (c Class) String() string {
+	sb := strings.Builder{}
+	sb.WriteString("[")
+	if c.Negated {
+		sb.WriteString("^")
+	}
+	for _, r := range c.Ranges {
+		sb.WriteString(escapeRune(r.Lo, `\[]-^`))
+		if r.Hi != r.Lo {
+			sb.WriteString("-")
+			sb.WriteString(escapeRune(r.Hi, `\[]-^`))
+		}
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func (g Group) String() string {
+	switch {
+	case g.Name != "":
+		return "(?P<" + g.Name + ">" + g.Expr.String() + ")"
+	case g.Capture:
+		return "(" + g.Expr.String() + ")"
+	}
+	return "(?:" + g.Expr.String() + ")"
+}
+
+func (r Repeat) String() string {
+	s := r.Expr.String()
+	switch r.Expr.(type) {
+	case Concat, Alternate, Repeat:
+		s = "(?:" + s + ")"
+	}
+	switch {
+	case r.Min == 0 && r.Max == -1:
+		s += "*"
+	case r.Min == 1 && r.Max == -1:
+		s += "+"
+	case r.Min == 0 && r.Max == 1:
+		s += "?"
+	case r.Min == r.Max:
+		s += fmt.Sprintf("{%d}", r.Min)
+	case r.Max == -1:
+		s += fmt.Sprintf("{%d,}", r.Min)
+	default:
+		s += fmt.Sprintf("{%d,%d}", r.Min, r.Max)
+	}
+	if r.Lazy {
+		s += "?"
+	}
+	return s
+}
+
+func (c Concat) String() string {
+	sb := strings.Builder{}
+	for _, n := range c {
+		if _, ok := n.(Alternate); ok {
+			sb.WriteString("(?:" + n.String() + ")")
+			continue
+		}
+		sb.WriteString(n.String())
+	}
+	return sb.String()
+}
+
+func (a Alternate) String() string {
+	parts := make([]string, len(a))
+	for i, n := range a {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, "|")
+}
+
+// Walk calls f for n and then, if f returns true, for each of its children
+// in order.
+func Walk(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+	switch n := n.(type) {
+	case Group:
+		Walk(n.Expr, f)
+	case Repeat:
+		Walk(n.Expr, f)
+	case Concat:
+		for _, c := range n {
+			Walk(c, f)
+		}
+	case Alternate:
+		for _, c := range n {
+			Walk(c, f)
+		}
+	}
+}
+
+var (
+	digits = []Range{{'0', '9'}}
+	word   = []Range{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+	space  = []Range{{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}}
+)
+
+// posix holds the ASCII classes named in [[:name:]].
+var posix = map[string][]Range{
+	"alnum":  {{'0', '9'}, {'A', 'Z'}, {'a', 'z'}},
+	"alpha":  {{'A', 'Z'}, {'a', 'z'}},
+	"ascii":  {{0, 0x7f}},
+	"blank":  {{'\t', '\t'}, {' ', ' '}},
+	"cntrl":  {{0, 0x1f}, {0x7f, 0x7f}},
+	"digit":  digits,
+	"graph":  {{'!', '~'}},
+	"lower":  {{'a', 'z'}},
+	"print":  {{' ', '~'}},
+	"punct":  {{'!', '/'}, {':', '@'}, {'[', '`'}, {'{', '~'}},
+	"space":  {{'\t', '\r'}, {' ', ' '}},
+	"upper":  {{'A', 'Z'}},
+	"word":   word,
+	"xdigit": {{'0', '9'}, {'A', 'F'}, {'a', 'f'}},
+}
+
+// negate returns the complement of sorted, non-overlapping ranges.
+func negate(rs []Range) []Range {
+	out := []Range{}
+	next := rune(0)
+	for _, r := range rs {
+		if r.Lo > next {
+			out = append(out, Range{next, r.Lo - 1})
+		}
+		next = r.Hi + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, Range{next, unicode.MaxRune})
+	}
+	return out
+}
+
+func fail(sr parser.StatefulReader, format string, args ...any) error {
+	return &parser.ParseError{Pos: parser.Pos(sr), Err: fmt.Errorf(format, args...)}
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	decimal = join(parser.Mult(1, 0, parser.Set("0-9")))
+	// count is {n}, {n,} or {n,m}
+	count = parser.Seq3(
+		parser.Right(parser.Lit("{"), decimal),
+		parser.Optional(parser.Seq2(parser.Lit(","), parser.Optional(decimal))),
+		parser.Lit("}"),
+	)
+	groupName = join(parser.Mult(1, 0, parser.Set("0-9A-Za-z_")))
+	// posixClass is [:name:] or [:^name:] inside a class
+	posixClass = parser.Seq2(
+		parser.Right(parser.Lit("[:"), parser.Optional(parser.Lit("^"))),
+		parser.Left(join(parser.Mult(1, 0, parser.Set("a-z"))), parser.Lit(":]")),
+	)
+	hexDigits = join(parser.Mult(1, 8, parser.Set("0-9a-fA-F")))
+	hex       = parser.Or(
+		parser.Right(parser.Lit("{"), parser.Left(hexDigits, parser.Lit("}"))),
+		join(parser.Mult(2, 2, parser.Set("0-9a-fA-F"))),
+	)
+)
+
+func readRune(sr parser.StatefulReader) (rune, bool) {
+	s, err := parser.NotSet("")(sr)
+	if err != nil {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, true
+}
+
+func peek(sr parser.StatefulReader) (rune, bool) {
+	s := sr.State()
+	r, ok := readRune(sr)
+	sr.Restore(s)
+	return r, ok
+}
+
+// escape parses the rest of an escape sequence after the backslash. It
+// returns a Literal, Anchor or Class.
+func escape(sr parser.StatefulReader) (Node, error) {
+	s := sr.State()
+	r, ok := readRune(sr)
+	if !ok {
+		return nil, fail(sr, "Trailing backslash")
+	}
+	switch r {
+	case 'd':
+		return Class{Ranges: digits}, nil
+	case 'D':
+		return Class{Negated: true, Ranges: digits}, nil
+	case 'w':
+		return Class{Ranges: word}, nil
+	case 'W':
+		return Class{Negated: true, Ranges: word}, nil
+	case 's':
+		return Class{Ranges: space}, nil
+	case 'S':
+		return Class{Negated: true, Ranges: space}, nil
+	case 'b', 'B', 'A', 'z':
+		return Anchor(`\` + string(r)), nil
+	case 'n':
+		return Literal('\n'), nil
+	case 't':
+		return Literal('\t'), nil
+	case 'r':
+		return Literal('\r'), nil
+	case 'f':
+		return Literal('\f'), nil
+	case 'v':
+		return Literal('\v'), nil
+	case 'a':
+		return Literal('\a'), nil
+	case 'x':
+		x, err := hex(sr)
+		if err == nil {
+			n, err := strconv.ParseUint(x, 16, 32)
+			if err == nil && n <= unicode.MaxRune {
+				return Literal(n), nil
+			}
+		}
+		sr.Restore(s)
+		return nil, fail(sr, "Invalid escape \\x")
+	}
+	if r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return Literal(r), nil
+	}
+	sr.Restore(s)
+	return nil, fail(sr, "Invalid escape \\%c", r)
+}
+
+// classRune parses a single rune in a class, which may instead be a
+// shorthand class such as \d or [:alpha:], returned as ranges.
+func classRune(sr parser.StatefulReader) (rune, []Range, error) {
+	s := sr.State()
+	if t, err := posixClass(sr); err == nil {
+		rs, ok := posix[t.Second]
+		if !ok {
+			sr.Restore(s)
+			return 0, nil, fail(sr, "Invalid character class name [:%s:]", t.Second)
+		}
+		if t.First != "" {
+			return 0, negate(rs), nil
+		}
+		return 0, rs, nil
+	}
+	sr.Restore(s)
+	if _, err := parser.Lit(`\`)(sr); err != nil {
+		r, ok := readRune(sr)
+		if !ok {
+			return 0, nil, fail(sr, "Missing closing ]")
+		}
+		return r, nil, nil
+	}
+	n, err := escape(sr)
+	if err != nil {
+		return 0, nil, err
+	}
+	switch n := n.(type) {
+	case Literal:
+		return rune(n), nil, nil
+	case Class:
+		if n.Negated {
+			return 0, negate(n.Ranges), nil
+		}
+		return 0, n.Ranges, nil
+	}
+	return 0, nil, fail(sr, "Invalid escape %v in character class", n)
+}
+
+func class(sr parser.StatefulReader) (Node, error) {
+	c := Class{}
+	if _, err := parser.Lit("^")(sr); err == nil {
+		c.Negated = true
+	}
+	// a ']' straight after the opening bracket is a literal
+	if _, err := parser.Lit("]")(sr); err == nil {
+		c.Ranges = append(c.Ranges, Range{']', ']'})
+	}
+	for {
+		if _, err := parser.Lit("]")(sr); err == nil {
+			return c, nil
+		}
+		rs := sr.State()
+		lo, shorthand, err := classRune(sr)
+		if err != nil {
+			return nil, err
+		}
+		if shorthand != nil {
+			c.Ranges = append(c.Ranges, shorthand...)
+			continue
+		}
+		hi := lo
+		ds := sr.State()
+		if _, err := parser.Lit("-")(sr); err == nil {
+			if _, err := parser.Lit("]")(sr); err == nil {
+				// a trailing '-' is a literal
+				sr.Restore(ds)
+			} else {
+				hi, shorthand, err = classRune(sr)
+				if err != nil {
+					return nil, err
+				}
+				if shorthand != nil || hi < lo {
+					sr.Restore(rs)
+					return nil, fail(sr, "Invalid character class range")
+				}
+			}
+		}
+		c.Ranges = append(c.Ranges, Range{lo, hi})
+	}
+}
+
+var namedGroup = parser.Right(parser.Or(parser.Lit("P<"), parser.Lit("<")), parser.Left(groupName, parser.Lit(">")))
+
+func group(sr parser.StatefulReader) (Node, error) {
+	g := Group{Capture: true}
+	s := sr.State()
+	if _, err := parser.Lit("?")(sr); err == nil {
+		if _, err := parser.Lit(":")(sr); err == nil {
+			g.Capture = false
+		} else if name, err := namedGroup(sr); err == nil {
+			g.Name = name
+		} else {
+			sr.Restore(s)
+			return nil, fail(sr, "Unsupported group syntax")
+		}
+	}
+	e, err := alternation(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.Lit(")")(sr); err != nil {
+		return nil, fail(sr, "Missing closing )")
+	}
+	g.Expr = e
+	return g, nil
+}
+
+func atom(sr parser.StatefulReader) (Node, error) {
+	s := sr.State()
+	r, ok := readRune(sr)
+	if !ok {
+		return nil, fail(sr, "Unexpected end of expression")
+	}
+	switch r {
+	case '(':
+		return group(sr)
+	case '[':
+		return class(sr)
+	case '\\':
+		return escape(sr)
+	case '.':
+		return Any{}, nil
+	case '^', '$':
+		return Anchor(string(r)), nil
+	case '*', '+', '?':
+		sr.Restore(s)
+		return nil, fail(sr, "Missing argument to repetition operator %c", r)
+	case '{':
+		sr.Restore(s)
+		if _, ok, err := counted(sr); err != nil || ok {
+			sr.Restore(s)
+			return nil, fail(sr, "Missing argument to repetition operator")
+		}
+		readRune(sr)
+	}
+	return Literal(r), nil
+}
+
+// counted parses a {n}, {n,} or {n,m} repeat. Anything else starting with
+// "{" is a literal brace, so ok is false with nothing consumed.
+func counted(sr parser.StatefulReader) (q Repeat, ok bool, err error) {
+	s := sr.State()
+	t, err := count(sr)
+	if err != nil {
+		sr.Restore(s)
+		return q, false, nil
+	}
+	q.Min, err = strconv.Atoi(t.First)
+	q.Max = q.Min
+	if err == nil && t.Second.First != "" {
+		q.Max = -1
+		if t.Second.Second != "" {
+			q.Max, err = strconv.Atoi(t.Second.Second)
+		}
+	}
+	if err != nil || q.Min > MaxRepeat || q.Max > MaxRepeat || (q.Max != -1 && q.Max < q.Min) {
+		sr.Restore(s)
+		return q, false, fail(sr, "Invalid repeat count")
+	}
+	return q, true, nil
+}
+
+// quantifier parses an optional repetition operator.
+func quantifier(sr parser.StatefulReader) (q Repeat, ok bool, err error) {
+	s := sr.State()
+	r, _ := readRune(sr)
+	switch r {
+	case '*':
+		q = Repeat{Min: 0, Max: -1}
+	case '+':
+		q = Repeat{Min: 1, Max: -1}
+	case '?':
+		q = Repeat{Min: 0, Max: 1}
+	case '{':
+		sr.Restore(s)
+		if q, ok, err = counted(sr); !ok {
+			return q, false, err
+		}
+	default:
+		sr.Restore(s)
+		return q, false, nil
+	}
+	if _, err := parser.Lit("?")(sr); err == nil {
+		q.Lazy = true
+	}
+	return q, true, nil
+}
+
+func concat(sr parser.StatefulReader) (Node, error) {
+	c := Concat{}
+	for {
+		if r, ok := peek(sr); !ok || r == '|' || r == ')' {
+			break
+		}
+		a, err := atom(sr)
+		if err != nil {
+			return nil, err
+		}
+		if q, ok, err := quantifier(sr); err != nil {
+			return nil, err
+		} else if ok {
+			if _, isAnchor := a.(Anchor); isAnchor {
+				return nil, fail(sr, "Missing argument to repetition operator")
+			}
+			q.Expr = a
+			a = q
+			if _, again, _ := quantifier(sr); again {
+				return nil, fail(sr, "Invalid nested repetition operator")
+			}
+		}
+		c = append(c, a)
+	}
+	if len(c) == 1 {
+		return c[0], nil
+	}
+	return c, nil
+}
+
+func alternation(sr parser.StatefulReader) (Node, error) {
+	a := Alternate{}
+	for {
+		c, err := concat(sr)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, c)
+		if _, err := parser.Lit("|")(sr); err != nil {
+			break
+		}
+	}
+	if len(a) == 1 {
+		return a[0], nil
+	}
+	return a, nil
+}
+
+// numberGroups assigns capture indexes in order of opening parenthesis.
+func numberGroups(n Node, next *int) Node {
+	switch n := n.(type) {
+	case Group:
+		if n.Capture {
+			*next++
+			n.Index = *next
+		}
+		n.Expr = numberGroups(n.Expr, next)
+		return n
+	case Repeat:
+		n.Expr = numberGroups(n.Expr, next)
+		return n
+	case Concat:
+		for i, c := range n {
+			n[i] = numberGroups(c, next)
+		}
+	case Alternate:
+		for i, c := range n {
+			n[i] = numberGroups(c, next)
+		}
+	}
+	return n
+}
+
+// Parse parses a complete regular expression. Errors are
+// *parser.ParseError values giving the offset of the problem.
+func Parse(expr string) (Node, error) {
+	sr := parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(expr)))
+	n, err := alternation(sr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.EOF()(sr); err != nil {
+		return nil, fail(sr, "Unexpected )")
+	}
+	groups := 0
+	return numberGroups(n, &groups), nil
+}
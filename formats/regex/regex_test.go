@@ -0,0 +1,154 @@
+package regex
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	n, err := Parse(`^(?P<key>\w+)=(a|[^\n,]*?)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Concat{
+		Anchor("^"),
+		Group{Capture: true, Index: 1, Name: "key", Expr: Repeat{Min: 1, Max: -1, Expr: Class{Ranges: word}}},
+		Literal('='),
+		Group{Capture: true, Index: 2, Expr: Alternate{
+			Literal('a'),
+			Repeat{Min: 0, Max: -1, Lazy: true, Expr: Class{Negated: true, Ranges: []Range{{'\n', '\n'}, {',', ','}}}},
+		}},
+		Anchor("$"),
+	}
+	if !reflect.DeepEqual(n, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, n)
+	}
+}
+
+// TestAgreesWithRegexp checks that printing the tree gives an expression
+// that Go's regexp package compiles and that matches the same strings as the
+// original.
+func TestAgreesWithRegexp(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		expr   string
+		inputs []string
+	}{
+		{`a|b|`, []string{"a", "b", "", "c"}},
+		{`colou?r`, []string{"color", "colour", "colouur"}},
+		{`x{2}y{1,}z{0,2}`, []string{"xxy", "xxyyyzz", "xy", "xxyzzz"}},
+		{`a{`, []string{"a{", "a"}},
+		{`a{1,x}`, []string{"a{1,x}"}},
+		{`(?:ab)+c`, []string{"ababc", "abc", "c"}},
+		{`[]a-c-]+`, []string{"]-b", "d"}},
+		{`[\d\s]`, []string{"1", " ", "x"}},
+		{`[^\D]`, []string{"5", "x"}},
+		{`\bfoo\B`, []string{"foox", "foo", "afoox"}},
+		{`\x41\x{263a}\.\*`, []string{"A☺.*", "A☺x*"}},
+		{`\Aa.c\z`, []string{"abc", "a\nc"}},
+		{`(a(b)(?:c))`, []string{"abc"}},
+		{`ñ+é`, []string{"ññé", "é"}},
+		{`[[:alpha:]]+`, []string{"abZ", "a1", ":"}},
+		{`[^[:^digit:][:space:]]`, []string{"1", "a", " "}},
+		{`[[:punct:]x]`, []string{"!", "x", "a"}},
+		{`[[:]+`, []string{"[:", "a"}},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("%q: %v", test.expr, err)
+			continue
+		}
+		want := regexp.MustCompile(`^(?:` + test.expr + `)$`)
+		got, err := regexp.Compile(`^(?:` + n.String() + `)$`)
+		if err != nil {
+			t.Errorf("%q printed as %q: %v", test.expr, n.String(), err)
+			continue
+		}
+		for _, in := range test.inputs {
+			if got.MatchString(in) != want.MatchString(in) {
+				t.Errorf("%q printed as %q disagrees on %q", test.expr, n.String(), in)
+			}
+		}
+		again, err := Parse(n.String())
+		if err != nil || !reflect.DeepEqual(again, n) {
+			t.Errorf("%q printed as %q reparsed as %#v, %v", test.expr, n.String(), again, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		expr   string
+		offset int64
+		msg    string
+	}{
+		{`(ab`, 3, "Missing closing )"},
+		{`ab)`, 2, "Unexpected )"},
+		{`*a`, 0, "Missing argument to repetition operator *"},
+		{`a**`, 3, "Invalid nested repetition operator"},
+		{`[a-`, 3, "Missing closing ]"},
+		{`[z-a]`, 1, "Invalid character class range"},
+		{`a{3,2}`, 1, "Invalid repeat count"},
+		{`a{1001}`, 1, "Invalid repeat count"},
+		{`\q`, 1, `Invalid escape \q`},
+		{`a\`, 2, "Trailing backslash"},
+		{`(a|[b)`, 6, "Missing closing ]"},
+		{`[[:foo:]]`, 1, "Invalid character class name [:foo:]"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.expr)
+		pe, ok := err.(*parser.ParseError)
+		if !ok {
+			t.Errorf("%q: expected a *parser.ParseError, got %v", test.expr, err)
+			continue
+		}
+		if pe.Pos.Offset != test.offset || pe.Err.Error() != test.msg {
+			t.Errorf("%q: expected %q at %d, got %q at %d", test.expr, test.msg, test.offset, pe.Err, pe.Pos.Offset)
+		}
+		if _, err := regexp.Compile(test.expr); err == nil {
+			t.Errorf("%q: regexp accepts it", test.expr)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	t.Parallel()
+	// flags, Unicode classes and quoting are valid RE2 but outside the
+	// subset this package parses
+	for expr, offset := range map[string]int64{
+		`(?i)a`:       1,
+		`(?s:.)`:      1,
+		`\pL`:         1,
+		`[\p{Greek}]`: 2,
+		`\Qa.b\E`:     1,
+	} {
+		_, err := Parse(expr)
+		if pe, ok := err.(*parser.ParseError); !ok || pe.Pos.Offset != offset {
+			t.Errorf("%q: got %v", expr, err)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	n, err := Parse(`(a)(?:b(c))|(d)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexes := []int{}
+	Walk(n, func(n Node) bool {
+		if g, ok := n.(Group); ok && g.Capture {
+			indexes = append(indexes, g.Index)
+		}
+		return true
+	})
+	if !reflect.DeepEqual(indexes, []int{1, 2, 3}) {
+		t.Errorf("got %v", indexes)
+	}
+}
@@ -0,0 +1,181 @@
+// Package sexpr parses S-expressions: symbols, strings, numbers, nested lists
+// and the quote family of reader macros.
+package sexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Kind identifies the type of a Node.
+type Kind int
+
+const (
+	Symbol Kind = iota
+	String
+	Int
+	Float
+	List
+)
+
+// Node is a single S-expression. Quoted forms such as 'x are expanded to
+// lists, e.g. (quote x).
+type Node struct {
+	Kind  Kind
+	Pos   parser.Position
+	Text  string
+	Int   int64
+	Float float64
+	List  []*Node
+}
+
+func (n *Node) String() string {
+	switch n.Kind {
+	case String:
+		return quote(n.Text)
+	case List:
+		parts := make([]string, len(n.List))
+		for i, e := range n.List {
+			parts[i] = e.String()
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	}
+	return n.Text
+}
+
+// quoter escapes a string for a literal, using only the escapes str reads.
+var quoter = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`, "\t", `\t`)
+
+func quote(s string) string {
+	return `"` + quoter.Replace(s) + `"`
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+const delimiters = "()'`,\"; \t\r\n"
+
+var (
+	space   = parser.Set(" \t\r\n")
+	comment = join(parser.And(parser.Lit(";"), join(parser.Mult(0, 0, parser.NotSet("\n")))))
+	trivia  = parser.Mult(0, 0, parser.Or(space, comment))
+	atom    = join(parser.Mult(1, 0, parser.NotSet(delimiters)))
+	escape  = parser.Convert(parser.And(parser.Lit(`\`), parser.Set(`"\nt`)), func(s []string) (string, error) {
+		switch s[1] {
+		case "n":
+			return "\n", nil
+		case "t":
+			return "\t", nil
+		}
+		return s[1], nil
+	})
+	str = parser.Convert(parser.And(
+		parser.Lit(`"`),
+		join(parser.Mult(0, 0, parser.Or(parser.NotSet(`"\`), escape))),
+		parser.Lit(`"`),
+	), func(s []string) (string, error) {
+		return s[1], nil
+	})
+	quotes = []struct {
+		prefix, name string
+	}{
+		{",@", "unquote-splicing"},
+		{"'", "quote"},
+		{"`", "quasiquote"},
+		{",", "unquote"},
+	}
+)
+
+// Expr parses a single S-expression, skipping leading whitespace and
+// comments.
+var Expr = newExpr()
+
+func newExpr() func(sr parser.StatefulReader) (*Node, error) {
+	var expr func(sr parser.StatefulReader) (*Node, error)
+	ref := parser.Lazy(func() func(sr parser.StatefulReader) (*Node, error) {
+		return expr
+	})
+	expr = func(sr parser.StatefulReader) (*Node, error) {
+		return parseExpr(sr, ref)
+	}
+	return expr
+}
+
+func parseExpr(sr parser.StatefulReader, expr func(sr parser.StatefulReader) (*Node, error)) (*Node, error) {
+	trivia(sr)
+	n := &Node{Pos: parser.Pos(sr)}
+	for _, q := range quotes {
+		if _, err := parser.Lit(q.prefix)(sr); err == nil {
+			inner, err := expr(sr)
+			if err != nil {
+				return nil, err
+			}
+			n.Kind = List
+			n.List = []*Node{{Kind: Symbol, Pos: n.Pos, Text: q.name}, inner}
+			return n, nil
+		}
+	}
+	if _, err := parser.Lit("(")(sr); err == nil {
+		n.Kind = List
+		n.List = []*Node{}
+		for {
+			trivia(sr)
+			if _, err := parser.Lit(")")(sr); err == nil {
+				return n, nil
+			}
+			if _, err := parser.EOF()(sr); err == nil {
+				return nil, fmt.Errorf("%s: Unclosed list", n.Pos)
+			}
+			e, err := expr(sr)
+			if err != nil {
+				return nil, err
+			}
+			n.List = append(n.List, e)
+		}
+	}
+	if s, err := str(sr); err == nil {
+		n.Kind = String
+		n.Text = s
+		return n, nil
+	}
+	a, err := atom(sr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: Expected expression", n.Pos)
+	}
+	n.Text = a
+	if i, err := strconv.ParseInt(a, 10, 64); err == nil {
+		n.Kind, n.Int = Int, i
+	} else if f, err := strconv.ParseFloat(a, 64); err == nil && strings.ContainsAny(a, "0123456789") {
+		n.Kind, n.Float = Float, f
+	} else {
+		n.Kind = Symbol
+	}
+	return n, nil
+}
+
+// Parse reads all expressions until the end of the input.
+func Parse(sr parser.StatefulReader) ([]*Node, error) {
+	ns := []*Node{}
+	for {
+		trivia(sr)
+		if _, err := parser.EOF()(sr); err == nil {
+			return ns, nil
+		}
+		n, err := Expr(sr)
+		if err != nil {
+			return nil, err
+		}
+		ns = append(ns, n)
+	}
+}
+
+// ParseString parses all expressions in s, with positions tracked.
+func ParseString(s string) ([]*Node, error) {
+	return Parse(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
@@ -0,0 +1,80 @@
+package sexpr
+
+import (
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"foo", "foo"},
+		{"(+ 1 2.5)", "(+ 1 2.5)"},
+		{"(define (sq x)\n  ; square it\n  (* x x))", "(define (sq x) (* x x))"},
+		{`("a \"b\"" ())`, `("a \"b\"" ())`},
+		{"'(a b)", "(quote (a b))"},
+		{"`(a ,b ,@c)", "(quasiquote (a (unquote b) (unquote-splicing c)))"},
+	}
+	for _, test := range tests {
+		ns, err := ParseString(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if len(ns) != 1 || ns[0].String() != test.out {
+			t.Errorf("Expected (%q) %s, got %v", test.in, test.out, ns)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, text := range []string{"a\\b\"c", "tab\there\nnext", `\`, "bell\x07"} {
+		n := &Node{Kind: String, Text: text}
+		ns, err := ParseString(n.String())
+		if err != nil || len(ns) != 1 || ns[0].Kind != String || ns[0].Text != text {
+			t.Errorf("%q printed as %s: got %v, %v", text, n, ns, err)
+		}
+	}
+}
+
+func TestAtoms(t *testing.T) {
+	t.Parallel()
+	ns, err := ParseString("42 -3.5 - 1e3 x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []Kind{Int, Float, Symbol, Float, Symbol}
+	for i, n := range ns {
+		if n.Kind != kinds[i] {
+			t.Errorf("%q: expected kind %d, got %d", n.Text, kinds[i], n.Kind)
+		}
+	}
+	if ns[0].Int != 42 || ns[1].Float != -3.5 {
+		t.Errorf("Unexpected values %d %f", ns[0].Int, ns[1].Float)
+	}
+	if ns[4].Pos != (parser.Position{Offset: 14, Line: 1, Column: 15}) {
+		t.Errorf("Unexpected position %v", ns[4].Pos)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"(a (b)", "1:1: Unclosed list"},
+		{"(a\n )) ", "2:3: Expected expression"},
+	}
+	for _, test := range tests {
+		_, err := ParseString(test.in)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
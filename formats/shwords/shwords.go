@@ -0,0 +1,109 @@
+// Package shwords splits strings into words using POSIX shell quoting rules,
+// without performing any expansions.
+package shwords
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+func drop(string) (string, error) {
+	return "", nil
+}
+
+const blanks = " \t\r\n"
+
+var (
+	space   = parser.Mult(1, 0, parser.Set(blanks))
+	comment = join(parser.And(parser.Lit("#"), join(parser.Mult(0, 0, parser.NotSet("\n")))))
+	trivia  = parser.Mult(0, 0, parser.Or(join(space), comment, parser.Lit("\\\n")))
+
+	// a backslash-newline pair is a line continuation and disappears
+	continuation = parser.Convert(parser.Lit("\\\n"), drop)
+
+	singleQuoted = parser.Convert(parser.And(
+		parser.Lit("'"),
+		join(parser.Mult(0, 0, parser.NotSet("'"))),
+		parser.Lit("'"),
+	), func(s []string) (string, error) { return s[1], nil })
+
+	// inside double quotes, backslash only escapes $ ` " \ and newline
+	dqEscape = parser.Convert(parser.And(parser.Lit(`\`), parser.Set("$`\"\\")), func(s []string) (string, error) {
+		return s[1], nil
+	})
+	doubleQuoted = parser.Convert(parser.And(
+		parser.Lit(`"`),
+		join(parser.Mult(0, 0, parser.Or(continuation, dqEscape, parser.NotSet(`"`)))),
+		parser.Lit(`"`),
+	), func(s []string) (string, error) { return s[1], nil })
+
+	bareEscape = parser.Convert(parser.And(parser.Lit(`\`), parser.NotSet("")), func(s []string) (string, error) {
+		return s[1], nil
+	})
+	bare = join(parser.Mult(1, 0, parser.NotSet(blanks+`'"\#`)))
+	// '#' only starts a comment at the beginning of a word
+	hash = parser.Lit("#")
+)
+
+// Word parses a single word, removing quotes and escapes. It does not skip
+// leading whitespace.
+func Word(sr parser.StatefulReader) (string, error) {
+	s := sr.State()
+	parts := []string{}
+	for {
+		if _, err := continuation(sr); err == nil {
+			continue
+		}
+		p, err := parser.Or(singleQuoted, doubleQuoted, bareEscape, bare)(sr)
+		if err != nil && len(parts) > 0 {
+			p, err = hash(sr)
+		}
+		if err != nil {
+			break
+		}
+		parts = append(parts, p)
+	}
+	if _, err := parser.Or(parser.Lit("'"), parser.Lit(`"`))(sr); err == nil {
+		sr.Restore(s)
+		return "", fmt.Errorf("Unterminated quote")
+	}
+	if _, err := parser.Lit(`\`)(sr); err == nil {
+		sr.Restore(s)
+		return "", fmt.Errorf("Trailing backslash")
+	}
+	if len(parts) == 0 {
+		sr.Restore(s)
+		return "", fmt.Errorf("Expected word")
+	}
+	return strings.Join(parts, ""), nil
+}
+
+// Words parses whitespace-separated words up to the end of the input,
+// skipping comments.
+func Words(sr parser.StatefulReader) ([]string, error) {
+	words := []string{}
+	for {
+		trivia(sr)
+		if _, err := parser.EOF()(sr); err == nil {
+			return words, nil
+		}
+		w, err := Word(sr)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+}
+
+// Split splits s into words.
+func Split(s string) ([]string, error) {
+	return Words(parser.NewSimpleReader(strings.NewReader(s)))
+}
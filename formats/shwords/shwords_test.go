@@ -0,0 +1,52 @@
+package shwords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{"", []string{}},
+		{"  a  b\tc\n", []string{"a", "b", "c"}},
+		{`echo 'hello world'`, []string{"echo", "hello world"}},
+		{`"a \"b\" \$c \x"`, []string{`a "b" $c \x`}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{`pre'mid'"post"`, []string{"premidpost"}},
+		{`''`, []string{""}},
+		{"a # comment\nb", []string{"a", "b"}},
+		{"a#b", []string{"a#b"}},
+		{"long \\\n  line", []string{"long", "line"}},
+		{"x\\\ny", []string{"xy"}},
+	}
+	for _, test := range tests {
+		out, err := Split(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("Expected (%q) %q, got %q", test.in, test.out, out)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		`a 'b`:  "Unterminated quote",
+		`a "b`:  "Unterminated quote",
+		`abc\`:  "Trailing backslash",
+		`"a"'b`: "Unterminated quote",
+	}
+	for in, msg := range tests {
+		_, err := Split(in)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%q: expected %q, got %v", in, msg, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package tmpl splits template text into literal chunks and delimited
+// actions, the first stage of a mustache or text/template style engine.
+package tmpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Chunk is a piece of template text. For actions, Text is the content
+// between the delimiters, untrimmed.
+type Chunk struct {
+	Action bool
+	Text   string
+	Pos    parser.Position
+}
+
+// Delims configures the action delimiters. Escape, when non-empty, makes
+// Escape+Left in literal text produce a literal Left.
+type Delims struct {
+	Left, Right string
+	Escape      string
+}
+
+// Default is {{ }} with backslash escaping.
+var Default = Delims{Left: "{{", Right: "}}", Escape: `\`}
+
+// Chunks returns a parser that splits the rest of the input into chunks.
+// Inside an action, nested delimiter pairs must balance and quoted strings
+// may contain delimiters.
+func Chunks(d Delims) func(sr parser.StatefulReader) ([]Chunk, error) {
+	left := parser.Lit(d.Left)
+	right := parser.Lit(d.Right)
+	var escaped func(sr parser.StatefulReader) (string, error)
+	if d.Escape != "" {
+		escaped = parser.Convert(parser.And(parser.Lit(d.Escape), left), func(s []string) (string, error) {
+			return s[1], nil
+		})
+	}
+	quoted := func(q string) func(sr parser.StatefulReader) (string, error) {
+		esc := parser.Convert(parser.And(parser.Lit(`\`), parser.NotSet("")), func(s []string) (string, error) {
+			return s[0] + s[1], nil
+		})
+		return parser.Convert(parser.And(
+			parser.Lit(q),
+			join(parser.Mult(0, 0, parser.Or(esc, parser.NotSet(q+`\`)))),
+			parser.Lit(q),
+		), func(s []string) (string, error) { return strings.Join(s, ""), nil })
+	}
+	str := parser.Or(quoted(`"`), quoted("'"), quoted("`"))
+	anyRune := parser.NotSet("")
+
+	action := func(sr parser.StatefulReader) (string, error) {
+		sb := strings.Builder{}
+		depth := 0
+		for {
+			if _, err := right(sr); err == nil {
+				if depth == 0 {
+					return sb.String(), nil
+				}
+				depth--
+				sb.WriteString(d.Right)
+				continue
+			}
+			if _, err := left(sr); err == nil {
+				depth++
+				sb.WriteString(d.Left)
+				continue
+			}
+			if s, err := str(sr); err == nil {
+				sb.WriteString(s)
+				continue
+			}
+			r, err := anyRune(sr)
+			if err != nil {
+				return "", fmt.Errorf("Unclosed action")
+			}
+			sb.WriteString(r)
+		}
+	}
+
+	return func(sr parser.StatefulReader) ([]Chunk, error) {
+		chunks := []Chunk{}
+		text := strings.Builder{}
+		textPos := parser.Pos(sr)
+		flush := func() {
+			if text.Len() > 0 {
+				chunks = append(chunks, Chunk{Text: text.String(), Pos: textPos})
+				text.Reset()
+			}
+		}
+		for {
+			if _, err := parser.EOF()(sr); err == nil {
+				flush()
+				return chunks, nil
+			}
+			pos := parser.Pos(sr)
+			if escaped != nil {
+				if s, err := escaped(sr); err == nil {
+					if text.Len() == 0 {
+						textPos = pos
+					}
+					text.WriteString(s)
+					continue
+				}
+			}
+			if _, err := left(sr); err == nil {
+				a, err := action(sr)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", pos, err)
+				}
+				flush()
+				chunks = append(chunks, Chunk{Action: true, Text: a, Pos: pos})
+				continue
+			}
+			r, err := anyRune(sr)
+			if err != nil {
+				return nil, err
+			}
+			if text.Len() == 0 {
+				textPos = pos
+			}
+			text.WriteString(r)
+		}
+	}
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+// Split splits s with the given delimiters, tracking positions.
+func Split(s string, d Delims) ([]Chunk, error) {
+	return Chunks(d)(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
@@ -0,0 +1,73 @@
+package tmpl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	chunks, err := Split("Hello, {{ .Name }}!\n{{if x}}y{{end}}", Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Chunk{
+		{Text: "Hello, ", Pos: parser.Position{Offset: 0, Line: 1, Column: 1}},
+		{Action: true, Text: " .Name ", Pos: parser.Position{Offset: 7, Line: 1, Column: 8}},
+		{Text: "!\n", Pos: parser.Position{Offset: 18, Line: 1, Column: 19}},
+		{Action: true, Text: "if x", Pos: parser.Position{Offset: 20, Line: 2, Column: 1}},
+		{Text: "y", Pos: parser.Position{Offset: 28, Line: 2, Column: 9}},
+		{Action: true, Text: "end", Pos: parser.Position{Offset: 29, Line: 2, Column: 10}},
+	}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, chunks)
+	}
+}
+
+func TestNestingAndQuotes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{`{{ f {{ g }} }}`, []string{" f {{ g }} "}},
+		{`{{ "}}" }}`, []string{` "}}" `}},
+		{`{{ 'a\'}}' }}`, []string{` 'a\'}}' `}},
+		{`a \{{ b`, []string{"a {{ b"}},
+	}
+	for _, test := range tests {
+		chunks, err := Split(test.in, Default)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		out := []string{}
+		for _, c := range chunks {
+			out = append(out, c.Text)
+		}
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("Expected (%q) %q, got %q", test.in, test.out, out)
+		}
+	}
+}
+
+func TestDelims(t *testing.T) {
+	t.Parallel()
+	chunks, err := Split("<% x %>{{y}}", Delims{Left: "<%", Right: "%>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || !chunks[0].Action || chunks[1].Text != "{{y}}" {
+		t.Errorf("Unexpected chunks %+v", chunks)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	_, err := Split("ok\n  {{ x {{ y }}", Default)
+	if err == nil || err.Error() != "2:3: Unclosed action" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
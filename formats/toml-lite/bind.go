@@ -0,0 +1,158 @@
+package tomllite
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Bind copies a parsed document onto the struct pointed to by v. Fields are
+// matched by their `toml:"name"` tag, or case-insensitively by field name;
+// a tag of "-" skips the field. Tables bind to structs or maps, arrays to
+// slices.
+func Bind(doc map[string]any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("Bind needs a non-nil pointer, got %T", v)
+	}
+	return bind("", doc, rv.Elem())
+}
+
+// Unmarshal parses s and binds it onto v.
+func Unmarshal(s string, v any) error {
+	doc, err := ParseString(s)
+	if err != nil {
+		return err
+	}
+	return Bind(doc, v)
+}
+
+func bind(path string, src any, dst reflect.Value) error {
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return bind(path, src, dst.Elem())
+	}
+	if dst.Type() == timeType {
+		t, ok := src.(time.Time)
+		if !ok {
+			return mismatch(path, src, dst)
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return mismatch(path, src, dst)
+		}
+		for k, v := range m {
+			f, ok := field(dst, k)
+			if !ok {
+				continue
+			}
+			if err := bind(keyPath(path, k), v, f); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := src.(map[string]any)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch(path, src, dst)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		for k, v := range m {
+			e := reflect.New(dst.Type().Elem()).Elem()
+			if err := bind(keyPath(path, k), v, e); err != nil {
+				return err
+			}
+			dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), e)
+		}
+	case reflect.Slice:
+		a, ok := src.([]any)
+		if !ok {
+			return mismatch(path, src, dst)
+		}
+		s := reflect.MakeSlice(dst.Type(), len(a), len(a))
+		for i, v := range a {
+			if err := bind(fmt.Sprintf("%s[%d]", path, i), v, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(src))
+	case reflect.String:
+		s, ok := src.(string)
+		if !ok {
+			return mismatch(path, src, dst)
+		}
+		dst.SetString(s)
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return mismatch(path, src, dst)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := src.(int64)
+		if !ok || dst.OverflowInt(i) {
+			return mismatch(path, src, dst)
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := src.(int64)
+		if !ok || i < 0 || dst.OverflowUint(uint64(i)) {
+			return mismatch(path, src, dst)
+		}
+		dst.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		switch n := src.(type) {
+		case float64:
+			dst.SetFloat(n)
+		case int64:
+			dst.SetFloat(float64(n))
+		default:
+			return mismatch(path, src, dst)
+		}
+	default:
+		return mismatch(path, src, dst)
+	}
+	return nil
+}
+
+func field(s reflect.Value, key string) (reflect.Value, bool) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == key || tag == "" && strings.EqualFold(f.Name, key) {
+			return s.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func keyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func mismatch(path string, src any, dst reflect.Value) error {
+	return fmt.Errorf("Cannot bind %T to %s (%s)", src, path, dst.Type())
+}
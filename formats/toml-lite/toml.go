@@ -0,0 +1,306 @@
+// Package tomllite parses a practical subset of TOML: tables, dotted keys,
+// basic and literal strings, integers, floats, booleans, arrays and
+// datetimes. Errors are collected line by line rather than stopping at the
+// first one, and documents can be bound onto structs with `toml` tags.
+package tomllite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andyleap/parser"
+)
+
+// Error is a problem at a position in the document.
+type Error struct {
+	Pos parser.Position
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList is every error found in a document.
+type ErrorList []*Error
+
+func (el ErrorList) Error() string {
+	if len(el) == 1 {
+		return el[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", el[0], len(el)-1)
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	blank   = join(parser.Mult(0, 0, parser.Set(" \t")))
+	newline = parser.Or(parser.Lit("\r\n"), parser.Lit("\n"))
+	comment = join(parser.And(parser.Lit("#"), join(parser.Mult(0, 0, parser.NotSet("\r\n")))))
+	eol     = parser.Or(newline, parser.EOF())
+	restOf  = join(parser.Mult(0, 0, parser.NotSet("\r\n")))
+	// trivia inside arrays may span lines
+	trivia = parser.Mult(0, 0, parser.Or(join(parser.Mult(1, 0, parser.Set(" \t\r\n"))), comment))
+
+	bareKey = join(parser.Mult(1, 0, parser.Set("A-Za-z0-9_-")))
+
+	escapes = map[string]string{"b": "\b", "t": "\t", "n": "\n", "f": "\f", "r": "\r", `"`: `"`, `\`: `\`}
+	escape  = parser.Convert(parser.And(parser.Lit(`\`), parser.Or(
+		parser.Set(`btnfr"\`),
+		join(parser.And(parser.Lit("u"), join(parser.Mult(4, 4, parser.Set("0-9a-fA-F"))))),
+		join(parser.And(parser.Lit("U"), join(parser.Mult(8, 8, parser.Set("0-9a-fA-F"))))),
+	)), func(s []string) (string, error) {
+		if e, ok := escapes[s[1]]; ok {
+			return e, nil
+		}
+		cp, err := strconv.ParseUint(s[1][1:], 16, 32)
+		if err != nil {
+			return "", err
+		}
+		return string(rune(cp)), nil
+	})
+	basicString = parser.Convert(parser.And(
+		parser.Lit(`"`),
+		join(parser.Mult(0, 0, parser.Or(escape, parser.NotSet("\"\\\r\n")))),
+		parser.Lit(`"`),
+	), func(s []string) (string, error) { return s[1], nil })
+	literalString = parser.Convert(parser.And(
+		parser.Lit("'"),
+		join(parser.Mult(0, 0, parser.NotSet("'\r\n"))),
+		parser.Lit("'"),
+	), func(s []string) (string, error) { return s[1], nil })
+	str = parser.Or(basicString, literalString)
+
+	simpleKey = parser.Or(bareKey, str)
+
+	digits  = join(parser.Mult(1, 0, parser.Set("0-9")))
+	digitsU = join(parser.And(digits, join(parser.Mult(0, 0, join(parser.And(parser.Lit("_"), digits))))))
+	sign    = parser.Optional(parser.Set("+-"))
+	integer = join(parser.And(sign, digitsU))
+	float   = join(parser.And(
+		sign,
+		digitsU,
+		parser.Or(
+			join(parser.And(parser.Lit("."), digitsU, parser.Optional(join(parser.And(parser.Set("eE"), sign, digitsU))))),
+			join(parser.And(parser.Set("eE"), sign, digitsU)),
+		),
+	))
+	special = join(parser.And(sign, parser.Or(parser.Lit("inf"), parser.Lit("nan"))))
+	boolean = parser.Or(parser.Lit("true"), parser.Lit("false"))
+
+	date     = join(parser.And(join(parser.Mult(4, 4, parser.Set("0-9"))), parser.Lit("-"), join(parser.Mult(2, 2, parser.Set("0-9"))), parser.Lit("-"), join(parser.Mult(2, 2, parser.Set("0-9")))))
+	clock    = join(parser.And(join(parser.Mult(2, 2, parser.Set("0-9"))), parser.Lit(":"), join(parser.Mult(2, 2, parser.Set("0-9"))), parser.Lit(":"), join(parser.Mult(2, 2, parser.Set("0-9"))), parser.Optional(join(parser.And(parser.Lit("."), digits)))))
+	offset   = parser.Or(parser.Set("zZ"), join(parser.And(parser.Set("+-"), join(parser.Mult(2, 2, parser.Set("0-9"))), parser.Lit(":"), join(parser.Mult(2, 2, parser.Set("0-9"))))))
+	datetime = parser.Or(
+		join(parser.And(date, parser.Set("Tt "), clock, parser.Optional(offset))),
+		date,
+		clock,
+	)
+)
+
+// parseTime interprets the datetime forms. Local datetimes, dates and times
+// have no zone and are returned in UTC.
+func parseTime(s string) (time.Time, error) {
+	s = strings.Replace(strings.Replace(s, " ", "T", 1), "t", "T", 1)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02", "15:04:05.999999999"} {
+		if t, err := time.Parse(layout, strings.ToUpper(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid datetime %q", s)
+}
+
+func value(sr parser.StatefulReader) (any, error) {
+	if s, err := str(sr); err == nil {
+		return s, nil
+	}
+	if _, err := parser.Lit("[")(sr); err == nil {
+		return array(sr)
+	}
+	if b, err := boolean(sr); err == nil {
+		return b == "true", nil
+	}
+	if d, err := datetime(sr); err == nil {
+		return parseTime(d)
+	}
+	if f, err := float(sr); err == nil {
+		return strconv.ParseFloat(strings.ReplaceAll(f, "_", ""), 64)
+	}
+	if f, err := special(sr); err == nil {
+		return strconv.ParseFloat(f, 64)
+	}
+	if i, err := integer(sr); err == nil {
+		clean := strings.ReplaceAll(i, "_", "")
+		if d := strings.TrimLeft(clean, "+-"); len(d) > 1 && d[0] == '0' {
+			return nil, fmt.Errorf("Leading zeros are not allowed in %q", i)
+		}
+		return strconv.ParseInt(clean, 10, 64)
+	}
+	return nil, fmt.Errorf("Expected value")
+}
+
+func array(sr parser.StatefulReader) ([]any, error) {
+	vs := []any{}
+	for {
+		trivia(sr)
+		if _, err := parser.Lit("]")(sr); err == nil {
+			return vs, nil
+		}
+		v, err := value(sr)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+		trivia(sr)
+		if _, err := parser.Lit(",")(sr); err != nil {
+			if _, err := parser.Lit("]")(sr); err != nil {
+				return nil, fmt.Errorf("Expected ',' or ']' in array")
+			}
+			return vs, nil
+		}
+	}
+}
+
+func dottedKey(sr parser.StatefulReader) ([]string, error) {
+	k, err := simpleKey(sr)
+	if err != nil {
+		return nil, fmt.Errorf("Expected key")
+	}
+	keys := []string{k}
+	for {
+		s := sr.State()
+		blank(sr)
+		if _, err := parser.Lit(".")(sr); err != nil {
+			sr.Restore(s)
+			return keys, nil
+		}
+		blank(sr)
+		k, err := simpleKey(sr)
+		if err != nil {
+			return nil, fmt.Errorf("Expected key after '.'")
+		}
+		keys = append(keys, k)
+	}
+}
+
+// table walks (creating as needed) the nested tables for keys.
+func table(root map[string]any, keys []string) (map[string]any, error) {
+	t := root
+	for i, k := range keys {
+		next, ok := t[k]
+		if !ok {
+			m := map[string]any{}
+			t[k] = m
+			t = m
+			continue
+		}
+		m, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("Key %q is already defined as a value", strings.Join(keys[:i+1], "."))
+		}
+		t = m
+	}
+	return t, nil
+}
+
+// Parse reads a document into nested map[string]any tables. Values are
+// string, int64, float64, bool, time.Time or []any. On failure it returns an
+// ErrorList; lines with errors are skipped so that later problems are
+// reported too.
+func Parse(sr parser.StatefulReader) (map[string]any, error) {
+	root := map[string]any{}
+	cur := root
+	defined := map[string]bool{}
+	errs := ErrorList{}
+	for {
+		blank(sr)
+		if _, err := parser.EOF()(sr); err == nil {
+			break
+		}
+		if _, err := parser.Or(comment, newline)(sr); err == nil {
+			continue
+		}
+		pos := parser.Pos(sr)
+		err := line(sr, root, &cur, defined)
+		if err == nil {
+			blank(sr)
+			comment(sr)
+			if _, err = eol(sr); err != nil {
+				err = fmt.Errorf("Unexpected text after value")
+			}
+		}
+		if err != nil {
+			errs = append(errs, &Error{Pos: pos, Err: err})
+			// recover at the next line
+			restOf(sr)
+			eol(sr)
+		}
+	}
+	if len(errs) > 0 {
+		return root, errs
+	}
+	return root, nil
+}
+
+func line(sr parser.StatefulReader, root map[string]any, cur *map[string]any, defined map[string]bool) error {
+	if _, err := parser.Lit("[")(sr); err == nil {
+		blank(sr)
+		keys, err := dottedKey(sr)
+		if err != nil {
+			return err
+		}
+		blank(sr)
+		if _, err := parser.Lit("]")(sr); err != nil {
+			return fmt.Errorf("Expected ']' after table name")
+		}
+		name := strings.Join(keys, ".")
+		if defined[name] {
+			return fmt.Errorf("Table %q is defined twice", name)
+		}
+		defined[name] = true
+		t, err := table(root, keys)
+		if err != nil {
+			return err
+		}
+		*cur = t
+		return nil
+	}
+	keys, err := dottedKey(sr)
+	if err != nil {
+		return err
+	}
+	blank(sr)
+	if _, err := parser.Lit("=")(sr); err != nil {
+		return fmt.Errorf("Expected '=' after key")
+	}
+	blank(sr)
+	v, err := value(sr)
+	if err != nil {
+		return err
+	}
+	t, err := table(*cur, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := t[last]; ok {
+		return fmt.Errorf("Key %q is defined twice", strings.Join(keys, "."))
+	}
+	t[last] = v
+	return nil
+}
+
+// ParseString parses a document held in a string, with positions tracked.
+func ParseString(s string) (map[string]any, error) {
+	return Parse(parser.NewPosReader(parser.NewSimpleReader(strings.NewReader(s))))
+}
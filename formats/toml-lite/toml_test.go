@@ -0,0 +1,115 @@
+package tomllite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const sample = `# server config
+title = "demo \"app\""
+path = 'C:\tmp'
+
+[server]
+host = "0.0.0.0"
+port = 8_080
+ratio = 0.75
+debug = false
+started = 1979-05-27T07:32:00Z
+tags = [
+  "a", # first
+  "b",
+]
+
+[server.limits]
+max.conns = 100
+birthday = 1979-05-27
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	doc, err := ParseString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"title": `demo "app"`,
+		"path":  `C:\tmp`,
+		"server": map[string]any{
+			"host":    "0.0.0.0",
+			"port":    int64(8080),
+			"ratio":   0.75,
+			"debug":   false,
+			"started": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+			"tags":    []any{"a", "b"},
+			"limits": map[string]any{
+				"max":      map[string]any{"conns": int64(100)},
+				"birthday": time.Date(1979, 5, 27, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("Expected %v, got %v", expected, doc)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	_, err := ParseString("a = 1\nb = \nc = 01\na = 2\n[t]\n[t]\nd = [1 2]\ne = 3 x\n")
+	var el ErrorList
+	if !errors.As(err, &el) {
+		t.Fatalf("Expected ErrorList, got %v", err)
+	}
+	msgs := []string{}
+	for _, e := range el {
+		msgs = append(msgs, e.Error())
+	}
+	expected := []string{
+		"2:1: Expected value",
+		"3:1: Leading zeros are not allowed in \"01\"",
+		"4:1: Key \"a\" is defined twice",
+		"6:1: Table \"t\" is defined twice",
+		"7:1: Expected ',' or ']' in array",
+		"8:1: Unexpected text after value",
+	}
+	if !reflect.DeepEqual(msgs, expected) {
+		t.Errorf("Expected %q, got %q", expected, msgs)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+	type limits struct {
+		Max      map[string]int
+		Birthday time.Time
+	}
+	var cfg struct {
+		Title  string
+		Server struct {
+			Host    string
+			Port    uint16
+			Ratio   float32
+			Debug   bool
+			Tags    []string
+			Limits  *limits
+			Started time.Time `toml:"started"`
+			Ignored string    `toml:"-"`
+		} `toml:"server"`
+	}
+	if err := Unmarshal(sample, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Title != `demo "app"` || cfg.Server.Port != 8080 || cfg.Server.Ratio != 0.75 {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Server.Tags, []string{"a", "b"}) || cfg.Server.Limits.Max["conns"] != 100 {
+		t.Errorf("Unexpected server %+v", cfg.Server)
+	}
+	var bad struct {
+		Title int
+	}
+	if err := Unmarshal(sample, &bad); err == nil || err.Error() != "Cannot bind string to title (int)" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package fuzzsupport helps fuzz grammars built with parser using Go's
+// native fuzzing. A fuzz target can be as short as
+//
+//	func FuzzConfig(f *testing.F) {
+//		fuzzsupport.Fuzz(f, config.Parse, "key = value\n")
+//	}
+//
+// Each input is parsed under a step budget and a timeout, and the target
+// fails if the parse panics or hangs. Parse errors, including running out
+// of budget, are expected outcomes and don't fail it.
+package fuzzsupport
+
+import (
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/andyleap/parser"
+)
+
+// Steps is the MaxSteps budget each parse runs under, so a grammar that
+// takes exponential time on some input fails with parser.ErrBudget rather
+// than hanging.
+var Steps = 1 << 20
+
+// Timeout bounds how long one parse may take before it is reported as a
+// hang, for grammars whose work isn't all in Grammar rules.
+var Timeout = 10 * time.Second
+
+type outcome[T any] struct {
+	v     T
+	err   error
+	panic any
+	stack []byte
+}
+
+// Parse parses data with p and returns the result, failing t if the parse
+// panics or takes longer than Timeout. opts are applied after the budget,
+// so they can change it.
+func Parse[T any](t testing.TB, p func(parser.StatefulReader) (T, error), data []byte, opts ...parser.Option) (T, error) {
+	t.Helper()
+	opts = append([]parser.Option{parser.MaxSteps(Steps)}, opts...)
+	done := make(chan outcome[T], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome[T]{panic: r, stack: debug.Stack()}
+			}
+		}()
+		v, _, err := parser.ParseBytes(p, data, opts...)
+		done <- outcome[T]{v: v, err: err}
+	}()
+	timer := time.NewTimer(Timeout)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		if o.panic != nil {
+			t.Fatalf("parsing %q panicked: %v\n%s", data, o.panic, o.stack)
+		}
+		return o.v, o.err
+	case <-timer.C:
+		t.Fatalf("parsing %q took longer than %v", data, Timeout)
+	}
+	var zero T
+	return zero, nil
+}
+
+// RoundTrip parses data with p and, if it parses, checks that printing the
+// result with print gives text that parses again and prints the same,
+// failing t if not.
+func RoundTrip[T any](t testing.TB, p func(parser.StatefulReader) (T, error), print func(T) string, data []byte, opts ...parser.Option) {
+	t.Helper()
+	v, err := Parse(t, p, data, opts...)
+	if err != nil {
+		return
+	}
+	text := print(v)
+	v2, err := Parse(t, p, []byte(text), opts...)
+	if err != nil {
+		t.Fatalf("%q printed as %q, which doesn't parse: %v", data, text, err)
+	}
+	if text2 := print(v2); text2 != text {
+		t.Fatalf("%q printed as %q, which reprints as %q", data, text, text2)
+	}
+}
+
+// Fuzz adds seeds to f's corpus and fuzzes p with Parse.
+func Fuzz[T any](f *testing.F, p func(parser.StatefulReader) (T, error), seeds ...string) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(t, p, data)
+	})
+}
+
+// FuzzRoundTrip is like Fuzz, but checks each input with RoundTrip.
+func FuzzRoundTrip[T any](f *testing.F, p func(parser.StatefulReader) (T, error), print func(T) string, seeds ...string) {
+	for _, s := range seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		RoundTrip(t, p, print, data)
+	})
+}
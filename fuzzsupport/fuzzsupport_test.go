@@ -0,0 +1,80 @@
+package fuzzsupport
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/formats/sexpr"
+)
+
+func printExprs(ns []*sexpr.Node) string {
+	s := []string{}
+	for _, n := range ns {
+		s = append(s, n.String())
+	}
+	return strings.Join(s, " ")
+}
+
+func FuzzSexpr(f *testing.F) {
+	FuzzRoundTrip(f, sexpr.Parse, printExprs, "(a b c)", `(define (f x) "s" 'q 1.5)`, "")
+}
+
+// recorder captures the failure of a test helper.
+type recorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func failure(t *testing.T, f func(tb testing.TB)) string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(r)
+	}()
+	<-done
+	return r.failure
+}
+
+func TestParse(t *testing.T) {
+	ok := parser.Lit("x")
+	boom := func(parser.StatefulReader) (string, error) { panic("boom") }
+	if msg := failure(t, func(tb testing.TB) { Parse(tb, ok, []byte("y")) }); msg != "" {
+		t.Errorf("parse error failed the test: %s", msg)
+	}
+	if msg := failure(t, func(tb testing.TB) { Parse(tb, boom, []byte("y")) }); !strings.Contains(msg, "panicked: boom") {
+		t.Errorf("got %q", msg)
+	}
+
+	defer func(d time.Duration) { Timeout = d }(Timeout)
+	Timeout = 10 * time.Millisecond
+	slow := func(parser.StatefulReader) (string, error) {
+		time.Sleep(time.Second)
+		return "", nil
+	}
+	if msg := failure(t, func(tb testing.TB) { Parse(tb, slow, nil) }); !strings.Contains(msg, "took longer") {
+		t.Errorf("got %q", msg)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := parser.Left(parser.Lits("a", "A"), parser.EOF())
+	if msg := failure(t, func(tb testing.TB) { RoundTrip(tb, p, strings.ToLower, []byte("A")) }); msg != "" {
+		t.Errorf("got %q", msg)
+	}
+	bad := func(s string) string { return s + "!" }
+	if msg := failure(t, func(tb testing.TB) { RoundTrip(tb, p, bad, []byte("a")) }); !strings.Contains(msg, "doesn't parse") {
+		t.Errorf("got %q", msg)
+	}
+}
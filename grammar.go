@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrTooDeep is returned when rules nest deeper than a Grammar's MaxDepth.
+// It ends the whole parse: ParseReader and Compiled.Run return it even if
+// an enclosing choice went on to match something else.
+var ErrTooDeep = errors.New("Rules nested too deeply")
+
+// Grammar is a registry of named rules. Rules registered through it are
+// lazily built, so they can refer to each other recursively, and by
+// default are memoized and protected by a nesting depth limit. The
+// protection needs per-parse state, so it only applies when parsing a
+// Context, as ParseReader and Compile do.
+type Grammar struct {
+	// MaxDepth limits how deeply rules may nest in one parse, so that
+	// pathological input such as "((((...))))" fails cleanly instead of
+	// exhausting the stack. Zero means 1000.
+	MaxDepth int
+	// Strategy is how Choices in the grammar's rules pick an alternative,
+	// unless a rule overrides it with UseStrategy. It is read as each parse
+	// runs, so it can be changed between parses.
+	Strategy Strategy
+
+	rules map[string]bool
+}
+
+func NewGrammar() *Grammar {
+	return &Grammar{rules: map[string]bool{}}
+}
+
+// Rules returns the names of the registered rules, sorted.
+func (g *Grammar) Rules() []string {
+	names := make([]string, 0, len(g.rules))
+	for n := range g.rules {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RuleOption changes how a single rule is wrapped.
+type RuleOption func(*ruleOptions)
+
+type ruleOptions struct {
+	noMemo      bool
+	leftRec     bool
+	strategy    Strategy
+	hasStrategy bool
+}
+
+// NoMemo turns off memoization for a rule that is cheap or only ever tried
+// once at each position.
+func NoMemo() RuleOption {
+	return func(o *ruleOptions) {
+		o.noMemo = true
+	}
+}
+
+// LeftRecursive allows a rule to refer to itself at the start of its body,
+// as in sum <- sum "+" num / num, by growing the match one step at a time
+// until it stops getting longer. Such rules must be run on a Context.
+func LeftRecursive() RuleOption {
+	return func(o *ruleOptions) {
+		o.leftRec = true
+	}
+}
+
+// UseStrategy sets the Strategy of the Choices in a rule, overriding the
+// grammar's. Rules the rule refers to keep their own.
+func UseStrategy(s Strategy) RuleOption {
+	return func(o *ruleOptions) {
+		o.strategy, o.hasStrategy = s, true
+	}
+}
+
+// Rule registers the rule name in g, built by body on first use, and
+// returns the parser for it. Registering the same name twice panics.
+func Rule[T any](g *Grammar, name string, body func() func(sr StatefulReader) (T, error), opts ...RuleOption) func(sr StatefulReader) (T, error) {
+	if g.rules == nil {
+		g.rules = map[string]bool{}
+	}
+	if g.rules[name] {
+		panic(fmt.Sprintf("parser: Rule: %q registered twice", name))
+	}
+	g.rules[name] = true
+	o := ruleOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := depthGuard(g, withStrategy(g, o, contracted(name, Lazy(body))))
+	switch {
+	case o.leftRec:
+		p = leftRec(name, p)
+	case !o.noMemo:
+		p = Memo(name, p)
+	}
+	return traced(name, p)
+}
+
+func depthGuard[T any](g *Grammar, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		m, ok := sr.(interface{ memo() *MemoReader })
+		if !ok {
+			return p(sr)
+		}
+		mr := m.memo()
+		max := g.MaxDepth
+		if max == 0 {
+			max = 1000
+		}
+		if mr.maxDepth > 0 && mr.maxDepth < max {
+			max = mr.maxDepth
+		}
+		if mr.depth >= max && mr.abort == nil {
+			mr.abort = &ParseError{Pos: mr.Pos(), Err: ErrTooDeep}
+		}
+		if mr.maxSteps > 0 {
+			mr.steps++
+			if mr.steps > mr.maxSteps && mr.abort == nil {
+				mr.abort = &ParseError{Pos: mr.Pos(), Err: ErrBudget}
+			}
+		}
+		if mr.abort != nil {
+			var t T
+			return t, fatalError{mr.abort}
+		}
+		mr.depth++
+		defer func() { mr.depth-- }()
+		return p(sr)
+	}
+}
+
+// leftRec memoizes p and grows left recursive matches: the first recursive
+// call at a position fails, and p is rerun with the previous match as the
+// memoized answer until the match stops getting longer.
+func leftRec[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	id := &ruleID{name: name}
+	return func(sr StatefulReader) (T, error) {
+		m, ok := sr.(interface{ memo() *MemoReader })
+		if !ok {
+			return p(sr)
+		}
+		mr := m.memo()
+		key := memoKey{rule: id, state: mr.sr.State()}
+		if r, ok := mr.table[key]; ok {
+			mr.replay(r)
+			// id is only used with T, so this fails only for a nil
+			// interface value
+			v, _ := r.value.(T)
+			return v, r.err
+		}
+		s, n := mr.State(), mr.logs()
+		start := mr.Pos()
+		best := memoResult{err: fmt.Errorf("Left recursion in %s", name), end: key.state}
+		bestOffset, _ := offset(sr)
+		mr.table[key] = best
+		for {
+			mr.Restore(s)
+			v, err := p(sr)
+			if _, isFE := err.(fatalError); isFE {
+				delete(mr.table, key)
+				mr.Restore(s)
+				return v, err
+			}
+			if err != nil {
+				break
+			}
+			end, _ := offset(sr)
+			if best.err == nil && end <= bestOffset {
+				break
+			}
+			best = mr.result(v, nil, n)
+			bestOffset = end
+			mr.table[key] = best
+		}
+		mr.Restore(s)
+		mr.replay(best)
+		mr.entries = append(mr.entries, MemoEntry{Rule: name, Start: start, End: mr.Pos(), Matched: best.err == nil})
+		v, _ := best.value.(T)
+		return v, best.err
+	}
+}
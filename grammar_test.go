@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGrammarLeftRecursion(t *testing.T) {
+	g := NewGrammar()
+	num := Rule(g, "num", func() func(StatefulReader) (int, error) {
+		return Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	}, NoMemo())
+	var sum func(StatefulReader) (int, error)
+	sum = Rule(g, "sum", func() func(StatefulReader) (int, error) {
+		return Or(
+			Map3(sum, Set("+-"), num, func(l int, op string, r int) int {
+				if op == "-" {
+					return l - r
+				}
+				return l + r
+			}),
+			num,
+		)
+	}, LeftRecursive())
+	assert(t, g.Rules(), []string{"num", "sum"})
+
+	// left associative: (10-3)-2
+	v, c, err := ParseReader(sum, strings.NewReader("10-3-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, v, 5)
+	assert(t, c.Pos().Offset, int64(6))
+	if es := c.At(0); len(es) != 1 || es[0].Rule != "sum" || es[0].End.Offset != 6 {
+		t.Errorf("At(0) = %v", es)
+	}
+
+	v, c, err = ParseReader(sum, strings.NewReader("7+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, v, 7)
+	assert(t, c.Pos().Offset, int64(1))
+}
+
+func TestGrammarDepth(t *testing.T) {
+	g := NewGrammar()
+	g.MaxDepth = 50
+	var nested func(StatefulReader) (string, error)
+	nested = Rule(g, "nested", func() func(StatefulReader) (string, error) {
+		return Or(join(And(Lit("("), nested, Lit(")"))), Lit("x"))
+	})
+	if _, _, err := ParseReader(nested, strings.NewReader("((x))")); err != nil {
+		t.Error(err)
+	}
+	deep := strings.Repeat("(", 100) + "x" + strings.Repeat(")", 100)
+	_, _, err := ParseReader(Optional(nested), strings.NewReader(deep))
+	if !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got %v", err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Pos.Offset != 50 {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestGrammarDuplicate(t *testing.T) {
+	g := NewGrammar()
+	Rule(g, "a", func() func(StatefulReader) (string, error) { return Lit("a") })
+	defer func() {
+		if r := recover(); r != `parser: Rule: "a" registered twice` {
+			t.Errorf("got %v", r)
+		}
+	}()
+	Rule(g, "a", func() func(StatefulReader) (string, error) { return Lit("a") })
+}
+
+func TestGrammarsShareNames(t *testing.T) {
+	g1, g2 := NewGrammar(), NewGrammar()
+	w1 := Rule(g1, "word", func() func(StatefulReader) (string, error) { return Lit("ab") })
+	w2 := Rule(g2, "word", func() func(StatefulReader) (string, error) { return Lit("a") })
+	p := Or(And(w2, EOF()), And(w1, EOF()))
+	v, _, err := ParseReader(p, strings.NewReader("ab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, v, []string{"ab", ""})
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrTooLarge is returned when the input is longer than MaxInput allows.
+var ErrTooLarge = errors.New("Input too large")
+
+// ErrBudget is returned when a parse runs more Grammar rules than MaxSteps
+// allows. Like ErrTooDeep it ends the whole parse.
+var ErrBudget = errors.New("Parse budget exhausted")
+
+// Limits bounds the resources one parse may use. A zero field takes its
+// value from DefaultLimits, and a negative field disables that limit.
+type Limits struct {
+	// MaxInput is the longest input, in bytes, that will be parsed.
+	MaxInput int64
+	// MaxDepth is how deeply Grammar rules may nest.
+	MaxDepth int
+	// MaxSteps is how many Grammar rules may run, not counting memo hits.
+	MaxSteps int
+	// MaxLookahead is how far the parse may backtrack, in bytes.
+	MaxLookahead int64
+	// MaxErrors is how many errors Resync may recover from.
+	MaxErrors int
+}
+
+// DefaultLimits are the limits Hardened uses for fields left zero. They
+// suit documents of up to a megabyte from grammars whose choices commit
+// within a few kilobytes.
+var DefaultLimits = Limits{
+	MaxInput:     1 << 20,
+	MaxDepth:     200,
+	MaxSteps:     1 << 24,
+	MaxLookahead: 4096,
+	MaxErrors:    10,
+}
+
+// Hardened is a profile for servers parsing untrusted input: it applies
+// every limit in l, filling in DefaultLimits, and recovers from panics, so
+// that a hostile document fails quickly with an error rather than using
+// unbounded time, memory or stack. Depth and step limits only see rules
+// registered with a Grammar, so the grammar should be built from them.
+func Hardened(l Limits) Option {
+	pick := func(v, def int64) int64 {
+		switch {
+		case v == 0:
+			return def
+		case v < 0:
+			return 0
+		}
+		return v
+	}
+	d := DefaultLimits
+	l = Limits{
+		MaxInput:     pick(l.MaxInput, d.MaxInput),
+		MaxDepth:     int(pick(int64(l.MaxDepth), int64(d.MaxDepth))),
+		MaxSteps:     int(pick(int64(l.MaxSteps), int64(d.MaxSteps))),
+		MaxLookahead: pick(l.MaxLookahead, d.MaxLookahead),
+		MaxErrors:    int(pick(int64(l.MaxErrors), int64(d.MaxErrors))),
+	}
+	return func(o *options) {
+		o.recover = true
+		o.maxInput = l.MaxInput
+		o.depth = l.MaxDepth
+		o.steps = l.MaxSteps
+		o.lookahead = l.MaxLookahead
+		o.maxErrors = l.MaxErrors
+		if l.MaxErrors == 0 {
+			o.maxErrors = -1
+		}
+	}
+}
+
+// MaxInput refuses input longer than n bytes with ErrTooLarge, before
+// parsing starts.
+func MaxInput(n int64) Option {
+	return func(o *options) {
+		o.maxInput = n
+	}
+}
+
+// MaxDepth lowers the nesting limit of Grammar rules to n for a parse, for
+// grammars whose own MaxDepth is more generous than a caller wants.
+func MaxDepth(n int) Option {
+	return func(o *options) {
+		o.depth = n
+	}
+}
+
+// MaxSteps abandons a parse with ErrBudget once it has run n Grammar rules,
+// bounding the work any input can cause.
+func MaxSteps(n int) Option {
+	return func(o *options) {
+		o.steps = n
+	}
+}
+
+// checkSize enforces MaxInput on the input remaining in sr, when its length
+// can be found.
+func (o options) checkSize(c *Context, sr StatefulReader) error {
+	if o.maxInput <= 0 {
+		return nil
+	}
+	if n, ok := inputSize(sr); ok && n > o.maxInput {
+		return &ParseError{Pos: c.Pos(), Err: ErrTooLarge}
+	}
+	return nil
+}
+
+func inputSize(sr StatefulReader) (int64, bool) {
+	switch r := sr.(type) {
+	case *BytesReader:
+		return int64(len(r.data) - r.off), true
+	case SimpleReader:
+		cur, err := r.r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		end, err := r.r.Seek(0, io.SeekEnd)
+		r.r.Seek(cur, io.SeekStart)
+		return end - cur, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func nestedGrammar() func(StatefulReader) (string, error) {
+	g := NewGrammar()
+	var nested func(StatefulReader) (string, error)
+	nested = Rule(g, "nested", func() func(StatefulReader) (string, error) {
+		return Or(join(And(Lit("("), nested, Lit(")"))), Lit("x"))
+	})
+	return nested
+}
+
+func nest(n int) string {
+	return strings.Repeat("(", n) + "x" + strings.Repeat(")", n)
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+	nested := nestedGrammar()
+	for _, c := range []struct {
+		name string
+		in   string
+		opt  Option
+		err  error
+	}{
+		{"depth", nest(10), MaxDepth(5), ErrTooDeep},
+		{"depth ok", nest(4), MaxDepth(5), nil},
+		{"steps", nest(10), MaxSteps(5), ErrBudget},
+		{"steps ok", nest(4), MaxSteps(5), nil},
+		{"input", nest(10), MaxInput(20), ErrTooLarge},
+		{"input ok", nest(10), MaxInput(21), nil},
+	} {
+		if _, _, err := ParseBytes(nested, []byte(c.in), c.opt); !errors.Is(err, c.err) {
+			t.Errorf("%s: ParseBytes got %v, want %v", c.name, err, c.err)
+		}
+		if _, _, err := ParseReader(nested, strings.NewReader(c.in), c.opt); !errors.Is(err, c.err) {
+			t.Errorf("%s: ParseReader got %v, want %v", c.name, err, c.err)
+		}
+		comp := Compile(nested, c.opt)
+		for i := 0; i < 2; i++ {
+			if _, err := comp.RunString(c.in); !errors.Is(err, c.err) {
+				t.Errorf("%s: run %d got %v, want %v", c.name, i, err, c.err)
+			}
+		}
+	}
+}
+
+func TestHardened(t *testing.T) {
+	t.Parallel()
+	nested := nestedGrammar()
+	if _, _, err := ParseBytes(nested, []byte(nest(100)), Hardened(Limits{})); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := ParseBytes(nested, []byte(nest(300)), Hardened(Limits{})); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got %v", err)
+	}
+	if _, _, err := ParseBytes(nested, []byte(nest(300)), Hardened(Limits{MaxDepth: -1})); err != nil {
+		t.Errorf("depth limit not disabled: %v", err)
+	}
+	if _, _, err := ParseBytes(nested, []byte(nest(100)), Hardened(Limits{MaxInput: 100})); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v", err)
+	}
+	boom := func(StatefulReader) (string, error) { panic("boom") }
+	if _, _, err := ParseBytes(boom, nil, Hardened(Limits{})); err == nil {
+		t.Error("panic not recovered")
+	}
+}
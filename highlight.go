@@ -0,0 +1,55 @@
+package parser
+
+import "sort"
+
+// Class is the highlighting class of a span of input.
+type Class string
+
+const (
+	ClassKeyword  Class = "keyword"
+	ClassIdent    Class = "ident"
+	ClassNumber   Class = "number"
+	ClassString   Class = "string"
+	ClassComment  Class = "comment"
+	ClassOperator Class = "operator"
+	ClassPunct    Class = "punct"
+)
+
+// Span is a classified range of input.
+type Span struct {
+	Class      Class
+	Start, End Position
+}
+
+// Classify marks the input p matches as class. When the parse is run with
+// the Highlight option the spans are recorded, in input order, for
+// Context.Spans, and spans from alternatives that were backtracked over are
+// dropped. Otherwise Classify just runs p. Classify terminals such as
+// keywords, numbers and strings rather than whole rules: spans inside
+// another span are kept, but renderers generally expect them not to nest.
+func Classify[T any](class Class, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Classify", p)
+	return func(sr StatefulReader) (T, error) {
+		m, ok := sr.(interface{ memo() *MemoReader })
+		if !ok || !m.memo().highlight {
+			return p(sr)
+		}
+		mr := m.memo()
+		start := mr.Pos()
+		v, err := p(sr)
+		if err == nil && mr.Pos().Offset > start.Offset {
+			mr.spans = append(mr.spans, Span{Class: class, Start: start, End: mr.Pos()})
+		}
+		return v, err
+	}
+}
+
+// Spans returns the spans recorded by Classify during a parse run with the
+// Highlight option.
+func (mr *MemoReader) Spans() []Span {
+	spans := append([]Span{}, mr.spans...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Start.Offset < spans[j].Start.Offset
+	})
+	return spans
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	ws := join(Mult(0, 0, Set(" ")))
+	ident := Memo("ident", Classify(ClassIdent, join(Mult(1, 0, Set("a-z")))))
+	num := Classify(ClassNumber, join(Mult(1, 0, Set("0-9"))))
+	value := Or(num, ident)
+	// "let" is tried as a call first, which fails after classifying it
+	call := join(And(ident, Classify(ClassPunct, Lit("(")), value, Lit(")")))
+	let := join(And(Classify(ClassKeyword, Lit("let")), ws, ident, ws, Classify(ClassOperator, Lit("=")), ws, value))
+	stmt := Or(call, let)
+
+	_, c, err := ParseReader(stmt, strings.NewReader("let x = 42"), Highlight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, s := range c.Spans() {
+		got = append(got, fmt.Sprintf("%s %d-%d", s.Class, s.Start.Offset, s.End.Offset))
+	}
+	assert(t, got, []string{"keyword 0-3", "ident 4-5", "operator 6-7", "number 8-10"})
+
+	_, c, err = ParseReader(stmt, strings.NewReader("f(y)"), Highlight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, s := range c.Spans() {
+		got = append(got, fmt.Sprintf("%s %d-%d", s.Class, s.Start.Offset, s.End.Offset))
+	}
+	assert(t, got, []string{"ident 0-1", "punct 1-2", "ident 2-3"})
+
+	// the second alternative gets ident from the memo table
+	_, c, err = ParseReader(Or(join(And(ident, Lit("!"))), ident), strings.NewReader("abc"), Highlight())
+	if err != nil || len(c.Spans()) != 1 || c.Spans()[0].End.Offset != 3 {
+		t.Errorf("got %v, %v", c.Spans(), err)
+	}
+
+	_, c, _ = ParseReader(stmt, strings.NewReader("let x = 1"))
+	assert(t, len(c.Spans()), 0)
+}
@@ -0,0 +1,79 @@
+package parser
+
+// Lits matches the first of texts that the input starts with, like
+// Or(Lit(texts[0]), Lit(texts[1]), ...), but merged into one matcher that
+// only tries the literals starting with the next byte. Use it for keyword
+// and operator tables, where a chain of Lits tries every entry at every
+// position. List longer literals that share a prefix with shorter ones
+// first, as with Or.
+//
+// Grammars are closures, so Compile can't find and merge such chains
+// itself; Lits is the hand-applied form of that optimization.
+func Lits(texts ...string) func(sr StatefulReader) (string, error) {
+	if len(texts) == 0 {
+		panic("parser: Lits: no literals")
+	}
+	var byFirst [256][]string
+	expected := make([]Expectation, len(texts))
+	for i, text := range texts {
+		if text == "" {
+			panic("parser: Lits: empty text")
+		}
+		byFirst[text[0]] = append(byFirst[text[0]], text)
+		expected[i] = Expectation{Kind: ExpectLiteral, Text: text}
+	}
+	return func(sr StatefulReader) (string, error) {
+		if c, ok := peekByte(sr); ok {
+			for _, text := range byFirst[c] {
+				if matchText(sr, text) {
+					return text, nil
+				}
+			}
+		}
+		pos := Pos(sr)
+		for _, e := range expected {
+			expect(sr, pos, e)
+		}
+		return "", msg(MsgNoMatch)
+	}
+}
+
+// peekByte returns the next byte without consuming it.
+func peekByte(sr StatefulReader) (byte, bool) {
+	if pk, ok := sr.(peeker); ok {
+		if b, ok := pk.PeekBytes(1); ok {
+			if len(b) == 0 {
+				return 0, false
+			}
+			return b[0], true
+		}
+	}
+	s := save(sr)
+	defer s.restore(sr)
+	buf := getScratch(1)
+	defer putScratch(buf)
+	n, _ := sr.Read(*buf)
+	return (*buf)[0], n == 1
+}
+
+// matchText consumes text if the input starts with it, recording no
+// expectation if it doesn't.
+func matchText(sr StatefulReader, text string) bool {
+	if pk, ok := sr.(peeker); ok {
+		if b, ok := pk.PeekBytes(len(text)); ok {
+			if string(b) != text {
+				return false
+			}
+			pk.Advance(len(text))
+			return true
+		}
+	}
+	s := save(sr)
+	buf := getScratch(len(text))
+	defer putScratch(buf)
+	if _, ok := readPrefix(sr, *buf, text, false); ok {
+		return true
+	}
+	s.restore(sr)
+	return false
+}
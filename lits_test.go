@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLits(t *testing.T) {
+	t.Parallel()
+	ops := []string{"<<=", "<=", "<<", "<", "==", "=", "!="}
+	merged := Lits(ops...)
+	chain := []func(StatefulReader) (string, error){}
+	for _, op := range ops {
+		chain = append(chain, Lit(op))
+	}
+	or := Or(chain...)
+	for _, in := range []string{"<<=", "<=x", "<<1", "<", "==", "=", "!=", "!", "x", ""} {
+		mv, mc, merr := ParseBytes(merged, []byte(in))
+		ov, oc, oerr := ParseBytes(or, []byte(in))
+		if mv != ov || !reflect.DeepEqual(merr, oerr) || mc.Pos() != oc.Pos() {
+			t.Errorf("%q: Lits gave %q, %v at %v; Or gave %q, %v at %v", in, mv, merr, mc.Pos(), ov, oerr, oc.Pos())
+		}
+		v, err := merged(NewSimpleReader(strings.NewReader(in)))
+		if v != ov || (err == nil) != (oerr == nil) {
+			t.Errorf("%q: on a SimpleReader got %q, %v", in, v, err)
+		}
+	}
+}
+
+func BenchmarkLits(b *testing.B) {
+	words := strings.Fields("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var")
+	chain := []func(StatefulReader) (string, error){}
+	for _, w := range words {
+		chain = append(chain, Lit(w))
+	}
+	for name, p := range map[string]func(StatefulReader) (string, error){
+		"Or":   Or(chain...),
+		"Lits": Lits(words...),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p(NewBytesReader([]byte("var")))
+			}
+		})
+	}
+}
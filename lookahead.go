@@ -0,0 +1,42 @@
+package parser
+
+import "errors"
+
+// ErrLookahead is the error a parse is abandoned with when it backtracks
+// further than MaxLookahead allows.
+var ErrLookahead = errors.New("Lookahead limit exceeded")
+
+// MaxLookahead limits backtracking to n bytes: once the parse has read a
+// byte, it may not rewind to more than n bytes before it, so every choice
+// must commit within n bytes of where it started. A parse that breaks the
+// limit is abandoned with ErrLookahead at the position it rewound to, as
+// with ErrTooDeep, so a grammar that needs unbounded lookahead fails fast
+// on untrusted input instead of rereading it. Memoized and peeked input
+// doesn't count, only input that is read again.
+//
+// The limit is checked as the grammar runs; closures can't be inspected
+// for their lookahead before a parse.
+func MaxLookahead(n int64) Option {
+	return func(o *options) {
+		o.lookahead = n
+	}
+}
+
+// backtracked counts a rewind from offset from and checks it against the
+// lookahead limit. reach is the furthest offset rewound from so far, since
+// offsets only grow between rewinds. Breaking the limit sets abort, after
+// which reads fail, so plain combinators give up as soon as they next read
+// rather than running on.
+func (mr *MemoReader) backtracked(from int64) {
+	pos := mr.Pos()
+	mr.rewound += from - pos.Offset
+	if mr.lookahead <= 0 {
+		return
+	}
+	if from > mr.reach {
+		mr.reach = from
+	}
+	if mr.reach-pos.Offset > mr.lookahead && mr.abort == nil {
+		mr.abort = &ParseError{Pos: pos, Err: ErrLookahead}
+	}
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxLookahead(t *testing.T) {
+	t.Parallel()
+	word := strings.Repeat("a", 10)
+	p := Or(And(Lit(word), Lit("!")), And(Lit(word), Lit("?")))
+	for _, c := range []struct {
+		in    string
+		limit int64
+		err   error
+	}{
+		{word + "?", 0, nil},
+		{word + "?", 10, nil},
+		{word + "?", 9, ErrLookahead},
+		{word + "!", 9, nil},
+	} {
+		_, _, err := ParseBytes(p, []byte(c.in), MaxLookahead(c.limit))
+		if !errors.Is(err, c.err) {
+			t.Errorf("%q with limit %d: got %v, want %v", c.in, c.limit, err, c.err)
+		}
+	}
+
+	// Rewinds are measured from the furthest byte read, so a rewind within
+	// the limit doesn't hide how far an enclosing one goes back.
+	nested := Or(Right(Lit("aaaaa"), Optional(Right(Lit("aaaaa"), Lit("!")))), Lit("b"))
+	_, _, err := ParseBytes(Left(nested, EOF()), []byte("aaaaaaaaaa?"), MaxLookahead(6))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Err != ErrLookahead || pe.Pos.Offset != 0 {
+		t.Errorf("nested: got %v", err)
+	}
+}
+
+func TestMaxLookaheadCompiled(t *testing.T) {
+	t.Parallel()
+	p := Or(And(Lit("aaaa"), Lit("!")), And(Lit("aaaa"), Lit("?")))
+	c := Compile(p, MaxLookahead(3))
+	for i := 0; i < 2; i++ {
+		if _, err := c.RunString("aaaa!"); err != nil {
+			t.Errorf("run %d: %v", i, err)
+		}
+		if _, err := c.RunString("aaaa?"); !errors.Is(err, ErrLookahead) {
+			t.Errorf("run %d: got %v", i, err)
+		}
+	}
+}
+
+func TestMaxLookaheadStops(t *testing.T) {
+	t.Parallel()
+	// each level tries its inner level twice, so without the limit this
+	// takes 2^20 calls
+	calls := 0
+	var level func(StatefulReader) (string, error)
+	inner := Lazy(func() func(StatefulReader) (string, error) { return level })
+	alts := Or(join(And(Lit("a"), inner, Lit("!"))), join(And(Lit("a"), inner, Lit("?"))), Lit("b"))
+	level = func(sr StatefulReader) (string, error) {
+		calls++
+		return alts(sr)
+	}
+	_, _, err := ParseBytes(level, []byte(strings.Repeat("a", 20)+"b"), MaxLookahead(4))
+	if !errors.Is(err, ErrLookahead) {
+		t.Errorf("got %v", err)
+	}
+	if calls > 1000 {
+		t.Errorf("%d calls after the limit was broken", calls)
+	}
+}
@@ -0,0 +1,256 @@
+package parser
+
+import "sort"
+
+// ruleID identifies one memoized rule, so that rules from different
+// grammars that share a name don't share results. It isn't empty, so
+// that each one has its own address.
+type ruleID struct {
+	name string
+}
+
+type memoKey struct {
+	rule  *ruleID
+	state any
+}
+
+type memoResult struct {
+	value any
+	err   error
+	end   any
+	// spans are the highlighting spans the rule emitted, and errs the
+	// errors it recovered from
+	spans []Span
+	errs  []*ParseError
+}
+
+// MemoEntry records the outcome of a memoized rule at one start position.
+// End equals Start when the rule failed.
+type MemoEntry struct {
+	Rule       string
+	Start, End Position
+	Matched    bool
+}
+
+// MemoReader wraps a StatefulReader with a memo table for rules built with
+// Memo. Create one per parse. Wrap a PosReader to get meaningful positions
+// in the table.
+type MemoReader struct {
+	sr      StatefulReader
+	table   map[memoKey]memoResult
+	entries []MemoEntry
+	// depth is the current nesting of Grammar rules
+	depth int
+	// abort, once set, fails every Grammar rule and read so the parse
+	// unwinds without doing any more work
+	abort error
+	// highlight turns on recording of Classify spans, which are then part
+	// of the reader state so that backtracking discards them
+	highlight bool
+	spans     []Span
+	// errs is the owning Context's Errors, if any, whose length is also
+	// part of the reader state
+	errs *ErrorList
+	// grammar is the Grammar whose rule is running, and strategy the
+	// Strategy in force for its Choices
+	grammar  *Grammar
+	strategy Strategy
+	// lookahead, if positive, is the MaxLookahead limit, and reach the
+	// furthest offset backtracked from
+	lookahead int64
+	reach     int64
+	// countRewinds totals the bytes backtracked over in rewound, for
+	// Observe
+	countRewinds bool
+	rewound      int64
+	// maxDepth and maxSteps are the MaxDepth and MaxSteps limits, if
+	// positive, and steps the number of rules run so far
+	maxDepth int
+	maxSteps int
+	steps    int
+}
+
+type spanState struct {
+	inner any
+	logs
+}
+
+// logs counts the spans and errors recorded so far, which backtracking
+// truncates back to.
+type logs struct {
+	spans  int
+	errors int
+}
+
+func (mr *MemoReader) logs() logs {
+	l := logs{spans: len(mr.spans)}
+	if mr.errs != nil {
+		l.errors = len(*mr.errs)
+	}
+	return l
+}
+
+func (mr *MemoReader) truncate(l logs) {
+	mr.spans = mr.spans[:l.spans]
+	// an abandoned parse unwinds through restores, but its errors are
+	// what gets reported
+	if mr.errs != nil && mr.abort == nil {
+		*mr.errs = (*mr.errs)[:l.errors]
+	}
+}
+
+func NewMemoReader(sr StatefulReader) *MemoReader {
+	return &MemoReader{
+		sr:    sr,
+		table: map[memoKey]memoResult{},
+	}
+}
+
+// reset readies mr to read sr as a new MemoReader would, keeping its
+// allocations and the Context it belongs to.
+func (mr *MemoReader) reset(sr StatefulReader) {
+	for k := range mr.table {
+		delete(mr.table, k)
+	}
+	*mr = MemoReader{
+		sr:      sr,
+		table:   mr.table,
+		entries: mr.entries[:0],
+		spans:   mr.spans[:0],
+		errs:    mr.errs,
+	}
+}
+
+func (mr *MemoReader) Read(p []byte) (int, error) {
+	if mr.abort != nil {
+		return 0, mr.abort
+	}
+	return mr.sr.Read(p)
+}
+
+func (mr *MemoReader) State() any {
+	if mr.highlight || mr.errs != nil {
+		return spanState{inner: mr.sr.State(), logs: mr.logs()}
+	}
+	return mr.sr.State()
+}
+
+func (mr *MemoReader) Restore(s any) {
+	if mr.lookahead > 0 || mr.countRewinds {
+		defer mr.backtracked(mr.Pos().Offset)
+	}
+	if ss, ok := s.(spanState); ok {
+		mr.sr.Restore(ss.inner)
+		mr.truncate(ss.logs)
+		return
+	}
+	mr.sr.Restore(s)
+}
+
+// result captures the outcome of a rule that started with l recorded.
+// Memo keys and results use the wrapped reader's state, so that a hit can
+// be replayed whatever spans and errors have been recorded since.
+func (mr *MemoReader) result(v any, err error, l logs) memoResult {
+	r := memoResult{value: v, err: err, end: mr.sr.State()}
+	if len(mr.spans) > l.spans {
+		r.spans = append([]Span{}, mr.spans[l.spans:]...)
+	}
+	if mr.errs != nil && len(*mr.errs) > l.errors {
+		r.errs = append([]*ParseError{}, (*mr.errs)[l.errors:]...)
+	}
+	return r
+}
+
+// replay moves the reader to the end of r and re-records its spans and
+// errors.
+func (mr *MemoReader) replay(r memoResult) {
+	mr.sr.Restore(r.end)
+	mr.spans = append(mr.spans, r.spans...)
+	if mr.errs != nil {
+		*mr.errs = append(*mr.errs, r.errs...)
+	}
+}
+
+func (mr *MemoReader) memo() *MemoReader {
+	return mr
+}
+
+// SetLine forwards to the wrapped reader, if it tracks positions.
+func (mr *MemoReader) SetLine(file string, line int) {
+	SetLine(mr.sr, file, line)
+}
+
+// Pos returns the position of the wrapped reader, if it tracks one.
+func (mr *MemoReader) Pos() Position {
+	return Pos(mr.sr)
+}
+
+// Entries returns every memoized result, ordered by start offset and then
+// by the order the rules were first tried.
+func (mr *MemoReader) Entries() []MemoEntry {
+	es := append([]MemoEntry{}, mr.entries...)
+	sort.SliceStable(es, func(i, j int) bool {
+		return es[i].Start.Offset < es[j].Start.Offset
+	})
+	return es
+}
+
+// At returns the rules that matched starting at offset.
+func (mr *MemoReader) At(offset int64) []MemoEntry {
+	es := []MemoEntry{}
+	for _, e := range mr.Entries() {
+		if e.Matched && e.Start.Offset == offset {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// Covering returns the rules whose match includes the byte at offset,
+// innermost (shortest) first, which is the usual order for hover and
+// highlighting.
+func (mr *MemoReader) Covering(offset int64) []MemoEntry {
+	es := []MemoEntry{}
+	for _, e := range mr.Entries() {
+		if e.Matched && e.Start.Offset <= offset && offset < e.End.Offset {
+			es = append(es, e)
+		}
+	}
+	sort.SliceStable(es, func(i, j int) bool {
+		return es[i].End.Offset-es[i].Start.Offset < es[j].End.Offset-es[j].Start.Offset
+	})
+	return es
+}
+
+// Memo caches the result of p for each start position when run on a
+// MemoReader or Context, so backtracking grammars don't reparse the same rule
+// at the same place. On any other reader it just runs p. Each call to Memo
+// gets its own memo table entries; the name labels them in Entries.
+func Memo[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	id := &ruleID{name: name}
+	return func(sr StatefulReader) (T, error) {
+		m, ok := sr.(interface{ memo() *MemoReader })
+		if !ok {
+			return p(sr)
+		}
+		mr := m.memo()
+		key := memoKey{rule: id, state: mr.sr.State()}
+		if r, ok := mr.table[key]; ok {
+			mr.replay(r)
+			// id is only used with T, so this fails only for a nil
+			// interface value
+			v, _ := r.value.(T)
+			return v, r.err
+		}
+		s, n := mr.State(), mr.logs()
+		start := mr.Pos()
+		v, err := p(sr)
+		if err != nil {
+			mr.Restore(s)
+		}
+		e := MemoEntry{Rule: name, Start: start, End: mr.Pos(), Matched: err == nil}
+		mr.table[key] = mr.result(v, err, n)
+		mr.entries = append(mr.entries, e)
+		return v, err
+	}
+}
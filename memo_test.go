@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemo(t *testing.T) {
+	calls := 0
+	word := Memo("word", func(sr StatefulReader) (string, error) {
+		calls++
+		return join(Mult(1, 0, Set("a-z")))(sr)
+	})
+	num := Memo("num", join(Mult(1, 0, Set("0-9"))))
+	call := Memo("call", join(And(word, Lit("("), num, Lit(")"))))
+	p := Or(call, word)
+
+	mr := NewMemoReader(NewPosReader(NewSimpleReader(strings.NewReader("foo(x)"))))
+	v, err := p(mr)
+	if err != nil || v != "foo" {
+		t.Fatalf("got %q, %v", v, err)
+	}
+	if calls != 1 {
+		t.Errorf("word ran %d times, want 1", calls)
+	}
+	if mr.Pos().Offset != 3 {
+		t.Errorf("reader left at %s", mr.Pos())
+	}
+
+	at := mr.At(0)
+	if len(at) != 1 || at[0].Rule != "word" || at[0].End.Offset != 3 {
+		t.Errorf("At(0) = %v", at)
+	}
+	if es := mr.At(4); len(es) != 0 {
+		t.Errorf("At(4) = %v", es)
+	}
+	es := mr.Entries()
+	if len(es) != 3 || es[2].Rule != "num" || es[2].Matched || es[2].Start.Offset != 4 {
+		t.Errorf("Entries() = %v", es)
+	}
+
+	if v, err := p(NewSimpleReader(strings.NewReader("bar"))); err != nil || v != "bar" {
+		t.Errorf("without MemoReader got %q, %v", v, err)
+	}
+}
+
+func TestMemoCovering(t *testing.T) {
+	word := Memo("word", join(Mult(1, 0, Set("a-z"))))
+	pair := Memo("pair", join(And(word, Lit("="), word)))
+	mr := NewMemoReader(NewPosReader(NewSimpleReader(strings.NewReader("ab=cd"))))
+	if _, err := pair(mr); err != nil {
+		t.Fatal(err)
+	}
+	es := mr.Covering(3)
+	if len(es) != 2 || es[0].Rule != "word" || es[0].Start.Offset != 3 || es[1].Rule != "pair" {
+		t.Errorf("Covering(3) = %v", es)
+	}
+	if es := mr.Covering(5); len(es) != 0 {
+		t.Errorf("Covering(5) = %v", es)
+	}
+}
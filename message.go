@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Message keys for the errors the built in combinators return. The
+// arguments each takes are listed with the key.
+const (
+	MsgUnexpectedEOF  = "unexpected-eof"  // none
+	MsgExpected       = "expected"        // wanted, got: strings
+	MsgUnexpected     = "unexpected"      // got: string
+	MsgExpectedEOF    = "expected-eof"    // got: string
+	MsgNoMatch        = "no-match"        // none
+	MsgTooFew         = "too-few"         // wanted, got: ints
+	MsgExpectedElem   = "expected-elem"   // wanted, got: elements
+	MsgUnexpectedElem = "unexpected-elem" // got: element
+	MsgFeatureOff     = "feature-off"     // feature: string
+	MsgFeatureOn      = "feature-on"      // feature: string
+	MsgTooOld         = "too-old"         // wanted, got: ints
+	MsgExpectedName   = "expected-name"   // name, got: strings
+	MsgColumn         = "column"          // wanted, got: ints
+	MsgNoCase         = "no-case"         // key: any
+)
+
+var messages = map[string]string{
+	MsgUnexpectedEOF:  "Unexpected EOF",
+	MsgExpected:       "Expected %q, got %q",
+	MsgUnexpected:     "Unexpected %q",
+	MsgExpectedEOF:    "Expected EOF, got %q",
+	MsgNoMatch:        "No match",
+	MsgTooFew:         "Expected at least %d items, got %d",
+	MsgExpectedElem:   "Expected %v, got %v",
+	MsgUnexpectedElem: "Unexpected %v",
+	MsgFeatureOff:     "Requires feature %q",
+	MsgFeatureOn:      "Not allowed with feature %q",
+	MsgTooOld:         "Requires version %d, parsing version %d",
+	MsgExpectedName:   "Expected %s, got %q",
+	MsgColumn:         "Expected column %d, at column %d",
+	MsgNoCase:         "No case for %v",
+}
+
+// MessageError is an error identified by a key and its arguments rather
+// than by English text, so applications can render it in other languages.
+// Error renders it in English.
+type MessageError struct {
+	Key  string
+	Args []any
+}
+
+func msg(key string, args ...any) error {
+	return &MessageError{Key: key, Args: args}
+}
+
+func (e *MessageError) Error() string {
+	return fmt.Sprintf(messages[e.Key], e.Args...)
+}
+
+// Renderer renders a message from its key and arguments. Returning "" falls
+// back to English.
+type Renderer interface {
+	Render(key string, args []any) string
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(key string, args []any) string
+
+func (f RendererFunc) Render(key string, args []any) string {
+	return f(key, args)
+}
+
+// Localize renders err with r, recursing through the error types of this
+// package so that positions and rule names are kept. Errors it does not
+// know are rendered with their Error method.
+func Localize(err error, r Renderer) string {
+	switch e := err.(type) {
+	case *MessageError:
+		if s := r.Render(e.Key, e.Args); s != "" {
+			return s
+		}
+		return e.Error()
+	case *ParseError:
+		return fmt.Sprintf("%s: %s", e.Pos, Localize(e.Err, r))
+	case *NamedError:
+		return fmt.Sprintf("%s: in %s: %s", e.Pos, e.Name, Localize(e.Err, r))
+	case fatalError:
+		return Localize(e.err, r)
+	case ErrorList:
+		lines := make([]string, len(e))
+		for i, pe := range e {
+			lines[i] = Localize(pe, r)
+		}
+		return strings.Join(lines, "\n")
+	}
+	var me *MessageError
+	if errors.As(err, &me) {
+		// wrapped by something we can't rebuild; render the message alone
+		return Localize(me, r)
+	}
+	return err.Error()
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var german = RendererFunc(func(key string, args []any) string {
+	switch key {
+	case MsgExpected:
+		return fmt.Sprintf("%q erwartet, %q gefunden", args...)
+	case MsgUnexpectedEOF:
+		return "Unerwartetes Dateiende"
+	}
+	return ""
+})
+
+func TestLocalize(t *testing.T) {
+	_, err := parse("b", Lit("a"))
+	var me *MessageError
+	if !errors.As(err, &me) || me.Key != MsgExpected {
+		t.Fatalf("got %#v", err)
+	}
+	assert(t, err.Error(), `Expected "a", got "b"`)
+	assert(t, Localize(err, german), `"a" erwartet, "b" gefunden`)
+
+	p := Named("greeting", join(And(Lit("hi"), Lit(" "), Set("a-z"))))
+	_, err = p(NewPosReader(NewSimpleReader(strings.NewReader("hi"))))
+	assert(t, Localize(err, german), "1:1: in greeting: Unerwartetes Dateiende")
+
+	_, err = parse("x", Or(Lit("a"), Lit("b")))
+	assert(t, Localize(err, german), "No match")
+
+	wrapped := fmt.Errorf("reading config: %w", msg(MsgExpected, "=", ":"))
+	assert(t, Localize(wrapped, german), `"=" erwartet, ":" gefunden`)
+	assert(t, Localize(errors.New("plain"), german), "plain")
+}
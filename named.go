@@ -0,0 +1,130 @@
+package parser
+
+import "fmt"
+
+// NamedError is the error of a Named parser, giving the name and the
+// position the parser started at.
+type NamedError struct {
+	Name string
+	Pos  Position
+	Err  error
+}
+
+func (e *NamedError) Error() string {
+	return fmt.Sprintf("%s: in %s: %s", e.Pos, e.Name, e.Err)
+}
+
+func (e *NamedError) Unwrap() error {
+	return e.Err
+}
+
+// TraceEvent is passed to a Context's Trace hook when a Named parser or
+// Grammar rule starts (Exit false) and when it finishes (Exit true, with End
+// and Err set).
+type TraceEvent struct {
+	Name       string
+	Exit       bool
+	Start, End Position
+	Err        error
+	// Depth is the number of traced parsers already running.
+	Depth int
+	// Watches holds the values of the Context's watch expressions when the
+	// event happened, in the order they were registered.
+	Watches []Watched
+}
+
+// Watched is the value of a watch expression registered with Watch.
+type Watched struct {
+	Name  string
+	Value any
+}
+
+type watch struct {
+	name string
+	f    func(c *Context) any
+}
+
+// Watch registers a watch expression, evaluated at every trace event and
+// passed to the Trace hook in TraceEvent.Watches, for following user state
+// such as a symbol table's size or the current indentation level while
+// debugging a context-sensitive grammar. Watches are only evaluated while
+// there is a Trace hook.
+func Watch(name string, f func(c *Context) any) Option {
+	return func(o *options) {
+		o.watches = append(o.watches, watch{name, f})
+	}
+}
+
+// watched evaluates c's watch expressions.
+func (c *Context) watched() []Watched {
+	if len(c.watches) == 0 {
+		return nil
+	}
+	ws := make([]Watched, len(c.watches))
+	for i, w := range c.watches {
+		ws[i] = Watched{w.name, w.f(c)}
+	}
+	return ws
+}
+
+// Named attaches a stable name to p for diagnostics. Errors from p are
+// wrapped in a *NamedError, and when running on a Context with a Trace hook
+// the hook sees p start and finish. If p fails without getting past its
+// first terminal, the parse's expected list names p instead of those
+// terminals.
+func Named[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Named", p)
+	return contracted(name, func(sr StatefulReader) (T, error) {
+		start := Pos(sr)
+		c := ContextOf(sr)
+		c.enter(name, start)
+		l := c.label(start)
+		v, err := profiled(c, name, p, sr)
+		if err != nil {
+			l.relabel(c, sr, Expectation{Kind: ExpectRule, Text: name})
+		}
+		if err != nil {
+			if fe, isFE := err.(fatalError); isFE {
+				err = fatalError{&NamedError{Name: name, Pos: start, Err: fe.err}}
+			} else {
+				err = &NamedError{Name: name, Pos: start, Err: err}
+			}
+		}
+		c.exit(name, start, Pos(sr), err)
+		return v, err
+	})
+}
+
+// traced reports p to the Context's Trace hook as it starts and finishes,
+// and labels it for ProfileRules, as Named does, for Grammar rules.
+func traced[T any](name string, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		c := ContextOf(sr)
+		if c == nil || c.Trace == nil && c.profile == nil {
+			return p(sr)
+		}
+		start := Pos(sr)
+		c.enter(name, start)
+		v, err := profiled(c, name, p, sr)
+		c.exit(name, start, Pos(sr), err)
+		return v, err
+	}
+}
+
+// enter and exit report a parser starting and finishing to c's Trace hook,
+// if c has one.
+func (c *Context) enter(name string, start Position) {
+	if c == nil || c.Trace == nil {
+		return
+	}
+	c.Trace(TraceEvent{Name: name, Start: start, Depth: c.traceDepth, Watches: c.watched()})
+	c.traceDepth++
+}
+
+func (c *Context) exit(name string, start, end Position, err error) {
+	if c == nil || c.Trace == nil {
+		return
+	}
+	c.traceDepth--
+	c.Trace(TraceEvent{Name: name, Exit: true, Start: start, End: end, Err: err, Depth: c.traceDepth, Watches: c.watched()})
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	ident := Named("ident", join(Mult(1, 0, Set("a-z"))))
+	assign := Named("assign", join(And(ident, Lit("="), ident)))
+
+	sr := NewPosReader(NewSimpleReader(strings.NewReader("a=1")))
+	_, err := assign(sr)
+	var ne *NamedError
+	if !errors.As(err, &ne) || ne.Name != "assign" {
+		t.Fatalf("got %v", err)
+	}
+	if err.Error() != `1:1: in assign: 1:3: in ident: Expected "a-z", got "1"` {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	c := NewContext(strings.NewReader("a=b"))
+	trace := []string{}
+	c.Trace = func(e TraceEvent) {
+		if e.Exit {
+			trace = append(trace, fmt.Sprintf("%*s%s %s-%s %v", e.Depth*2, "", e.Name, e.Start, e.End, e.Err))
+		} else {
+			trace = append(trace, fmt.Sprintf("%*s%s %s", e.Depth*2, "", e.Name, e.Start))
+		}
+	}
+	if _, err := assign(c); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, trace, []string{
+		"assign 1:1",
+		"  ident 1:1",
+		"  ident 1:1-1:2 <nil>",
+		"  ident 1:3",
+		"  ident 1:3-1:4 <nil>",
+		"assign 1:1-1:4 <nil>",
+	})
+}
+
+func TestTraceRules(t *testing.T) {
+	names := []string{}
+	trace := Trace(func(e TraceEvent) {
+		if !e.Exit {
+			names = append(names, fmt.Sprintf("%*s%s", e.Depth, "", e.Name))
+		}
+	})
+	if _, _, err := ParseBytes(debugGrammar(), []byte("1+2"), trace); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, names, []string{"sum", " num", " num"})
+}
+
+func TestWatch(t *testing.T) {
+	g := NewGrammar()
+	decl := Rule(g, "decl", func() func(StatefulReader) (string, error) {
+		ident := join(Mult(1, 0, Set("a-z")))
+		return func(sr StatefulReader) (string, error) {
+			v, err := ident(sr)
+			if c := ContextOf(sr); err == nil && c != nil {
+				n, _ := c.User.(int)
+				c.User = n + 1
+			}
+			return v, err
+		}
+	})
+	decls := MultSep(0, 0, decl, Lit(";"))
+	events := []string{}
+	_, _, err := ParseBytes(decls, []byte("a;b"), Trace(func(e TraceEvent) {
+		events = append(events, fmt.Sprintf("%s %v %v", e.Name, e.Exit, e.Watches))
+	}), Watch("decls", func(c *Context) any {
+		n, _ := c.User.(int)
+		return n
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, events, []string{
+		"decl false [{decls 0}]",
+		"decl true [{decls 1}]",
+		"decl false [{decls 1}]",
+		"decl true [{decls 2}]",
+	})
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+const (
+	ipChars   = "0123456789abcdefABCDEF.:"
+	zoneChars = "%_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	macChars  = "0123456789abcdefABCDEF:-."
+)
+
+// whole reads the run of runes found in chars and parses all of it with f,
+// so that a match never stops partway through a longer run, as 1.2.3.45
+// would in 1.2.3.456.
+func whole[T any](name, chars string, f func(string) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (T, error) {
+		start := sr.State()
+		text := []rune{}
+		for {
+			s := sr.State()
+			r, err := readRune(sr)
+			if err != nil || !strings.ContainsRune(chars, r) {
+				sr.Restore(s)
+				break
+			}
+			text = append(text, r)
+		}
+		v, err := f(string(text))
+		if err != nil {
+			sr.Restore(start)
+			var t T
+			return t, fmt.Errorf("Expected %s, got %q", name, string(text))
+		}
+		return v, nil
+	}
+}
+
+// atBoundary reports whether the next rune, if any, is outside chars, so
+// that a match just made doesn't stop partway through a longer token.
+func atBoundary(sr StatefulReader, chars string) bool {
+	s := sr.State()
+	r, err := readRune(sr)
+	sr.Restore(s)
+	return err != nil || !strings.ContainsRune(chars, r)
+}
+
+// IP matches an IPv4 or IPv6 address. IPv6 addresses may carry a zone.
+// Leading zeros in IPv4 octets are rejected. The address can't be followed
+// directly by a colon, so use IPv4 for an address with a port such as
+// 10.0.0.1:80.
+func IP() func(sr StatefulReader) (netip.Addr, error) {
+	return whole("IP address", ipChars+zoneChars, netip.ParseAddr)
+}
+
+// IPv4 matches a dotted-quad IPv4 address.
+func IPv4() func(sr StatefulReader) (netip.Addr, error) {
+	return whole("IPv4 address", "0123456789.", func(s string) (netip.Addr, error) {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return a, err
+		}
+		if !a.Is4() {
+			return netip.Addr{}, fmt.Errorf("%q is not an IPv4 address", s)
+		}
+		return a, nil
+	})
+}
+
+// IPv6 matches an IPv6 address, including IPv4-mapped forms.
+func IPv6() func(sr StatefulReader) (netip.Addr, error) {
+	return whole("IPv6 address", ipChars+zoneChars, func(s string) (netip.Addr, error) {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return a, err
+		}
+		if !a.Is6() {
+			return netip.Addr{}, fmt.Errorf("%q is not an IPv6 address", s)
+		}
+		return a, nil
+	})
+}
+
+// CIDR matches an address prefix such as 10.0.0.0/8 or 2001:db8::/32. The
+// address must not have bits set beyond the prefix length.
+func CIDR() func(sr StatefulReader) (netip.Prefix, error) {
+	return whole("CIDR prefix", ipChars+"/", func(s string) (netip.Prefix, error) {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return p, err
+		}
+		if p.Masked() != p {
+			return netip.Prefix{}, fmt.Errorf("%q has host bits set", s)
+		}
+		return p, nil
+	})
+}
+
+// MAC matches a hardware address in any of the forms accepted by
+// net.ParseMAC.
+func MAC() func(sr StatefulReader) (net.HardwareAddr, error) {
+	return whole("MAC address", macChars, net.ParseMAC)
+}
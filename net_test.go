@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestIP(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out string
+		ok  bool
+	}{
+		{"10.0.0.1", "10.0.0.1", true},
+		{"::1", "::1", true},
+		{"fe80::1%eth0", "fe80::1%eth0", true},
+		{"10.0.0.1:80", "", false},
+		{"1.2.3.456", "", false},
+		{"010.0.0.1", "", false},
+		{"256.0.0.1", "", false},
+		{"foo", "", false},
+	}
+	for _, test := range tests {
+		out, err := parse(test.in, IP())
+		if (err == nil) != test.ok {
+			t.Errorf("%q: unexpected error state %v", test.in, err)
+			continue
+		}
+		if test.ok {
+			assertSrc(t, test.in, out, netip.MustParseAddr(test.out))
+		}
+	}
+}
+
+func TestIPv4(t *testing.T) {
+	t.Parallel()
+	_, err := parse("::1", IPv4())
+	if err == nil {
+		t.Error("Expected error for IPv6 input")
+	}
+	p := And(Convert(IPv4(), func(a netip.Addr) (string, error) { return a.String(), nil }), Lit(":80"))
+	out, err := parse("192.168.1.1:80", p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []string{"192.168.1.1", ":80"})
+}
+
+func TestCIDR(t *testing.T) {
+	t.Parallel()
+	out, err := parse("10.0.0.0/8", CIDR())
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, netip.MustParsePrefix("10.0.0.0/8"))
+	for _, in := range []string{"10.0.0.1/8", "10.0.0.0/33", "10.0.0.0", "10.0.0.0/88"} {
+		if _, err := parse(in, CIDR()); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+}
+
+func TestMAC(t *testing.T) {
+	t.Parallel()
+	out, err := parse("00:1a:2b:3c:4d:5e", MAC())
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, net.HardwareAddr{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e})
+	if _, err := parse("00:1a:2b:3c:4d:5e0", MAC()); err == nil {
+		t.Error("Expected error for long MAC")
+	}
+	if _, err := parse("00:1a:2b", MAC()); err == nil {
+		t.Error("Expected error for short MAC")
+	}
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"errors"
+	"time"
+)
+
+// ParseStats describes a finished parse, for monitoring parser health in
+// production.
+type ParseStats struct {
+	Start    time.Time
+	Duration time.Duration
+	// Input is the length of the input, or -1 if the reader couldn't tell,
+	// and Consumed how much of it the parse got through.
+	Input, Consumed int64
+	// Backtracked is the total number of bytes the parse rewound over, a
+	// measure of how much work went into alternatives that didn't match.
+	Backtracked int64
+	// Err is the error the parse returned, and ErrPos its position when it
+	// has one.
+	Err    error
+	ErrPos Position
+}
+
+// Observe calls f with the statistics of each parse as it finishes. It has
+// no dependencies of its own, so it suits any metrics or tracing library;
+// for OpenTelemetry, a span per parse is
+//
+//	parser.Observe(func(s parser.ParseStats) {
+//		_, span := tracer.Start(ctx, "parse", trace.WithTimestamp(s.Start))
+//		span.SetAttributes(
+//			attribute.Int64("parse.input_bytes", s.Input),
+//			attribute.Int64("parse.backtrack_bytes", s.Backtracked),
+//		)
+//		if s.Err != nil {
+//			span.SetAttributes(attribute.Int64("parse.error_offset", s.ErrPos.Offset))
+//			span.SetStatus(codes.Error, s.Err.Error())
+//		}
+//		span.End(trace.WithTimestamp(s.Start.Add(s.Duration)))
+//	})
+//
+// f runs on the parsing goroutine, so it should be quick.
+func Observe(f func(ParseStats)) Option {
+	return func(o *options) {
+		o.observer = f
+	}
+}
+
+func unobserved(error) {}
+
+// observe starts observing a parse of sr on c, returning the function to
+// call with its outcome.
+func (o options) observe(c *Context, sr StatefulReader) func(error) {
+	if o.observer == nil {
+		return unobserved
+	}
+	start := time.Now()
+	size, ok := inputSize(sr)
+	if !ok {
+		size = -1
+	}
+	return func(err error) {
+		s := ParseStats{
+			Start:       start,
+			Duration:    time.Since(start),
+			Input:       size,
+			Consumed:    c.Pos().Offset,
+			Backtracked: c.rewound,
+			Err:         err,
+		}
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			s.ErrPos = pe.Pos
+		}
+		o.observer(s)
+	}
+}
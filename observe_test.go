@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestObserve(t *testing.T) {
+	t.Parallel()
+	p := Left(Or(Right(Lit("ab"), Lit("c")), Lit("abd")), EOF())
+	var stats []ParseStats
+	observe := Observe(func(s ParseStats) { stats = append(stats, s) })
+
+	if _, _, err := ParseBytes(p, []byte("abd"), observe); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := ParseReader(p, strings.NewReader("abx"), observe)
+	if err == nil {
+		t.Fatal("parsed abx")
+	}
+	c := Compile(p, observe)
+	stats = nil
+	c.RunString("abd")
+	c.RunString("abd")
+	if len(stats) != 2 {
+		t.Fatalf("got %d stats", len(stats))
+	}
+	for _, s := range stats {
+		if s.Input != 3 || s.Consumed != 3 || s.Backtracked != 2 || s.Err != nil || s.Duration < 0 || s.Start.IsZero() {
+			t.Errorf("got %+v", s)
+		}
+	}
+
+	stats = nil
+	ParseReader(p, strings.NewReader("abx"), observe)
+	if s := stats[0]; s.Err == nil || s.Err.Error() != err.Error() || s.ErrPos.Offset != 2 || s.Input != 3 {
+		t.Errorf("got %+v", s)
+	}
+
+	stats = nil
+	ParseBytes(p, []byte("abcd"), observe, MaxInput(3))
+	if s := stats[0]; !errors.Is(s.Err, ErrTooLarge) || s.Input != 4 {
+		t.Errorf("got %+v", s)
+	}
+}
@@ -0,0 +1,130 @@
+package parser
+
+import "context"
+
+// Option changes how ParseReader and Compile run a grammar.
+type Option func(*options)
+
+type options struct {
+	recover   bool
+	maxErrors int
+	cascade   int64
+	sev       map[string]Severity
+	highlight bool
+	tabWidth  int
+	features  map[string]bool
+	version   int
+	lookahead int64
+	maxInput  int64
+	depth     int
+	steps     int
+	record    *decisions
+	trace     func(TraceEvent)
+	contracts bool
+	watches   []watch
+	profile   context.Context
+	observer  func(ParseStats)
+}
+
+func buildOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// wrap applies the options that change the grammar itself.
+func wrap[T any](p func(sr StatefulReader) (T, error), o options) func(sr StatefulReader) (T, error) {
+	if o.recover {
+		p = Recover(p)
+	}
+	return p
+}
+
+// configure applies the options that live in the per-parse Context.
+func (o options) configure(c *Context) {
+	c.MaxErrors = o.maxErrors
+	c.CascadeWindow = o.cascade
+	c.Severities = o.sev
+	c.highlight = o.highlight
+	c.Features = o.features
+	c.Version = o.version
+	c.lookahead = o.lookahead
+	c.maxDepth = o.depth
+	c.maxSteps = o.steps
+	c.decisions = o.record.start(c)
+	c.Trace = o.trace
+	c.CheckContracts = o.contracts
+	c.watches = o.watches
+	c.profile = o.profile
+	c.countRewinds = o.observer != nil
+	if pr, ok := c.MemoReader.sr.(*PosReader); ok {
+		pr.TabWidth = o.tabWidth
+	}
+}
+
+// RecoverPanics wraps the grammar with Recover.
+func RecoverPanics() Option {
+	return func(o *options) {
+		o.recover = true
+	}
+}
+
+// MaxErrors sets how many errors Resync may recover from before the parse
+// is abandoned. See Context.MaxErrors.
+func MaxErrors(n int) Option {
+	return func(o *options) {
+		o.maxErrors = n
+	}
+}
+
+// SuppressCascades sets the Context's CascadeWindow to n bytes.
+func SuppressCascades(n int64) Option {
+	return func(o *options) {
+		o.cascade = n
+	}
+}
+
+// Severities overrides the severity of diagnostics with the given codes, like
+// a compiler's -W flags. Later options add to earlier ones.
+func Severities(sev map[string]Severity) Option {
+	return func(o *options) {
+		if o.sev == nil {
+			o.sev = map[string]Severity{}
+		}
+		for code, s := range sev {
+			o.sev[code] = s
+		}
+	}
+}
+
+// Highlight records the spans of Classify parsers, for Context.Spans.
+func Highlight() Option {
+	return func(o *options) {
+		o.highlight = true
+	}
+}
+
+// TabWidth sets the PosReader's TabWidth, so columns count display cells.
+func TabWidth(n int) Option {
+	return func(o *options) {
+		o.tabWidth = n
+	}
+}
+
+// Trace sets the Context's Trace hook, which is otherwise only reachable
+// through NewContext.
+func Trace(f func(TraceEvent)) Option {
+	return func(o *options) {
+		o.trace = f
+	}
+}
+
+// CheckContracts sets the Context's CheckContracts flag. Combine it with
+// RecoverPanics to get the *ContractError as an error.
+func CheckContracts() Option {
+	return func(o *options) {
+		o.contracts = true
+	}
+}
@@ -0,0 +1,73 @@
+package parser
+
+// Cloner is implemented by readers that can make an independent copy of
+// themselves at the current position, sharing the underlying input. States
+// from a clone must be valid to Restore on the original.
+type Cloner interface {
+	Clone() (StatefulReader, bool)
+}
+
+// CloneReader clones sr if it supports it.
+func CloneReader(sr StatefulReader) (StatefulReader, bool) {
+	if c, ok := sr.(Cloner); ok {
+		return c.Clone()
+	}
+	return nil, false
+}
+
+type branchResult[T any] struct {
+	i   int
+	v   T
+	err error
+	end any
+}
+
+// OrParallel is Or with the alternatives run concurrently, each on its own
+// clone of sr. It returns as soon as the first alternative in order that
+// matches is known, so the result is the same as Or's; later alternatives
+// still running are abandoned: they aren't cancelled, but run to the end in
+// the background and their results are dropped. It is only worth it for
+// expensive alternatives over in-memory input. If sr cannot be cloned it
+// runs the alternatives in turn, like Or.
+//
+// A Context can't be cloned, since its memo table, spans and errors belong
+// to one parse, so under ParseReader, ParseBytes and Compiled OrParallel is
+// always sequential. Use it on a plain reader, such as a BytesReader.
+//
+// The alternatives must be safe to run at the same time, which plain
+// combinators are.
+func OrParallel[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	seq := Or(ps...)
+	return func(sr StatefulReader) (T, error) {
+		clones := make([]StatefulReader, len(ps))
+		for i := range ps {
+			c, ok := CloneReader(sr)
+			if !ok {
+				return seq(sr)
+			}
+			clones[i] = c
+		}
+		results := make(chan branchResult[T], len(ps))
+		for i, p := range ps {
+			go func(i int, p func(sr StatefulReader) (T, error), c StatefulReader) {
+				v, err := p(c)
+				results <- branchResult[T]{i: i, v: v, err: err, end: c.State()}
+			}(i, p, clones[i])
+		}
+		done := make([]*branchResult[T], len(ps))
+		next := 0
+		for range ps {
+			r := <-results
+			done[r.i] = &r
+			for next < len(ps) && done[next] != nil {
+				if done[next].err == nil {
+					sr.Restore(done[next].end)
+					return done[next].v, nil
+				}
+				next++
+			}
+		}
+		var t T
+		return t, msg(MsgNoMatch)
+	}
+}
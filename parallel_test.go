@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrParallel(t *testing.T) {
+	slow := func(d time.Duration, p func(sr StatefulReader) (string, error)) func(sr StatefulReader) (string, error) {
+		return func(sr StatefulReader) (string, error) {
+			time.Sleep(d)
+			return p(sr)
+		}
+	}
+	p := OrParallel(
+		slow(20*time.Millisecond, Lit("foobar")),
+		slow(10*time.Millisecond, Lit("foo")),
+		Lit("f"),
+	)
+	for _, tc := range []struct {
+		in, want string
+		next     int64
+	}{
+		{"foobar!", "foobar", 6},
+		{"foobaz", "foo", 3},
+		{"fig", "f", 1},
+	} {
+		sr := NewPosReader(NewSimpleReader(strings.NewReader(tc.in)))
+		v, err := p(sr)
+		if err != nil || v != tc.want || sr.Pos().Offset != tc.next {
+			t.Errorf("%q: got %q, %v at %d", tc.in, v, err, sr.Pos().Offset)
+		}
+	}
+	sr := NewSimpleReader(strings.NewReader("x"))
+	if _, err := p(sr); err == nil {
+		t.Error("expected no match")
+	}
+	if sr.State().(int64) != 0 {
+		t.Error("reader moved on failure")
+	}
+}
+
+func TestOrParallelFallback(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("bar")
+	f.Seek(0, 0)
+	sr := NewSimpleReader(f)
+	if _, ok := CloneReader(sr); ok {
+		t.Fatal("expected *os.File reader to be uncloneable")
+	}
+	v, err := OrParallel(Lit("foo"), Lit("ba"))(sr)
+	if err != nil || v != "ba" {
+		t.Errorf("got %q, %v", v, err)
+	}
+}
+
+func TestOrParallelContext(t *testing.T) {
+	ran := false
+	second := Convert(Lit("a"), func(s string) (string, error) {
+		ran = true
+		return s, nil
+	})
+	p := OrParallel(Lit("a"), second)
+	if _, ok := CloneReader(newContext(NewBytesReader(nil))); ok {
+		t.Fatal("expected a Context to be uncloneable")
+	}
+	v, _, err := ParseBytes(p, []byte("a"))
+	if err != nil || v != "a" || ran {
+		t.Errorf("got %q, %v, second alternative run: %v", v, err, ran)
+	}
+}
+
+func TestCloneSlice(t *testing.T) {
+	sr := NewSliceReader([]int{1, 2, 3})
+	Exact(1)(sr)
+	c, ok := CloneReader(sr)
+	if !ok {
+		t.Fatal("SliceReader should clone")
+	}
+	Exact(2)(c)
+	if sr.Offset() != 1 || c.State().(int) != 2 {
+		t.Errorf("clone not independent: %d, %v", sr.Offset(), c.State())
+	}
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// FileResult is the outcome of parsing one file with ParseFS.
+type FileResult[T any] struct {
+	Name        string
+	Value       T
+	Err         error
+	Diagnostics []Diagnostic
+}
+
+// ParseFS parses every file in fsys matching pattern (in path.Match syntax)
+// with p, using one worker per CPU. The whole tree is walked: a pattern
+// with a slash is matched against each file's path from the root, and one
+// without against its base name, so "*.cfg" finds config files at any
+// depth. Each file gets its own Session, so grammars can use Include.
+// Results are in the lexical order fs.WalkDir visits files in; the error
+// is for a bad pattern or a directory that can't be read.
+func ParseFS[T any](fsys fs.FS, pattern string, p func(sr StatefulReader) (T, error), opts ...Option) ([]FileResult[T], error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		subject := name
+		if !strings.Contains(pattern, "/") {
+			subject = d.Name()
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]FileResult[T], len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				s := NewSession(fsys, opts...)
+				v, err := ParseFile(s, names[i], p)
+				results[i] = FileResult[T]{Name: names[i], Value: v, Err: err, Diagnostics: s.Diagnostics}
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/common.cfg": {Data: []byte("port=5\n")},
+		"conf/notes.txt":  {Data: []byte("not config")},
+		"conf/z.cfg":      {Data: []byte("x=\n")},
+		"conf/bad.cfg":    {Data: []byte("!\n")},
+		"conf/web.cfg":    {Data: []byte("include common.cfg\nroot=/srv\n")},
+	}
+	for i := 0; i < 20; i++ {
+		fsys[fmt.Sprintf("conf/gen%02d.cfg", i)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("n=%d\n", i))}
+	}
+	rs, err := ParseFS(fsys, "conf/*.cfg", config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 24 {
+		t.Fatalf("got %d results", len(rs))
+	}
+	byName := map[string]FileResult[[]string]{}
+	for _, r := range rs {
+		byName[r.Name] = r
+	}
+	if byName["conf/bad.cfg"].Err == nil {
+		t.Error("Expected error for bad.cfg")
+	}
+	assert(t, byName["conf/web.cfg"].Value, []string{"port=5", "root=/srv"})
+	assert(t, byName["conf/gen07.cfg"].Value, []string{"n=7"})
+	if ds := byName["conf/z.cfg"].Diagnostics; len(ds) != 1 || ds[0].Pos.File != "conf/z.cfg" {
+		t.Errorf("got %v", ds)
+	}
+	assert(t, rs[0].Name, "conf/bad.cfg")
+
+	fsys["conf/site/local.cfg"] = &fstest.MapFile{Data: []byte("a=1\n")}
+	rs, err = ParseFS(fsys, "*.cfg", config())
+	if err != nil || len(rs) != 25 {
+		t.Fatalf("got %d results, %v", len(rs), err)
+	}
+	assert(t, rs[24].Name, "conf/z.cfg")
+	rs, _ = ParseFS(fsys, "conf/*/*.cfg", config())
+	if len(rs) != 1 || rs[0].Name != "conf/site/local.cfg" {
+		t.Errorf("got %v", rs)
+	}
+
+	if _, err := ParseFS(fsys, "[", config()); err == nil {
+		t.Error("Expected error for bad pattern")
+	}
+}
@@ -1,12 +1,18 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
-//type Parser func[T any](rs io.ReadSeeker) (T, error)
+// Parser is the type of every parser in this package, named so that it can
+// carry methods such as ThenSkip. The combinators take and return the
+// unnamed func type, which a Parser converts to and from implicitly.
+type Parser[T any] func(sr StatefulReader) (T, error)
 
 type fatalError struct {
 	err error
@@ -16,6 +22,10 @@ func (fe fatalError) Error() string {
 	return fmt.Sprintf("Fatal match error: %s", fe.err)
 }
 
+func (fe fatalError) Unwrap() error {
+	return fe.err
+}
+
 type StatefulReader interface {
 	io.Reader
 	State() any
@@ -26,6 +36,10 @@ type SimpleReader struct {
 	r io.ReadSeeker
 }
 
+func NewSimpleReader(r io.ReadSeeker) SimpleReader {
+	return SimpleReader{r: r}
+}
+
 func (sr SimpleReader) Read(p []byte) (n int, err error) {
 	return sr.r.Read(p)
 }
@@ -39,40 +53,133 @@ func (sr SimpleReader) Restore(s any) {
 	sr.r.Seek(s.(int64), 0)
 }
 
+// Clone returns an independent reader at the same offset when the
+// underlying reader also supports io.ReaderAt, as *strings.Reader and
+// *bytes.Reader do.
+func (sr SimpleReader) Clone() (StatefulReader, bool) {
+	ra, ok := sr.r.(interface {
+		io.ReaderAt
+		Size() int64
+	})
+	if !ok {
+		return nil, false
+	}
+	c := SimpleReader{r: io.NewSectionReader(ra, 0, ra.Size())}
+	c.Restore(sr.State())
+	return c, true
+}
+
+// mustParsers panics if any of ps is nil, naming the combinator it was
+// passed to.
+func mustParsers[T any](name string, ps ...func(sr StatefulReader) (T, error)) {
+	for i, p := range ps {
+		if p == nil {
+			panic(fmt.Sprintf("parser: %s: parser %d is nil", name, i))
+		}
+	}
+}
+
 func Lit(text string) func(sr StatefulReader) (string, error) {
+	if text == "" {
+		panic("parser: Lit: empty text")
+	}
 	return func(sr StatefulReader) (string, error) {
-		s := sr.State()
-		b := make([]byte, len(text))
-		c, _ := io.ReadFull(sr, b)
-		if c < len(text) {
-			sr.Restore(s)
-			return "", fmt.Errorf("Unexpected EOF")
-		}
-		if string(b) == text {
+		if pk, ok := sr.(peeker); ok {
+			if b, ok := pk.PeekBytes(len(text)); ok {
+				if string(b) == text {
+					pk.Advance(len(text))
+					return text, nil
+				}
+				return litFailed(sr, text, b)
+			}
+		}
+		s := save(sr)
+		buf := getScratch(len(text))
+		defer putScratch(buf)
+		b := *buf
+		c, ok := readPrefix(sr, b, text, false)
+		if ok {
 			return text, nil
 		}
-		sr.Restore(s)
-		return "", fmt.Errorf("Expected %q, got %q", text, string(b))
+		s.restore(sr)
+		return litFailed(sr, text, b[:c])
+	}
+}
+
+// readPrefix reads up to len(text) bytes into b, stopping as soon as what
+// it has read stops matching text, so that a reader over a live stream
+// isn't waited on for bytes that can't make a match. fold compares under
+// Unicode case folding, as LitFold does. It returns the number of bytes
+// read and whether they were all of text.
+func readPrefix(sr StatefulReader, b []byte, text string, fold bool) (int, bool) {
+	n := 0
+	for {
+		m, err := sr.Read(b[n:])
+		n += m
+		if fold && !strings.EqualFold(string(b[:n]), text[:n]) || !fold && string(b[:n]) != text[:n] {
+			return n, false
+		}
+		if n == len(b) {
+			return n, true
+		}
+		if err != nil || m == 0 {
+			return n, false
+		}
+	}
+}
+
+func litFailed(sr StatefulReader, text string, got []byte) (string, error) {
+	expect(sr, Pos(sr), Expectation{Kind: ExpectLiteral, Text: text})
+	if len(got) < len(text) && string(got) == text[:len(got)] {
+		return "", msg(MsgUnexpectedEOF)
 	}
+	return "", msg(MsgExpected, text, string(got))
 }
 
+// readRune reads one rune. An invalid encoding reads as utf8.RuneError
+// and consumes a single byte, as peekRune does.
 func readRune(sr StatefulReader) (rune, error) {
-	b := make([]byte, 1, 4)
-	_, err := sr.Read(b)
-	for !utf8.FullRune(b) && err != nil {
-		b = b[:len(b)+1]
-		_, err = sr.Read(b[len(b)-1:])
+	buf := getScratch(utf8.UTFMax)
+	defer putScratch(buf)
+	b := (*buf)[:1]
+	if n, err := sr.Read(b); n == 0 {
+		return utf8.RuneError, err
 	}
-	r, _ := utf8.DecodeRune(b)
-	return r, err
+	if b[0] < utf8.RuneSelf {
+		return rune(b[0]), nil
+	}
+	s := save(sr)
+	n, _ := io.ReadFull(sr, (*buf)[1:runeLen(b[0])])
+	r, size := utf8.DecodeRune((*buf)[:1+n])
+	if size < 1+n {
+		s.restore(sr)
+	}
+	return r, nil
 }
 
-func Set(text string) func(sr StatefulReader) (string, error) {
+// runeLen is the length of the encoding that starts with lead, or 1 if
+// lead can't start one.
+func runeLen(lead byte) int {
+	switch {
+	case lead&0xE0 == 0xC0:
+		return 2
+	case lead&0xF0 == 0xE0:
+		return 3
+	case lead&0xF8 == 0xF0:
+		return 4
+	}
+	return 1
+}
+
+func expandSet(text string) []rune {
 	//expand 0-9 to 0123456789
 	final := []rune{}
 	rawtext := []rune(text)
 	for i := range rawtext {
 		if rawtext[i] == '-' && i > 0 && i < len(rawtext)-1 {
+			if rawtext[i+1] < rawtext[i-1] {
+				panic(fmt.Sprintf("parser: set %q: range %c-%c is backwards", text, rawtext[i-1], rawtext[i+1]))
+			}
 			for j := rawtext[i-1] + 1; j < rawtext[i+1]; j++ {
 				final = append(final, j)
 			}
@@ -80,47 +187,209 @@ func Set(text string) func(sr StatefulReader) (string, error) {
 			final = append(final, rawtext[i])
 		}
 	}
+	return final
+}
+
+func Set(text string) func(sr StatefulReader) (string, error) {
+	if text == "" {
+		panic("parser: Set: empty set")
+	}
+	final := expandSet(text)
+
+	return func(sr StatefulReader) (string, error) {
+		if r, size, ok := peekRune(sr); ok {
+			if size > 0 && inSet(final, r) {
+				sr.(peeker).Advance(size)
+				return string(r), nil
+			}
+			var err error
+			if size == 0 {
+				err = io.EOF
+			}
+			return setFailed(sr, text, r, err)
+		}
+		s := save(sr)
+		r, err := readRune(sr)
+		if err == nil && inSet(final, r) {
+			return string(r), nil
+		}
+		s.restore(sr)
+		return setFailed(sr, text, r, err)
+	}
+}
+
+func inSet(set []rune, r rune) bool {
+	for _, tr := range set {
+		if r == tr {
+			return true
+		}
+	}
+	return false
+}
+
+func setFailed(sr StatefulReader, text string, r rune, err error) (string, error) {
+	expect(sr, Pos(sr), Expectation{Kind: ExpectClass, Text: text})
+	if err != nil {
+		return "", err
+	}
+	return "", msg(MsgExpected, text, string(r))
+}
+
+// NotSet matches any single rune not in text, using the same range syntax as
+// Set.
+func NotSet(text string) func(sr StatefulReader) (string, error) {
+	final := expandSet(text)
 
 	return func(sr StatefulReader) (string, error) {
-		s := sr.State()
+		if r, size, ok := peekRune(sr); ok {
+			switch {
+			case size == 0:
+				return notSetFailed(sr, text, r, io.EOF)
+			case inSet(final, r):
+				return notSetFailed(sr, text, r, nil)
+			}
+			sr.(peeker).Advance(size)
+			return string(r), nil
+		}
+		s := save(sr)
 		r, err := readRune(sr)
+		if err != nil || inSet(final, r) {
+			s.restore(sr)
+			return notSetFailed(sr, text, r, err)
+		}
+		return string(r), nil
+	}
+}
+
+func notSetFailed(sr StatefulReader, text string, r rune, err error) (string, error) {
+	expect(sr, Pos(sr), Expectation{Kind: ExpectNotClass, Text: text})
+	if err != nil {
+		return "", err
+	}
+	return "", msg(MsgUnexpected, string(r))
+}
+
+// EOF matches the end of the input without consuming anything.
+func EOF() func(sr StatefulReader) (string, error) {
+	return func(sr StatefulReader) (string, error) {
+		var r rune
+		var err error
+		if pr, size, ok := peekRune(sr); ok {
+			r = pr
+			if size == 0 {
+				err = io.EOF
+			}
+		} else {
+			s := save(sr)
+			r, err = readRune(sr)
+			s.restore(sr)
+		}
+		if err == io.EOF {
+			return "", nil
+		}
 		if err != nil {
-			sr.Restore(s)
 			return "", err
 		}
-		for _, tr := range final {
-			if r == tr {
-				return string(r), nil
+		expect(sr, Pos(sr), Expectation{Kind: ExpectEOF})
+		return "", msg(MsgExpectedEOF, string(r))
+	}
+}
+
+// Assert runs p as a check only: it consumes nothing and discards p's value,
+// failing with msg if p does not match. For example,
+// Assert(Or(Set(" \t\n"), EOF()), "Expected whitespace") requires a word
+// to be followed by a space without consuming it.
+func Assert[T any](p func(sr StatefulReader) (T, error), msg string) func(sr StatefulReader) (string, error) {
+	mustParsers("Assert", p)
+	return func(sr StatefulReader) (string, error) {
+		s := save(sr)
+		_, err := p(sr)
+		s.restore(sr)
+		if err != nil {
+			if _, isFE := err.(fatalError); isFE {
+				return "", err
 			}
+			return "", errors.New(msg)
 		}
-		sr.Restore(s)
-		return "", fmt.Errorf("Expected %q, got %q", text, string(r))
+		return "", nil
 	}
 }
 
 func Or[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Or", ps...)
 	return func(sr StatefulReader) (T, error) {
-		s := sr.State()
-		for _, p := range ps {
+		c, n := choose(sr)
+		s := save(sr)
+		for i, p := range ps {
 			v, err := p(sr)
 			if err == nil {
+				c.chose(sr, n, i)
 				return v, nil
 			}
-			sr.Restore(s)
+			s.restore(sr)
 		}
+		c.chose(sr, n, -1)
 		var t T
-		return t, fmt.Errorf("No match")
+		return t, msg(MsgNoMatch)
+	}
+}
+
+// Cut marks p as past the point of no return: if p fails, the failure is
+// fatal and an enclosing OrCut, Mult or Optional does not backtrack past it
+// to try something else. Put it around the part of an alternative that
+// follows its distinguishing prefix, as in
+// And(Lit("if"), Cut(condition)), so that a broken condition is reported
+// as such instead of as "No match".
+func Cut[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Cut", p)
+	return func(sr StatefulReader) (T, error) {
+		v, err := p(sr)
+		if err != nil {
+			if _, isFE := err.(fatalError); !isFE {
+				err = fatalError{err}
+			}
+		}
+		return v, err
+	}
+}
+
+// OrCut is Or where an alternative that fails inside a Cut stops the
+// search: the remaining alternatives are not tried and the Cut's error is
+// returned. The cut does not reach past OrCut, so enclosing choices still
+// backtrack normally.
+func OrCut[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("OrCut", ps...)
+	return func(sr StatefulReader) (T, error) {
+		c, n := choose(sr)
+		s := save(sr)
+		for i, p := range ps {
+			v, err := p(sr)
+			if err == nil {
+				c.chose(sr, n, i)
+				return v, nil
+			}
+			s.restore(sr)
+			if fe, isFE := err.(fatalError); isFE {
+				c.chose(sr, n, -1)
+				var t T
+				return t, fe.err
+			}
+		}
+		c.chose(sr, n, -1)
+		var t T
+		return t, msg(MsgNoMatch)
 	}
 }
 
 func And[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader) ([]T, error) {
+	mustParsers("And", ps...)
 	return func(sr StatefulReader) ([]T, error) {
 		vs := []T{}
-		s := sr.State()
+		s := save(sr)
 		for _, p := range ps {
 			v, err := p(sr)
 			if err != nil {
-				sr.Restore(s)
+				s.restore(sr)
 				return nil, err
 			}
 			vs = append(vs, v)
@@ -130,11 +399,12 @@ func And[T any](ps ...func(sr StatefulReader) (T, error)) func(sr StatefulReader
 }
 
 func Optional[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Optional", p)
 	return func(sr StatefulReader) (T, error) {
-		s := sr.State()
+		s := save(sr)
 		p, err := p(sr)
 		if err != nil {
-			sr.Restore(s)
+			s.restore(sr)
 		}
 		if _, isFE := err.(fatalError); isFE {
 			return p, err
@@ -143,12 +413,35 @@ func Optional[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReade
 	}
 }
 
+// Atomic runs p and, if it fails, restores the reader to where p started,
+// even if p itself consumed input before failing. Use it to wrap hand
+// written parsers that don't restore on their own; the built in
+// combinators already leave the reader untouched when they fail.
+func Atomic[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Atomic", p)
+	return func(sr StatefulReader) (T, error) {
+		s := save(sr)
+		v, err := p(sr)
+		if err != nil {
+			s.restore(sr)
+		}
+		return v, err
+	}
+}
+
 func Mult[T any](n, m int, p func(sr StatefulReader) (T, error)) func(sr StatefulReader) ([]T, error) {
+	mustParsers("Mult", p)
+	if n < 0 || m < 0 {
+		panic(fmt.Sprintf("parser: Mult: negative count %d, %d", n, m))
+	}
+	if m != 0 && n > m {
+		panic(fmt.Sprintf("parser: Mult: minimum %d is greater than maximum %d", n, m))
+	}
 	if m == 0 {
 		m = int(^uint(0) >> 1)
 	}
 	return func(sr StatefulReader) ([]T, error) {
-		s := sr.State()
+		s := save(sr)
 		ms := []T{}
 		for i := 0; i < m; i++ {
 			match, err := p(sr)
@@ -157,7 +450,7 @@ func Mult[T any](n, m int, p func(sr StatefulReader) (T, error)) func(sr Statefu
 					return nil, err
 				}
 				if i < n {
-					sr.Restore(s)
+					s.restore(sr)
 					return nil, err
 				}
 				return ms, nil
@@ -168,7 +461,83 @@ func Mult[T any](n, m int, p func(sr StatefulReader) (T, error)) func(sr Statefu
 	}
 }
 
+// MultSep matches between n and m occurrences of p separated by sep, with
+// m == 0 meaning no maximum. A separator not followed by another p is left
+// unconsumed.
+func MultSep[T, S any](n, m int, p func(sr StatefulReader) (T, error), sep func(sr StatefulReader) (S, error)) func(sr StatefulReader) ([]T, error) {
+	return multSep(n, m, p, sep, false)
+}
+
+// MultSepTrailing is MultSep that also consumes one optional separator after
+// the last item, as in "[1, 2, 3,]".
+func MultSepTrailing[T, S any](n, m int, p func(sr StatefulReader) (T, error), sep func(sr StatefulReader) (S, error)) func(sr StatefulReader) ([]T, error) {
+	return multSep(n, m, p, sep, true)
+}
+
+// MultTerm matches between n and m occurrences of p, each followed by term,
+// as in "a; b; c;".
+func MultTerm[T, S any](n, m int, p func(sr StatefulReader) (T, error), term func(sr StatefulReader) (S, error)) func(sr StatefulReader) ([]T, error) {
+	mustParsers("MultTerm", term)
+	return Mult(n, m, Bind(p, func(v T) func(sr StatefulReader) (T, error) {
+		return Convert(term, func(S) (T, error) { return v, nil })
+	}))
+}
+
+func multSep[T, S any](n, m int, p func(sr StatefulReader) (T, error), sep func(sr StatefulReader) (S, error), trailing bool) func(sr StatefulReader) ([]T, error) {
+	mustParsers("MultSep", p)
+	mustParsers("MultSep", sep)
+	if n < 0 || m < 0 {
+		panic(fmt.Sprintf("parser: MultSep: negative count %d, %d", n, m))
+	}
+	if m != 0 && n > m {
+		panic(fmt.Sprintf("parser: MultSep: minimum %d is greater than maximum %d", n, m))
+	}
+	if m == 0 {
+		m = int(^uint(0) >> 1)
+	}
+	return func(sr StatefulReader) ([]T, error) {
+		s := save(sr)
+		ms := []T{}
+		for len(ms) < m {
+			before := save(sr)
+			if len(ms) > 0 {
+				if _, err := sep(sr); err != nil {
+					if _, isFE := err.(fatalError); isFE {
+						return nil, err
+					}
+					break
+				}
+			}
+			match, err := p(sr)
+			if err != nil {
+				if _, isFE := err.(fatalError); isFE {
+					return nil, err
+				}
+				before.restore(sr)
+				if len(ms) < n {
+					s.restore(sr)
+					return nil, err
+				}
+				break
+			}
+			ms = append(ms, match)
+		}
+		if len(ms) < n {
+			s.restore(sr)
+			return nil, msg(MsgTooFew, n, len(ms))
+		}
+		if trailing && len(ms) > 0 {
+			Optional(sep)(sr)
+		}
+		return ms, nil
+	}
+}
+
 func Convert[T, U any](p func(sr StatefulReader) (T, error), f func(T) (U, error)) func(sr StatefulReader) (U, error) {
+	mustParsers("Convert", p)
+	if f == nil {
+		panic("parser: Convert: nil function")
+	}
 	return func(sr StatefulReader) (U, error) {
 		v, err := p(sr)
 		if err != nil {
@@ -178,3 +547,41 @@ func Convert[T, U any](p func(sr StatefulReader) (T, error), f func(T) (U, error
 		return f(v)
 	}
 }
+
+// Bind runs p and then the parser that f builds from its result, allowing
+// later parts of a grammar to depend on earlier values.
+func Bind[T, U any](p func(sr StatefulReader) (T, error), f func(T) func(sr StatefulReader) (U, error)) func(sr StatefulReader) (U, error) {
+	mustParsers("Bind", p)
+	if f == nil {
+		panic("parser: Bind: nil function")
+	}
+	return func(sr StatefulReader) (U, error) {
+		s := save(sr)
+		v, err := p(sr)
+		if err != nil {
+			var u U
+			return u, err
+		}
+		u, err := f(v)(sr)
+		if err != nil {
+			s.restore(sr)
+		}
+		return u, err
+	}
+}
+
+// Lazy defers building a parser until it is first run, so that grammars can
+// refer to rules that are defined later or recursively.
+func Lazy[T any](f func() func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	if f == nil {
+		panic("parser: Lazy: nil function")
+	}
+	var once sync.Once
+	var p func(sr StatefulReader) (T, error)
+	return func(sr StatefulReader) (T, error) {
+		once.Do(func() {
+			p = f()
+		})
+		return p(sr)
+	}
+}
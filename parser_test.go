@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strconv"
@@ -120,6 +122,29 @@ func TestLit(t *testing.T) {
 	}
 }
 
+// dataEOF returns io.EOF along with the last bytes, as io.Reader allows.
+type dataEOF struct {
+	*strings.Reader
+}
+
+func (r dataEOF) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == nil && r.Len() == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestLitDataEOF(t *testing.T) {
+	t.Parallel()
+	for _, p := range []func(StatefulReader) (string, error){Lit("abc"), LitFold("ABC"), Lits("abd", "abc")} {
+		out, err := p(NewSimpleReader(dataEOF{strings.NewReader("abc")}))
+		if err != nil || out == "" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	}
+}
+
 func TestMult(t *testing.T) {
 	t.Parallel()
 	p := Mult(0, 3, Lit("foo"))
@@ -150,6 +175,229 @@ func TestMultOr(t *testing.T) {
 	assert(t, out, []string{"foo", "bar", "foo"})
 }
 
+func TestNotSet(t *testing.T) {
+	t.Parallel()
+	p := Mult(0, 0, NotSet(",\n"))
+	out, err := parse("añb,c", p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []string{"a", "ñ", "b"})
+
+	// an invalid byte reads as one RuneError, as it does when peeked
+	p = Mult(0, 0, NotSet("x"))
+	out, err = parse("\xe9ab", p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []string{"\uFFFD", "a", "b"})
+	out, _ = p(NewBytesReader([]byte("\xe9ab")))
+	assert(t, out, []string{"\uFFFD", "a", "b"})
+	out, _ = parse("\xf0\x9f", p)
+	assert(t, out, []string{"\uFFFD", "\uFFFD"})
+}
+
+func TestEOF(t *testing.T) {
+	t.Parallel()
+	out, err := parse("foo", And(Lit("foo"), EOF()))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []string{"foo", ""})
+	_, err = parse("foobar", And(Lit("foo"), EOF()))
+	if err == nil {
+		t.Error("Expected error for trailing input")
+	}
+}
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+	var nested func(StatefulReader) (string, error)
+	nested = Or(
+		Convert(And(Lit("("), Lazy(func() func(StatefulReader) (string, error) { return nested }), Lit(")")), func(s []string) (string, error) {
+			return strings.Join(s, ""), nil
+		}),
+		Lit("x"),
+	)
+	out, err := parse("((x))", nested)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "((x))")
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+	p := Bind(Set("'\""), func(q string) func(StatefulReader) (string, error) {
+		return Convert(And(join(Mult(0, 0, NotSet(q))), Lit(q)), func(s []string) (string, error) {
+			return s[0], nil
+		})
+	})
+	out, err := parse(`"it's"`, p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "it's")
+	sr := SimpleReader{strings.NewReader(`'abc`)}
+	_, err = p(sr)
+	if err == nil {
+		t.Error("Expected error for unterminated quote")
+	}
+	assert(t, sr.State(), any(int64(0)))
+}
+
+func TestAssert(t *testing.T) {
+	t.Parallel()
+	word := Convert(And(join(Mult(1, 0, Set("a-z"))), Assert(Or(Set(" "), EOF()), "Expected end of word")), func(s []string) (string, error) {
+		return s[0], nil
+	})
+	sr := SimpleReader{strings.NewReader("foo bar")}
+	out, err := word(sr)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "foo")
+	assert(t, sr.State(), any(int64(3)))
+	out, err = parse("foo", word)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "foo")
+	sr = SimpleReader{strings.NewReader("foo1")}
+	_, err = word(sr)
+	if err == nil || err.Error() != "Expected end of word" {
+		t.Errorf("got %v", err)
+	}
+	assert(t, sr.State(), any(int64(0)))
+}
+
+func TestAtomic(t *testing.T) {
+	t.Parallel()
+	sloppy := func(sr StatefulReader) (string, error) {
+		b := make([]byte, 2)
+		sr.Read(b)
+		if string(b) != "ab" {
+			return "", fmt.Errorf("Expected \"ab\"")
+		}
+		return "ab", nil
+	}
+	sr := SimpleReader{strings.NewReader("ax")}
+	if _, err := Atomic(sloppy)(sr); err == nil {
+		t.Error("Expected error")
+	}
+	assert(t, sr.State(), any(int64(0)))
+	out, err := parse("ab", Atomic(sloppy))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "ab")
+}
+
+func TestOrCut(t *testing.T) {
+	t.Parallel()
+	num := join(Mult(1, 0, Set("0-9")))
+	stmt := OrCut(
+		join(And(Lit("let "), Cut(join(And(Set("a-z"), Lit("="), num))))),
+		join(Mult(1, 0, Set("a-z "))),
+	)
+	out, err := parse("let x=1", stmt)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "let x=1")
+	out, err = parse("lettuce", stmt)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "lettuce")
+
+	sr := SimpleReader{strings.NewReader("let x=y")}
+	_, err = stmt(sr)
+	if err == nil || err.Error() != `Expected "0-9", got "y"` {
+		t.Errorf("got %v", err)
+	}
+	assert(t, sr.State(), any(int64(0)))
+
+	// without OrCut the second alternative silently matches "let x"
+	out, err = parse("let x=y", Or(
+		join(And(Lit("let "), Cut(join(And(Set("a-z"), Lit("="), num))))),
+		join(Mult(1, 0, Set("a-z "))),
+	))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "let x")
+
+	// the cut is scoped to the OrCut, so an outer Or still backtracks
+	out, err = parse("let x=y", Or(stmt, Lit("let")))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, "let")
+}
+
+func TestMultSep(t *testing.T) {
+	t.Parallel()
+	item := Set("a-z")
+	tests := []struct {
+		p    func(StatefulReader) ([]string, error)
+		in   string
+		want []string
+		rest int64
+	}{
+		{MultSep(2, 0, item, Lit(",")), "a,b,c", []string{"a", "b", "c"}, 5},
+		{MultSep(2, 0, item, Lit(",")), "a,b,", []string{"a", "b"}, 3},
+		{MultSep(0, 2, item, Lit(",")), "a,b,c", []string{"a", "b"}, 3},
+		{MultSep(0, 0, item, Lit(",")), "1", []string{}, 0},
+		{MultSepTrailing(2, 0, item, Lit(",")), "a,b,", []string{"a", "b"}, 4},
+		{MultSepTrailing(1, 0, item, Lit(",")), "a,,", []string{"a"}, 2},
+		{MultSepTrailing(0, 0, item, Lit(",")), ",", []string{}, 0},
+		{MultTerm(1, 0, item, Lit(";")), "a;b;c", []string{"a", "b"}, 4},
+	}
+	for _, tc := range tests {
+		sr := SimpleReader{strings.NewReader(tc.in)}
+		out, err := tc.p(sr)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		assert(t, out, tc.want)
+		assert(t, sr.State(), any(tc.rest))
+	}
+	sr := SimpleReader{strings.NewReader("a,b,")}
+	if _, err := MultSep(3, 0, item, Lit(","))(sr); err == nil {
+		t.Error("Expected error for too few items")
+	}
+	assert(t, sr.State(), any(int64(0)))
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+	var nilParser func(StatefulReader) (string, error)
+	for name, f := range map[string]func(){
+		"parser: Or: parser 1 is nil":                       func() { Or(Lit("a"), nilParser) },
+		"parser: And: parser 0 is nil":                      func() { And(nilParser) },
+		"parser: Mult: minimum 3 is greater than maximum 2": func() { Mult(3, 2, Lit("a")) },
+		"parser: Mult: negative count -1, 0":                func() { Mult(-1, 0, Lit("a")) },
+		"parser: Lit: empty text":                           func() { Lit("") },
+		"parser: Set: empty set":                            func() { Set("") },
+		`parser: set "z-a": range z-a is backwards`:         func() { Set("z-a") },
+		"parser: Convert: nil function":                     func() { Convert[string, int](Lit("a"), nil) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != name {
+					t.Errorf("got panic %v, want %q", r, name)
+				}
+			}()
+			f()
+		}()
+	}
+	// a dash at either end is literal
+	Set("-a-z")
+	Set("+-")
+}
+
 func TestExpr(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
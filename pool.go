@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pooling is 1 while scratch buffers are reused.
+var pooling int32 = 1
+
+// maxScratch is the largest buffer returned to the pool, so one long
+// literal doesn't pin a large buffer for the life of the process.
+const maxScratch = 1024
+
+var scratch = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// SetPooling turns the reuse of the scratch buffers Lit and the rune
+// parsers read into on or off. It is on by default, so services parsing
+// many small documents don't churn the garbage collector; turning it off
+// makes every buffer a fresh allocation, which makes a suspected aliasing
+// bug easier to find with the race detector or a heap profile.
+func SetPooling(on bool) {
+	if on {
+		atomic.StoreInt32(&pooling, 1)
+	} else {
+		atomic.StoreInt32(&pooling, 0)
+	}
+}
+
+// getScratch returns a buffer of length n. Release it with putScratch once
+// nothing refers to its contents.
+func getScratch(n int) *[]byte {
+	if atomic.LoadInt32(&pooling) == 0 {
+		b := make([]byte, n)
+		return &b
+	}
+	b := scratch.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	*b = (*b)[:n]
+	return b
+}
+
+func putScratch(b *[]byte) {
+	if atomic.LoadInt32(&pooling) == 0 || cap(*b) > maxScratch {
+		return
+	}
+	scratch.Put(b)
+}
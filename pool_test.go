@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPooling(t *testing.T) {
+	p := And(Lit("héllo"), Set(" "), join(Mult(1, 0, NotSet("!"))), Lit("!"))
+	for _, on := range []bool{false, true} {
+		SetPooling(on)
+		out, err := p(NewSimpleReader(strings.NewReader("héllo wörld!")))
+		if err != nil {
+			t.Fatalf("pooling %v: %v", on, err)
+		}
+		assert(t, out, []string{"héllo", " ", "wörld", "!"})
+		// the text of a failed literal must not alias a pooled buffer
+		_, err = Lit("abc")(NewSimpleReader(strings.NewReader("abd")))
+		before := err.Error()
+		Lit("xyz")(NewSimpleReader(strings.NewReader("xyq")))
+		if err.Error() != before {
+			t.Errorf("pooling %v: error changed from %q to %q", on, before, err.Error())
+		}
+	}
+}
+
+func TestScratchSize(t *testing.T) {
+	b := getScratch(maxScratch + 1)
+	if len(*b) != maxScratch+1 {
+		t.Errorf("got length %d", len(*b))
+	}
+	putScratch(b)
+	if b := getScratch(3); len(*b) != 3 {
+		t.Errorf("got length %d", len(*b))
+	}
+}
+
+func BenchmarkSimpleReader(b *testing.B) {
+	p := Mult(0, 0, Or(Lit("let "), join(Mult(1, 0, Set("a-z"))), Lit(" = "), Lit(";\n")))
+	input := strings.Repeat("let abc = def;\n", 100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p(NewSimpleReader(strings.NewReader(input)))
+	}
+}
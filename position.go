@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position is a location in the input. Line and Column are 1-based. Column
+// counts runes, or display cells if the PosReader has a TabWidth.
+type Position struct {
+	Offset int64
+	Line   int
+	Column int
+	// File is the name of the source, if known. Line and File can be
+	// changed mid-parse with SetLine.
+	File string
+}
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+type posState struct {
+	inner any
+	pos   Position
+}
+
+// PosReader wraps a StatefulReader and tracks the position of the next byte
+// to be read.
+type PosReader struct {
+	sr  StatefulReader
+	pos Position
+	// TabWidth, if set, makes columns count display cells the way a
+	// terminal or editor does, so carets under error positions line up:
+	// tabs advance to the next multiple of TabWidth, wide East Asian
+	// characters count two and combining marks none.
+	TabWidth int
+
+	// partial holds the start of a multi-byte rune split across reads
+	partial []byte
+}
+
+func NewPosReader(sr StatefulReader) *PosReader {
+	return &PosReader{
+		sr:  sr,
+		pos: Position{Line: 1, Column: 1},
+	}
+}
+
+func (pr *PosReader) Read(p []byte) (n int, err error) {
+	n, err = pr.sr.Read(p)
+	pr.track(p[:n])
+	return n, err
+}
+
+// track moves the position past bs.
+func (pr *PosReader) track(bs []byte) {
+	for _, b := range bs {
+		pr.pos.Offset++
+		switch {
+		case b == '\n':
+			pr.pos.Line++
+			pr.pos.Column = 1
+		case pr.TabWidth > 0:
+			pr.advance(b)
+		case b&0xC0 != 0x80:
+			pr.pos.Column++
+		}
+	}
+}
+
+// advance moves the column past b in display cells, once b completes a
+// rune.
+func (pr *PosReader) advance(b byte) {
+	pr.partial = append(pr.partial, b)
+	if !utf8.FullRune(pr.partial) {
+		return
+	}
+	r, _ := utf8.DecodeRune(pr.partial)
+	pr.partial = pr.partial[:0]
+	if r == '\t' {
+		pr.pos.Column = (pr.pos.Column-1)/pr.TabWidth*pr.TabWidth + pr.TabWidth + 1
+		return
+	}
+	pr.pos.Column += RuneWidth(r)
+}
+
+// RuneWidth returns the number of display cells r takes up in a monospaced
+// terminal: 0 for combining marks and other zero width characters, 2 for
+// wide East Asian characters and emoji, and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115F,
+		r >= 0x2E80 && r <= 0x303E,
+		r >= 0x3041 && r <= 0xA4CF,
+		r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF,
+		r >= 0xFE30 && r <= 0xFE4F,
+		r >= 0xFF00 && r <= 0xFF60,
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F,
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	}
+	return 1
+}
+
+func (pr *PosReader) State() any {
+	return posState{inner: pr.sr.State(), pos: pr.pos}
+}
+
+func (pr *PosReader) Restore(s any) {
+	ps := s.(posState)
+	pr.sr.Restore(ps.inner)
+	pr.pos = ps.pos
+	pr.partial = pr.partial[:0]
+}
+
+// Clone returns an independent copy of pr if the wrapped reader can be
+// cloned.
+func (pr *PosReader) Clone() (StatefulReader, bool) {
+	inner, ok := CloneReader(pr.sr)
+	if !ok {
+		return nil, false
+	}
+	return &PosReader{sr: inner, pos: pr.pos, TabWidth: pr.TabWidth}, true
+}
+
+// Pos returns the position of the next byte to be read.
+func (pr *PosReader) Pos() Position {
+	return pr.pos
+}
+
+// SetLine makes the next byte read be on line of file, like C's #line
+// directive, so diagnostics for generated or embedded sources point at the
+// original. An empty file keeps the current name. Offsets and columns are
+// unaffected, and backtracking past the call undoes it.
+func (pr *PosReader) SetLine(file string, line int) {
+	if file != "" {
+		pr.pos.File = file
+	}
+	pr.pos.Line = line
+}
+
+// SetLine calls SetLine on sr if it tracks positions, and does nothing
+// otherwise.
+func SetLine(sr StatefulReader, file string, line int) {
+	if pr, ok := sr.(interface{ SetLine(string, int) }); ok {
+		pr.SetLine(file, line)
+	}
+}
+
+// Pos returns the current position of sr if it tracks one, and the zero
+// Position otherwise.
+func Pos(sr StatefulReader) Position {
+	if pr, ok := sr.(interface{ Pos() Position }); ok {
+		return pr.Pos()
+	}
+	return Position{}
+}
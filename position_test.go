@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPosReader(t *testing.T) {
+	t.Parallel()
+	pr := NewPosReader(SimpleReader{strings.NewReader("ab\nñd")})
+	_, err := Lit("ab\n")(pr)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, Pos(pr), Position{Offset: 3, Line: 2, Column: 1})
+	s := pr.State()
+	_, err = Set("ñ")(pr)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, Pos(pr), Position{Offset: 5, Line: 2, Column: 2})
+	_, err = Lit("x")(pr)
+	if err == nil {
+		t.Error("Expected error")
+	}
+	assert(t, Pos(pr), Position{Offset: 5, Line: 2, Column: 2})
+	pr.Restore(s)
+	assert(t, Pos(pr), Position{Offset: 3, Line: 2, Column: 1})
+}
+
+func TestPosReaderTabWidth(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		in  string
+		col int
+	}{
+		{"\t", 5},
+		{"ab\t", 5},
+		{"abcd\t", 9},
+		{"日本", 5},
+		{"e\u0301x", 3},
+		{"\u00e9x", 3},
+		{"a\tb\n\t", 5},
+	} {
+		pr := NewPosReader(SimpleReader{strings.NewReader(tc.in)})
+		pr.TabWidth = 4
+		Mult(0, 0, NotSet(""))(pr)
+		assertSrc(t, tc.in, pr.Pos().Column, tc.col)
+	}
+
+	_, c, _ := ParseReader(Lit("\t"), strings.NewReader("\tx"), TabWidth(8))
+	assert(t, c.Pos().Column, 9)
+}
+
+func TestSetLine(t *testing.T) {
+	t.Parallel()
+	directive := Bind(Right(Lit("#line "), join(Mult(1, 0, Set("0-9")))), func(n string) func(StatefulReader) (string, error) {
+		return func(sr StatefulReader) (string, error) {
+			file, err := Optional(Right(Lit(" "), join(Mult(1, 0, NotSet("\n")))))(sr)
+			if err != nil {
+				return "", err
+			}
+			if _, err := Lit("\n")(sr); err != nil {
+				return "", err
+			}
+			line, _ := strconv.Atoi(n)
+			SetLine(sr, file, line)
+			return n, nil
+		}
+	})
+	stmt := Left(join(Mult(1, 0, Set("a-z"))), Lit("\n"))
+	p := Mult(0, 0, Or(directive, stmt))
+
+	c := NewContext(strings.NewReader("a\n#line 40 gen.y\nb\nc\n#line 7\n!"))
+	_, err := p(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, c.Pos().String(), "gen.y:7:1")
+	_, err = stmt(c)
+	var me *MessageError
+	if !errors.As(err, &me) {
+		t.Errorf("got %v", err)
+	}
+
+	pr := NewPosReader(SimpleReader{strings.NewReader("x\ny")})
+	s := pr.State()
+	pr.SetLine("in.txt", 10)
+	Lit("x\n")(pr)
+	assert(t, pr.Pos().String(), "in.txt:11:1")
+	pr.Restore(s)
+	assert(t, pr.Pos().String(), "1:1")
+}
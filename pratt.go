@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Pratt is a top-down operator precedence parser. Operators are registered
+// with binding powers and handlers: "nud" handlers run when an operator
+// starts an expression (prefix operators, brackets) and "led" handlers when
+// it follows a complete left operand (infix and postfix operators).
+//
+// Operators may be added at any time between parses, which makes Pratt a
+// good fit for languages with user-defined operators. It must not be
+// modified while a parse is running.
+type Pratt[T any] struct {
+	operand func(sr StatefulReader) (T, error)
+	// Skip, if set, runs before every operand and operator, typically to
+	// consume whitespace.
+	Skip func(sr StatefulReader) (string, error)
+
+	nuds map[string]func(p *Pratt[T], sr StatefulReader, op string) (T, error)
+	leds map[string]led[T]
+	ops  []string
+}
+
+type led[T any] struct {
+	bp int
+	f  func(p *Pratt[T], sr StatefulReader, op string, left T) (T, error)
+}
+
+// NewPratt returns a Pratt parser whose atoms are parsed by operand.
+func NewPratt[T any](operand func(sr StatefulReader) (T, error)) *Pratt[T] {
+	return &Pratt[T]{
+		operand: operand,
+		nuds:    map[string]func(p *Pratt[T], sr StatefulReader, op string) (T, error){},
+		leds:    map[string]led[T]{},
+	}
+}
+
+func (p *Pratt[T]) addOp(op string) {
+	for _, o := range p.ops {
+		if o == op {
+			return
+		}
+	}
+	p.ops = append(p.ops, op)
+	// try longer operators first so "**" wins over "*"
+	sort.SliceStable(p.ops, func(i, j int) bool {
+		return len(p.ops[i]) > len(p.ops[j])
+	})
+}
+
+// Nud registers a handler for op in prefix position. The handler is called
+// after op has been consumed.
+func (p *Pratt[T]) Nud(op string, f func(p *Pratt[T], sr StatefulReader, op string) (T, error)) {
+	p.addOp(op)
+	p.nuds[op] = f
+}
+
+// Led registers a handler for op following a left operand, with left binding
+// power bp. The handler is called after op has been consumed.
+func (p *Pratt[T]) Led(op string, bp int, f func(p *Pratt[T], sr StatefulReader, op string, left T) (T, error)) {
+	p.addOp(op)
+	p.leds[op] = led[T]{bp: bp, f: f}
+}
+
+// Prefix registers a prefix operator whose operand binds with power bp.
+func (p *Pratt[T]) Prefix(op string, bp int, f func(op string, x T) (T, error)) {
+	p.Nud(op, func(p *Pratt[T], sr StatefulReader, op string) (T, error) {
+		x, err := p.ParseBP(sr, bp)
+		if err != nil {
+			return x, err
+		}
+		return f(op, x)
+	})
+}
+
+// Infix registers a left-associative binary operator.
+func (p *Pratt[T]) Infix(op string, bp int, f func(op string, l, r T) (T, error)) {
+	p.Led(op, bp, func(p *Pratt[T], sr StatefulReader, op string, left T) (T, error) {
+		r, err := p.ParseBP(sr, bp)
+		if err != nil {
+			return r, err
+		}
+		return f(op, left, r)
+	})
+}
+
+// InfixRight registers a right-associative binary operator.
+func (p *Pratt[T]) InfixRight(op string, bp int, f func(op string, l, r T) (T, error)) {
+	p.Led(op, bp, func(p *Pratt[T], sr StatefulReader, op string, left T) (T, error) {
+		r, err := p.ParseBP(sr, bp-1)
+		if err != nil {
+			return r, err
+		}
+		return f(op, left, r)
+	})
+}
+
+// Postfix registers a postfix operator.
+func (p *Pratt[T]) Postfix(op string, bp int, f func(op string, x T) (T, error)) {
+	p.Led(op, bp, func(p *Pratt[T], sr StatefulReader, op string, left T) (T, error) {
+		return f(op, left)
+	})
+}
+
+func (p *Pratt[T]) skip(sr StatefulReader) {
+	if p.Skip != nil {
+		p.Skip(sr)
+	}
+}
+
+// operator consumes the longest registered operator accepted by ok.
+func (p *Pratt[T]) operator(sr StatefulReader, ok func(string) bool) (string, bool) {
+	s := sr.State()
+	p.skip(sr)
+	for _, op := range p.ops {
+		if !ok(op) {
+			continue
+		}
+		if _, err := Lit(op)(sr); err == nil {
+			return op, true
+		}
+	}
+	sr.Restore(s)
+	return "", false
+}
+
+// Parse parses a complete expression.
+func (p *Pratt[T]) Parse(sr StatefulReader) (T, error) {
+	return p.ParseBP(sr, 0)
+}
+
+// ParseBP parses an expression containing only operators that bind more
+// tightly than minBP. Handlers use it to parse their operands.
+func (p *Pratt[T]) ParseBP(sr StatefulReader, minBP int) (T, error) {
+	s := sr.State()
+	var left T
+	var err error
+	if op, ok := p.operator(sr, func(op string) bool { return p.nuds[op] != nil }); ok {
+		left, err = p.nuds[op](p, sr, op)
+	} else {
+		p.skip(sr)
+		left, err = p.operand(sr)
+	}
+	if err != nil {
+		sr.Restore(s)
+		return left, err
+	}
+	for {
+		op, ok := p.operator(sr, func(op string) bool {
+			l, ok := p.leds[op]
+			return ok && l.bp > minBP
+		})
+		if !ok {
+			return left, nil
+		}
+		next, err := p.leds[op].f(p, sr, op, left)
+		if err != nil {
+			sr.Restore(s)
+			return left, fmt.Errorf("After %q: %w", op, err)
+		}
+		left = next
+	}
+}
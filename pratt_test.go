@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newPrattCalc() *Pratt[string] {
+	p := NewPratt(join(Mult(1, 0, Set("0-9a-z"))))
+	p.Skip = join(Mult(0, 0, Set(" ")))
+	bin := func(op string, l, r string) (string, error) {
+		return fmt.Sprintf("(%s %s %s)", l, op, r), nil
+	}
+	p.Infix("+", 10, bin)
+	p.Infix("-", 10, bin)
+	p.Infix("*", 20, bin)
+	p.Infix("/", 20, bin)
+	p.InfixRight("^", 30, bin)
+	p.Prefix("-", 25, func(op string, x string) (string, error) {
+		return "(-" + x + ")", nil
+	})
+	p.Postfix("!", 40, func(op string, x string) (string, error) {
+		return "(" + x + "!)", nil
+	})
+	p.Nud("(", func(p *Pratt[string], sr StatefulReader, op string) (string, error) {
+		x, err := p.Parse(sr)
+		if err != nil {
+			return x, err
+		}
+		p.Skip(sr)
+		_, err = Lit(")")(sr)
+		return x, err
+	})
+	return p
+}
+
+func TestPratt(t *testing.T) {
+	t.Parallel()
+	p := newPrattCalc()
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"-a ^ 2", "(-(a ^ 2))"},
+		{"-a * b", "((-a) * b)"},
+		{"(1 + 2) * n!", "((1 + 2) * (n!))"},
+		{"a - -b", "(a - (-b))"},
+	}
+	for _, test := range tests {
+		out, err := parse(test.in, p.Parse)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		assertSrc(t, test.in, out, test.out)
+	}
+}
+
+func TestPrattExtend(t *testing.T) {
+	t.Parallel()
+	p := newPrattCalc()
+	out, err := parse("a <> b + c", And(p.Parse, EOF()))
+	if err == nil {
+		t.Errorf("Expected error before operator is defined, got %v", out)
+	}
+	p.Infix("<>", 5, func(op string, l, r string) (string, error) {
+		return "(" + l + " <> " + r + ")", nil
+	})
+	p.Infix("<", 5, func(op string, l, r string) (string, error) {
+		return "(" + l + " < " + r + ")", nil
+	})
+	res, err := parse("a <> b + c < d", p.Parse)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, res, "((a <> (b + c)) < d)")
+}
+
+func TestPrattErrors(t *testing.T) {
+	t.Parallel()
+	p := newPrattCalc()
+	out, err := parse("1 + ", And(p.Parse, EOF()))
+	if err == nil {
+		t.Errorf("Expected error, got %v", out)
+	}
+	_, err = parse("(1 + 2", p.Parse)
+	if err == nil {
+		t.Error("Expected error for unclosed paren")
+	}
+
+	// a failed infix operand leaves the reader where the expression began
+	sr := NewBytesReader([]byte("a+)"))
+	if _, err := Checked("expr", p.Parse)(sr); err == nil {
+		t.Error("Expected error for a missing operand")
+	}
+	if off := sr.State().(int64); off != 0 {
+		t.Errorf("reader left at %d", off)
+	}
+}
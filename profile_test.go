@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestProfileRules(t *testing.T) {
+	t.Parallel()
+	labels := []string{}
+	record := func(sr StatefulReader) (string, error) {
+		c := ContextOf(sr)
+		rule, _ := pprof.Label(c.profile, "rule")
+		req, _ := pprof.Label(c.profile, "request")
+		labels = append(labels, req+"/"+rule)
+		return "", nil
+	}
+	g := NewGrammar()
+	inner := Rule(g, "inner", func() func(StatefulReader) (string, error) {
+		return record
+	})
+	outer := Named("outer", join(And(record, inner, record)))
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("request", "r1"))
+	if _, _, err := ParseBytes(outer, nil, ProfileRules(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	assert(t, labels, []string{"r1/outer", "r1/inner", "r1/outer"})
+}
@@ -0,0 +1,276 @@
+// Package dns parses DNS messages as described in RFC 1035, including name
+// compression. It is built on the binary combinators and serves as their
+// real-world test bed.
+package dns
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/binary"
+)
+
+// Header is the fixed 12 byte message header with its flags unpacked.
+type Header struct {
+	ID                 uint16
+	Response           bool
+	Opcode             uint8
+	Authoritative      bool
+	Truncated          bool
+	RecursionDesired   bool
+	RecursionAvailable bool
+	Z                  uint8
+	RCode              uint8
+	QDCount            uint16
+	ANCount            uint16
+	NSCount            uint16
+	ARCount            uint16
+}
+
+// Question is an entry of the question section.
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// Resource is a resource record. Data holds the raw RDATA; the common types
+// are also decoded into Addr, Target or Text.
+type Resource struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+
+	// Addr is set for A and AAAA records.
+	Addr netip.Addr
+	// Target is set for CNAME, NS, PTR and MX records.
+	Target string
+	// Preference is set for MX records.
+	Preference uint16
+	// Text is set for TXT records.
+	Text []string
+}
+
+// Message is a complete DNS message.
+type Message struct {
+	Header     Header
+	Questions  []Question
+	Answers    []Resource
+	Authority  []Resource
+	Additional []Resource
+}
+
+// Record types decoded by Parse.
+const (
+	TypeA     = 1
+	TypeNS    = 2
+	TypeCNAME = 5
+	TypePTR   = 12
+	TypeMX    = 15
+	TypeTXT   = 16
+	TypeAAAA  = 28
+)
+
+var (
+	u8  = binary.U8()
+	u16 = binary.U16BE()
+	u32 = binary.U32BE()
+)
+
+func header(sr parser.StatefulReader) (Header, error) {
+	fields, err := parser.And(u16, u16, u16, u16, u16, u16)(sr)
+	if err != nil {
+		return Header{}, fmt.Errorf("header: %w", err)
+	}
+	flags := binary.Bits(uint64(fields[1]), 16, 1, 4, 1, 1, 1, 1, 3, 4)
+	return Header{
+		ID:                 fields[0],
+		Response:           flags[0] == 1,
+		Opcode:             uint8(flags[1]),
+		Authoritative:      flags[2] == 1,
+		Truncated:          flags[3] == 1,
+		RecursionDesired:   flags[4] == 1,
+		RecursionAvailable: flags[5] == 1,
+		Z:                  uint8(flags[6]),
+		RCode:              uint8(flags[7]),
+		QDCount:            fields[2],
+		ANCount:            fields[3],
+		NSCount:            fields[4],
+		ARCount:            fields[5],
+	}, nil
+}
+
+// decoder holds the whole message so that compression pointers can be
+// followed.
+type decoder struct {
+	msg []byte
+}
+
+func (d *decoder) reader(offset int) parser.StatefulReader {
+	r := bytes.NewReader(d.msg)
+	r.Seek(int64(offset), io.SeekStart)
+	return parser.NewSimpleReader(r)
+}
+
+func offset(sr parser.StatefulReader) int {
+	return int(sr.State().(int64))
+}
+
+// name reads a possibly compressed domain name. Pointers must point
+// strictly backwards, which rules out loops.
+func (d *decoder) name(sr parser.StatefulReader) (string, error) {
+	labels := []string{}
+	limit := offset(sr)
+	for {
+		start := offset(sr)
+		n, err := u8(sr)
+		if err != nil {
+			return "", err
+		}
+		switch binary.Bits(uint64(n), 8, 2)[0] {
+		case 0:
+			if n == 0 {
+				return strings.Join(labels, ".") + ".", nil
+			}
+			b, err := binary.Bytes(int(n))(sr)
+			if err != nil {
+				return "", err
+			}
+			labels = append(labels, string(b))
+		case 3:
+			lo, err := u8(sr)
+			if err != nil {
+				return "", err
+			}
+			ptr := int(n&0x3f)<<8 | int(lo)
+			if ptr >= limit || ptr >= start {
+				return "", fmt.Errorf("Compression pointer at %d to %d does not point backwards", start, ptr)
+			}
+			rest, err := d.name(d.reader(ptr))
+			if err != nil {
+				return "", err
+			}
+			if len(labels) == 0 {
+				return rest, nil
+			}
+			if rest == "." {
+				rest = ""
+			}
+			return strings.Join(labels, ".") + "." + rest, nil
+		default:
+			return "", fmt.Errorf("Unsupported label type 0x%02x at %d", n, start)
+		}
+		if len(strings.Join(labels, ".")) > 253 {
+			return "", fmt.Errorf("Name too long")
+		}
+	}
+}
+
+func (d *decoder) question(sr parser.StatefulReader) (Question, error) {
+	q := Question{}
+	var err error
+	if q.Name, err = d.name(sr); err != nil {
+		return q, err
+	}
+	if q.Type, err = u16(sr); err != nil {
+		return q, err
+	}
+	q.Class, err = u16(sr)
+	return q, err
+}
+
+func (d *decoder) resource(sr parser.StatefulReader) (Resource, error) {
+	r := Resource{}
+	var err error
+	if r.Name, err = d.name(sr); err != nil {
+		return r, err
+	}
+	if r.Type, err = u16(sr); err != nil {
+		return r, err
+	}
+	if r.Class, err = u16(sr); err != nil {
+		return r, err
+	}
+	if r.TTL, err = u32(sr); err != nil {
+		return r, err
+	}
+	start := offset(sr) + 2
+	if r.Data, err = binary.LengthPrefixed(u16, parser.Mult(0, 0, u8))(sr); err != nil {
+		return r, err
+	}
+	return r, d.rdata(&r, start)
+}
+
+// rdata decodes well-known record types. start is the offset of the RDATA in
+// the message, needed for names that use compression.
+func (d *decoder) rdata(r *Resource, start int) error {
+	var err error
+	switch r.Type {
+	case TypeA, TypeAAAA:
+		var ok bool
+		if r.Addr, ok = netip.AddrFromSlice(r.Data); !ok || (r.Type == TypeA) != r.Addr.Is4() {
+			return fmt.Errorf("Invalid address length %d for type %d", len(r.Data), r.Type)
+		}
+	case TypeNS, TypeCNAME, TypePTR:
+		r.Target, err = d.name(d.reader(start))
+	case TypeMX:
+		sr := d.reader(start)
+		if r.Preference, err = u16(sr); err == nil {
+			r.Target, err = d.name(sr)
+		}
+	case TypeTXT:
+		r.Text, err = binary.Region(r.Data, parser.Mult(0, 0, binary.LengthPrefixed(u8, parser.Convert(parser.Mult(0, 0, u8), func(b []byte) (string, error) {
+			return string(b), nil
+		}))))
+	}
+	if err != nil {
+		return fmt.Errorf("type %d data: %w", r.Type, err)
+	}
+	return nil
+}
+
+func count[T any](n uint16, section string, p func(sr parser.StatefulReader) (T, error), sr parser.StatefulReader) ([]T, error) {
+	// n is untrusted, so grow vs as records parse rather than up front
+	vs := []T{}
+	for i := 0; i < int(n); i++ {
+		v, err := p(sr)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", section, i+1, err)
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// Parse parses a complete DNS message.
+func Parse(msg []byte) (*Message, error) {
+	d := &decoder{msg: msg}
+	sr := d.reader(0)
+	m := &Message{}
+	var err error
+	if m.Header, err = header(sr); err != nil {
+		return nil, err
+	}
+	if m.Questions, err = count(m.Header.QDCount, "question", d.question, sr); err != nil {
+		return nil, err
+	}
+	if m.Answers, err = count(m.Header.ANCount, "answer", d.resource, sr); err != nil {
+		return nil, err
+	}
+	if m.Authority, err = count(m.Header.NSCount, "authority", d.resource, sr); err != nil {
+		return nil, err
+	}
+	if m.Additional, err = count(m.Header.ARCount, "additional", d.resource, sr); err != nil {
+		return nil, err
+	}
+	if rest := len(msg) - offset(sr); rest > 0 {
+		return nil, fmt.Errorf("%d trailing bytes after message", rest)
+	}
+	return m, nil
+}
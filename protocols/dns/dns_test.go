@@ -0,0 +1,73 @@
+package dns
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// response for example.com with an A record, an MX record and a TXT record,
+// using compression pointers back to the question name at offset 12.
+var response = []byte{
+	0x12, 0x34, 0x81, 0x80, 0x00, 0x01, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00,
+	// question: example.com A IN
+	7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0x00, 0x01, 0x00, 0x01,
+	// answer: ptr(example.com) A IN ttl 300 93.184.216.34
+	0xc0, 0x0c, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x04, 93, 184, 216, 34,
+	// answer: ptr(example.com) MX 10 mail.ptr(example.com)
+	0xc0, 0x0c, 0x00, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x09, 0x00, 0x0a, 4, 'm', 'a', 'i', 'l', 0xc0, 0x0c,
+	// answer: ptr(example.com) TXT "v=spf1" "-all"
+	0xc0, 0x0c, 0x00, 0x10, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x0c, 6, 'v', '=', 's', 'p', 'f', '1', 4, '-', 'a', 'l', 'l',
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	m, err := Parse(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Header{ID: 0x1234, Response: true, RecursionDesired: true, RecursionAvailable: true, QDCount: 1, ANCount: 3}
+	if m.Header != h {
+		t.Errorf("Expected header %+v, got %+v", h, m.Header)
+	}
+	if !reflect.DeepEqual(m.Questions, []Question{{Name: "example.com.", Type: TypeA, Class: 1}}) {
+		t.Errorf("Unexpected questions %+v", m.Questions)
+	}
+	if len(m.Answers) != 3 {
+		t.Fatalf("Expected 3 answers, got %d", len(m.Answers))
+	}
+	a := m.Answers[0]
+	if a.Name != "example.com." || a.TTL != 300 || a.Addr != netip.MustParseAddr("93.184.216.34") {
+		t.Errorf("Unexpected A record %+v", a)
+	}
+	mx := m.Answers[1]
+	if mx.Preference != 10 || mx.Target != "mail.example.com." {
+		t.Errorf("Unexpected MX record %+v", mx)
+	}
+	if !reflect.DeepEqual(m.Answers[2].Text, []string{"v=spf1", "-all"}) {
+		t.Errorf("Unexpected TXT record %+v", m.Answers[2])
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	loop := append([]byte{}, response[:12]...)
+	loop[5] = 1
+	loop = append(loop, 0xc0, 0x0c, 0, 1, 0, 1)
+	tests := []struct {
+		msg []byte
+		err string
+	}{
+		{response[:5], "header:"},
+		{response[:len(response)-1], "answer 3:"},
+		{append(append([]byte{}, response...), 0), "1 trailing bytes"},
+		{loop, "question 1: Compression pointer at 12 to 12 does not point backwards"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.msg)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("Expected error starting %q, got %v", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Package http1 parses HTTP/1.x request lines, status lines and header
+// fields following the ABNF in RFC 9112 and RFC 9110.
+package http1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// RequestLine is the first line of an HTTP request.
+type RequestLine struct {
+	Method  string
+	Target  string
+	Version Version
+}
+
+// StatusLine is the first line of an HTTP response.
+type StatusLine struct {
+	Version Version
+	Code    int
+	Reason  string
+}
+
+// Version is an HTTP protocol version such as HTTP/1.1.
+type Version struct {
+	Major, Minor int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("HTTP/%d.%d", v.Major, v.Minor)
+}
+
+// Field is a single header field. Names keep their original case.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Header is an ordered list of header fields.
+type Header []Field
+
+// Get returns the value of the first field named name, compared
+// case-insensitively.
+func (h Header) Get(name string) (string, bool) {
+	for _, f := range h {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Request is a request line followed by its header section.
+type Request struct {
+	RequestLine
+	Header Header
+}
+
+// Response is a status line followed by its header section.
+type Response struct {
+	StatusLine
+	Header Header
+}
+
+func join(p func(sr parser.StatefulReader) ([]string, error)) func(sr parser.StatefulReader) (string, error) {
+	return parser.Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+const tchar = "!#$%&'*+.^_`|~0-9a-zA-Z-"
+
+var (
+	sp    = parser.Lit(" ")
+	crlf  = parser.Lit("\r\n")
+	ows   = join(parser.Mult(0, 0, parser.Set(" \t")))
+	token = join(parser.Mult(1, 0, parser.Set(tchar)))
+	digit = parser.Set("0-9")
+	// visible characters, spaces, tabs and obs-text
+	fieldChars = octets(0, func(c byte) bool { return c == '\t' || c >= ' ' && c != 0x7f })
+	target     = octets(1, func(c byte) bool { return c > ' ' && c != 0x7f })
+
+	version = parser.Convert(parser.And(parser.Lit("HTTP/"), digit, parser.Lit("."), digit), func(s []string) (Version, error) {
+		return Version{Major: int(s[1][0] - '0'), Minor: int(s[3][0] - '0')}, nil
+	})
+	statusCode = parser.Convert(join(parser.Mult(3, 3, digit)), strconv.Atoi)
+)
+
+// octets reads a run of at least min bytes that satisfy ok. The bytes are
+// kept as they are rather than decoded as UTF-8, since obs-text is
+// typically Latin-1.
+func octets(min int, ok func(c byte) bool) func(sr parser.StatefulReader) (string, error) {
+	return func(sr parser.StatefulReader) (string, error) {
+		b := []byte{}
+		one := make([]byte, 1)
+		for {
+			s := sr.State()
+			if n, _ := sr.Read(one); n == 0 || !ok(one[0]) {
+				sr.Restore(s)
+				break
+			}
+			b = append(b, one[0])
+		}
+		if len(b) < min {
+			return "", fmt.Errorf("Expected at least %d bytes", min)
+		}
+		return string(b), nil
+	}
+}
+
+func expect[T any](what string, p func(sr parser.StatefulReader) (T, error)) func(sr parser.StatefulReader) (T, error) {
+	return func(sr parser.StatefulReader) (T, error) {
+		v, err := p(sr)
+		if err != nil {
+			return v, fmt.Errorf("Expected %s: %w", what, err)
+		}
+		return v, nil
+	}
+}
+
+// ParseRequestLine parses method SP request-target SP HTTP-version CRLF.
+func ParseRequestLine(sr parser.StatefulReader) (RequestLine, error) {
+	s := sr.State()
+	rl, err := parseRequestLine(sr)
+	if err != nil {
+		sr.Restore(s)
+	}
+	return rl, err
+}
+
+func parseRequestLine(sr parser.StatefulReader) (RequestLine, error) {
+	rl := RequestLine{}
+	var err error
+	if rl.Method, err = expect("method", token)(sr); err != nil {
+		return rl, err
+	}
+	if _, err = expect("space after method", sp)(sr); err != nil {
+		return rl, err
+	}
+	if rl.Target, err = expect("request target", target)(sr); err != nil {
+		return rl, err
+	}
+	if _, err = expect("space after request target", sp)(sr); err != nil {
+		return rl, err
+	}
+	if rl.Version, err = expect("HTTP version", version)(sr); err != nil {
+		return rl, err
+	}
+	if _, err = expect("CRLF", crlf)(sr); err != nil {
+		return rl, err
+	}
+	return rl, nil
+}
+
+// ParseStatusLine parses HTTP-version SP status-code SP [reason-phrase] CRLF.
+func ParseStatusLine(sr parser.StatefulReader) (StatusLine, error) {
+	s := sr.State()
+	sl, err := parseStatusLine(sr)
+	if err != nil {
+		sr.Restore(s)
+	}
+	return sl, err
+}
+
+func parseStatusLine(sr parser.StatefulReader) (StatusLine, error) {
+	sl := StatusLine{}
+	var err error
+	if sl.Version, err = expect("HTTP version", version)(sr); err != nil {
+		return sl, err
+	}
+	if _, err = expect("space after HTTP version", sp)(sr); err != nil {
+		return sl, err
+	}
+	if sl.Code, err = expect("status code", statusCode)(sr); err != nil {
+		return sl, err
+	}
+	if _, err = expect("space after status code", sp)(sr); err != nil {
+		return sl, err
+	}
+	if sl.Reason, err = fieldChars(sr); err != nil {
+		return sl, err
+	}
+	if _, err = expect("CRLF", crlf)(sr); err != nil {
+		return sl, err
+	}
+	return sl, nil
+}
+
+// ParseField parses a single field-line: field-name ":" OWS field-value OWS
+// CRLF. Whitespace between the name and the colon is rejected, as is obsolete
+// line folding.
+func ParseField(sr parser.StatefulReader) (Field, error) {
+	s := sr.State()
+	f, err := parseField(sr)
+	if err != nil {
+		sr.Restore(s)
+	}
+	return f, err
+}
+
+func parseField(sr parser.StatefulReader) (Field, error) {
+	f := Field{}
+	var err error
+	if f.Name, err = expect("field name", token)(sr); err != nil {
+		return f, err
+	}
+	if _, err = expect("':' after field name", parser.Lit(":"))(sr); err != nil {
+		return f, err
+	}
+	ows(sr)
+	if f.Value, err = fieldChars(sr); err != nil {
+		return f, err
+	}
+	f.Value = strings.TrimRight(f.Value, " \t")
+	if _, err = expect("CRLF", crlf)(sr); err != nil {
+		return f, err
+	}
+	if _, err := parser.Set(" \t")(sr); err == nil {
+		return f, fmt.Errorf("Obsolete line folding is not supported")
+	}
+	return f, nil
+}
+
+// ParseHeader parses header fields up to and including the empty line that
+// ends the header section.
+func ParseHeader(sr parser.StatefulReader) (Header, error) {
+	h := Header{}
+	for {
+		if _, err := crlf(sr); err == nil {
+			return h, nil
+		}
+		f, err := ParseField(sr)
+		if err != nil {
+			return nil, fmt.Errorf("header field %d: %w", len(h)+1, err)
+		}
+		h = append(h, f)
+	}
+}
+
+// ParseRequest parses a request line and header section, leaving the reader
+// positioned at the start of the body.
+func ParseRequest(sr parser.StatefulReader) (Request, error) {
+	rl, err := ParseRequestLine(sr)
+	if err != nil {
+		return Request{}, fmt.Errorf("request line: %w", err)
+	}
+	h, err := ParseHeader(sr)
+	if err != nil {
+		return Request{}, err
+	}
+	return Request{RequestLine: rl, Header: h}, nil
+}
+
+// ParseResponse parses a status line and header section, leaving the reader
+// positioned at the start of the body.
+func ParseResponse(sr parser.StatefulReader) (Response, error) {
+	sl, err := ParseStatusLine(sr)
+	if err != nil {
+		return Response{}, fmt.Errorf("status line: %w", err)
+	}
+	h, err := ParseHeader(sr)
+	if err != nil {
+		return Response{}, err
+	}
+	return Response{StatusLine: sl, Header: h}, nil
+}
@@ -0,0 +1,93 @@
+package http1
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func reader(s string) parser.SimpleReader {
+	return parser.NewSimpleReader(strings.NewReader(s))
+}
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+	sr := reader("GET /index.html?q=1 HTTP/1.1\r\nHost: example.com\r\nX-Empty:\r\nAccept:  text/html \t\r\n\r\nbody")
+	req, err := ParseRequest(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Request{
+		RequestLine: RequestLine{Method: "GET", Target: "/index.html?q=1", Version: Version{1, 1}},
+		Header: Header{
+			{Name: "Host", Value: "example.com"},
+			{Name: "X-Empty", Value: ""},
+			{Name: "Accept", Value: "text/html"},
+		},
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, req)
+	}
+	if v, ok := req.Header.Get("host"); !ok || v != "example.com" {
+		t.Errorf("Unexpected Host %q", v)
+	}
+	body, _ := io.ReadAll(sr)
+	if string(body) != "body" {
+		t.Errorf("Expected body to remain, got %q", body)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+	resp, err := ParseResponse(reader("HTTP/1.0 404 Not Found\r\nContent-Length: 0\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != 404 || resp.Reason != "Not Found" || resp.Version.String() != "HTTP/1.0" {
+		t.Errorf("Unexpected status line %+v", resp.StatusLine)
+	}
+	resp, err = ParseResponse(reader("HTTP/1.1 204 \r\n\r\n"))
+	if err != nil || resp.Code != 204 || resp.Reason != "" {
+		t.Errorf("Unexpected response %+v, %v", resp, err)
+	}
+}
+
+func TestObsText(t *testing.T) {
+	t.Parallel()
+	req, err := ParseRequest(reader("GET / HTTP/1.1\r\nX-A: caf\xe9!\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := req.Header.Get("X-A"); v != "caf\xe9!" {
+		t.Errorf("Unexpected value %q", v)
+	}
+	resp, err := ParseResponse(reader("HTTP/1.1 200 \xc0k\r\n\r\n"))
+	if err != nil || resp.Reason != "\xc0k" {
+		t.Errorf("Unexpected response %+v, %v", resp, err)
+	}
+}
+
+func TestStrictness(t *testing.T) {
+	t.Parallel()
+	bad := []string{
+		"GET  / HTTP/1.1\r\n\r\n",
+		"GET / HTTP/1.1\n\r\n",
+		"GET / HTTP/11\r\n\r\n",
+		"GET / HTTP/1.1\r\nHost : x\r\n\r\n",
+		"GET / HTTP/1.1\r\nX-A: a\r\n b\r\n\r\n",
+		"GET / HTTP/1.1\r\nBad\x01: x\r\n\r\n",
+		"G@T / HTTP/1.1\r\n\r\n",
+	}
+	for _, in := range bad {
+		if _, err := ParseRequest(reader(in)); err == nil {
+			t.Errorf("Expected error for %q", in)
+		}
+	}
+	_, err := ParseRequest(reader("GET / HTTP/1.1\r\nHost : x\r\n\r\n"))
+	if err == nil || err.Error() != "header field 1: Expected ':' after field name: Expected \":\", got \" \"" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+// Package protobuf parses the protocol buffers wire format without a
+// schema, into the numbered fields of each message with their raw values,
+// much as protoc --decode_raw does. It is meant for debugging and generic
+// tooling; interpreting a field needs its declared type, which Field's
+// methods apply. It is built on the binary varint and length-prefixed
+// combinators.
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andyleap/parser"
+	"github.com/andyleap/parser/binary"
+)
+
+// WireType is how a field's value is encoded.
+type WireType uint8
+
+const (
+	Varint     WireType = 0
+	I64        WireType = 1
+	Len        WireType = 2
+	StartGroup WireType = 3
+	EndGroup   WireType = 4
+	I32        WireType = 5
+)
+
+var wireNames = []string{"VARINT", "I64", "LEN", "SGROUP", "EGROUP", "I32"}
+
+func (w WireType) String() string {
+	if int(w) < len(wireNames) {
+		return wireNames[w]
+	}
+	return fmt.Sprintf("WireType(%d)", uint8(w))
+}
+
+// Field is one field of a message as it appears on the wire.
+type Field struct {
+	Number int
+	Type   WireType
+	// Offset is where the field's tag starts in the outermost input.
+	Offset int64
+	// Varint holds a VARINT value, and Fixed an I64 or I32 one.
+	Varint uint64
+	Fixed  uint64
+	// Bytes holds a LEN value, and Group the fields of a group.
+	Bytes []byte
+	Group []Field
+
+	// payload is the offset of Bytes in the outermost input
+	payload int64
+}
+
+// Message holds a message's fields by number, each in the order it
+// appeared. Fields repeated on the wire appear more than once.
+type Message map[int][]Field
+
+// Get returns the last occurrence of field n, which is the one that counts
+// for a non-repeated field.
+func (m Message) Get(n int) (Field, bool) {
+	fs := m[n]
+	if len(fs) == 0 {
+		return Field{}, false
+	}
+	return fs[len(fs)-1], true
+}
+
+const maxField = 1<<29 - 1
+
+var (
+	uvarint = binary.Uvarint()
+	fixed64 = binary.U64LE()
+	fixed32 = binary.U32LE()
+)
+
+// offset returns the offset of sr, whose state must be its offset, as for
+// SimpleReader and BytesReader.
+func offset(sr parser.StatefulReader) int64 {
+	off, _ := sr.State().(int64)
+	return off
+}
+
+// Fields returns a parser for the fields of a message, up to the end of
+// the input, which must be a reader whose state is its offset, such as a
+// SimpleReader or BytesReader. Offsets count from base, the offset of the
+// input in the outermost message.
+func Fields(base int64) func(sr parser.StatefulReader) ([]Field, error) {
+	return func(sr parser.StatefulReader) ([]Field, error) {
+		fs, end, err := fields(sr, base, 0)
+		if err != nil {
+			return nil, err
+		}
+		if end != nil {
+			return nil, &binary.Error{Offset: end.Offset, Err: fmt.Errorf("End of group %d outside a group", end.Number)}
+		}
+		return fs, nil
+	}
+}
+
+// fields reads fields until the end of the input or an EGROUP tag, which
+// it returns. depth limits how deeply groups nest.
+func fields(sr parser.StatefulReader, base int64, depth int) ([]Field, *Field, error) {
+	fs := []Field{}
+	for {
+		s := sr.State()
+		if _, err := binary.Bytes(1)(sr); err != nil {
+			return fs, nil, nil
+		}
+		sr.Restore(s)
+		f, err := field(sr, base, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		if f.Type == EndGroup {
+			return fs, &f, nil
+		}
+		fs = append(fs, f)
+	}
+}
+
+// field reads one field.
+func field(sr parser.StatefulReader, base int64, depth int) (Field, error) {
+	f := Field{Offset: base + offset(sr)}
+	fail := func(format string, args ...any) (Field, error) {
+		return f, &binary.Error{Offset: f.Offset, Err: fmt.Errorf(format, args...)}
+	}
+	tag, err := uvarint(sr)
+	if err != nil {
+		return f, shift(err, base)
+	}
+	f.Number, f.Type = int(tag>>3), WireType(tag&7)
+	if f.Number == 0 || tag>>3 > maxField {
+		return fail("Invalid field number %d", tag>>3)
+	}
+	switch f.Type {
+	case Varint:
+		f.Varint, err = uvarint(sr)
+	case I64:
+		f.Fixed, err = fixed64(sr)
+	case I32:
+		var v uint32
+		v, err = fixed32(sr)
+		f.Fixed = uint64(v)
+	case Len:
+		var n uint64
+		if n, err = uvarint(sr); err != nil {
+			break
+		}
+		if n > math.MaxInt32 {
+			return fail("Length %d of field %d is too long", n, f.Number)
+		}
+		f.payload = base + offset(sr)
+		f.Bytes, err = binary.Bytes(int(n))(sr)
+	case StartGroup:
+		if depth >= 100 {
+			return fail("Groups nested too deeply")
+		}
+		var end *Field
+		if f.Group, end, err = fields(sr, base, depth+1); err != nil {
+			return f, err
+		}
+		if end == nil || end.Number != f.Number {
+			return fail("Group %d is not ended", f.Number)
+		}
+	case EndGroup:
+	default:
+		return fail("Invalid wire type %d for field %d", f.Type, f.Number)
+	}
+	if err != nil {
+		return f, fmt.Errorf("field %d: %w", f.Number, shift(err, base))
+	}
+	return f, nil
+}
+
+// shift moves the offset of a binary error from a nested message into the
+// outermost input.
+func shift(err error, base int64) error {
+	if e, ok := err.(*binary.Error); ok && base != 0 {
+		return &binary.Error{Offset: e.Offset + base, Err: e.Err}
+	}
+	return err
+}
+
+// Parse parses a complete message.
+func Parse(data []byte) (Message, error) {
+	return parse(data, 0)
+}
+
+func parse(data []byte, base int64) (Message, error) {
+	fs, err := binary.Region(data, Fields(base))
+	if err != nil {
+		return nil, err
+	}
+	return Collect(fs), nil
+}
+
+// Collect gathers fields by number into a Message, as for the fields of a
+// group.
+func Collect(fs []Field) Message {
+	m := Message{}
+	for _, f := range fs {
+		m[f.Number] = append(m[f.Number], f)
+	}
+	return m
+}
+
+// Message parses a LEN field's value as an embedded message. A string or
+// bytes value may happen to parse too, so without a schema this is a
+// guess, as it is for protoc --decode_raw.
+func (f Field) Message() (Message, error) {
+	if f.Type != Len {
+		return nil, fmt.Errorf("Field %d is %s, not LEN", f.Number, f.Type)
+	}
+	return parse(f.Bytes, f.payload)
+}
+
+// Sint returns a VARINT field's value decoded as a zig-zag sint32 or
+// sint64.
+func (f Field) Sint() int64 {
+	return int64(f.Varint>>1) ^ -int64(f.Varint&1)
+}
+
+// Float returns an I32 field's value as a float.
+func (f Field) Float() float32 {
+	return math.Float32frombits(uint32(f.Fixed))
+}
+
+// Double returns an I64 field's value as a double.
+func (f Field) Double() float64 {
+	return math.Float64frombits(f.Fixed)
+}
+
+// Packed returns a LEN field's value decoded as a packed repeated varint
+// field.
+func (f Field) Packed() ([]uint64, error) {
+	if f.Type != Len {
+		return nil, fmt.Errorf("Field %d is %s, not LEN", f.Number, f.Type)
+	}
+	return binary.Region(f.Bytes, parser.Mult(0, 0, uvarint))
+}
+
+// Text renders fields in the style of protoc --decode_raw: LEN values that
+// parse as messages are shown nested, valid UTF-8 as strings and anything
+// else as escaped bytes.
+func Text(fs []Field) string {
+	b := &strings.Builder{}
+	text(b, fs, 0)
+	return b.String()
+}
+
+func text(b *strings.Builder, fs []Field, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, f := range fs {
+		switch f.Type {
+		case Varint:
+			fmt.Fprintf(b, "%s%d: %d\n", indent, f.Number, f.Varint)
+		case I64:
+			fmt.Fprintf(b, "%s%d: 0x%016x\n", indent, f.Number, f.Fixed)
+		case I32:
+			fmt.Fprintf(b, "%s%d: 0x%08x\n", indent, f.Number, f.Fixed)
+		case StartGroup:
+			fmt.Fprintf(b, "%s%d {\n", indent, f.Number)
+			text(b, f.Group, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		case Len:
+			if sub, err := binary.Region(f.Bytes, Fields(f.payload)); err == nil && len(sub) > 0 && depth < 100 {
+				fmt.Fprintf(b, "%s%d {\n", indent, f.Number)
+				text(b, sub, depth+1)
+				fmt.Fprintf(b, "%s}\n", indent)
+			} else if utf8.Valid(f.Bytes) {
+				fmt.Fprintf(b, "%s%d: %q\n", indent, f.Number, f.Bytes)
+			} else {
+				fmt.Fprintf(b, "%s%d: %+q\n", indent, f.Number, f.Bytes)
+			}
+		}
+	}
+}
+
+// Numbers returns the field numbers present in m, in increasing order.
+func (m Message) Numbers() []int {
+	ns := make([]int, 0, len(m))
+	for n := range m {
+		ns = append(ns, n)
+	}
+	sort.Ints(ns)
+	return ns
+}
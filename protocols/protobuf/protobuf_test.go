@@ -0,0 +1,119 @@
+package protobuf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/andyleap/parser/binary"
+)
+
+var message = []byte{
+	0x08, 0x96, 0x01, // 1: 150
+	0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g', // 2: "testing"
+	0x1a, 0x03, 0x08, 0x96, 0x01, // 3: {1: 150}
+	0x25, 0x00, 0x00, 0x80, 0x3f, // 4: float 1
+	0x29, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // 5: double 1
+	0x33, 0x08, 0x01, 0x34, // 6: group {1: 1}
+	0x38, 0x05, // 7: sint -3
+	0x42, 0x04, 0x01, 0x02, 0xac, 0x02, // 8: packed [1, 2, 300]
+	0x08, 0x07, // 1 again: 7
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	m, err := Parse(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Numbers(), []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("Unexpected fields %v", m.Numbers())
+	}
+	if f, _ := m.Get(1); f.Varint != 7 || len(m[1]) != 2 || m[1][0].Varint != 150 {
+		t.Errorf("Unexpected field 1 %+v", m[1])
+	}
+	if f, _ := m.Get(2); string(f.Bytes) != "testing" || f.Offset != 3 {
+		t.Errorf("Unexpected field 2 %+v", f)
+	}
+	f3, _ := m.Get(3)
+	sub, err := f3.Message()
+	if f, _ := sub.Get(1); err != nil || f.Varint != 150 || f.Offset != 14 {
+		t.Errorf("Unexpected field 3 %+v, %v", sub, err)
+	}
+	if f, _ := m.Get(4); f.Float() != 1 {
+		t.Errorf("Unexpected field 4 %+v", f)
+	}
+	if f, _ := m.Get(5); f.Double() != 1 {
+		t.Errorf("Unexpected field 5 %+v", f)
+	}
+	if f, _ := m.Get(6); f.Type != StartGroup || !reflect.DeepEqual(Collect(f.Group)[1][0].Varint, uint64(1)) {
+		t.Errorf("Unexpected field 6 %+v", f)
+	}
+	if f, _ := m.Get(7); f.Sint() != -3 {
+		t.Errorf("Unexpected field 7 %+v", f)
+	}
+	f8, _ := m.Get(8)
+	if vs, err := f8.Packed(); err != nil || !reflect.DeepEqual(vs, []uint64{1, 2, 300}) {
+		t.Errorf("Unexpected field 8 %v, %v", vs, err)
+	}
+	if _, ok := m.Get(9); ok {
+		t.Errorf("Unexpected field 9")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	for _, c := range []struct {
+		name string
+		in   []byte
+		at   int64
+		msg  string
+	}{
+		{"wire type", []byte{0x08, 0x01, 0x0f}, 2, "Invalid wire type 7 for field 1"},
+		{"field zero", []byte{0x00}, 0, "Invalid field number 0"},
+		{"stray end", []byte{0x0c}, 0, "End of group 1 outside a group"},
+		{"open group", []byte{0x0b, 0x08, 0x01}, 0, "Group 1 is not ended"},
+		{"mismatched group", []byte{0x0b, 0x14}, 0, "Group 1 is not ended"},
+		{"truncated", []byte{0x12, 0x05, 'a'}, 3, "field 2: Unexpected EOF: wanted 5 bytes, got 1"},
+	} {
+		_, err := Parse(c.in)
+		var e *binary.Error
+		if !errors.As(err, &e) || e.Offset != c.at || err.Error() != c.msg {
+			t.Errorf("%s: got %v", c.name, err)
+		}
+	}
+
+	// Offsets in an embedded message count from the outermost input.
+	m, _ := Parse([]byte{0x08, 0x01, 0x1a, 0x02, 0x08, 0x80})
+	f, _ := m.Get(3)
+	_, err := f.Message()
+	var e *binary.Error
+	if !errors.As(err, &e) || e.Offset != 6 {
+		t.Errorf("nested: got %v", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Parallel()
+	fs, err := binary.Region(message, Fields(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `1: 150
+2: "testing"
+3 {
+  1: 150
+}
+4: 0x3f800000
+5: 0x3ff0000000000000
+6 {
+  1: 1
+}
+7: 5
+8: "\x01\x02\xac\x02"
+1: 7
+`
+	if got := Text(fs); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package parser
+
+// raceEnabled reports whether tests were built with the race detector,
+// which adds allocations of its own, so allocation counts are skipped.
+const raceEnabled = true
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrDiverged is returned by Replay when the grammar no longer makes the
+// decisions a Recording holds.
+var ErrDiverged = errors.New("Replay diverged from the recording")
+
+// Decision is the outcome of one Or or OrCut in a recorded parse: where it
+// started and the index of the alternative that matched, or -1 if none did.
+type Decision struct {
+	Offset int64 `json:"offset"`
+	Choice int   `json:"choice"`
+}
+
+// Recording is a parse captured by Record: the input, and the decision of
+// every choice in the order the choices started. It marshals to JSON, so
+// it can be attached to a bug report and replayed offline with Replay.
+type Recording struct {
+	Input     []byte     `json:"input"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// Record captures the input of a parse and the decision of each Or and
+// OrCut into rec. Decisions inside alternatives that failed are kept, since
+// Replay reruns those alternatives too. Input is captured from readers over
+// bytes: ParseBytes and Compiled.RunBytes, or ParseReader over a seekable
+// reader. With Compile, rec holds the latest run and must not be shared by
+// concurrent runs.
+func Record(rec *Recording) Option {
+	return func(o *options) {
+		o.record = &decisions{rec: rec}
+	}
+}
+
+// Replay reruns p over the recording's input, checking that every Or and
+// OrCut decides as it did when recorded. step, if not nil, is called as
+// each choice starts with its index and recorded decision, so a debugger
+// can stop at the one a report points to. The parse is abandoned with
+// ErrDiverged at the first choice that decides differently, which means the
+// grammar has changed or depends on something besides its input.
+func Replay[T any](p func(sr StatefulReader) (T, error), rec *Recording, step func(i int, d Decision), opts ...Option) (T, *Context, error) {
+	opts = append(opts[:len(opts):len(opts)], func(o *options) {
+		o.record = &decisions{rec: rec, replay: true, step: step}
+	})
+	return ParseBytes(p, rec.Input, opts...)
+}
+
+// decisions is the recording or replay in progress in a Context.
+type decisions struct {
+	rec    *Recording
+	replay bool
+	step   func(int, Decision)
+	next   int
+}
+
+// start readies d for a parse of c, capturing its input when recording.
+// Each parse gets its own copy, so Compiled runs don't share one.
+func (d *decisions) start(c *Context) *decisions {
+	if d == nil {
+		return nil
+	}
+	if d.replay {
+		return &decisions{rec: d.rec, replay: true, step: d.step}
+	}
+	d.rec.Input, d.rec.Decisions = nil, nil
+	if pr, ok := c.MemoReader.sr.(*PosReader); ok {
+		d.rec.Input = inputBytes(pr.sr)
+	}
+	return d
+}
+
+func inputBytes(sr StatefulReader) []byte {
+	switch r := sr.(type) {
+	case *BytesReader:
+		return append([]byte{}, r.data[r.off:]...)
+	case SimpleReader:
+		cur, err := r.r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil
+		}
+		b, _ := io.ReadAll(r.r)
+		r.r.Seek(cur, io.SeekStart)
+		return b
+	}
+	return nil
+}
+
+// choose starts a choice, returning the Context and the choice's index for
+// chose, or a nil Context when decisions aren't being recorded or replayed.
+func choose(sr StatefulReader) (*Context, int) {
+	c := ContextOf(sr)
+	if c == nil || c.decisions == nil {
+		return nil, 0
+	}
+	d := c.decisions
+	off := Pos(sr).Offset
+	if !d.replay {
+		d.rec.Decisions = append(d.rec.Decisions, Decision{Offset: off, Choice: -1})
+		return c, len(d.rec.Decisions) - 1
+	}
+	i := d.next
+	d.next++
+	if i >= len(d.rec.Decisions) || d.rec.Decisions[i].Offset != off {
+		c.diverged(sr)
+		return nil, 0
+	}
+	if d.step != nil {
+		d.step(i, d.rec.Decisions[i])
+	}
+	return c, i
+}
+
+// chose settles choice i on c, which may be nil.
+func (c *Context) chose(sr StatefulReader, i, choice int) {
+	if c == nil {
+		return
+	}
+	d := c.decisions
+	if !d.replay {
+		d.rec.Decisions[i].Choice = choice
+	} else if d.rec.Decisions[i].Choice != choice {
+		c.diverged(sr)
+	}
+}
+
+func (c *Context) diverged(sr StatefulReader) {
+	if c.abort == nil {
+		c.abort = &ParseError{Pos: Pos(sr), Err: ErrDiverged}
+	}
+}
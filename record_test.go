@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+	word := join(Mult(1, 0, Set("a-z")))
+	num := join(Mult(1, 0, Set("0-9")))
+	item := Or(Right(Lit("#"), num), word)
+	p := Left(MultSep(1, 0, item, Lit(",")), EOF())
+
+	rec := &Recording{}
+	v, _, err := ParseReader(p, strings.NewReader("ab,#12,c"), Record(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rec.Input) != "ab,#12,c" {
+		t.Errorf("input %q", rec.Input)
+	}
+	want := []Decision{{0, 1}, {3, 0}, {7, 1}}
+	if !reflect.DeepEqual(rec.Decisions, want) {
+		t.Errorf("decisions %v, want %v", rec.Decisions, want)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Recording{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	steps := []int{}
+	rv, _, err := Replay(p, loaded, func(i int, d Decision) {
+		steps = append(steps, i)
+	})
+	if err != nil || !reflect.DeepEqual(rv, v) {
+		t.Errorf("replay got %v, %v", rv, err)
+	}
+	if !reflect.DeepEqual(steps, []int{0, 1, 2}) {
+		t.Errorf("steps %v", steps)
+	}
+
+	// A grammar that now tries words first diverges once the first choice
+	// has decided.
+	changed := Left(MultSep(1, 0, Or(word, Right(Lit("#"), num)), Lit(",")), EOF())
+	_, _, err = Replay(changed, rec, nil)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Err != ErrDiverged || pe.Pos.Offset != 2 {
+		t.Errorf("changed grammar: got %v", err)
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	t.Parallel()
+	p := Or(Lit("a"), Or(Lit("b"), Lit("c")))
+	rec := &Recording{}
+	_, _, err := ParseBytes(p, []byte("x"), Record(rec))
+	if err == nil {
+		t.Fatal("parsed x")
+	}
+	want := []Decision{{0, -1}, {0, -1}}
+	if !reflect.DeepEqual(rec.Decisions, want) {
+		t.Errorf("decisions %v, want %v", rec.Decisions, want)
+	}
+	if _, _, rerr := Replay(p, rec, nil); rerr == nil || rerr.Error() != err.Error() {
+		t.Errorf("replay got %v, want %v", rerr, err)
+	}
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// ParseError is an error with the position it happened at. Stack is set
+// when the error was recovered from a panic. Expected is set when the error
+// ends a parse run by ParseReader or Compiled.Run, and lists what would
+// have been accepted at Pos.
+type ParseError struct {
+	Pos      Position
+	Err      error
+	Stack    []byte
+	Expected []Expectation
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Recover runs p and turns any panic, typically from a Convert or Bind
+// callback, into a *ParseError at the position the panic happened, so one
+// bad input can't crash a long-running service. The reader is restored to
+// where p started.
+func Recover[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	return func(sr StatefulReader) (v T, err error) {
+		s := sr.State()
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			perr, ok := r.(error)
+			if !ok {
+				perr = fmt.Errorf("%v", r)
+			}
+			pos := Pos(sr)
+			sr.Restore(s)
+			var zero T
+			v, err = zero, &ParseError{Pos: pos, Err: fmt.Errorf("panic: %w", perr), Stack: debug.Stack()}
+		}()
+		return p(sr)
+	}
+}
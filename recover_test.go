@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errBoom = errors.New("boom")
+
+func TestRecover(t *testing.T) {
+	p := And(Lit("a"), Convert(Lit("b"), func(string) (string, error) {
+		panic(errBoom)
+	}))
+	_, _, err := ParseReader(p, strings.NewReader("ab"), RecoverPanics())
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v", err)
+	}
+	if !errors.Is(err, errBoom) || pe.Pos.Offset != 2 || len(pe.Stack) == 0 {
+		t.Errorf("got %v at %d", pe, pe.Pos.Offset)
+	}
+	if pe.Error() != "1:3: panic: boom" {
+		t.Errorf("Error() = %q", pe.Error())
+	}
+
+	c := Compile(Convert(Lit("x"), func(string) (int, error) {
+		var m map[string]int
+		m["x"] = 1
+		return 0, nil
+	}), RecoverPanics())
+	if _, err := c.RunString("x"); !errors.As(err, &pe) {
+		t.Errorf("Compiled got %v", err)
+	}
+
+	sr := NewSimpleReader(strings.NewReader("ab"))
+	if _, err := Recover(p)(sr); err == nil || sr.State().(int64) != 0 {
+		t.Errorf("got %v, reader at %v", err, sr.State())
+	}
+	if v, err := Recover(Lit("a"))(sr); err != nil || v != "a" {
+		t.Errorf("got %q, %v", v, err)
+	}
+}
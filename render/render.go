@@ -0,0 +1,185 @@
+// Package render draws source text annotated with the highlighting spans
+// and diagnostics collected during a parse, as ANSI coloured terminal output
+// or as HTML.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/andyleap/parser"
+)
+
+// Theme maps span classes to ANSI SGR parameters, such as "1;34" for bold
+// blue.
+type Theme map[parser.Class]string
+
+// DefaultTheme is the Theme used when ANSI is passed nil.
+var DefaultTheme = Theme{
+	parser.ClassKeyword:  "1;34",
+	parser.ClassIdent:    "36",
+	parser.ClassNumber:   "35",
+	parser.ClassString:   "32",
+	parser.ClassComment:  "2",
+	parser.ClassOperator: "33",
+	parser.ClassPunct:    "",
+}
+
+var severityColour = map[parser.Severity]string{
+	parser.SeverityNote:    "36",
+	parser.SeverityWarning: "33",
+	parser.SeverityError:   "1;31",
+}
+
+// Errors converts the errors from a failed parse into diagnostics, so they
+// can be drawn along with any others.
+func Errors(err error) []parser.Diagnostic {
+	var l parser.ErrorList
+	if errors.As(err, &l) {
+		ds := []parser.Diagnostic{}
+		for _, pe := range l {
+			ds = append(ds, Errors(pe)...)
+		}
+		return ds
+	}
+	var pe *parser.ParseError
+	if errors.As(err, &pe) {
+		return []parser.Diagnostic{{Pos: pe.Pos, Severity: parser.SeverityError, Message: pe.Err.Error()}}
+	}
+	if err == nil {
+		return nil
+	}
+	return []parser.Diagnostic{{Severity: parser.SeverityError, Message: err.Error()}}
+}
+
+type line struct {
+	start, end int
+	text       string
+}
+
+func lines(input string) []line {
+	ls := []line{}
+	start := 0
+	for {
+		i := strings.IndexByte(input[start:], '\n')
+		if i < 0 {
+			ls = append(ls, line{start, len(input), input[start:]})
+			return ls
+		}
+		ls = append(ls, line{start, start + i, input[start : start+i]})
+		start += i + 1
+	}
+}
+
+// classes returns the class of each byte of input.
+func classes(input string, spans []parser.Span) []parser.Class {
+	cs := make([]parser.Class, len(input))
+	for _, s := range spans {
+		for i := s.Start.Offset; i < s.End.Offset && i < int64(len(cs)); i++ {
+			cs[i] = s.Class
+		}
+	}
+	return cs
+}
+
+// caret returns the indent that puts a caret under rune column col of
+// text, copying tabs and padding wide characters so it lines up however
+// they are displayed. Columns are expected to count runes, as they do from
+// a PosReader without a TabWidth.
+func caret(text string, col int) string {
+	b := strings.Builder{}
+	for i, r := range []rune(text) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteString(strings.Repeat(" ", parser.RuneWidth(r)))
+		}
+	}
+	return b.String()
+}
+
+func message(d parser.Diagnostic) string {
+	if d.Code == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Code)
+}
+
+// render walks input line by line, writing runs of same-class text with
+// text and the diagnostics of each line after it with diag.
+func render(input string, spans []parser.Span, diags []parser.Diagnostic, b *strings.Builder, text func(c parser.Class, s string), diag func(indent string, d parser.Diagnostic)) {
+	cs := classes(input, spans)
+	for n, l := range lines(input) {
+		for i := l.start; i < l.end; {
+			j := i
+			for j < l.end && cs[j] == cs[i] {
+				j++
+			}
+			text(cs[i], input[i:j])
+			i = j
+		}
+		if l.end < len(input) || len(l.text) > 0 {
+			b.WriteString("\n")
+		}
+		for _, d := range diags {
+			if d.Pos.Line == n+1 {
+				diag(caret(l.text, d.Pos.Column), d)
+			}
+		}
+	}
+}
+
+// ANSI renders input with spans coloured by theme (DefaultTheme if nil) and
+// a caret and message under each diagnostic's position.
+func ANSI(input string, spans []parser.Span, diags []parser.Diagnostic, theme Theme) string {
+	if theme == nil {
+		theme = DefaultTheme
+	}
+	b := &strings.Builder{}
+	render(input, spans, diags, b, func(c parser.Class, s string) {
+		if code := theme[c]; code != "" {
+			fmt.Fprintf(b, "\x1b[%sm%s\x1b[0m", code, s)
+		} else {
+			b.WriteString(s)
+		}
+	}, func(indent string, d parser.Diagnostic) {
+		fmt.Fprintf(b, "%s\x1b[%sm^ %s\x1b[0m\n", indent, severityColour[d.Severity], message(d))
+	})
+	return b.String()
+}
+
+// Text renders input as plain text with a caret and message under each
+// diagnostic's position, for output that isn't a terminal.
+func Text(input string, diags []parser.Diagnostic) string {
+	b := &strings.Builder{}
+	render(input, nil, diags, b, func(c parser.Class, s string) {
+		b.WriteString(s)
+	}, func(indent string, d parser.Diagnostic) {
+		fmt.Fprintf(b, "%s^ %s\n", indent, message(d))
+	})
+	return b.String()
+}
+
+// HTML renders input as a <pre class="source"> block. Spans become
+// <span class="tok-CLASS"> elements and diagnostics a line with a caret in
+// a <span class="diag diag-SEVERITY"> element, to be styled by the page.
+func HTML(input string, spans []parser.Span, diags []parser.Diagnostic) string {
+	b := &strings.Builder{}
+	b.WriteString(`<pre class="source">`)
+	render(input, spans, diags, b, func(c parser.Class, s string) {
+		if c != "" {
+			fmt.Fprintf(b, `<span class="tok-%s">%s</span>`, c, html.EscapeString(s))
+		} else {
+			b.WriteString(html.EscapeString(s))
+		}
+	}, func(indent string, d parser.Diagnostic) {
+		fmt.Fprintf(b, `%s<span class="diag diag-%s">^ %s</span>`+"\n", indent, d.Severity, html.EscapeString(message(d)))
+	})
+	b.WriteString("</pre>")
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andyleap/parser"
+)
+
+func pos(offset int64, line, col int) parser.Position {
+	return parser.Position{Offset: offset, Line: line, Column: col}
+}
+
+var (
+	input = "let x = 1\n\tx + <"
+	spans = []parser.Span{
+		{Class: parser.ClassKeyword, Start: pos(0, 1, 1), End: pos(3, 1, 4)},
+		{Class: parser.ClassNumber, Start: pos(8, 1, 9), End: pos(9, 1, 10)},
+	}
+	diags = []parser.Diagnostic{
+		{Pos: pos(15, 2, 6), Severity: parser.SeverityError, Message: "Expected operand"},
+	}
+)
+
+func TestANSI(t *testing.T) {
+	got := ANSI(input, spans, diags, nil)
+	want := "\x1b[1;34mlet\x1b[0m x = \x1b[35m1\x1b[0m\n" +
+		"\tx + <\n" +
+		"\t    \x1b[1;31m^ error: Expected operand\x1b[0m\n"
+	if got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestText(t *testing.T) {
+	got := Text(input, diags)
+	want := "let x = 1\n" +
+		"\tx + <\n" +
+		"\t    ^ error: Expected operand\n"
+	if got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := HTML(input, spans, diags)
+	want := `<pre class="source"><span class="tok-keyword">let</span> x = <span class="tok-number">1</span>` + "\n" +
+		"\tx + &lt;\n" +
+		"\t    " + `<span class="diag diag-error">^ error: Expected operand</span>` + "\n</pre>"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	p := parser.Convert(parser.And(parser.Lit("a"), parser.Lit("b")), func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+	_, _, err := parser.ParseReader(p, strings.NewReader("ac"))
+	ds := Errors(err)
+	if len(ds) != 1 || ds[0].Pos.Column != 2 || ds[0].Severity != parser.SeverityError {
+		t.Fatalf("got %v", ds)
+	}
+	got := ANSI("ac", nil, ds, Theme{})
+	want := "ac\n \x1b[1;31m^ error: Expected \"b\", got \"c\"\x1b[0m\n"
+	if got != want {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCaretWide(t *testing.T) {
+	got := ANSI("名前=?", nil, []parser.Diagnostic{{Pos: pos(7, 1, 4), Severity: parser.SeverityWarning, Message: "odd"}}, Theme{})
+	want := "名前=?\n     \x1b[33m^ warning: odd\x1b[0m\n"
+	if got != want {
+		t.Errorf("got %q", got)
+	}
+}
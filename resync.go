@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxErrors is the error budget of a Context whose MaxErrors is
+// zero.
+const DefaultMaxErrors = 100
+
+// ErrTooManyErrors ends a parse that has used up its error budget.
+var ErrTooManyErrors = errors.New("Too many errors")
+
+// ErrorList is the errors recovered from during a parse, in the order they
+// were found.
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "No errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
+
+// Is reports whether any error in the list matches target.
+func (l ErrorList) Is(target error) bool {
+	for _, e := range l {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resync runs p and, if it fails while parsing a Context, records the
+// error, skips input up to and including the next match of sync (or to the
+// end of input) and succeeds with the zero value, so that parsing carries on
+// and further errors can be found. If there is no input left to skip, p's
+// error is returned as usual. ParseReader then returns all the
+// recorded errors as an ErrorList. An error that is already a *ParseError
+// is recorded as it is, keeping its own position. On other readers it is
+// just p.
+//
+// Once the Context's error budget is spent the parse is abandoned with an
+// ErrorList ending in ErrTooManyErrors.
+func Resync[T any](p func(sr StatefulReader) (T, error), sync func(sr StatefulReader) (string, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Resync", p)
+	mustParsers("Resync", sync)
+	return func(sr StatefulReader) (T, error) {
+		c := ContextOf(sr)
+		if c == nil {
+			return p(sr)
+		}
+		var t T
+		if c.abort != nil {
+			return t, fatalError{c.abort}
+		}
+		pos := c.Pos()
+		v, err := p(sr)
+		if err == nil {
+			c.quietUntil = 0
+			return v, nil
+		}
+		if _, isFE := err.(fatalError); isFE {
+			return v, err
+		}
+		start := sr.State()
+		for {
+			if _, err := sync(sr); err == nil {
+				break
+			}
+			if _, err := readRune(sr); err != nil {
+				break
+			}
+		}
+		if sr.State() == start {
+			// nothing to skip, so recovering would make no progress
+			return v, err
+		}
+		if pos.Offset < c.quietUntil {
+			return t, nil
+		}
+		if c.CascadeWindow > 0 {
+			c.quietUntil = c.Pos().Offset + c.CascadeWindow
+		}
+		pe, ok := err.(*ParseError)
+		if !ok {
+			pe = &ParseError{Pos: pos, Err: err}
+		}
+		c.Errors = append(c.Errors, pe)
+		max := c.MaxErrors
+		if max == 0 {
+			max = DefaultMaxErrors
+		}
+		if max > 0 && len(c.Errors) >= max {
+			c.abort = append(c.Errors[:len(c.Errors):len(c.Errors)], &ParseError{Pos: pos, Err: ErrTooManyErrors})
+			return t, fatalError{c.abort}
+		}
+		return t, nil
+	}
+}
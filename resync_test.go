@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func stmts() func(StatefulReader) ([]string, error) {
+	stmt := Resync(join(And(Set("a-z"), Lit("="), Set("0-9"), Lit(";"))), Lit(";"))
+	return Convert(And(join(Mult(0, 0, stmt)), EOF()), func(s []string) ([]string, error) {
+		return s, nil
+	})
+}
+
+func TestResync(t *testing.T) {
+	_, c, err := ParseReader(stmts(), strings.NewReader("a=1;b=x;c=3;d4;"))
+	var l ErrorList
+	if !errors.As(err, &l) || len(l) != 2 {
+		t.Fatalf("got %v", err)
+	}
+	if l[0].Pos.Offset != 4 || l[1].Pos.Offset != 12 {
+		t.Errorf("got errors at %s and %s", l[0].Pos, l[1].Pos)
+	}
+	if err.Error() != `1:5: Expected "0-9", got "x" (and 1 more errors)` {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	assert(t, len(c.Errors), 2)
+
+	v, _, err := ParseReader(stmts(), strings.NewReader("a=1;"))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, v, []string{"a=1;", ""})
+
+	if _, err := stmts()(NewSimpleReader(strings.NewReader("a=x;"))); err == nil {
+		t.Error("Expected error without a Context")
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	in := strings.Repeat("x;", 500)
+	_, c, err := ParseReader(stmts(), strings.NewReader(in))
+	if !errors.Is(err, ErrTooManyErrors) {
+		t.Fatalf("got %v", err)
+	}
+	assert(t, len(c.Errors), DefaultMaxErrors)
+
+	_, _, err = ParseReader(stmts(), strings.NewReader(in), MaxErrors(3))
+	var l ErrorList
+	if !errors.As(err, &l) || len(l) != 4 || !errors.Is(l[3], ErrTooManyErrors) {
+		t.Errorf("got %v", err)
+	}
+
+	_, c, err = ParseReader(stmts(), strings.NewReader(in), MaxErrors(-1))
+	if errors.Is(err, ErrTooManyErrors) || len(c.Errors) != 500 {
+		t.Errorf("got %d errors, %v", len(c.Errors), err)
+	}
+
+	p := Compile(stmts(), MaxErrors(2))
+	if _, err := p.RunString(in); !errors.Is(err, ErrTooManyErrors) {
+		t.Errorf("Compiled got %v", err)
+	}
+	if _, err := p.RunString("a=1;"); err != nil {
+		t.Errorf("Compiled reuse got %v", err)
+	}
+}
+
+func TestSuppressCascades(t *testing.T) {
+	// the bad "a=1 b" swallows the next statement, which then cascades
+	in := "a=1 b=2;=3;c=4;d=x;"
+	_, c, _ := ParseReader(stmts(), strings.NewReader(in))
+	assert(t, len(c.Errors), 3)
+
+	_, c, _ = ParseReader(stmts(), strings.NewReader(in), SuppressCascades(4))
+	got := []int64{}
+	for _, e := range c.Errors {
+		got = append(got, e.Pos.Offset)
+	}
+	// "=3;" is suppressed; "d=x" follows the clean "c=4;" so it is reported
+	assert(t, got, []int64{0, 15})
+}
+
+func TestResyncBacktrack(t *testing.T) {
+	// the first alternative recovers from "x;" but then fails, so the
+	// error it recorded goes with it
+	p := Or(
+		And(Resync(Lit("a"), Lit(";")), Lit("!")),
+		And(Lit("x"), Lit(";"), Lit("?")),
+	)
+	v, c, err := ParseReader(p, strings.NewReader("x;?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, v, []string{"x", ";", "?"})
+	assert(t, len(c.Errors), 0)
+
+	// a memoized recovery is replayed with its error
+	stmt := Memo("stmt", Resync(Lit("a"), Lit(";")))
+	p = Or(And(stmt, Lit("!")), And(stmt, Lit("?")))
+	_, c, err = ParseReader(p, strings.NewReader("x;?"))
+	var l ErrorList
+	if !errors.As(err, &l) || len(l) != 1 || len(c.Errors) != 1 {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestResyncParseError(t *testing.T) {
+	// an error that has its own position is recorded as it is
+	at := &ParseError{Pos: Position{Offset: 2, Line: 1, Column: 3}, Err: errors.New("bad")}
+	bad := func(sr StatefulReader) (string, error) { return "", at }
+	_, c, _ := ParseReader(Resync(bad, Lit(";")), strings.NewReader("abc;"))
+	if len(c.Errors) != 1 || c.Errors[0] != at {
+		t.Errorf("got %v", c.Errors)
+	}
+}
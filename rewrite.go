@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrOverlap is returned by Rewriter.Apply when two edits change the same
+// input.
+var ErrOverlap = errors.New("Overlapping edits")
+
+// Located is a value along with the stretch of input it was parsed from,
+// for building trees whose nodes can be found again in the source.
+type Located[T any] struct {
+	Value      T
+	Start, End Position
+}
+
+// Locate runs p and returns its result with where it started and ended,
+// so that a tree built with Convert remembers where its nodes came from,
+// for a Rewriter or for error messages about them after the parse.
+func Locate[T any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (Located[T], error) {
+	mustParsers("Locate", p)
+	return func(sr StatefulReader) (Located[T], error) {
+		start := Pos(sr)
+		v, err := p(sr)
+		if err != nil {
+			return Located[T]{}, err
+		}
+		return Located[T]{Value: v, Start: start, End: Pos(sr)}, nil
+	}
+}
+
+// Edit replaces the input from offset Start up to End with Text.
+type Edit struct {
+	Start, End int64
+	Text       string
+}
+
+// Rewriter changes parts of an input and leaves the rest as it was,
+// comments, spacing and all, for codemods over a language parsed with this
+// package. Edits are found from a parse: from Located values in the tree,
+// or from spans marked with Classify, which can use a Class of the
+// grammar's own such as "field-name":
+//
+//	rw := parser.NewRewriter(src)
+//	rw.ReplaceSpans(c.Spans(), "field-name", func(name string) string {
+//		return renames[name]
+//	})
+//	out, err := rw.Apply()
+//
+// Positions must be from a parse of the same input, read from its start.
+type Rewriter struct {
+	input string
+	edits []Edit
+}
+
+// NewRewriter returns a Rewriter for input.
+func NewRewriter(input string) *Rewriter {
+	return &Rewriter{input: input}
+}
+
+// Replace replaces the input from start up to end with text.
+func (r *Rewriter) Replace(start, end Position, text string) {
+	r.edits = append(r.edits, Edit{start.Offset, end.Offset, text})
+}
+
+// Insert inserts text at at. Several insertions at one place keep the
+// order they were made in, and come before a replacement starting there.
+func (r *Rewriter) Insert(at Position, text string) {
+	r.Replace(at, at, text)
+}
+
+// Delete removes the input from start up to end.
+func (r *Rewriter) Delete(start, end Position) {
+	r.Replace(start, end, "")
+}
+
+// Source returns the original input from start up to end.
+func (r *Rewriter) Source(start, end Position) string {
+	return r.input[start.Offset:end.Offset]
+}
+
+// ReplaceSpans replaces the text of each span of class with what f returns
+// for it, leaving it alone if f returns it unchanged.
+func (r *Rewriter) ReplaceSpans(spans []Span, class Class, f func(text string) string) {
+	for _, s := range spans {
+		if s.Class != class {
+			continue
+		}
+		old := r.Source(s.Start, s.End)
+		if text := f(old); text != old {
+			r.Replace(s.Start, s.End, text)
+		}
+	}
+}
+
+// Edits returns the edits made so far, in input order.
+func (r *Rewriter) Edits() []Edit {
+	es := append([]Edit{}, r.edits...)
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].Start != es[j].Start {
+			return es[i].Start < es[j].Start
+		}
+		return es[i].End < es[j].End
+	})
+	return es
+}
+
+// Apply returns the input with every edit made. It fails with ErrOverlap,
+// and makes none of them, if two edits change the same input or one is
+// out of range.
+func (r *Rewriter) Apply() (string, error) {
+	b := &strings.Builder{}
+	at := int64(0)
+	for _, e := range r.Edits() {
+		if e.Start < at || e.End < e.Start || e.End > int64(len(r.input)) {
+			return "", fmt.Errorf("edit of %d-%d: %w", e.Start, e.End, ErrOverlap)
+		}
+		b.WriteString(r.input[at:e.Start])
+		b.WriteString(e.Text)
+		at = e.End
+	}
+	b.WriteString(r.input[at:])
+	return b.String(), nil
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRewriteSpans(t *testing.T) {
+	t.Parallel()
+	ws := Mult(0, 0, Or(Set(" \n"), Right(Lit("#"), Left(Lit(" note"), Lit("\n")))))
+	tok := func(p func(sr StatefulReader) (string, error)) func(sr StatefulReader) (string, error) {
+		return Left(p, ws)
+	}
+	name := Classify("var", Convert(Mult(1, 0, Set("a-z")), func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	}))
+	stmt := And(tok(Lit("let")), tok(name), tok(Lit("=")), tok(Or(name, Set("0-9"))), tok(Lit(";")))
+	src := "let x = 1;  # note\nlet  y=x;\n"
+	_, c, err := ParseBytes(Right(ws, Mult(0, 0, stmt)), []byte(src), Highlight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := NewRewriter(src)
+	rw.ReplaceSpans(c.Spans(), "var", func(s string) string {
+		if s == "x" {
+			return "count"
+		}
+		return s
+	})
+	out, err := rw.Apply()
+	if want := "let count = 1;  # note\nlet  y=count;\n"; err != nil || out != want {
+		t.Errorf("got %q, %v", out, err)
+	}
+	if len(rw.Edits()) != 2 {
+		t.Errorf("Unexpected edits %v", rw.Edits())
+	}
+}
+
+func TestRewriteLocated(t *testing.T) {
+	t.Parallel()
+	num := Locate(Set("0-9"))
+	list := MultSep(1, 0, num, Lit(", "))
+	src := "1, 2, 3"
+	ns, _, err := ParseBytes(list, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := NewRewriter(src)
+	rw.Insert(ns[0].Start, "[")
+	rw.Replace(ns[1].Start, ns[1].End, "two")
+	rw.Delete(ns[1].End, ns[2].Start)
+	rw.Insert(ns[2].End, "]")
+	if out, err := rw.Apply(); err != nil || out != "[1, two3]" {
+		t.Errorf("got %q, %v", out, err)
+	}
+	if s := rw.Source(ns[1].Start, ns[2].End); s != "2, 3" {
+		t.Errorf("Source: got %q", s)
+	}
+
+	rw.Replace(ns[0].Start, ns[1].End, "x")
+	if _, err := rw.Apply(); !errors.Is(err, ErrOverlap) {
+		t.Errorf("overlap: got %v", err)
+	}
+}
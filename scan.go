@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	goscanner "go/scanner"
+	"go/token"
+	"text/scanner"
+)
+
+// Token is a token from an existing lexer, for parsing with a SliceReader.
+// Kind is the lexer's token type: a rune such as scanner.Ident for
+// text/scanner, or a token.Token for go/scanner.
+type Token[K comparable] struct {
+	Kind K
+	Text string
+	Pos  Position
+}
+
+func (t Token[K]) String() string {
+	return fmt.Sprintf("%q", t.Text)
+}
+
+// Position returns where the token starts. A SliceReader of tokens uses it
+// to report positions.
+func (t Token[K]) Position() Position {
+	return t.Pos
+}
+
+// End returns the position just after the token.
+func (t Token[K]) End() Position {
+	p := t.Pos
+	p.Offset += int64(len(t.Text))
+	for _, r := range t.Text {
+		if r == '\n' {
+			p.Line++
+			p.Column = 1
+			continue
+		}
+		p.Column++
+	}
+	return p
+}
+
+// Kind matches a single token of kind k.
+func Kind[K comparable](k K) func(sr StatefulReader) (Token[K], error) {
+	return Elem(func(t Token[K]) bool { return t.Kind == k })
+}
+
+// Keyword matches a single token of kind k with the given text, such as an
+// identifier that is reserved by the grammar.
+func Keyword[K comparable](k K, text string) func(sr StatefulReader) (Token[K], error) {
+	return Elem(func(t Token[K]) bool { return t.Kind == k && t.Text == text })
+}
+
+// TextTokens runs s to the end of its input and returns the tokens, which
+// can then be parsed with NewSliceReader. What is skipped is up to s.Mode
+// and s.Whitespace. Errors s reports are returned together as an ErrorList
+// after scanning is done, along with the tokens.
+func TextTokens(s *scanner.Scanner) ([]Token[rune], error) {
+	var errs ErrorList
+	prev := s.Error
+	s.Error = func(s *scanner.Scanner, msg string) {
+		pos := s.Position
+		if !pos.IsValid() {
+			pos = s.Pos()
+		}
+		errs = append(errs, &ParseError{Pos: textPosition(pos), Err: errors.New(msg)})
+	}
+	defer func() { s.Error = prev }()
+	toks := []Token[rune]{}
+	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
+		toks = append(toks, Token[rune]{Kind: tok, Text: s.TokenText(), Pos: textPosition(s.Position)})
+	}
+	if len(errs) > 0 {
+		return toks, errs
+	}
+	return toks, nil
+}
+
+func textPosition(p scanner.Position) Position {
+	return Position{Offset: int64(p.Offset), Line: p.Line, Column: p.Column, File: p.Filename}
+}
+
+// GoTokens scans src, which file describes, with go/scanner and returns the
+// tokens. Literals and identifiers keep their source text, other tokens
+// get their spelling, and automatically inserted semicolons have the text
+// "\n". Errors are returned as an ErrorList along with the tokens.
+func GoTokens(file *token.File, src []byte, mode goscanner.Mode) ([]Token[token.Token], error) {
+	var errs ErrorList
+	var s goscanner.Scanner
+	s.Init(file, src, func(pos token.Position, msg string) {
+		errs = append(errs, &ParseError{Pos: goPosition(pos), Err: errors.New(msg)})
+	}, mode)
+	toks := []Token[token.Token]{}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		toks = append(toks, Token[token.Token]{Kind: tok, Text: lit, Pos: goPosition(file.Position(pos))})
+	}
+	if len(errs) > 0 {
+		return toks, errs
+	}
+	return toks, nil
+}
+
+func goPosition(p token.Position) Position {
+	return Position{Offset: int64(p.Offset), Line: p.Line, Column: p.Column, File: p.Filename}
+}
+
+// positioned is implemented by elements that know where they came from,
+// such as Token.
+type positioned interface {
+	Position() Position
+	End() Position
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	goscanner "go/scanner"
+	"go/token"
+	"strings"
+	"testing"
+	"text/scanner"
+)
+
+func TestTextTokens(t *testing.T) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader("let x = 42\nlet y = x"))
+	s.Filename = "in.txt"
+	toks, err := TextTokens(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ident := Kind[rune](scanner.Ident)
+	stmt := And(Keyword[rune](scanner.Ident, "let"), ident, Keyword[rune]('=', "="), Or(Kind[rune](scanner.Int), ident))
+	prog := Mult(1, 0, stmt)
+	sr := NewSliceReader(toks)
+	out, err := prog(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[1][3].Text != "x" {
+		t.Errorf("got %v", out)
+	}
+	if _, err := EOF()(sr); err != nil {
+		t.Error(err)
+	}
+	if got := Pos(sr).String(); got != "in.txt:2:10" {
+		t.Errorf("end position = %s", got)
+	}
+}
+
+func TestTextTokensError(t *testing.T) {
+	var s scanner.Scanner
+	s.Init(strings.NewReader(`x = "open`))
+	toks, err := TextTokens(&s)
+	errs, ok := err.(ErrorList)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got %v, want one error", err)
+	}
+	if len(toks) != 3 {
+		t.Errorf("got %d tokens", len(toks))
+	}
+}
+
+func TestGoTokens(t *testing.T) {
+	src := []byte("package p\n\nfunc f() int { return 1 }\n")
+	fset := token.NewFileSet()
+	file := fset.AddFile("p.go", -1, len(src))
+	toks, err := GoTokens(file, src, goscanner.ScanComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := And(Kind(token.PACKAGE), Kind(token.IDENT), Kind(token.SEMICOLON))
+	sr := NewSliceReader(toks)
+	if _, err := header(sr); err != nil {
+		t.Fatal(err)
+	}
+	if got := Pos(sr).String(); got != "p.go:3:1" {
+		t.Errorf("position = %s", got)
+	}
+	_, err = Kind(token.VAR)(sr)
+	if err == nil || err.Error() != `Unexpected "func"` {
+		t.Errorf("got %v", err)
+	}
+}
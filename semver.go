@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version as described by semver.org.
+type Version struct {
+	Major, Minor, Patch uint64
+	Prerelease          []string
+	Build               []string
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+const semverChars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-+."
+
+var (
+	semverNum   = join(Mult(1, 0, Set("0-9")))
+	semverIdent = join(Mult(1, 0, Set("0-9a-zA-Z-")))
+)
+
+func semverNumber(sr StatefulReader) (uint64, error) {
+	s := sr.State()
+	n, err := semverNum(sr)
+	if err != nil {
+		return 0, err
+	}
+	if len(n) > 1 && n[0] == '0' {
+		sr.Restore(s)
+		return 0, fmt.Errorf("Numeric identifier %q has a leading zero", n)
+	}
+	v, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		sr.Restore(s)
+		return 0, err
+	}
+	return v, nil
+}
+
+func semverIdents(numeric bool) func(sr StatefulReader) ([]string, error) {
+	return func(sr StatefulReader) ([]string, error) {
+		ids := []string{}
+		for {
+			id, err := semverIdent(sr)
+			if err != nil {
+				return nil, err
+			}
+			if numeric && len(id) > 1 && id[0] == '0' && strings.Trim(id, "0123456789") == "" {
+				return nil, fmt.Errorf("Numeric identifier %q has a leading zero", id)
+			}
+			ids = append(ids, id)
+			s := sr.State()
+			if _, err := Lit(".")(sr); err != nil {
+				sr.Restore(s)
+				return ids, nil
+			}
+		}
+	}
+}
+
+// Semver matches a semantic version such as 1.2.3-rc.1+build.5. Leading
+// zeros in numeric components are rejected, as is a version running on
+// into more of one, as 1.2.3 would in 1.2.3.4.
+func Semver() func(sr StatefulReader) (Version, error) {
+	pre := semverIdents(true)
+	build := semverIdents(false)
+	return func(sr StatefulReader) (Version, error) {
+		s := sr.State()
+		v, err := parseSemver(sr, pre, build)
+		if err == nil && !atBoundary(sr, semverChars) {
+			err = errors.New("Version continues past its patch, prerelease or build")
+		}
+		if err != nil {
+			sr.Restore(s)
+			return Version{}, fmt.Errorf("Expected semantic version: %w", err)
+		}
+		return v, nil
+	}
+}
+
+func parseSemver(sr StatefulReader, pre, build func(sr StatefulReader) ([]string, error)) (Version, error) {
+	v := Version{}
+	var err error
+	if v.Major, err = semverNumber(sr); err != nil {
+		return v, err
+	}
+	if _, err = Lit(".")(sr); err != nil {
+		return v, err
+	}
+	if v.Minor, err = semverNumber(sr); err != nil {
+		return v, err
+	}
+	if _, err = Lit(".")(sr); err != nil {
+		return v, err
+	}
+	if v.Patch, err = semverNumber(sr); err != nil {
+		return v, err
+	}
+	s := sr.State()
+	if _, err := Lit("-")(sr); err == nil {
+		if v.Prerelease, err = pre(sr); err != nil {
+			return v, err
+		}
+	} else {
+		sr.Restore(s)
+	}
+	s = sr.State()
+	if _, err := Lit("+")(sr); err == nil {
+		if v.Build, err = build(sr); err != nil {
+			return v, err
+		}
+	} else {
+		sr.Restore(s)
+	}
+	return v, nil
+}
@@ -0,0 +1,35 @@
+package parser
+
+import "testing"
+
+func TestSemver(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out Version
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"0.0.0", Version{}},
+		{"1.0.0-alpha.1", Version{Major: 1, Prerelease: []string{"alpha", "1"}}},
+		{"1.0.0-rc.1+build.0012", Version{Major: 1, Prerelease: []string{"rc", "1"}, Build: []string{"build", "0012"}}},
+		{"1.0.0+sha-abc", Version{Major: 1, Build: []string{"sha-abc"}}},
+	}
+	for _, test := range tests {
+		out, err := parse(test.in, Semver())
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		assertSrc(t, test.in, out, test.out)
+		assertSrc(t, test.in, out.String(), test.in)
+	}
+	// a delimiter after the version is fine
+	if v, err := parse("1.2.3, ", Semver()); err != nil || v.Patch != 3 {
+		t.Errorf("got %v, %v", v, err)
+	}
+	for _, bad := range []string{"1.2", "01.2.3", "1.2.3-01", "1.2.3-", "v1.2.3", "1.2.3.4", "1.2.3x"} {
+		if _, err := parse(bad, Semver()); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ErrIncludeCycle is returned when a file includes itself, directly or
+// through other files.
+var ErrIncludeCycle = errors.New("Include cycle")
+
+// Session parses a set of files from a file system that may include one
+// another. Positions carry the name of the file they are in, and the
+// diagnostics of every file end up in the session.
+//
+// A Session is not safe for concurrent use.
+type Session struct {
+	fsys    fs.FS
+	opts    []Option
+	sources map[string]string
+	read    []string
+	stack   []string
+	// Diagnostics holds the diagnostics of every file parsed so far.
+	Diagnostics []Diagnostic
+}
+
+// NewSession returns a Session reading files from fsys. The options apply
+// to the parse of every file.
+func NewSession(fsys fs.FS, opts ...Option) *Session {
+	return &Session{fsys: fsys, opts: opts, sources: map[string]string{}}
+}
+
+// Source returns the text of a file the session has read, for rendering
+// diagnostics.
+func (s *Session) Source(name string) (string, bool) {
+	src, ok := s.sources[name]
+	return src, ok
+}
+
+// Files returns the names of the files read so far, in the order they were
+// first read.
+func (s *Session) Files() []string {
+	return append([]string{}, s.read...)
+}
+
+// ParseFile parses the named file with p in its own Context, whose
+// SessionOf is s.
+func ParseFile[T any](s *Session, name string, p func(sr StatefulReader) (T, error)) (T, error) {
+	var t T
+	for i, n := range s.stack {
+		if n == name {
+			chain := append(append([]string{}, s.stack[i:]...), name)
+			return t, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(chain, " -> "))
+		}
+	}
+	src, ok := s.sources[name]
+	if !ok {
+		b, err := fs.ReadFile(s.fsys, name)
+		if err != nil {
+			return t, err
+		}
+		src = string(b)
+		s.sources[name] = src
+		s.read = append(s.read, name)
+	}
+	s.stack = append(s.stack, name)
+	defer func() { s.stack = s.stack[:len(s.stack)-1] }()
+
+	c := newContext(NewBytesReader([]byte(src)))
+	c.session = s
+	c.SetLine(name, 1)
+	o := buildOptions(s.opts)
+	o.configure(c)
+	v, err := wrap(p, o)(c)
+	s.Diagnostics = append(s.Diagnostics, c.Diagnostics...)
+	return finish(c, v, err)
+}
+
+// Include parses another file of the session that sr is parsing, from
+// within a grammar. Relative names are resolved against the directory of
+// the including file, and absolute ones against the root of the file
+// system. An error in the included file, including ErrIncludeCycle, ends
+// the whole parse, like ErrTooDeep, rather than being backtracked over.
+func Include[T any](sr StatefulReader, name string, p func(sr StatefulReader) (T, error)) (T, error) {
+	var t T
+	c := ContextOf(sr)
+	if c == nil || c.session == nil {
+		return t, errors.New("Include needs a reader from ParseFile")
+	}
+	s := c.session
+	if path.IsAbs(name) {
+		name = path.Clean(name)[1:]
+	} else {
+		name = path.Join(path.Dir(s.stack[len(s.stack)-1]), name)
+	}
+	v, err := ParseFile(s, name, p)
+	if err != nil {
+		if c.abort == nil {
+			c.abort = &ParseError{Pos: c.Pos(), Err: err}
+		}
+		return t, fatalError{c.abort}
+	}
+	return v, nil
+}
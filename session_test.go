@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// config is a list of "key=value" lines and "include FILE" lines.
+func config() func(StatefulReader) ([]string, error) {
+	var file func(StatefulReader) ([]string, error)
+	name := join(Mult(1, 0, NotSet(" \n")))
+	include := Bind(Right(Lit("include "), name), func(n string) func(StatefulReader) ([]string, error) {
+		return func(sr StatefulReader) ([]string, error) {
+			return Include(sr, n, Lazy(func() func(StatefulReader) ([]string, error) { return file }))
+		}
+	})
+	entry := Bind(join(And(join(Mult(1, 0, Set("a-z"))), Lit("="), join(Mult(0, 0, NotSet("\n"))))), func(e string) func(StatefulReader) ([]string, error) {
+		return func(sr StatefulReader) ([]string, error) {
+			if strings.HasSuffix(e, "=") {
+				ReportCode(sr, SeverityWarning, "empty", "empty value")
+			}
+			return []string{e}, nil
+		}
+	})
+	file = Convert(And(Mult(0, 0, Left(Or(include, entry), Lit("\n"))), Convert(EOF(), func(string) ([][]string, error) { return nil, nil })), func(vs [][][]string) ([]string, error) {
+		out := []string{}
+		for _, v := range vs[0] {
+			out = append(out, v...)
+		}
+		return out, nil
+	})
+	return file
+}
+
+func TestSession(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.cfg":        {Data: []byte("a=1\ninclude conf/db.cfg\nb=\n")},
+		"conf/db.cfg":     {Data: []byte("host=x\ninclude common.cfg\n")},
+		"conf/common.cfg": {Data: []byte("port=5\n")},
+		"loop.cfg":        {Data: []byte("include conf/loop.cfg\n")},
+		"conf/loop.cfg":   {Data: []byte("include /loop.cfg\n")},
+		"bad.cfg":         {Data: []byte("a=1\ninclude conf/broken.cfg\n")},
+		"conf/broken.cfg": {Data: []byte("x=1\n!\n")},
+	}
+	s := NewSession(fsys)
+	v, err := ParseFile(s, "main.cfg", config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, v, []string{"a=1", "host=x", "port=5", "b="})
+	assert(t, s.Files(), []string{"main.cfg", "conf/db.cfg", "conf/common.cfg"})
+	if len(s.Diagnostics) != 1 || s.Diagnostics[0].String() != "main.cfg:3:3: warning: empty value [empty]" {
+		t.Errorf("Diagnostics = %v", s.Diagnostics)
+	}
+	if src, ok := s.Source("conf/common.cfg"); !ok || src != "port=5\n" {
+		t.Errorf("Source = %q", src)
+	}
+
+	_, err = ParseFile(NewSession(fsys), "loop.cfg", config())
+	if !errors.Is(err, ErrIncludeCycle) || !strings.Contains(err.Error(), "loop.cfg -> conf/loop.cfg -> loop.cfg") {
+		t.Errorf("got %v", err)
+	}
+
+	_, err = ParseFile(NewSession(fsys), "bad.cfg", config())
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Pos.File != "bad.cfg" || pe.Pos.Line != 2 {
+		t.Fatalf("got %v", err)
+	}
+	var inner *ParseError
+	if !errors.As(pe.Err, &inner) || inner.Pos.String() != "conf/broken.cfg:2:1" {
+		t.Errorf("got %v", pe.Err)
+	}
+
+	if _, err := ParseFile(NewSession(fsys), "missing.cfg", config()); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
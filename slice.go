@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SliceReader is a StatefulReader over a slice of arbitrary elements, such as
+// pre-lexed tokens or events. It is consumed with Elem and Exact; the byte
+// level combinators (Lit, Set) fail on it, except that EOF works as usual.
+type SliceReader[E any] struct {
+	elems []E
+	pos   int
+}
+
+func NewSliceReader[E any](elems []E) *SliceReader[E] {
+	return &SliceReader[E]{elems: elems}
+}
+
+var errElementReader = errors.New("SliceReader holds elements, not bytes")
+
+// Read reports io.EOF once all elements are consumed and an error otherwise,
+// since elements cannot be read as bytes.
+func (r *SliceReader[E]) Read(p []byte) (int, error) {
+	if r.pos >= len(r.elems) {
+		return 0, io.EOF
+	}
+	return 0, errElementReader
+}
+
+func (r *SliceReader[E]) State() any {
+	return r.pos
+}
+
+func (r *SliceReader[E]) Restore(s any) {
+	r.pos = s.(int)
+}
+
+// Clone returns an independent reader over the same elements.
+func (r *SliceReader[E]) Clone() (StatefulReader, bool) {
+	return &SliceReader[E]{elems: r.elems, pos: r.pos}, true
+}
+
+// Offset returns the index of the next element.
+func (r *SliceReader[E]) Offset() int {
+	return r.pos
+}
+
+// Pos returns the position of the next element, or the end of the last one
+// at the end of input, if the elements are positioned like Token. Otherwise
+// the offset is the element index and the line and column are zero.
+func (r *SliceReader[E]) Pos() Position {
+	if r.pos < len(r.elems) {
+		if p, ok := any(r.elems[r.pos]).(positioned); ok {
+			return p.Position()
+		}
+	} else if r.pos > 0 {
+		if p, ok := any(r.elems[r.pos-1]).(positioned); ok {
+			return p.End()
+		}
+	}
+	return Position{Offset: int64(r.pos)}
+}
+
+// Remaining returns the elements not yet consumed.
+func (r *SliceReader[E]) Remaining() []E {
+	return r.elems[r.pos:]
+}
+
+func (r *SliceReader[E]) next() (E, bool) {
+	if r.pos >= len(r.elems) {
+		var e E
+		return e, false
+	}
+	e := r.elems[r.pos]
+	r.pos++
+	return e, true
+}
+
+func sliceReader[E any](sr StatefulReader) (*SliceReader[E], error) {
+	r, ok := sr.(*SliceReader[E])
+	if !ok {
+		var zero E
+		return nil, fmt.Errorf("Expected a *SliceReader[%T], got %T", zero, sr)
+	}
+	return r, nil
+}
+
+// Elem matches a single element for which pred returns true. It must be run
+// on a *SliceReader[E].
+func Elem[E any](pred func(E) bool) func(sr StatefulReader) (E, error) {
+	return func(sr StatefulReader) (E, error) {
+		var zero E
+		r, err := sliceReader[E](sr)
+		if err != nil {
+			return zero, err
+		}
+		e, ok := r.next()
+		if !ok {
+			return zero, msg(MsgUnexpectedEOF)
+		}
+		if !pred(e) {
+			r.pos--
+			return zero, msg(MsgUnexpectedElem, e)
+		}
+		return e, nil
+	}
+}
+
+// Exact matches a single element equal to e.
+func Exact[E comparable](e E) func(sr StatefulReader) (E, error) {
+	return func(sr StatefulReader) (E, error) {
+		var zero E
+		r, err := sliceReader[E](sr)
+		if err != nil {
+			return zero, err
+		}
+		x, ok := r.next()
+		if !ok {
+			return zero, msg(MsgUnexpectedEOF)
+		}
+		if x != e {
+			r.pos--
+			return zero, msg(MsgExpectedElem, e, x)
+		}
+		return x, nil
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+import "testing"
+
+type tok struct {
+	kind string
+	text string
+}
+
+func TestSliceReader(t *testing.T) {
+	t.Parallel()
+	kind := func(k string) func(StatefulReader) (tok, error) {
+		return Elem(func(t tok) bool { return t.kind == k })
+	}
+	assign := And(kind("ident"), Exact(tok{"op", "="}), Or(kind("num"), kind("ident")))
+	sr := NewSliceReader([]tok{{"ident", "x"}, {"op", "="}, {"num", "1"}, {"ident", "y"}})
+	out, err := assign(sr)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []tok{{"ident", "x"}, {"op", "="}, {"num", "1"}})
+	assert(t, sr.Remaining(), []tok{{"ident", "y"}})
+	_, err = assign(sr)
+	if err == nil {
+		t.Error("Expected error for truncated input")
+	}
+	assert(t, sr.Offset(), 3)
+	_, err = kind("ident")(sr)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = EOF()(sr)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSliceMult(t *testing.T) {
+	t.Parallel()
+	evens := Mult(1, 0, Elem(func(i int) bool { return i%2 == 0 }))
+	sr := NewSliceReader([]int{2, 4, 6, 7, 8})
+	out, err := evens(sr)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []int{2, 4, 6})
+	_, err = Exact(8)(sr)
+	assert(t, err.Error(), "Expected 8, got 7")
+	_, err = Exact(8)(SimpleReader{nil})
+	assert(t, err.Error(), "Expected a *SliceReader[int], got parser.SimpleReader")
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+// ErrWindow is the error a StreamReader fails with once it has been
+// restored to an offset that has already left its window.
+var ErrWindow = errors.New("Backtracked past the stream window")
+
+// StreamReader is a StatefulReader over a plain io.Reader, such as a
+// network connection or a decompressor, that can't seek. It keeps the last
+// Window bytes it has read so that parsers can backtrack within them, and
+// lets go of everything older, so a long stream is parsed in constant
+// memory. Its state is the offset as an int64, as for SimpleReader.
+//
+// Restoring to an offset older than the window can't be undone; from then
+// on reads fail with ErrWindow, which Err also reports. MaxLookahead with a
+// limit below the window catches such grammars earlier and more clearly.
+type StreamReader struct {
+	r      io.Reader
+	window int
+	// buf holds the input from offset start on, and off is the offset of
+	// the next byte to be read
+	buf   []byte
+	start int64
+	off   int64
+	eof   error
+	err   error
+}
+
+// NewStreamReader returns a reader over r that can backtrack window bytes.
+func NewStreamReader(r io.Reader, window int) *StreamReader {
+	return &StreamReader{r: r, window: window}
+}
+
+// fill reads until at least one byte past off is buffered or r is done. It
+// doesn't wait for more than one read's worth, so that a parser at the end
+// of a message on a live connection doesn't block on the next one.
+func (s *StreamReader) fill() {
+	for s.eof == nil && s.start+int64(len(s.buf)) <= s.off {
+		if len(s.buf) == cap(s.buf) {
+			s.trim()
+		}
+		if len(s.buf) == cap(s.buf) {
+			grown := make([]byte, len(s.buf), 2*cap(s.buf)+4096)
+			copy(grown, s.buf)
+			s.buf = grown
+		}
+		m, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+m]
+		if err != nil {
+			s.eof = err
+		}
+	}
+}
+
+// trim drops buffered bytes more than window before both the read offset
+// and the end of the buffer.
+func (s *StreamReader) trim() {
+	keep := s.start + int64(len(s.buf)) - int64(s.window)
+	if s.off < keep {
+		keep = s.off
+	}
+	if drop := int(keep - s.start); drop > 0 {
+		n := copy(s.buf, s.buf[drop:])
+		s.buf = s.buf[:n]
+		s.start = keep
+	}
+}
+
+func (s *StreamReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.fill()
+	n := copy(p, s.buf[s.off-s.start:])
+	s.off += int64(n)
+	if n == 0 {
+		return 0, s.eof
+	}
+	return n, nil
+}
+
+func (s *StreamReader) State() any {
+	return s.off
+}
+
+func (s *StreamReader) Restore(st any) {
+	s.seek(st.(int64))
+}
+
+func (s *StreamReader) Checkpoint() (Checkpoint, bool) {
+	return Checkpoint{Offset: s.off}, true
+}
+
+func (s *StreamReader) Rewind(cp Checkpoint) {
+	s.seek(cp.Offset)
+}
+
+func (s *StreamReader) seek(off int64) {
+	if off < s.start && s.err == nil {
+		s.err = &ParseError{Pos: Position{Offset: off}, Err: ErrWindow}
+	}
+	s.off = off
+}
+
+// Commit lets go of the input before the current offset, whatever the
+// window, for a caller that knows nothing will backtrack over it, such as
+// a server between two messages. Restoring to before it fails as for the
+// window.
+func (s *StreamReader) Commit() {
+	if s.err != nil || s.off <= s.start {
+		return
+	}
+	n := copy(s.buf, s.buf[s.off-s.start:])
+	s.buf = s.buf[:n]
+	s.start = s.off
+}
+
+// PeekBytes returns up to the next n bytes without consuming them. The
+// result aliases the reader's buffer and is only valid until the next read.
+// If fewer than n bytes have arrived and r isn't done, ok is false, so that
+// callers fall back to reading just as much as they need.
+func (s *StreamReader) PeekBytes(n int) ([]byte, bool) {
+	if s.err != nil {
+		return nil, false
+	}
+	s.fill()
+	b := s.buf[s.off-s.start:]
+	if len(b) < n && s.eof == nil {
+		return nil, false
+	}
+	if len(b) > n {
+		b = b[:n]
+	}
+	return b, true
+}
+
+// Advance consumes n bytes, which must have been returned by PeekBytes.
+func (s *StreamReader) Advance(n int) {
+	s.off += int64(n)
+}
+
+// Err returns ErrWindow, wrapped in a *ParseError at the offset restored
+// to, if the reader was restored past its window, and otherwise the error
+// that ended the underlying reader, if not io.EOF.
+func (s *StreamReader) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.eof == io.EOF {
+		return nil
+	}
+	return s.eof
+}
+
+// ParseStream runs p over r, backtracking within window bytes, in a fresh
+// Context. Errors from r itself, such as a corrupt compressed stream, and
+// backtracking past the window are returned in preference to p's own error.
+func ParseStream[T any](p func(sr StatefulReader) (T, error), r io.Reader, window int, opts ...Option) (T, *Context, error) {
+	s := NewStreamReader(r, window)
+	v, c, err := parseWith(p, s, opts)
+	if serr := s.Err(); serr != nil {
+		var t T
+		return t, c, serr
+	}
+	return v, c, err
+}
+
+// Decompress returns a reader over the decompressed contents of r if it
+// starts with a gzip or zlib header, and a reader over r as it is
+// otherwise, for stacking under NewStreamReader or ParseStream so that
+// compressed logs can be parsed without unpacking them first.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(2)
+	switch {
+	case len(head) == 2 && head[0] == 0x1f && head[1] == 0x8b:
+		return gzip.NewReader(br)
+	case len(head) == 2 && head[0]&0x0f == 8 && (uint16(head[0])<<8|uint16(head[1]))%31 == 0:
+		return zlib.NewReader(br)
+	}
+	return br, nil
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestParseStream(t *testing.T) {
+	t.Parallel()
+	var text strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&text, "line %d\n", i)
+	}
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(text.String()))
+	w.Close()
+
+	line := Convert(Left(Mult(1, 0, NotSet("\n")), Lit("\n")), func(rs []string) (string, error) {
+		return strings.Join(rs, ""), nil
+	})
+	p := Left(Mult(0, 0, Or(Left(Lit("line 1999"), Lit("\n")), line)), EOF())
+	r, err := Decompress(iotest.OneByteReader(&gz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStreamReader(r, 64)
+	v, _, err := parseWith(p, s, nil)
+	if err != nil || len(v) != 2000 || v[1999] != "line 1999" {
+		t.Fatalf("got %d lines, %v", len(v), err)
+	}
+	if cap(s.buf) > 8192 {
+		t.Errorf("buffer grew to %d bytes", cap(s.buf))
+	}
+}
+
+func TestStreamWindow(t *testing.T) {
+	t.Parallel()
+	as := Mult(0, 0, Lit("a"))
+	p := Or(Right(as, Lit("!")), Right(as, Lit("?")))
+	in := strings.Repeat("a", 10000)
+
+	_, _, err := ParseStream(p, strings.NewReader(in+"?"), 10000)
+	if err != nil {
+		t.Errorf("within the window: %v", err)
+	}
+	_, _, err = ParseStream(p, strings.NewReader(in+"?"), 10)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Err != ErrWindow || pe.Pos.Offset != 0 {
+		t.Errorf("past the window: got %v", err)
+	}
+	_, _, err = ParseStream(p, strings.NewReader(in+"!"), 10)
+	if err != nil {
+		t.Errorf("without backtracking: %v", err)
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	t.Parallel()
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write([]byte("hello"))
+	w.Close()
+	for _, in := range []io.Reader{&z, strings.NewReader("hello")} {
+		r, err := Decompress(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _, err := ParseStream(Lit("hello"), r, 16)
+		if err != nil || v != "hello" {
+			t.Errorf("got %q, %v", v, err)
+		}
+	}
+
+	bad := append([]byte{0x1f, 0x8b}, "not gzip at all"...)
+	if _, err := Decompress(bytes.NewReader(bad)); err == nil {
+		t.Errorf("corrupt gzip header accepted")
+	}
+}
+
+func TestStreamCommit(t *testing.T) {
+	t.Parallel()
+	s := NewStreamReader(strings.NewReader("abcdef"), 100)
+	if v, err := Lit("abc")(s); err != nil || v != "abc" {
+		t.Fatalf("got %q, %v", v, err)
+	}
+	s.Commit()
+	if len(s.buf) != 3 || s.start != 3 {
+		t.Errorf("kept %q from %d", s.buf, s.start)
+	}
+	if v, err := Lit("def")(s); err != nil || v != "def" {
+		t.Errorf("after Commit: got %q, %v", v, err)
+	}
+	s.Restore(int64(2))
+	if _, err := Lit("c")(s); err == nil || !errors.Is(s.Err(), ErrWindow) {
+		t.Errorf("before Commit: got %v, %v", err, s.Err())
+	}
+}
+
+func TestStreamPipe(t *testing.T) {
+	t.Parallel()
+	// a parser that has what it needs returns without waiting for the
+	// writer to send more or close
+	for _, tt := range []struct {
+		in string
+		p  func(StatefulReader) ([]string, error)
+	}{
+		{"key=é;", And(Lit("key"), Lit("="), Set("a-zé"), Lit(";"))},
+		// literals longer than the input fail at the first difference
+		{"x;", And(Or(Lit("xyzzy"), LitFold("XYZZY"), Lits("xylophone", "x")), Lit(";"))},
+	} {
+		pr, pw := io.Pipe()
+		go pw.Write([]byte(tt.in))
+		s := NewStreamReader(pr, 64)
+		done := make(chan error, 1)
+		go func() {
+			_, err := tt.p(s)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("%q: %v", tt.in, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: parse blocked on the open pipe", tt.in)
+		}
+		pw.Close()
+	}
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// If runs then if cond holds and otherwise els, so that a layout that
+// depends on parse state, such as the language version or a flag a
+// previous rule stored in User, reads as one parser rather than a Bind:
+//
+//	header := If(func(c *Context) bool { return c.Version >= 2 }, headerV2, headerV1)
+//
+// cond is given nil when running without a Context.
+func If[T any](cond func(c *Context) bool, then, els func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("If", then, els)
+	return func(sr StatefulReader) (T, error) {
+		if cond(ContextOf(sr)) {
+			return then(sr)
+		}
+		return els(sr)
+	}
+}
+
+// Switch parses a key with key and then the case cases holds for it, for
+// tagged unions and records whose layout depends on a type or version
+// field:
+//
+//	shape := Switch(Lits("circle", "rect"), map[string]func(StatefulReader) (Shape, error){
+//		"circle": circle,
+//		"rect":   rect,
+//	})
+//
+// It fails with MsgNoCase, without consuming the key, if there is no case
+// for the key, and also restores the key if the case fails.
+func Switch[K comparable, T any](key func(sr StatefulReader) (K, error), cases map[K]func(sr StatefulReader) (T, error)) func(sr StatefulReader) (T, error) {
+	mustParsers("Switch", key)
+	table := make(map[K]func(sr StatefulReader) (T, error), len(cases))
+	for k, p := range cases {
+		mustParsers("Switch", p)
+		table[k] = p
+	}
+	return func(sr StatefulReader) (T, error) {
+		var t T
+		s := save(sr)
+		k, err := key(sr)
+		if err != nil {
+			return t, err
+		}
+		p, ok := table[k]
+		if !ok {
+			s.restore(sr)
+			return t, msg(MsgNoCase, k)
+		}
+		v, err := p(sr)
+		if err != nil {
+			s.restore(sr)
+			return t, err
+		}
+		return v, nil
+	}
+}
+
+// OneOfTagged is Switch for the common "type byte then body" layout whose
+// result is a sum type: every payload parser yields some implementation of
+// the interface I, adapted with Case. tags lists every tag the format
+// defines, and OneOfTagged panics unless cases has exactly one parser for
+// each, so a tag added to the list without a parser is caught when the
+// grammar is built rather than on the first message that uses it:
+//
+//	msg := OneOfTagged(binary.U8(), []uint8{TagPing, TagData}, map[uint8]func(StatefulReader) (Message, error){
+//		TagPing: Case[Ping, Message](ping),
+//		TagData: Case[Data, Message](data),
+//	})
+func OneOfTagged[K comparable, I any](tag func(sr StatefulReader) (K, error), tags []K, cases map[K]func(sr StatefulReader) (I, error)) func(sr StatefulReader) (I, error) {
+	known := make(map[K]bool, len(tags))
+	for _, k := range tags {
+		if _, ok := cases[k]; !ok {
+			panic(fmt.Sprintf("parser: OneOfTagged: no case for tag %v", k))
+		}
+		known[k] = true
+	}
+	for k := range cases {
+		if !known[k] {
+			panic(fmt.Sprintf("parser: OneOfTagged: case for unknown tag %v", k))
+		}
+	}
+	return Switch(tag, cases)
+}
+
+// Case adapts a payload parser for OneOfTagged, converting its result to
+// the interface I. It panics if T doesn't implement I.
+func Case[T, I any](p func(sr StatefulReader) (T, error)) func(sr StatefulReader) (I, error) {
+	mustParsers("Case", p)
+	it := reflect.TypeOf((*I)(nil)).Elem()
+	if tt := reflect.TypeOf((*T)(nil)).Elem(); it.Kind() != reflect.Interface || !tt.Implements(it) {
+		panic(fmt.Sprintf("parser: Case: %s does not implement %s", tt, it))
+	}
+	return func(sr StatefulReader) (I, error) {
+		v, err := p(sr)
+		if err != nil {
+			var i I
+			return i, err
+		}
+		i, _ := any(v).(I)
+		return i, nil
+	}
+}
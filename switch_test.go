@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIf(t *testing.T) {
+	t.Parallel()
+	v2 := func(c *Context) bool { return c != nil && c.Version >= 2 }
+	p := If(v2, Lit("new"), Lit("old"))
+	if v, _, err := ParseBytes(p, []byte("new"), LanguageVersion(2)); err != nil || v != "new" {
+		t.Errorf("version 2: got %q, %v", v, err)
+	}
+	if v, _, err := ParseBytes(p, []byte("old"), LanguageVersion(1)); err != nil || v != "old" {
+		t.Errorf("version 1: got %q, %v", v, err)
+	}
+	if _, _, err := ParseBytes(p, []byte("new")); err == nil {
+		t.Errorf("version 0 accepted the new layout")
+	}
+	if v, err := p(NewBytesReader(nil)); v != "" || err == nil {
+		t.Errorf("without a Context: got %q, %v", v, err)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+	p := Switch(Left(Lits("int", "str"), Lit(":")), map[string]func(StatefulReader) (string, error){
+		"int": Convert(Mult(1, 0, Set("0-9")), func(ds []string) (string, error) { return "digits", nil }),
+		"str": Lit(`"x"`),
+	})
+	for in, want := range map[string]string{"int:42": "digits", `str:"x"`: `"x"`} {
+		if v, _, err := ParseBytes(p, []byte(in)); err != nil || v != want {
+			t.Errorf("%q: got %q, %v", in, v, err)
+		}
+	}
+	_, c, err := ParseBytes(p, []byte("int:x"))
+	if err == nil || c.Pos().Offset != 0 {
+		t.Errorf("failed case: got %v at %d", err, c.Pos().Offset)
+	}
+
+	bytes := Switch(Convert(Set("a-z"), func(s string) (byte, error) { return s[0], nil }), map[byte]func(StatefulReader) (string, error){
+		'a': Lit("1"),
+	})
+	_, err = bytes(NewBytesReader(nil))
+	if err == nil {
+		t.Errorf("empty input accepted")
+	}
+	var me *MessageError
+	if _, _, err := ParseBytes(bytes, []byte("b1")); !errors.As(err, &me) || me.Key != MsgNoCase || err.Error() != "No case for 98" {
+		t.Errorf("missing case: got %v", err)
+	}
+}
+
+type shape interface{ area() int }
+
+type square struct{ side int }
+
+func (s square) area() int { return s.side * s.side }
+
+type rect struct{ w, h int }
+
+func (r rect) area() int { return r.w * r.h }
+
+func TestOneOfTagged(t *testing.T) {
+	t.Parallel()
+	digit := Convert(Set("0-9"), func(s string) (int, error) { return int(s[0] - '0'), nil })
+	sq := Case[square, shape](Convert(digit, func(n int) (square, error) { return square{n}, nil }))
+	rc := Case[rect, shape](Convert(Seq2(digit, digit), func(p Pair[int, int]) (rect, error) { return rect{p.First, p.Second}, nil }))
+	p := OneOfTagged(Set("sr"), []string{"s", "r"}, map[string]func(StatefulReader) (shape, error){"s": sq, "r": rc})
+	for in, want := range map[string]int{"s3": 9, "r23": 6} {
+		if v, _, err := ParseBytes(p, []byte(in)); err != nil || v.area() != want {
+			t.Errorf("%q: got %v, %v", in, v, err)
+		}
+	}
+
+	for name, f := range map[string]func(){
+		"missing case": func() {
+			OneOfTagged(Set("sr"), []string{"s", "r", "t"}, map[string]func(StatefulReader) (shape, error){"s": sq, "r": rc})
+		},
+		"unknown tag": func() {
+			OneOfTagged(Set("sr"), []string{"s"}, map[string]func(StatefulReader) (shape, error){"s": sq, "r": rc})
+		},
+		"not an implementation": func() { Case[int, shape](digit) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"bytes"
+	"encoding"
+)
+
+type textUnmarshaler[T any] struct {
+	p      func(sr StatefulReader) (T, error)
+	target *T
+	opts   []Option
+}
+
+// AsTextUnmarshaler returns an encoding.TextUnmarshaler that parses text
+// with p, which must consume all of it, and stores the result in target.
+// It lets a grammar back a type's UnmarshalText method, and through it
+// encoding/json, flag.TextVar and other decoders:
+//
+//	type Version struct {
+//		parser.Version
+//	}
+//
+//	func (v *Version) UnmarshalText(b []byte) error {
+//		return parser.AsTextUnmarshaler(parser.Semver(), &v.Version).UnmarshalText(b)
+//	}
+//
+// target is left unchanged if parsing fails.
+func AsTextUnmarshaler[T any](p func(sr StatefulReader) (T, error), target *T, opts ...Option) encoding.TextUnmarshaler {
+	if p == nil {
+		panic("parser: AsTextUnmarshaler: parser is nil")
+	}
+	if target == nil {
+		panic("parser: AsTextUnmarshaler: target is nil")
+	}
+	return textUnmarshaler[T]{p: p, target: target, opts: opts}
+}
+
+func (u textUnmarshaler[T]) UnmarshalText(text []byte) error {
+	all := func(sr StatefulReader) (T, error) {
+		v, err := u.p(sr)
+		if err != nil {
+			return v, err
+		}
+		_, err = EOF()(sr)
+		return v, err
+	}
+	v, _, err := ParseReader(all, bytes.NewReader(text), u.opts...)
+	if err != nil {
+		return err
+	}
+	*u.target = v
+	return nil
+}
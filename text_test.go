@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type textVersion struct {
+	Version
+}
+
+func (v *textVersion) UnmarshalText(b []byte) error {
+	return AsTextUnmarshaler(Semver(), &v.Version).UnmarshalText(b)
+}
+
+func (v textVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func TestAsTextUnmarshaler(t *testing.T) {
+	var v Version
+	u := AsTextUnmarshaler(Semver(), &v)
+	if err := u.UnmarshalText([]byte("1.2.3-rc.1")); err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "1.2.3-rc.1" {
+		t.Errorf("got %v", v)
+	}
+	err := u.UnmarshalText([]byte("1.2.3 trailing"))
+	if err == nil {
+		t.Fatal("expected error for trailing input")
+	}
+	pe, ok := err.(*ParseError)
+	if !ok || pe.Pos.Offset != 5 {
+		t.Errorf("got %#v, want a ParseError at offset 5", err)
+	}
+	if v.String() != "1.2.3-rc.1" {
+		t.Errorf("target changed on failure: %v", v)
+	}
+}
+
+func TestAsTextUnmarshalerJSON(t *testing.T) {
+	var cfg struct {
+		Min textVersion `json:"min"`
+	}
+	if err := json.Unmarshal([]byte(`{"min": "2.0.1"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Min.Major != 2 || cfg.Min.Patch != 1 {
+		t.Errorf("got %v", cfg.Min)
+	}
+	if err := json.Unmarshal([]byte(`{"min": "2.x"}`), &cfg); err == nil {
+		t.Error("expected error")
+	}
+}
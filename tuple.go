@@ -0,0 +1,116 @@
+package parser
+
+// Pair holds the results of two parsers run in sequence.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Triple holds the results of three parsers run in sequence.
+type Triple[A, B, C any] struct {
+	First  A
+	Second B
+	Third  C
+}
+
+// Seq2 runs pa then pb, keeping both results. Like And, it consumes nothing
+// if either fails.
+func Seq2[A, B any](pa func(sr StatefulReader) (A, error), pb func(sr StatefulReader) (B, error)) func(sr StatefulReader) (Pair[A, B], error) {
+	mustParsers("Seq2", pa)
+	mustParsers("Seq2", pb)
+	return func(sr StatefulReader) (Pair[A, B], error) {
+		s := save(sr)
+		a, err := pa(sr)
+		if err != nil {
+			return Pair[A, B]{}, err
+		}
+		b, err := pb(sr)
+		if err != nil {
+			s.restore(sr)
+			return Pair[A, B]{}, err
+		}
+		return Pair[A, B]{a, b}, nil
+	}
+}
+
+// Seq3 runs pa, pb and pc in turn, keeping all three results.
+func Seq3[A, B, C any](pa func(sr StatefulReader) (A, error), pb func(sr StatefulReader) (B, error), pc func(sr StatefulReader) (C, error)) func(sr StatefulReader) (Triple[A, B, C], error) {
+	return Map(Seq2(Seq2(pa, pb), pc), func(p Pair[Pair[A, B], C]) Triple[A, B, C] {
+		return Triple[A, B, C]{p.First.First, p.First.Second, p.Second}
+	})
+}
+
+// Map is Convert for functions that cannot fail.
+func Map[T, U any](p func(sr StatefulReader) (T, error), f func(T) U) func(sr StatefulReader) (U, error) {
+	if f == nil {
+		panic("parser: Map: nil function")
+	}
+	return Convert(p, func(v T) (U, error) {
+		return f(v), nil
+	})
+}
+
+// First keeps the first element of a Pair parser's result.
+func First[A, B any](p func(sr StatefulReader) (Pair[A, B], error)) func(sr StatefulReader) (A, error) {
+	return Map(p, func(v Pair[A, B]) A { return v.First })
+}
+
+// Second keeps the second element of a Pair parser's result.
+func Second[A, B any](p func(sr StatefulReader) (Pair[A, B], error)) func(sr StatefulReader) (B, error) {
+	return Map(p, func(v Pair[A, B]) B { return v.Second })
+}
+
+// Third keeps the third element of a Triple parser's result.
+func Third[A, B, C any](p func(sr StatefulReader) (Triple[A, B, C], error)) func(sr StatefulReader) (C, error) {
+	return Map(p, func(v Triple[A, B, C]) C { return v.Third })
+}
+
+// Left runs p then q and keeps p's result, as in Left(value, Lit(";")).
+func Left[A, B any](p func(sr StatefulReader) (A, error), q func(sr StatefulReader) (B, error)) func(sr StatefulReader) (A, error) {
+	return First(Seq2(p, q))
+}
+
+// Right runs p then q and keeps q's result, as in Right(Lit("-"), number).
+func Right[A, B any](p func(sr StatefulReader) (A, error), q func(sr StatefulReader) (B, error)) func(sr StatefulReader) (B, error) {
+	return Second(Seq2(p, q))
+}
+
+// Map2 runs pa then pb and combines their results with f, as in
+// Map2(key, Right(Lit("="), value), NewEntry).
+func Map2[A, B, C any](pa func(sr StatefulReader) (A, error), pb func(sr StatefulReader) (B, error), f func(A, B) C) func(sr StatefulReader) (C, error) {
+	if f == nil {
+		panic("parser: Map2: nil function")
+	}
+	return Map(Seq2(pa, pb), func(v Pair[A, B]) C {
+		return f(v.First, v.Second)
+	})
+}
+
+// Map3 runs pa, pb and pc in turn and combines their results with f.
+func Map3[A, B, C, D any](pa func(sr StatefulReader) (A, error), pb func(sr StatefulReader) (B, error), pc func(sr StatefulReader) (C, error), f func(A, B, C) D) func(sr StatefulReader) (D, error) {
+	if f == nil {
+		panic("parser: Map3: nil function")
+	}
+	return Map(Seq3(pa, pb, pc), func(v Triple[A, B, C]) D {
+		return f(v.First, v.Second, v.Third)
+	})
+}
+
+// Then runs p then q and keeps q's result, like Parsec's *>. Go methods
+// cannot introduce type parameters, so q must have the same result type;
+// use Right when it doesn't.
+func (p Parser[T]) Then(q Parser[T]) Parser[T] {
+	return Right(p, q)
+}
+
+// ThenSkip runs p then the punctuation q and keeps p's result, like
+// Parsec's <*, as in Parser[Node](expr).ThenSkip(Lit(";")).
+func (p Parser[T]) ThenSkip(q func(sr StatefulReader) (string, error)) Parser[T] {
+	return Left(p, q)
+}
+
+// SkipThen runs the punctuation skip then p and keeps p's result. It is a
+// function rather than a method because its result type comes from p.
+func SkipThen[T any](skip func(sr StatefulReader) (string, error), p func(sr StatefulReader) (T, error)) Parser[T] {
+	return Right(skip, p)
+}
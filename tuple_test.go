@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTuples(t *testing.T) {
+	num := Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	kv := Seq3(join(Mult(1, 0, Set("a-z"))), Lit("="), num)
+	out, err := parse("x=42", kv)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, Triple[string, string, int]{"x", "=", 42})
+
+	v, err := parse("x=42", Third(kv))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, v, 42)
+
+	sr := NewSimpleReader(strings.NewReader("x=y"))
+	if _, err := kv(sr); err == nil {
+		t.Error("Expected error")
+	}
+	assert(t, sr.State(), any(int64(0)))
+
+	items := Mult(0, 0, Left(num, Lit(";")))
+	ns, err := parse("1;2;3", items)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, ns, []int{1, 2})
+
+	neg := Right(Lit("-"), num)
+	n, err := parse("-7", neg)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, n, 7)
+
+	p, err := parse("a1", Seq2(Set("a-z"), num))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, p, Pair[string, int]{"a", 1})
+	f, err := parse("a1", First(Seq2(Set("a-z"), num)))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, f, "a")
+}
+
+func TestMap2(t *testing.T) {
+	num := Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	type binop struct {
+		l  int
+		op string
+		r  int
+	}
+	bin := Map3(num, Set("+-"), num, func(l int, op string, r int) binop {
+		return binop{l, op, r}
+	})
+	out, err := parse("1+2", bin)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, binop{1, "+", 2})
+
+	neg := Map2(Optional(Lit("-")), num, func(sign string, n int) int {
+		if sign == "-" {
+			return -n
+		}
+		return n
+	})
+	n, err := parse("-5", neg)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, n, -5)
+	sr := NewSimpleReader(strings.NewReader("-x"))
+	if _, err := neg(sr); err == nil {
+		t.Error("Expected error")
+	}
+	assert(t, sr.State(), any(int64(0)))
+}
+
+func TestThen(t *testing.T) {
+	num := Convert(join(Mult(1, 0, Set("0-9"))), strconv.Atoi)
+	call := SkipThen(Lit("("), num).ThenSkip(Lit(")"))
+	out, err := parse("(12)", call)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, 12)
+	sr := NewSimpleReader(strings.NewReader("(12"))
+	if _, err := call(sr); err == nil {
+		t.Error("Expected error")
+	}
+	assert(t, sr.State(), any(int64(0)))
+
+	kw := Parser[string](Lit("let")).Then(Lit(" ")).Then(join(Mult(1, 0, Set("a-z"))))
+	s, err := parse("let x", kw)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, s, "x")
+	ns, err := parse("(1)(2)", Mult(0, 0, call))
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, ns, []int{1, 2})
+}
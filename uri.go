@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// URI is a URI split into its RFC 3986 components. Components are returned
+// as they appear in the input, without percent-decoding.
+type URI struct {
+	Scheme       string
+	HasAuthority bool
+	Userinfo     string
+	Host         string
+	Port         string
+	Path         string
+	HasQuery     bool
+	Query        string
+	HasFragment  bool
+	Fragment     string
+}
+
+func (u URI) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(u.Scheme)
+	sb.WriteString(":")
+	if u.HasAuthority {
+		sb.WriteString("//")
+		if u.Userinfo != "" {
+			sb.WriteString(u.Userinfo)
+			sb.WriteString("@")
+		}
+		sb.WriteString(u.Host)
+		if u.Port != "" {
+			sb.WriteString(":")
+			sb.WriteString(u.Port)
+		}
+	}
+	sb.WriteString(u.Path)
+	if u.HasQuery {
+		sb.WriteString("?")
+		sb.WriteString(u.Query)
+	}
+	if u.HasFragment {
+		sb.WriteString("#")
+		sb.WriteString(u.Fragment)
+	}
+	return sb.String()
+}
+
+func join(p func(sr StatefulReader) ([]string, error)) func(sr StatefulReader) (string, error) {
+	return Convert(p, func(s []string) (string, error) {
+		return strings.Join(s, ""), nil
+	})
+}
+
+var (
+	uriAlpha      = Set("a-zA-Z")
+	uriHex        = Set("0-9a-fA-F")
+	uriUnreserved = Set("a-zA-Z0-9._~-")
+	uriSubDelims  = Set("!$&'()*+,;=")
+	uriPctEncoded = join(And(Lit("%"), uriHex, uriHex))
+	uriPchar      = Or(uriUnreserved, uriPctEncoded, uriSubDelims, Set(":@"))
+
+	uriScheme   = join(And(uriAlpha, join(Mult(0, 0, Set("a-zA-Z0-9+.-")))))
+	uriUserinfo = join(And(join(Mult(0, 0, Or(uriUnreserved, uriPctEncoded, uriSubDelims, Lit(":")))), Lit("@")))
+	uriIPLit    = join(And(Lit("["), join(Mult(1, 0, Set("0-9a-fA-F:.vV%_~!$&'()*+,;=-"))), Lit("]")))
+	uriRegName  = join(Mult(0, 0, Or(uriUnreserved, uriPctEncoded, uriSubDelims)))
+	uriHost     = Or(uriIPLit, uriRegName)
+	uriPort     = join(And(Lit(":"), join(Mult(0, 0, Set("0-9")))))
+	uriPath     = join(Mult(0, 0, Or(uriPchar, Lit("/"))))
+	uriQuery    = join(Mult(0, 0, Or(uriPchar, Set("/?"))))
+)
+
+// AbsoluteURI returns a parser for a URI as defined by RFC 3986.
+// Parsing stops at the first character that cannot continue the URI, so it
+// can be embedded in larger grammars.
+func AbsoluteURI() func(sr StatefulReader) (URI, error) {
+	return func(sr StatefulReader) (URI, error) {
+		s := sr.State()
+		u, err := parseURI(sr)
+		if err != nil {
+			sr.Restore(s)
+			return URI{}, fmt.Errorf("Invalid URI: %w", err)
+		}
+		return u, nil
+	}
+}
+
+func parseURI(sr StatefulReader) (URI, error) {
+	u := URI{}
+	var err error
+	if u.Scheme, err = uriScheme(sr); err != nil {
+		return u, err
+	}
+	if _, err = Lit(":")(sr); err != nil {
+		return u, err
+	}
+	s := sr.State()
+	if _, err = Lit("//")(sr); err == nil {
+		u.HasAuthority = true
+		if ui, err := uriUserinfo(sr); err == nil {
+			u.Userinfo = ui[:len(ui)-1]
+		}
+		if u.Host, err = uriHost(sr); err != nil {
+			return u, err
+		}
+		if port, err := uriPort(sr); err == nil {
+			u.Port = port[1:]
+		}
+		s = sr.State()
+		if _, err := Lit("/")(sr); err == nil {
+			sr.Restore(s)
+			if u.Path, err = uriPath(sr); err != nil {
+				return u, err
+			}
+		}
+	} else {
+		sr.Restore(s)
+		if u.Path, err = uriPath(sr); err != nil {
+			return u, err
+		}
+		if strings.HasPrefix(u.Path, "//") {
+			return u, fmt.Errorf("Path may not begin with %q without an authority", "//")
+		}
+	}
+	s = sr.State()
+	if _, err := Lit("?")(sr); err == nil {
+		u.HasQuery = true
+		if u.Query, err = uriQuery(sr); err != nil {
+			return u, err
+		}
+	} else {
+		sr.Restore(s)
+	}
+	s = sr.State()
+	if _, err := Lit("#")(sr); err == nil {
+		u.HasFragment = true
+		if u.Fragment, err = uriQuery(sr); err != nil {
+			return u, err
+		}
+	} else {
+		sr.Restore(s)
+	}
+	return u, nil
+}
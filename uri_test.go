@@ -0,0 +1,50 @@
+package parser
+
+import "testing"
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in  string
+		out URI
+	}{
+		{"http://example.com", URI{Scheme: "http", HasAuthority: true, Host: "example.com"}},
+		{"https://user:pw@example.com:8443/a/b?x=1&y=2#frag", URI{
+			Scheme: "https", HasAuthority: true, Userinfo: "user:pw", Host: "example.com", Port: "8443",
+			Path: "/a/b", HasQuery: true, Query: "x=1&y=2", HasFragment: true, Fragment: "frag",
+		}},
+		{"http://[::1]:80/", URI{Scheme: "http", HasAuthority: true, Host: "[::1]", Port: "80", Path: "/"}},
+		{"mailto:someone@example.com", URI{Scheme: "mailto", Path: "someone@example.com"}},
+		{"urn:isbn:0451450523", URI{Scheme: "urn", Path: "isbn:0451450523"}},
+		{"file:///etc/hosts", URI{Scheme: "file", HasAuthority: true, Path: "/etc/hosts"}},
+		{"http://example.com/%7Euser", URI{Scheme: "http", HasAuthority: true, Host: "example.com", Path: "/%7Euser"}},
+	}
+	for _, test := range tests {
+		out, err := parse(test.in, AbsoluteURI())
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		assertSrc(t, test.in, out, test.out)
+		assertSrc(t, test.in, out.String(), test.in)
+	}
+}
+
+func TestURIEmbedded(t *testing.T) {
+	t.Parallel()
+	p := And(Lit("<"), Convert(AbsoluteURI(), func(u URI) (string, error) { return u.String(), nil }), Lit(">"))
+	out, err := parse("<http://example.com/x>", p)
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out, []string{"<", "http://example.com/x", ">"})
+}
+
+func TestURIInvalid(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"", "1http://x", "http//x"} {
+		if out, err := parse(in, AbsoluteURI()); err == nil {
+			t.Errorf("Expected error for %q, got %v", in, out)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// UUIDValue is a 128-bit universally unique identifier.
+type UUIDValue [16]byte
+
+func (u UUIDValue) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// UUID matches a UUID in its canonical 8-4-4-4-12 hexadecimal form.
+// Upper and lower case hex digits are both accepted. The UUID must not run
+// on into more hex digits or dashes.
+func UUID() func(sr StatefulReader) (UUIDValue, error) {
+	hexDigits := func(n int) func(sr StatefulReader) (string, error) {
+		return join(Mult(n, n, Set("0-9a-fA-F")))
+	}
+	p := And(hexDigits(8), Lit("-"), hexDigits(4), Lit("-"), hexDigits(4), Lit("-"), hexDigits(4), Lit("-"), hexDigits(12))
+	return func(sr StatefulReader) (UUIDValue, error) {
+		var u UUIDValue
+		s := sr.State()
+		parts, err := p(sr)
+		if err != nil {
+			return u, fmt.Errorf("Expected UUID: %w", err)
+		}
+		if !atBoundary(sr, "0123456789abcdefABCDEF-") {
+			sr.Restore(s)
+			return u, errors.New("Expected UUID, got a longer token")
+		}
+		raw := parts[0] + parts[2] + parts[4] + parts[6] + parts[8]
+		if _, err := hex.Decode(u[:], []byte(raw)); err != nil {
+			return u, err
+		}
+		return u, nil
+	}
+}
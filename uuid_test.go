@@ -0,0 +1,28 @@
+package parser
+
+import "testing"
+
+func TestUUID(t *testing.T) {
+	t.Parallel()
+	in := "123e4567-e89b-12d3-a456-426614174000"
+	out, err := parse(in, UUID())
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out.String(), in)
+	assert(t, out[0], byte(0x12))
+	out, err = parse("123E4567-E89B-12D3-A456-426614174000", UUID())
+	if err != nil {
+		t.Error(err)
+	}
+	assert(t, out.String(), in)
+	// a delimiter after the UUID is fine
+	if _, err := parse(in+"}", UUID()); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []string{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400", "g23e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000ff", "123e4567-e89b-12d3-a456-426614174000-1"} {
+		if _, err := parse(bad, UUID()); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}